@@ -40,9 +40,26 @@ func main() {
 
 	// Apply global middleware
 	router.Use(middleware.CORS())
+	router.Use(middleware.Auth())
 	
 	// Initialize clients
-	githubClient, err := github.NewClient(cfg.GitHubToken)
+	githubHosts, err := github.NewHosts(cfg.GitHubAPIURL, cfg.GitHubUploadURL, cfg.GitHubWebURL)
+	if err != nil {
+		log.Fatalf("Invalid GitHub URLs: %v", err)
+	}
+	githubOptions := github.ClientOptions{
+		RateLimitPolicy:  cfg.RateLimitPolicy,
+		RateLimitMaxWait: cfg.RateLimitMaxWait,
+		CacheEntries:     cfg.GitHubCacheEntries,
+		Hosts:            githubHosts,
+	}
+	if cfg.GitHubAppID != 0 {
+		githubOptions.App, err = github.NewAppAuth(cfg.GitHubAppID, []byte(cfg.GitHubAppKey))
+		if err != nil {
+			log.Fatalf("Failed to initialize GitHub App authentication: %v", err)
+		}
+	}
+	githubClient, err := github.NewClientWithOptions(cfg.GitHubToken, githubOptions)
 	if err != nil {
 		log.Fatalf("Failed to initialize GitHub client: %v", err)
 	}
@@ -0,0 +1,182 @@
+// internal/api/handlers/auth.go
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pbearc/github-agent/backend/internal/api/middleware"
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// oauthStateCookie holds the state parameter of a pending OAuth login
+	oauthStateCookie = "github_oauth_state"
+	// userClientKey is the context key of the caller's request-scoped GitHub client
+	userClientKey = "github_user_client"
+)
+
+// githubClient returns the client acting for the caller: their own when the request carries
+// a GitHub token, and the shared server client otherwise
+func (h *Handler) githubClient(c *gin.Context) *github.Client {
+	token, ok := middleware.GitHubToken(c)
+	if !ok {
+		return h.GithubClient
+	}
+	if client, ok := c.Get(userClientKey); ok {
+		return client.(*github.Client)
+	}
+
+	client := h.GithubClient.WithToken(token)
+	c.Set(userClientKey, client)
+	return client
+}
+
+// userGithubClient returns the caller's own client and writes a 401 response if the request
+// carries no GitHub token. Writes always use it so they are attributed to the user.
+func (h *Handler) userGithubClient(c *gin.Context) (*github.Client, bool) {
+	if _, ok := middleware.GitHubToken(c); !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Details: "send a GitHub token in the Authorization header or sign in with GitHub",
+		})
+		return nil, false
+	}
+	return h.githubClient(c), true
+}
+
+// readGithubClient returns the client for reading owner/repo and writes a 401 response if it
+// cannot be read. The shared server client is only used for public repositories.
+func (h *Handler) readGithubClient(c *gin.Context, owner, repo string) (*github.Client, bool) {
+	if _, ok := middleware.GitHubToken(c); ok {
+		return h.githubClient(c), true
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	repository, err := h.GithubClient.GetRepository(ctx, owner, repo)
+	if err != nil || repository.GetPrivate() {
+		details := "private repositories require a GitHub token in the Authorization header"
+		if err != nil {
+			details = err.Error()
+		}
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Authentication required",
+			Details: details,
+		})
+		return nil, false
+	}
+	return h.GithubClient, true
+}
+
+// parseRepoClient parses a GitHub URL and resolves the client for reading it,
+// writing an error response if either step fails
+func (h *Handler) parseRepoClient(c *gin.Context, url string) (string, string, *github.Client, bool) {
+	owner, repo, err := h.GithubClient.Hosts().ParseRepoURL(url)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid GitHub URL",
+			Details: err.Error(),
+		})
+		return "", "", nil, false
+	}
+
+	client, ok := h.readGithubClient(c, owner, repo)
+	if !ok {
+		return "", "", nil, false
+	}
+	return owner, repo, client, true
+}
+
+// oauthConfig returns the GitHub OAuth app configuration, or nil if sign-in is not configured
+func (h *Handler) oauthConfig() *oauth2.Config {
+	if h.Config == nil || h.Config.GitHubOAuthClientID == "" {
+		return nil
+	}
+
+	// Enterprise Server hosts the OAuth endpoints on its web host, like github.com
+	webURL := h.GithubClient.Hosts().WebURL
+	return &oauth2.Config{
+		ClientID:     h.Config.GitHubOAuthClientID,
+		ClientSecret: h.Config.GitHubOAuthClientSecret,
+		RedirectURL:  h.Config.GitHubOAuthRedirectURL,
+		Scopes:       []string{"repo"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  webURL + "login/oauth/authorize",
+			TokenURL: webURL + "login/oauth/access_token",
+		},
+	}
+}
+
+// GitHubLogin redirects the user to GitHub to authorize the app
+func (h *Handler) GitHubLogin(c *gin.Context) {
+	config := h.oauthConfig()
+	if config == nil {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{
+			Error: "GitHub sign-in is not configured",
+		})
+		return
+	}
+
+	state := make([]byte, 16)
+	if _, err := rand.Read(state); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to start GitHub sign-in",
+			Details: err.Error(),
+		})
+		return
+	}
+	stateValue := hex.EncodeToString(state)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, stateValue, int((10 * time.Minute).Seconds()), "/", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, config.AuthCodeURL(stateValue))
+}
+
+// GitHubCallback exchanges the authorization code from GitHub for the user's access token
+func (h *Handler) GitHubCallback(c *gin.Context) {
+	config := h.oauthConfig()
+	if config == nil {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{
+			Error: "GitHub sign-in is not configured",
+		})
+		return
+	}
+
+	expected, err := c.Cookie(oauthStateCookie)
+	state := c.Query("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expected)) != 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid OAuth state",
+			Details: "restart the sign-in from the login endpoint",
+		})
+		return
+	}
+	c.SetCookie(oauthStateCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	token, err := config.Exchange(ctx, c.Query("code"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Failed to complete GitHub sign-in",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token.AccessToken,
+		"token_type":   "bearer",
+		"scope":        token.Extra("scope"),
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -48,13 +49,9 @@ func (h *Handler) IndexCodebaseForNavigation(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error: "Invalid GitHub URL",
-            Details: err.Error(),
-        })
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
         return
     }
 
@@ -63,7 +60,7 @@ func (h *Handler) IndexCodebaseForNavigation(c *gin.Context) {
     defer cancel()
 
     // Get repository info
-    repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+    repoInfo, err := src.Info(ctx)
     if err != nil {
         c.JSON(http.StatusInternalServerError, models.ErrorResponse{
             Error: "Failed to get repository info",
@@ -73,7 +70,7 @@ func (h *Handler) IndexCodebaseForNavigation(c *gin.Context) {
     }
 
     // Get repository structure
-    structure, err := h.GithubClient.GetRepositoryStructure(ctx, owner, repo, req.Branch)
+    structure, err := github.BuildRepositoryStructure(ctx, src, req.Branch)
     if err != nil {
         c.JSON(http.StatusInternalServerError, models.ErrorResponse{
             Error: "Failed to get repository structure",
@@ -83,7 +80,7 @@ func (h *Handler) IndexCodebaseForNavigation(c *gin.Context) {
     }
 
     // Create the navigation service
-    navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
 
     // Generate default walkthrough
     walkthrough, err := navigationService.GenerateCodeWalkthrough(
@@ -141,13 +138,9 @@ func (h *Handler) NavigateCodebaseWithLLM(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error: "Invalid GitHub URL",
-            Details: err.Error(),
-        })
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
         return
     }
 
@@ -156,7 +149,7 @@ func (h *Handler) NavigateCodebaseWithLLM(c *gin.Context) {
     defer cancel()
 
     // Create the navigation service
-    navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
 
     // Get answer to the question
     answer, err := navigationService.AnswerCodebaseQuestion(
@@ -189,13 +182,9 @@ func (h *Handler) GenerateCodeWalkthrough(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error: "Invalid GitHub URL",
-            Details: err.Error(),
-        })
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
         return
     }
 
@@ -204,7 +193,7 @@ func (h *Handler) GenerateCodeWalkthrough(c *gin.Context) {
     defer cancel()
 
     // Create the navigation service
-    navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
 
     // Generate code walkthrough
     walkthrough, err := navigationService.GenerateCodeWalkthrough(
@@ -217,10 +206,7 @@ func (h *Handler) GenerateCodeWalkthrough(c *gin.Context) {
         req.EntryPoints,
     )
     if err != nil {
-        c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-            Error: "Failed to generate code walkthrough",
-            Details: err.Error(),
-        })
+        writeServiceError(c, "Failed to generate code walkthrough", err)
         return
     }
 
@@ -238,22 +224,26 @@ func (h *Handler) ExplainFunction(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
-    if err != nil {
+    if req.FunctionName == "" && req.Line <= 0 && (req.LineStart <= 0 || req.LineEnd <= 0) {
         c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error: "Invalid GitHub URL",
-            Details: err.Error(),
+            Error: "Invalid request",
+            Details: "function_name, line or line_start and line_end is required",
         })
         return
     }
 
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
     // Set a timeout for the GitHub API request
     ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
     defer cancel()
 
     // Create the navigation service
-    navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
 
     // Generate function explanation
     explanation, err := navigationService.ExplainFunction(
@@ -263,6 +253,7 @@ func (h *Handler) ExplainFunction(c *gin.Context) {
         req.Branch,
         req.FilePath,
         req.FunctionName,
+        req.Line,
         req.LineStart,
         req.LineEnd,
     )
@@ -277,9 +268,108 @@ func (h *Handler) ExplainFunction(c *gin.Context) {
     c.JSON(http.StatusOK, explanation)
 }
 
-// GetArchitectureGraph handles architecture graph data requests
-func (h *Handler) GetArchitectureGraph(c *gin.Context) {
-    var req models.RepositoryRequest
+// FindDefinition handles go-to-definition requests
+func (h *Handler) FindDefinition(c *gin.Context) {
+    var req models.CodePositionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, models.ErrorResponse{
+            Error: "Invalid request",
+            Details: err.Error(),
+        })
+        return
+    }
+
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
+    // Set a timeout for the GitHub API request
+    ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
+    defer cancel()
+
+    // Create the navigation service
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+    definition, err := navigationService.FindDefinition(ctx, owner, repo, req.Branch, req.FilePath, req.Line, req.Column)
+    if err != nil {
+        writeServiceError(c, "Failed to find definition", err)
+        return
+    }
+
+    c.JSON(http.StatusOK, definition)
+}
+
+// FindReferences handles find-references requests
+func (h *Handler) FindReferences(c *gin.Context) {
+    var req models.CodePositionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, models.ErrorResponse{
+            Error: "Invalid request",
+            Details: err.Error(),
+        })
+        return
+    }
+
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
+    // Set a timeout for the GitHub API request
+    ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
+    defer cancel()
+
+    // Create the navigation service
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+    references, err := navigationService.FindReferences(ctx, owner, repo, req.Branch, req.FilePath, req.Line, req.Column)
+    if err != nil {
+        writeServiceError(c, "Failed to find references", err)
+        return
+    }
+
+    c.JSON(http.StatusOK, references)
+}
+
+// GetCodeOutline handles symbol outline requests
+func (h *Handler) GetCodeOutline(c *gin.Context) {
+    var req models.CodeOutlineRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, models.ErrorResponse{
+            Error: "Invalid request",
+            Details: err.Error(),
+        })
+        return
+    }
+
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
+    // Set a timeout for the GitHub API request
+    ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
+    defer cancel()
+
+    // Create the navigation service
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+    outline, err := navigationService.GenerateOutline(ctx, owner, repo, req.Branch, req.Path, req.ExportedOnly, req.MaxFiles)
+    if err != nil {
+        writeServiceError(c, "Failed to generate outline", err)
+        return
+    }
+
+    c.JSON(http.StatusOK, outline)
+}
+
+// AnalyzeMetrics handles code metrics requests
+func (h *Handler) AnalyzeMetrics(c *gin.Context) {
+    var req models.CodeMetricsRequest
     if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, models.ErrorResponse{
             Error: "Invalid request",
@@ -288,16 +378,53 @@ func (h *Handler) GetArchitectureGraph(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
+    // Set a timeout for the GitHub API request
+    ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
+    defer cancel()
+
+    // Create the navigation service
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+    metrics, err := navigationService.AnalyzeMetrics(ctx, owner, repo, req.Branch, req.Path, req.MaxFiles)
     if err != nil {
+        writeServiceError(c, "Failed to analyze code metrics", err)
+        return
+    }
+
+    c.JSON(http.StatusOK, metrics)
+}
+
+// GetArchitectureGraph handles architecture graph data requests
+func (h *Handler) GetArchitectureGraph(c *gin.Context) {
+    var req models.ArchitectureGraphRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
         c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error: "Invalid GitHub URL",
+            Error: "Invalid request",
             Details: err.Error(),
         })
         return
     }
 
+    if !graph.IsSupportedFormat(req.Format) {
+        c.JSON(http.StatusBadRequest, models.ErrorResponse{
+            Error: "Invalid format",
+            Details: "Supported formats are json, mermaid, dot, graphml and plantuml",
+        })
+        return
+    }
+
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
     // Check if Neo4j client is available
     if h.Neo4jClient == nil {
         c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
@@ -311,11 +438,25 @@ func (h *Handler) GetArchitectureGraph(c *gin.Context) {
     ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
     defer cancel()
 
+    // Resolve the default branch here, so the graph is read back under the branch it was stored under
+    branch := req.Branch
+    if branch == "" {
+        repoInfo, err := src.Info(ctx)
+        if err != nil {
+            c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+                Error: "Failed to get repository info",
+                Details: err.Error(),
+            })
+            return
+        }
+        branch = repoInfo.DefaultBranch
+    }
+
     // Create the navigation service
-    navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
     
     // Store the codebase structure in Neo4j
-    err = navigationService.StoreCodebaseInNeo4j(ctx, owner, repo, req.Branch)
+    err := navigationService.StoreCodebaseInNeo4j(ctx, owner, repo, branch)
     if err != nil {
         c.JSON(http.StatusInternalServerError, models.ErrorResponse{
             Error: "Failed to store codebase structure",
@@ -325,7 +466,7 @@ func (h *Handler) GetArchitectureGraph(c *gin.Context) {
     }
 
     // Get graph data
-    graphData, err := h.Neo4jClient.GetCodebaseGraph(ctx, owner, repo, req.Branch)
+    graphData, err := h.Neo4jClient.GetCodebaseGraph(ctx, owner, repo, branch)
     if err != nil {
         c.JSON(http.StatusInternalServerError, models.ErrorResponse{
             Error: "Failed to get architecture graph",
@@ -334,7 +475,36 @@ func (h *Handler) GetArchitectureGraph(c *gin.Context) {
         return
     }
 
-    c.JSON(http.StatusOK, graphData)
+    if isJSONFormat(req.Format) {
+        c.JSON(http.StatusOK, graphData)
+        return
+    }
+
+    importGraph, err := h.Neo4jClient.GetImportGraph(ctx, owner, repo, branch)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+            Error: "Failed to get architecture graph",
+            Details: err.Error(),
+        })
+        return
+    }
+    if importGraph == nil {
+        importGraph = graph.NewCodeGraph(nil, nil)
+    }
+
+    diagram, err := renderDiagram(importGraph.DiagramData(), req.Format, req.MaxNodes)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+            Error: "Failed to export architecture graph",
+            Details: err.Error(),
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, models.ArchitectureDiagramResponse{
+        Format:  req.Format,
+        Diagram: diagram,
+    })
 }
 
 // ExplainArchitectureGraph generates an explanation of the architecture graph
@@ -351,12 +521,9 @@ func (h *Handler) ExplainArchitectureGraph(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Invalid GitHub URL: " + err.Error(),
-        })
+    // Resolve the GitHub URL or local path
+    owner, repo, _, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
         return
     }
 
@@ -427,12 +594,16 @@ func (h *Handler) VisualizeArchitecture(c *gin.Context) {
         return
     }
 
-    // Parse the GitHub URL
-    owner, repo, err := github.ParseRepoURL(req.URL)
-    if err != nil {
+    // Resolve the GitHub URL or local path
+    owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+    if !ok {
+        return
+    }
+
+    if !graph.IsSupportedFormat(req.Format) {
         c.JSON(http.StatusBadRequest, models.ErrorResponse{
-            Error: "Invalid GitHub URL",
-            Details: err.Error(),
+            Error: "Invalid format",
+            Details: "Supported formats are json, mermaid, dot, graphml and plantuml",
         })
         return
     }
@@ -442,7 +613,7 @@ func (h *Handler) VisualizeArchitecture(c *gin.Context) {
     defer cancel()
 
     // Create the navigation service
-    navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+    navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
 
     // Generate architecture visualization
     architecture, err := navigationService.VisualizeArchitecture(
@@ -453,13 +624,38 @@ func (h *Handler) VisualizeArchitecture(c *gin.Context) {
         req.Detail,
         req.FocusPaths,
     )
+    if err != nil {
+        writeServiceError(c, "Failed to visualize architecture", err)
+        return
+    }
+
+    if isJSONFormat(req.Format) {
+        c.JSON(http.StatusOK, architecture)
+        return
+    }
+
+    diagram, err := renderDiagram(architecture.DiagramData, req.Format, req.MaxNodes)
     if err != nil {
         c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-            Error: "Failed to visualize architecture",
+            Error: "Failed to export architecture diagram",
             Details: err.Error(),
         })
         return
     }
 
-    c.JSON(http.StatusOK, architecture)
+    if strings.EqualFold(req.Format, graph.FormatMermaid) {
+        c.JSON(http.StatusOK, models.ArchitectureVisualizerMermaidResponse{
+            Overview:              architecture.Overview,
+            MermaidDiagram:        diagram,
+            ComponentDescriptions: architecture.ComponentDescriptions,
+        })
+        return
+    }
+
+    c.JSON(http.StatusOK, models.ArchitectureDiagramResponse{
+        Format:                req.Format,
+        Diagram:               diagram,
+        Overview:              architecture.Overview,
+        ComponentDescriptions: architecture.ComponentDescriptions,
+    })
 }
@@ -0,0 +1,48 @@
+// internal/api/handlers/errors.go
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/services"
+)
+
+// writeServiceError writes the response of a failed service call: 400 with every problem of
+// an invalid agent configuration or for a bad position, 404 when the request names a file,
+// symbol or path the repository doesn't have, 409 when the target branch moved, and 500 otherwise
+func writeServiceError(c *gin.Context, message string, err error) {
+	var configErr *github.AgentConfigError
+	switch {
+	case errors.As(err, &configErr):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:    message,
+			Details:  err.Error(),
+			Problems: configErr.Problems,
+		})
+	case errors.Is(err, services.ErrInvalidPosition):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   message,
+			Details: err.Error(),
+		})
+	case errors.Is(err, github.ErrFileNotFound), errors.Is(err, services.ErrNoDefinition),
+		errors.Is(err, services.ErrNoSourceFiles):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   message,
+			Details: err.Error(),
+		})
+	case errors.Is(err, github.ErrBranchMoved):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   message,
+			Details: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Details: err.Error(),
+		})
+	}
+}
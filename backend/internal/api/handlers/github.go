@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/services"
 )
 
 // GetRepositoryInfo handles repository info requests
@@ -23,13 +25,9 @@ func (h *Handler) GetRepositoryInfo(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Resolve the GitHub URL or local path
+	_, _, src, ok := h.parseRepoSource(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -38,7 +36,7 @@ func (h *Handler) GetRepositoryInfo(c *gin.Context) {
 	defer cancel()
 
 	// Get repository info
-	repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+	repoInfo, err := src.Info(ctx)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to get repository info",
@@ -47,15 +45,9 @@ func (h *Handler) GetRepositoryInfo(c *gin.Context) {
 		return
 	}
 
-	// Get repository languages
-	languages, err := h.GithubClient.GetRepositoryLanguages(ctx, owner, repo)
-	if err != nil {
-		h.Logger.WithField("error", err).Warning("Failed to get repository languages")
-	}
-
 	// Convert languages to a slice
-	languageSlice := make([]string, 0, len(languages))
-	for lang := range languages {
+	languageSlice := make([]string, 0, len(repoInfo.Languages))
+	for lang := range repoInfo.Languages {
 		languageSlice = append(languageSlice, lang)
 	}
 
@@ -89,13 +81,9 @@ func (h *Handler) GetFileContent(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Resolve the GitHub URL or local path
+	_, _, src, ok := h.parseRepoSource(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -106,7 +94,7 @@ func (h *Handler) GetFileContent(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 		
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := src.Info(ctx)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -119,7 +107,7 @@ func (h *Handler) GetFileContent(c *gin.Context) {
 	defer cancel()
 
 	// Get file content
-	fileContent, err := h.GithubClient.GetFileContentText(ctx, owner, repo, req.Path, branch)
+	fileContent, err := src.ReadFile(ctx, branch, req.Path)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to get file content",
@@ -150,13 +138,9 @@ func (h *Handler) ListFiles(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Resolve the GitHub URL or local path
+	owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -173,7 +157,7 @@ func (h *Handler) ListFiles(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 		
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := src.Info(ctx)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -185,8 +169,26 @@ func (h *Handler) ListFiles(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 	defer cancel()
 
+	// Snapshot and local sources list the directory from their own tree
+	if _, isAPI := src.(*github.APISource); !isAPI {
+		fileList, err := github.ListDirectory(ctx, src, branch, path)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to list files",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"files": fileList,
+			"path":  path,
+		})
+		return
+	}
+
 	// List files
-	files, err := h.GithubClient.ListFiles(ctx, owner, repo, path, branch)
+	files, err := h.githubClient(c).ListFiles(ctx, owner, repo, path, branch)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to list files",
@@ -231,13 +233,9 @@ func (h *Handler) SearchCode(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Parse the GitHub URL and resolve the client for the caller
+	owner, repo, client, ok := h.parseRepoClient(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -246,7 +244,7 @@ func (h *Handler) SearchCode(c *gin.Context) {
 	defer cancel()
 
 	// Search code
-	results, err := h.GithubClient.SearchCode(ctx, owner, repo, req.Query)
+	results, err := client.SearchCode(ctx, owner, repo, req.Query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to search code",
@@ -269,7 +267,7 @@ func (h *Handler) SearchCode(c *gin.Context) {
 			}
 			
 			// Get file content for each result
-			fileContent, err := h.GithubClient.GetFileContentText(ctx, owner, repo, *item.Path, req.Branch)
+			fileContent, err := client.GetFileContentText(ctx, owner, repo, *item.Path, req.Branch)
 			if err != nil {
 				h.Logger.WithField("error", err).Warning("Failed to get file content for search result")
 				continue
@@ -309,14 +307,10 @@ func (h *Handler) SearchCode(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// PushFile handles file pushing requests
+// PushFile handles file pushing requests. All files in the request are written and deleted
+// in a single commit, on the branch or in a pull request against it.
 func (h *Handler) PushFile(c *gin.Context) {
-	var req struct {
-		models.RepositoryRequest
-		Path    string `json:"path" binding:"required"`
-		Content string `json:"content" binding:"required"`
-		Message string `json:"message"`
-	}
+	var req models.PushRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: "Invalid request",
@@ -325,8 +319,29 @@ func (h *Handler) PushFile(c *gin.Context) {
 		return
 	}
 
+	files := req.Files
+	if req.Path != "" {
+		files = append([]models.FileChange{{Path: req.Path, Content: req.Content}}, files...)
+	}
+	if len(files) == 0 && len(req.Delete) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Details: "path, files or delete is required",
+		})
+		return
+	}
+
+	mode, err := github.ParsePushMode(req.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
+	owner, repo, err := h.GithubClient.Hosts().ParseRepoURL(req.URL)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: "Invalid GitHub URL",
@@ -335,6 +350,12 @@ func (h *Handler) PushFile(c *gin.Context) {
 		return
 	}
 
+	// Pushes act as the caller so commits are attributed to them
+	client, ok := h.userGithubClient(c)
+	if !ok {
+		return
+	}
+
 	// Use the specified branch or default to main
 	branch := req.Branch
 	if branch == "" {
@@ -342,7 +363,7 @@ func (h *Handler) PushFile(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 		
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := client.GetRepositoryInfo(ctx, owner, repo)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -350,32 +371,58 @@ func (h *Handler) PushFile(c *gin.Context) {
 		}
 	}
 
+	// Stage every change in one commit
+	commit := client.NewCommit(owner, repo, branch).ExpectHead(req.BaseSHA)
+	contents := make(map[string]string, len(files))
+	paths := make([]string, 0, len(files)+len(req.Delete))
+	for _, file := range files {
+		commit.Put(file.Path, file.Content)
+		contents[file.Path] = file.Content
+		paths = append(paths, file.Path)
+	}
+	for _, path := range req.Delete {
+		commit.Delete(path)
+		paths = append(paths, path)
+	}
+
+	// The same set of paths reuses the same pull request
+	prBranch := req.PRBranch
+	if prBranch == "" {
+		sort.Strings(paths)
+		prBranch = github.PullRequestBranch(branch, append([]string{"update"}, paths...)...)
+	}
+
 	// Use default commit message if not provided
 	message := req.Message
 	if message == "" {
-		message = "Update " + req.Path + " via GitHub Agent"
+		if commit.Len() == 1 && len(files) == 1 {
+			message = "Update " + files[0].Path + " via GitHub Agent"
+		} else {
+			message = fmt.Sprintf("Update %d files via GitHub Agent", commit.Len())
+		}
 	}
 
 	// Set a timeout for the GitHub API request
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 	defer cancel()
 
-	// Push the file
-	err = h.GithubClient.CreateOrUpdateFile(ctx, owner, repo, req.Path, message, req.Content, branch)
+	// Push the files
+	result, err := services.NewPushService(client, h.LLMClient).Push(ctx, commit, services.Change{
+		Mode:    mode,
+		Message: message,
+		Branch:  prBranch,
+		Intent:  message,
+		Files:   contents,
+		Deleted: req.Delete,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Failed to push file",
-			Details: err.Error(),
-		})
+		writeServiceError(c, "Failed to push files", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
-		Message: "File pushed successfully",
-		Data: gin.H{
-			"path":   req.Path,
-			"branch": branch,
-		},
+		Message: "Files pushed successfully",
+		Data: result,
 	})
 }
 
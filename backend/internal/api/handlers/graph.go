@@ -0,0 +1,185 @@
+// internal/api/handlers/graph.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/graph"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/services"
+)
+
+// DiffArchitectureGraph handles requests to compare the architecture of two refs
+func (h *Handler) DiffArchitectureGraph(c *gin.Context) {
+	var req models.GraphDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	// Resolve the GitHub URL or local path
+	owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+	if !ok {
+		return
+	}
+
+	// Building two graphs can take a while on large repositories
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 600*time.Second)
+	defer cancel()
+
+	navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+	result, err := navigationService.DiffArchitecture(ctx, owner, repo, req.BaseRef, req.HeadRef, req.Refresh)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to diff architecture graphs",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetGraphHistory handles requests for graph metrics over recent commits or tags
+func (h *Handler) GetGraphHistory(c *gin.Context) {
+	var req models.GraphHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	owner, repo, src, ok := h.parseStoredGraphRepo(c, req.URL)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 600*time.Second)
+	defer cancel()
+
+	navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+	result, err := navigationService.GetGraphHistory(ctx, owner, repo, req.Branch, req.Limit, req.UseTags)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get graph history",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// FindDependencyIntroduction handles requests asking when one package started depending on another
+func (h *Handler) FindDependencyIntroduction(c *gin.Context) {
+	var req models.DependencyIntroductionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	owner, repo, src, ok := h.parseStoredGraphRepo(c, req.URL)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 600*time.Second)
+	defer cancel()
+
+	navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+	result, err := navigationService.FindDependencyIntroduction(ctx, owner, repo, req.Branch, req.Limit, req.UseTags, req.From, req.To)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to find dependency introduction",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// QueryGraph handles natural-language questions answered by a read-only graph query
+func (h *Handler) QueryGraph(c *gin.Context) {
+	var req models.GraphQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	owner, repo, src, ok := h.parseStoredGraphRepo(c, req.URL)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
+	defer cancel()
+
+	navigationService := services.NewCodeNavigationService(h.githubClient(c), h.LLMClient, h.Neo4jClient).WithSource(src)
+
+	result, err := navigationService.QueryGraph(ctx, owner, repo, req.Branch, req.Question)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, graph.ErrQueryNotAllowed) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to query graph",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseStoredGraphRepo resolves the repository and checks that Neo4j is available for stored graph queries
+func (h *Handler) parseStoredGraphRepo(c *gin.Context, url string) (string, string, github.RepoSource, bool) {
+	owner, repo, src, ok := h.parseRepoSource(c, url)
+	if !ok {
+		return "", "", nil, false
+	}
+
+	if h.Neo4jClient == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error:   "Neo4j service not available",
+			Details: "The Neo4j database is not configured or not accessible",
+		})
+		return "", "", nil, false
+	}
+
+	return owner, repo, src, true
+}
+
+// isJSONFormat reports whether a requested export format is the default JSON output
+func isJSONFormat(format string) bool {
+	return format == "" || strings.EqualFold(format, graph.FormatJSON)
+}
+
+// renderDiagram collapses large diagrams into directory clusters and exports them
+func renderDiagram(data models.DiagramData, format string, maxNodes int) (string, error) {
+	if maxNodes <= 0 {
+		maxNodes = graph.DefaultMaxDiagramNodes
+	}
+	return graph.Export(graph.CollapseDirectories(data, maxNodes), format)
+}
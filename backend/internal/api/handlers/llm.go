@@ -6,9 +6,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/llm"
 	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/services"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 )
 
 // GenerateReadme handles README generation requests
@@ -22,13 +23,9 @@ func (h *Handler) GenerateReadme(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Parse the GitHub URL and resolve the client for the caller
+	owner, repo, client, ok := h.parseRepoClient(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -36,59 +33,16 @@ func (h *Handler) GenerateReadme(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 	defer cancel()
 
-	// Get repository info
-	repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to get repository info",
-			Details: err.Error(),
-		})
-		return
-	}
-
-	// Convert repository info to a map for the LLM
-	repoInfoMap := map[string]interface{}{
-		"owner":          repoInfo.Owner,
-		"name":           repoInfo.Name,
-		"description":    repoInfo.Description,
-		"default_branch": repoInfo.DefaultBranch,
-		"url":            repoInfo.URL,
-		"language":       repoInfo.Language,
-		"stars":          repoInfo.Stars,
-		"forks":          repoInfo.Forks,
-		"has_readme":     repoInfo.HasReadme,
-	}
-
-	// Get file list if requested
-	var files []string
-	if req.IncludeFiles {
-		// Use the specified branch or default
-		branch := req.Branch
-		if branch == "" {
-			branch = repoInfo.DefaultBranch
-		}
-
-		// Get repository structure
-		repoStructure, err := h.GithubClient.GetRepositoryStructure(ctx, owner, repo, branch)
-		if err != nil {
-			h.Logger.WithField("error", err).Warning("Failed to get repository structure")
-		} else {
-			files = splitLines(repoStructure)
-		}
-	}
-
-	// Generate README
-	readmeContent, err := h.LLMClient.GenerateReadme(ctx, repoInfoMap, files)
+	// Generate README, following the repository's agent configuration
+	readme, err := services.NewReadmeService(client, h.LLMClient).GenerateReadme(ctx, owner, repo, req.Branch, req.IncludeFiles)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to generate README",
-			Details: err.Error(),
-		})
+		writeServiceError(c, "Failed to generate README", err)
 		return
 	}
 
 	response := models.GenerateResponse{
-		Content: readmeContent,
+		Content: readme.Content,
+		Skipped: readme.Skipped,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -105,13 +59,9 @@ func (h *Handler) GenerateDockerfile(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Parse the GitHub URL and resolve the client for the caller
+	owner, repo, client, ok := h.parseRepoClient(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -120,7 +70,7 @@ func (h *Handler) GenerateDockerfile(c *gin.Context) {
 	defer cancel()
 
 	// Get repository info
-	repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+	repoInfo, err := client.GetRepositoryInfo(ctx, owner, repo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to get repository info",
@@ -173,13 +123,9 @@ func (h *Handler) GenerateComments(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Parse the GitHub URL and resolve the client for the caller
+	owner, repo, client, ok := h.parseRepoClient(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -190,7 +136,7 @@ func (h *Handler) GenerateComments(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := client.GetRepositoryInfo(ctx, owner, repo)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -202,26 +148,10 @@ func (h *Handler) GenerateComments(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 	defer cancel()
 
-	// Get file content
-	fileContent, err := h.GithubClient.GetFileContentText(ctx, owner, repo, req.FilePath, branch)
+	// Generate comments, following the repository's agent configuration
+	commentedCode, err := services.NewCommenterService(client, h.LLMClient).GenerateComments(ctx, owner, repo, branch, req.FilePath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to get file content",
-			Details: err.Error(),
-		})
-		return
-	}
-
-	// Detect language from file extension
-	language := detectLanguageFromPath(req.FilePath)
-
-	// Generate comments
-	commentedCode, err := h.LLMClient.GenerateCodeComments(ctx, fileContent.Content, language)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to generate comments",
-			Details: err.Error(),
-		})
+		writeServiceError(c, "Failed to generate comments", err)
 		return
 	}
 
@@ -243,13 +173,9 @@ func (h *Handler) RefactorCode(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Parse the GitHub URL and resolve the client for the caller
+	owner, repo, client, ok := h.parseRepoClient(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -260,7 +186,7 @@ func (h *Handler) RefactorCode(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := client.GetRepositoryInfo(ctx, owner, repo)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -273,7 +199,7 @@ func (h *Handler) RefactorCode(c *gin.Context) {
 	defer cancel()
 
 	// Get file content
-	fileContent, err := h.GithubClient.GetFileContentText(ctx, owner, repo, req.FilePath, branch)
+	fileContent, err := client.GetFileContentText(ctx, owner, repo, req.FilePath, branch)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to get file content",
@@ -282,8 +208,8 @@ func (h *Handler) RefactorCode(c *gin.Context) {
 		return
 	}
 
-	// Detect language from file extension
-	language := detectLanguageFromPath(req.FilePath)
+	// Detect the language from the file name and content
+	language := utils.LanguageID(req.FilePath, fileContent.Content)
 
 	// Generate refactored code
 	refactoredCode, err := h.LLMClient.GenerateCodeRefactor(ctx, fileContent.Content, language, req.Instructions)
@@ -342,81 +268,3 @@ func (h *Handler) ProcessLLMOperation(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
-
-// Helper functions
-
-// detectLanguageFromPath detects the programming language from a file path
-func detectLanguageFromPath(path string) string {
-	// Extract extension from path
-	ext := ""
-	for i := len(path) - 1; i >= 0; i-- {
-		if path[i] == '.' {
-			ext = path[i+1:]
-			break
-		}
-		if path[i] == '/' {
-			break
-		}
-	}
-
-	// Map extension to language
-	switch ext {
-	case "py":
-		return "python"
-	case "js":
-		return "javascript"
-	case "ts":
-		return "typescript"
-	case "go":
-		return "go"
-	case "java":
-		return "java"
-	case "c":
-		return "c"
-	case "cpp", "cc", "cxx":
-		return "c++"
-	case "cs":
-		return "c#"
-	case "rb":
-		return "ruby"
-	case "php":
-		return "php"
-	case "swift":
-		return "swift"
-	case "kt", "kts":
-		return "kotlin"
-	case "rs":
-		return "rust"
-	case "sh":
-		return "shell"
-	case "html":
-		return "html"
-	case "css":
-		return "css"
-	case "md":
-		return "markdown"
-	default:
-		return "unknown"
-	}
-}
-
-// splitLines splits a string into lines
-func splitLines(s string) []string {
-	var lines []string
-	var line []rune
-
-	for _, r := range s {
-		if r == '\n' {
-			lines = append(lines, string(line))
-			line = line[:0]
-		} else {
-			line = append(line, r)
-		}
-	}
-
-	if len(line) > 0 {
-		lines = append(lines, string(line))
-	}
-
-	return lines
-}
\ No newline at end of file
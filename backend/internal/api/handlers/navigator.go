@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/models"
 	"github.com/pbearc/github-agent/backend/internal/pinecone"
 	"github.com/pbearc/github-agent/backend/internal/services"
@@ -23,13 +22,9 @@ func (h *Handler) IndexCodebase(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Resolve the GitHub URL or local path
+	owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -40,7 +35,7 @@ func (h *Handler) IndexCodebase(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 		
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := src.Info(ctx)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -78,22 +73,22 @@ func (h *Handler) IndexCodebase(c *gin.Context) {
 	}
 
 	// Create indexer service
-	indexerService := services.NewIndexerService(h.GithubClient, pineconeClient, h.LLMClient)
+	indexerService := services.NewIndexerService(h.githubClient(c), pineconeClient, h.LLMClient).WithSource(src)
 
 	// Index repository
-	namespace, err := indexerService.IndexRepository(ctx, owner, repo, branch)
+	result, err := indexerService.IndexRepository(ctx, owner, repo, branch)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to index repository",
-			Details: err.Error(),
-		})
+		writeServiceError(c, "Failed to index repository", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, models.CodebaseIndexResponse{
-		Message:   "Repository indexed successfully",
-		Status:    "completed",
-		Namespace: namespace,
+		Message:    "Repository indexed successfully",
+		Status:     "completed",
+		Namespace:  result.Namespace,
+		FileCount:  result.FileCount,
+		ChunkCount: result.ChunkCount,
+		Skipped:    result.Skipped,
 	})
 }
 
@@ -108,13 +103,9 @@ func (h *Handler) NavigateCodebase(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Resolve the GitHub URL or local path
+	owner, repo, src, ok := h.parseRepoSource(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -125,7 +116,7 @@ func (h *Handler) NavigateCodebase(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 		defer cancel()
 		
-		repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+		repoInfo, err := src.Info(ctx)
 		if err != nil {
 			branch = "main" // Fallback to main if unable to determine
 		} else {
@@ -154,10 +145,10 @@ func (h *Handler) NavigateCodebase(c *gin.Context) {
 
 	// Create navigator service
 	navigatorService := services.NewNavigatorService(
-		h.GithubClient,
+		h.githubClient(c),
 		pineconeClient,
 		h.LLMClient,
-	)
+	).WithSource(src)
 
 	// Answer the question
 	response, err := navigatorService.AnswerQuestion(ctx, owner, repo, branch, req.Question, req.TopK)
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/models"
 	"github.com/pbearc/github-agent/backend/internal/services"
 )
@@ -24,7 +23,7 @@ func (h *Handler) GetPRSummary(c *gin.Context) {
 	}
 
 	// Parse the GitHub PR URL
-	owner, repo, prNumber, err := github.ParsePullRequestURL(req.URL)
+	owner, repo, prNumber, err := h.GithubClient.Hosts().ParsePullRequestURL(req.URL)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid GitHub pull request URL",
@@ -33,20 +32,23 @@ func (h *Handler) GetPRSummary(c *gin.Context) {
 		return
 	}
 
+	// Resolve the client for the caller
+	client, ok := h.readGithubClient(c, owner, repo)
+	if !ok {
+		return
+	}
+
 	// Set a timeout for the operation
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 300*time.Second)
 	defer cancel()
 
 	// Create PR summary service
-	prSummaryService := services.NewPRSummaryService(h.GithubClient, h.LLMClient)
+	prSummaryService := services.NewPRSummaryService(client, h.LLMClient)
 
 	// Generate summary
 	summary, err := prSummaryService.GenerateSummary(ctx, owner, repo, prNumber)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to generate pull request summary",
-			Details: err.Error(),
-		})
+		writeServiceError(c, "Failed to generate pull request summary", err)
 		return
 	}
 
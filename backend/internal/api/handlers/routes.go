@@ -18,6 +18,13 @@ func SetupRoutes(router *gin.Engine, githubClient *github.Client, llmClient *llm
     // API routes
     api := router.Group("/api")
     {
+        // GitHub sign-in routes
+        auth := api.Group("/auth/github")
+        {
+            auth.GET("/login", handler.GitHubLogin)
+            auth.GET("/callback", handler.GitHubCallback)
+        }
+
         // Repository routes
         repo := api.Group("/repo")
         {
@@ -41,6 +48,20 @@ func SetupRoutes(router *gin.Engine, githubClient *github.Client, llmClient *llm
             generate.POST("/refactor", handler.RefactorCode)
         }
 
+        // Code navigation routes
+        code := api.Group("/code")
+        {
+            code.POST("/definition", handler.FindDefinition)
+            code.POST("/references", handler.FindReferences)
+            code.POST("/outline", handler.GetCodeOutline)
+        }
+
+        // Analysis routes
+        analyze := api.Group("/analyze")
+        {
+            analyze.POST("/metrics", handler.AnalyzeMetrics)
+        }
+
         // Navigator routes (replacing search)
         navigate := api.Group("/navigate")
         {
@@ -60,6 +81,15 @@ func SetupRoutes(router *gin.Engine, githubClient *github.Client, llmClient *llm
             push.POST("/file", handler.PushFile)
         }
 
+        // Architecture graph routes
+        graphRoutes := api.Group("/graph")
+        {
+            graphRoutes.POST("/diff", handler.DiffArchitectureGraph)
+            graphRoutes.POST("/history", handler.GetGraphHistory)
+            graphRoutes.POST("/history/dependency", handler.FindDependencyIntroduction)
+            graphRoutes.POST("/query", handler.QueryGraph)
+        }
+
         pr := api.Group("/pr")
         {
             pr.POST("/summary", handler.GetPRSummary)
@@ -81,8 +111,15 @@ func SetupRoutes(router *gin.Engine, githubClient *github.Client, llmClient *llm
 
 // HealthCheck handles health check requests
 func (h *Handler) HealthCheck(c *gin.Context) {
-	c.JSON(200, gin.H{
+	response := gin.H{
 		"status": "ok",
 		"service": "github-agent",
-	})
+	}
+
+	// Report the last GitHub quota seen, so probes never call GitHub themselves
+	if rateLimits := h.GithubClient.ObservedRateLimits(); len(rateLimits) > 0 {
+		response["github_rate_limits"] = rateLimits
+	}
+
+	c.JSON(200, response)
 }
\ No newline at end of file
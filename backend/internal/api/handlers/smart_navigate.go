@@ -43,13 +43,9 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		return
 	}
 
-	// Parse the GitHub URL
-	owner, repo, err := github.ParseRepoURL(req.URL)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid GitHub URL",
-			Details: err.Error(),
-		})
+	// Parse the GitHub URL and resolve the client for the caller
+	owner, repo, client, ok := h.parseRepoClient(c, req.URL)
+	if !ok {
 		return
 	}
 
@@ -69,13 +65,16 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 
 	h.Logger.WithField("api_type", routerResponse.APIType).WithField("keywords", routerResponse.Keywords).Info("Routed question")
 
+	// Time windows, authors and other filters the router found in the question
+	filter := activityFilter(routerResponse)
+
 	// Handle different API types
 	var response *SmartNavigateResponse
 	
 	switch routerResponse.APIType {
 	case llm.APITypeCodeSearch:
 		// Use existing code navigation system
-		resp, err := h.handleCodeSearchQuestion(ctx, owner, repo, req.Branch, req.Question, routerResponse.Keywords)
+		resp, err := h.handleCodeSearchQuestion(ctx, client, owner, repo, req.Branch, req.Question, routerResponse.Keywords)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question with code search",
@@ -86,7 +85,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypeCommits:
-		resp, err := h.handleCommitsQuestion(ctx, owner, repo, req.Branch, req.Question, routerResponse.Keywords)
+		resp, err := h.handleCommitsQuestion(ctx, client, owner, repo, req.Branch, req.Question, routerResponse.Keywords, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about commits",
@@ -97,7 +96,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypePulls:
-		resp, err := h.handlePullsQuestion(ctx, owner, repo, req.Question, routerResponse.Keywords)
+		resp, err := h.handlePullsQuestion(ctx, client, owner, repo, req.Question, routerResponse.Keywords, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about pull requests",
@@ -108,7 +107,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypeIssues:
-		resp, err := h.handleIssuesQuestion(ctx, owner, repo, req.Question, routerResponse.Keywords)
+		resp, err := h.handleIssuesQuestion(ctx, client, owner, repo, req.Question, routerResponse.Keywords, filter)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about issues",
@@ -119,7 +118,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypeReleases:
-		resp, err := h.handleReleasesQuestion(ctx, owner, repo, req.Question, routerResponse.Keywords)
+		resp, err := h.handleReleasesQuestion(ctx, client, owner, repo, req.Question, routerResponse.Keywords)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about releases",
@@ -130,7 +129,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypeStats:
-		resp, err := h.handleStatsQuestion(ctx, owner, repo, req.Question, routerResponse.Keywords)
+		resp, err := h.handleStatsQuestion(ctx, client, owner, repo, req.Question, routerResponse.Keywords)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about repository statistics",
@@ -141,7 +140,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypeUsers:
-		resp, err := h.handleUsersQuestion(ctx, owner, repo, req.Question, routerResponse.Keywords)
+		resp, err := h.handleUsersQuestion(ctx, client, owner, repo, req.Question, routerResponse.Keywords)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about users",
@@ -152,7 +151,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 		response = resp
 
 	case llm.APITypeRepos:
-		resp, err := h.handleReposQuestion(ctx, owner, repo, req.Question, routerResponse.Keywords)
+		resp, err := h.handleReposQuestion(ctx, client, owner, repo, req.Question, routerResponse.Keywords)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question about repository",
@@ -164,7 +163,7 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 
 	default:
 		// Fallback to code search if routing fails
-		resp, err := h.handleCodeSearchQuestion(ctx, owner, repo, req.Branch, req.Question, nil)
+		resp, err := h.handleCodeSearchQuestion(ctx, client, owner, repo, req.Branch, req.Question, nil)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 				Error:   "Failed to answer question",
@@ -178,10 +177,23 @@ func (h *Handler) SmartNavigate(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// activityFilter builds the commit, pull request and issue filter from a routed question
+func activityFilter(route *llm.QuestionRouterResponse) github.ActivityFilter {
+	since, until := route.TimeWindow()
+	return github.ActivityFilter{
+		Since:   since,
+		Until:   until,
+		Authors: route.Authors,
+		Labels:  route.Labels,
+		State:   strings.ToLower(route.State),
+		Path:    route.Path,
+	}
+}
+
 // handleCodeSearchQuestion handles questions about code using the existing code navigation system
-func (h *Handler) handleCodeSearchQuestion(ctx context.Context, owner, repo, branch, question string, keywords []string) (*SmartNavigateResponse, error) {
+func (h *Handler) handleCodeSearchQuestion(ctx context.Context, client *github.Client, owner, repo, branch, question string, keywords []string) (*SmartNavigateResponse, error) {
 	// Create the navigation service
-	navigationService := services.NewCodeNavigationService(h.GithubClient, h.LLMClient, h.Neo4jClient)
+	navigationService := services.NewCodeNavigationService(client, h.LLMClient, h.Neo4jClient)
 
 	// Use the existing AnswerCodebaseQuestion method but pass the keywords if available
 	answer, err := navigationService.AnswerCodebaseQuestion(ctx, owner, repo, branch, question, keywords)
@@ -199,9 +211,9 @@ func (h *Handler) handleCodeSearchQuestion(ctx context.Context, owner, repo, bra
 }
 
 // handleCommitsQuestion handles questions about commits
-func (h *Handler) handleCommitsQuestion(ctx context.Context, owner, repo, branch, question string, keywords []string) (*SmartNavigateResponse, error) {
-	// Get commit data - implement this method in your GitHub client
-	commits, err := h.GithubClient.GetCommits(ctx, owner, repo, branch, keywords)
+func (h *Handler) handleCommitsQuestion(ctx context.Context, client *github.Client, owner, repo, branch, question string, keywords []string, filter github.ActivityFilter) (*SmartNavigateResponse, error) {
+	// Get commit data across every page in the question's window
+	commits, truncated, err := client.GetCommits(ctx, owner, repo, branch, keywords, filter)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get commits")
 	}
@@ -220,7 +232,7 @@ Question: %s
 
 Here are the relevant commits:
 %s
-
+%s
 Please provide:
 1. A comprehensive answer to the question
 2. Include references to specific commits when relevant
@@ -228,7 +240,7 @@ Please provide:
 4. If appropriate, suggest 2-3 follow-up questions
 
 Format your response in a clear, structured manner with markdown formatting.
-`, question, string(commitsJSON))
+`, question, string(commitsJSON), truncationNote(truncated))
 
 	// Generate response using LLM
 	answer, err := h.LLMClient.GenerateText(ctx, prompt)
@@ -244,21 +256,59 @@ Format your response in a clear, structured manner with markdown formatting.
 		APIType:           llm.APITypeCommits,
 		FollowupQuestions: followups,
 		ExtraData: map[string]interface{}{
-			"commits": commits,
+			"commits":   commits,
+			"truncated": truncated,
 		},
 	}, nil
 }
 
+// truncationNote tells the LLM when a listing stopped at a cap, so answers about counts and
+// totals say the data is incomplete
+func truncationNote(truncated bool) string {
+	if !truncated {
+		return ""
+	}
+	return "\nThis list stopped at a limit and may not hold every matching item. Say so if the answer depends on the rest.\n"
+}
+
+// maxContextItems is the number of pull requests or issues whose full context is sent to the LLM
+const maxContextItems = 20
+
+// toAny converts a slice to a slice of interface{} so slices of different types can be joined
+func toAny[T any](items []T) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result
+}
+
 // handlePullsQuestion handles questions about pull requests
-func (h *Handler) handlePullsQuestion(ctx context.Context, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
-	// Get pull request data - implement this method in your GitHub client
-	pullRequests, err := h.GithubClient.GetPullRequests(ctx, owner, repo, keywords)
+func (h *Handler) handlePullsQuestion(ctx context.Context, client *github.Client, owner, repo, question string, keywords []string, filter github.ActivityFilter) (*SmartNavigateResponse, error) {
+	// Get pull request data across every page in the question's window
+	pullRequests, truncated, err := client.GetPullRequests(ctx, owner, repo, keywords, filter)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get pull requests")
 	}
 
+	// Add reviews, threads, linked issues and checks in bulk when GraphQL is available
+	var pullsData interface{} = pullRequests
+	if client.GraphQLAvailable() && len(pullRequests) > 0 {
+		numbers := make([]int, 0, min(len(pullRequests), maxContextItems))
+		for _, pr := range pullRequests[:min(len(pullRequests), maxContextItems)] {
+			numbers = append(numbers, pr.Number)
+		}
+		contexts, err := client.GetPullRequestContexts(ctx, owner, repo, numbers)
+		if err != nil {
+			h.Logger.WithError(err).Warning("Failed to get pull request context, using listed pull requests")
+		} else {
+			// Pull requests past the first maxContextItems stay as listed
+			pullsData = append(toAny(contexts), toAny(pullRequests[len(numbers):])...)
+		}
+	}
+
 	// Convert pull requests to a format suitable for the LLM
-	pullsJSON, err := json.Marshal(pullRequests)
+	pullsJSON, err := json.Marshal(pullsData)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to marshal pull requests")
 	}
@@ -271,7 +321,7 @@ Question: %s
 
 Here are the relevant pull requests:
 %s
-
+%s
 Please provide:
 1. A comprehensive answer to the question
 2. Include references to specific pull requests when relevant
@@ -279,7 +329,7 @@ Please provide:
 4. If appropriate, suggest 2-3 follow-up questions
 
 Format your response in a clear, structured manner with markdown formatting.
-`, question, string(pullsJSON))
+`, question, string(pullsJSON), truncationNote(truncated))
 
 	// Generate response using LLM
 	answer, err := h.LLMClient.GenerateText(ctx, prompt)
@@ -296,20 +346,37 @@ Format your response in a clear, structured manner with markdown formatting.
 		FollowupQuestions: followups,
 		ExtraData: map[string]interface{}{
 			"pull_requests": pullRequests,
+			"truncated":     truncated,
 		},
 	}, nil
 }
 
 // handleIssuesQuestion handles questions about issues
-func (h *Handler) handleIssuesQuestion(ctx context.Context, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
-	// Get issues data - implement this method in your GitHub client
-	issues, err := h.GithubClient.GetIssues(ctx, owner, repo, keywords)
+func (h *Handler) handleIssuesQuestion(ctx context.Context, client *github.Client, owner, repo, question string, keywords []string, filter github.ActivityFilter) (*SmartNavigateResponse, error) {
+	// Get issues data across every page in the question's window
+	issues, truncated, err := client.GetIssues(ctx, owner, repo, keywords, filter)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get issues")
 	}
 
+	// Add comments and timelines in bulk when GraphQL is available
+	var issuesData interface{} = issues
+	if client.GraphQLAvailable() && len(issues) > 0 {
+		numbers := make([]int, 0, min(len(issues), maxContextItems))
+		for _, issue := range issues[:min(len(issues), maxContextItems)] {
+			numbers = append(numbers, issue.Number)
+		}
+		contexts, err := client.GetIssueContexts(ctx, owner, repo, numbers)
+		if err != nil {
+			h.Logger.WithError(err).Warning("Failed to get issue context, using listed issues")
+		} else {
+			// Issues past the first maxContextItems stay as listed
+			issuesData = append(toAny(contexts), toAny(issues[len(numbers):])...)
+		}
+	}
+
 	// Convert issues to a format suitable for the LLM
-	issuesJSON, err := json.Marshal(issues)
+	issuesJSON, err := json.Marshal(issuesData)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to marshal issues")
 	}
@@ -322,7 +389,7 @@ Question: %s
 
 Here are the relevant issues:
 %s
-
+%s
 Please provide:
 1. A comprehensive answer to the question
 2. Include references to specific issues when relevant
@@ -330,7 +397,7 @@ Please provide:
 4. If appropriate, suggest 2-3 follow-up questions
 
 Format your response in a clear, structured manner with markdown formatting.
-`, question, string(issuesJSON))
+`, question, string(issuesJSON), truncationNote(truncated))
 
 	// Generate response using LLM
 	answer, err := h.LLMClient.GenerateText(ctx, prompt)
@@ -346,15 +413,16 @@ Format your response in a clear, structured manner with markdown formatting.
 		APIType:           llm.APITypeIssues,
 		FollowupQuestions: followups,
 		ExtraData: map[string]interface{}{
-			"issues": issues,
+			"issues":    issues,
+			"truncated": truncated,
 		},
 	}, nil
 }
 
 // handleReleasesQuestion handles questions about releases
-func (h *Handler) handleReleasesQuestion(ctx context.Context, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
+func (h *Handler) handleReleasesQuestion(ctx context.Context, client *github.Client, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
 	// Get releases data - implement this method in your GitHub client
-	releases, err := h.GithubClient.GetReleases(ctx, owner, repo)
+	releases, err := client.GetReleases(ctx, owner, repo)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get releases")
 	}
@@ -403,9 +471,9 @@ Format your response in a clear, structured manner with markdown formatting.
 }
 
 // handleStatsQuestion handles questions about repository statistics
-func (h *Handler) handleStatsQuestion(ctx context.Context, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
+func (h *Handler) handleStatsQuestion(ctx context.Context, client *github.Client, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
 	// Get repository stats - implement this method in your GitHub client
-	stats, err := h.GithubClient.GetRepositoryStats(ctx, owner, repo)
+	stats, err := client.GetRepositoryStats(ctx, owner, repo)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get repository stats")
 	}
@@ -454,9 +522,9 @@ Format your response in a clear, structured manner with markdown formatting.
 }
 
 // handleUsersQuestion handles questions about users
-func (h *Handler) handleUsersQuestion(ctx context.Context, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
+func (h *Handler) handleUsersQuestion(ctx context.Context, client *github.Client, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
 	// Get repository contributors - implement this method in your GitHub client
-	contributors, err := h.GithubClient.GetContributors(ctx, owner, repo)
+	contributors, err := client.GetContributors(ctx, owner, repo)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get contributors")
 	}
@@ -505,22 +573,22 @@ Format your response in a clear, structured manner with markdown formatting.
 }
 
 // handleReposQuestion handles questions about the repository itself
-func (h *Handler) handleReposQuestion(ctx context.Context, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
+func (h *Handler) handleReposQuestion(ctx context.Context, client *github.Client, owner, repo, question string, keywords []string) (*SmartNavigateResponse, error) {
 	// Get repository info - implement this method in your GitHub client
-	repoInfo, err := h.GithubClient.GetRepositoryInfo(ctx, owner, repo)
+	repoInfo, err := client.GetRepositoryInfo(ctx, owner, repo)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get repository info")
 	}
 
 	// Get repository languages
-	languages, err := h.GithubClient.GetRepositoryLanguages(ctx, owner, repo)
+	languages, err := client.GetRepositoryLanguages(ctx, owner, repo)
 	if err != nil {
 		// Non-critical error, just log and continue
 		h.Logger.WithError(err).Warning("Failed to get repository languages")
 	}
 
 	// Get repository topics/tags
-	topics, err := h.GithubClient.GetRepositoryTopics(ctx, owner, repo)
+	topics, err := client.GetRepositoryTopics(ctx, owner, repo)
 	if err != nil {
 		// Non-critical error, just log and continue
 		h.Logger.WithError(err).Warning("Failed to get repository topics")
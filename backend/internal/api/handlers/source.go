@@ -0,0 +1,52 @@
+// internal/api/handlers/source.go
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// newLocalSource opens a local checkout if local sources are enabled
+func (h *Handler) newLocalSource(path string) (*github.LocalSource, error) {
+	if h.Config == nil || !h.Config.AllowLocalSources {
+		return nil, common.NewError("local repository paths are disabled; set ALLOW_LOCAL_SOURCES=true to enable them")
+	}
+	return github.NewLocalSource(path)
+}
+
+// newRepoSource returns the source the files of owner/repo are read from through client
+func (h *Handler) newRepoSource(client *github.Client, owner, repo string) github.RepoSource {
+	if h.Config != nil && h.Config.RepoSource == "archive" {
+		return github.NewArchiveSource(client, owner, repo, h.Config.ArchiveCacheDir)
+	}
+	return client.NewAPISource(owner, repo)
+}
+
+// parseRepoSource resolves the repository of a request to the source its files are read from,
+// writing an error response if the URL or path is invalid or the caller cannot read it
+func (h *Handler) parseRepoSource(c *gin.Context, url string) (string, string, github.RepoSource, bool) {
+	if !github.IsLocalPath(url) {
+		owner, repo, client, ok := h.parseRepoClient(c, url)
+		if !ok {
+			return "", "", nil, false
+		}
+		return owner, repo, h.newRepoSource(client, owner, repo), true
+	}
+
+	src, err := h.newLocalSource(url)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid repository URL or path",
+			Details: err.Error(),
+		})
+		return "", "", nil, false
+	}
+
+	// Results are stored under a namespace of their own, apart from the GitHub repository
+	owner, repo := src.Namespace()
+	return owner, repo, src, true
+}
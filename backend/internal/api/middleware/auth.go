@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -20,10 +21,25 @@ func CORS() gin.HandlerFunc {
 	return cors.New(config)
 }
 
-// Auth middleware (placeholder for future implementation)
+// GitHubTokenKey is the context key of the caller's GitHub token
+const GitHubTokenKey = "github_token"
+
+// Auth middleware reads the caller's GitHub token from the Authorization header.
+// Both "Bearer <token>" and "token <token>" are accepted; requests without one continue anonymously.
 func Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Add authentication logic here when needed
+		scheme, token, ok := strings.Cut(strings.TrimSpace(c.GetHeader("Authorization")), " ")
+		if ok && (strings.EqualFold(scheme, "bearer") || strings.EqualFold(scheme, "token")) {
+			if token = strings.TrimSpace(token); token != "" {
+				c.Set(GitHubTokenKey, token)
+			}
+		}
 		c.Next()
 	}
 }
+
+// GitHubToken returns the caller's GitHub token, if the request carried one
+func GitHubToken(c *gin.Context) (string, bool) {
+	token := c.GetString(GitHubTokenKey)
+	return token, token != ""
+}
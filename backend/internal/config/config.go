@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
@@ -15,7 +17,27 @@ type Config struct {
 	Environment string
 
 	// GitHub configuration
-	GitHubToken string
+	GitHubToken        string // Personal access token; the fallback when a GitHub App is configured
+	GitHubAppID        int64
+	GitHubAppKey       string // PEM private key of the GitHub App
+	RateLimitPolicy    string        // "wait" or "fail" once the quota is exhausted
+	RateLimitMaxWait   time.Duration // Longest wait for a rate limit reset
+	GitHubCacheEntries int           // Responses cached for conditional requests; 0 disables the cache
+
+	// GitHub OAuth app used to sign users in
+	GitHubOAuthClientID     string
+	GitHubOAuthClientSecret string
+	GitHubOAuthRedirectURL  string
+
+	// GitHub deployment; empty URLs mean github.com, and a web URL alone implies Enterprise Server paths
+	GitHubAPIURL    string
+	GitHubUploadURL string
+	GitHubWebURL    string
+
+	// Repository source configuration
+	RepoSource        string // "api" or "archive"
+	ArchiveCacheDir   string // Where archive snapshots are cached per commit
+	AllowLocalSources bool   // Accept local paths in place of GitHub URLs
 
 	// Gemini configuration
 	GeminiAPIKey string
@@ -40,8 +62,29 @@ func New() (*Config, error) {
 	}
 
 	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
-		return nil, common.NewError("GITHUB_TOKEN environment variable is required")
+
+	// GitHub App authentication replaces the token for repositories the app is installed on
+	var githubAppID int64
+	if appID := os.Getenv("GITHUB_APP_ID"); appID != "" {
+		githubAppID, err = strconv.ParseInt(appID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GITHUB_APP_ID: %w", err)
+		}
+	}
+	githubAppKey := os.Getenv("GITHUB_APP_PRIVATE_KEY")
+	if keyPath := os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"); githubAppKey == "" && keyPath != "" {
+		key, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GITHUB_APP_PRIVATE_KEY_PATH: %w", err)
+		}
+		githubAppKey = string(key)
+	}
+	if githubAppID != 0 && githubAppKey == "" {
+		return nil, common.NewError("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_PATH is required with GITHUB_APP_ID")
+	}
+
+	if githubToken == "" && githubAppID == 0 {
+		return nil, common.NewError("GITHUB_TOKEN or GITHUB_APP_ID environment variable is required")
 	}
 
 	geminiAPIKey := os.Getenv("GEMINI_API_KEY")
@@ -55,10 +98,49 @@ func New() (*Config, error) {
 		return nil, common.NewError("PINECONE_API_KEY environment variable is required")
 	}
 
+	repoSource := getEnvOrDefault("REPO_SOURCE", "api")
+	if repoSource != "api" && repoSource != "archive" {
+		return nil, fmt.Errorf("invalid REPO_SOURCE: %s", repoSource)
+	}
+
+	rateLimitPolicy := getEnvOrDefault("GITHUB_RATE_LIMIT_POLICY", "wait")
+	if rateLimitPolicy != "wait" && rateLimitPolicy != "fail" {
+		return nil, fmt.Errorf("invalid GITHUB_RATE_LIMIT_POLICY: %s", rateLimitPolicy)
+	}
+
+	rateLimitMaxWait, err := time.ParseDuration(getEnvOrDefault("GITHUB_RATE_LIMIT_MAX_WAIT", "60s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_RATE_LIMIT_MAX_WAIT: %w", err)
+	}
+
+	githubCacheEntries, err := strconv.Atoi(getEnvOrDefault("GITHUB_CACHE_ENTRIES", "1000"))
+	if err != nil || githubCacheEntries < 0 {
+		return nil, fmt.Errorf("invalid GITHUB_CACHE_ENTRIES: %s", os.Getenv("GITHUB_CACHE_ENTRIES"))
+	}
+
+	allowLocalSources, err := strconv.ParseBool(getEnvOrDefault("ALLOW_LOCAL_SOURCES", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ALLOW_LOCAL_SOURCES: %w", err)
+	}
+
 	return &Config{
 		Port:                port,
 		Environment:         getEnvOrDefault("ENVIRONMENT", "development"),
 		GitHubToken:         githubToken,
+		GitHubAppID:         githubAppID,
+		GitHubAppKey:        githubAppKey,
+		GitHubOAuthClientID:     os.Getenv("GITHUB_OAUTH_CLIENT_ID"),
+		GitHubOAuthClientSecret: os.Getenv("GITHUB_OAUTH_CLIENT_SECRET"),
+		GitHubOAuthRedirectURL:  os.Getenv("GITHUB_OAUTH_REDIRECT_URL"),
+		RateLimitPolicy:     rateLimitPolicy,
+		RateLimitMaxWait:    rateLimitMaxWait,
+		GitHubCacheEntries:  githubCacheEntries,
+		GitHubAPIURL:        os.Getenv("GITHUB_API_URL"),
+		GitHubUploadURL:     os.Getenv("GITHUB_UPLOAD_URL"),
+		GitHubWebURL:        os.Getenv("GITHUB_WEB_URL"),
+		RepoSource:          repoSource,
+		ArchiveCacheDir:     getEnvOrDefault("ARCHIVE_CACHE_DIR", filepath.Join(os.TempDir(), "github-agent", "archives")),
+		AllowLocalSources:   allowLocalSources,
 		GeminiAPIKey:        geminiAPIKey,
 		GeminiModel:         getEnvOrDefault("GEMINI_MODEL", "gemini-1.5-pro"),
 		PineconeAPIKey:      pineconeAPIKey,
@@ -0,0 +1,251 @@
+// internal/github/activity.go
+package github
+
+import (
+	"context"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// ActivityFilter narrows the commits, pull requests and issues of a repository. Filters are sent
+// to the API where it supports them and applied to each item otherwise.
+type ActivityFilter struct {
+	Since   time.Time // Only activity at or after this time
+	Until   time.Time // Only activity at or before this time
+	Authors []string  // Logins, names or emails; a single author is sent to the API
+	Labels  []string  // Pull requests and issues carrying all of these labels
+	State   string    // "open", "closed" or "all" (default); pull requests also accept "merged"
+	Path    string    // Commits touching this file or directory
+}
+
+// pageSize is the page size of paginated listings, the API maximum
+const pageSize = 100
+
+// paginate yields the items of each page returned by list until the pages run out or the
+// caller stops. An error is yielded once and ends the sequence.
+func paginate[T any](ctx context.Context, what string, list func(opts *github.ListOptions) ([]T, *github.Response, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		opts := &github.ListOptions{PerPage: pageSize}
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			items, resp, err := list(opts)
+			if err != nil {
+				yield(zero, common.WrapError(err, "failed to list "+what))
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if resp == nil || resp.NextPage == 0 {
+				return
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+}
+
+// Commits iterates over the commits of a branch, newest first. The time window, path and a
+// single author are filtered by the API.
+func (c *Client) Commits(ctx context.Context, owner, repo, branch string, filter ActivityFilter) iter.Seq2[*github.RepositoryCommit, error] {
+	opts := &github.CommitsListOptions{
+		SHA:   branch,
+		Path:  filter.Path,
+		Since: filter.Since,
+		Until: filter.Until,
+	}
+	if len(filter.Authors) == 1 {
+		opts.Author = strings.TrimPrefix(filter.Authors[0], "@")
+	}
+
+	pages := paginate(ctx, "commits", func(page *github.ListOptions) ([]*github.RepositoryCommit, *github.Response, error) {
+		opts.ListOptions = *page
+		return c.client.Repositories.ListCommits(ctx, owner, repo, opts)
+	})
+
+	return func(yield func(*github.RepositoryCommit, error) bool) {
+		for commit, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(filter.Authors) > 1 {
+				author := commit.GetCommit().GetAuthor()
+				if !filter.matchesAuthor(commit.GetAuthor().GetLogin(), author.GetName(), author.GetEmail()) {
+					continue
+				}
+			}
+			if !yield(commit, nil) {
+				return
+			}
+		}
+	}
+}
+
+// PullRequests iterates over the pull requests of a repository, most recently updated first.
+// The state is filtered by the API; authors, labels and the time window on each item, and
+// the listing stops at the first pull request last updated before the window.
+func (c *Client) PullRequests(ctx context.Context, owner, repo string, filter ActivityFilter) iter.Seq2[*github.PullRequest, error] {
+	opts := &github.PullRequestListOptions{
+		State:     filter.apiState(),
+		Sort:      "updated",
+		Direction: "desc",
+	}
+
+	pages := paginate(ctx, "pull requests", func(page *github.ListOptions) ([]*github.PullRequest, *github.Response, error) {
+		opts.ListOptions = *page
+		return c.client.PullRequests.List(ctx, owner, repo, opts)
+	})
+
+	return func(yield func(*github.PullRequest, error) bool) {
+		for pr, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !filter.Since.IsZero() && pr.GetUpdatedAt().Before(filter.Since) {
+				return // Everything after this was last touched before the window
+			}
+			if filter.State == "merged" && pr.MergedAt == nil {
+				continue
+			}
+			if !filter.matchesAuthor(pr.GetUser().GetLogin()) || !filter.hasLabels(pr.Labels) {
+				continue
+			}
+			if !filter.inWindow(pr.GetCreatedAt(), pr.GetClosedAt(), pr.GetMergedAt()) {
+				continue
+			}
+			if !yield(pr, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Issues iterates over the issues of a repository, most recently updated first, without pull
+// requests. State, labels, a single author and the start of the window are filtered by the API.
+func (c *Client) Issues(ctx context.Context, owner, repo string, filter ActivityFilter) iter.Seq2[*github.Issue, error] {
+	opts := &github.IssueListByRepoOptions{
+		State:     filter.apiState(),
+		Labels:    filter.Labels,
+		Since:     filter.Since,
+		Sort:      "updated",
+		Direction: "desc",
+	}
+	if len(filter.Authors) == 1 {
+		opts.Creator = strings.TrimPrefix(filter.Authors[0], "@")
+	}
+
+	pages := paginate(ctx, "issues", func(page *github.ListOptions) ([]*github.Issue, *github.Response, error) {
+		opts.ListOptions = *page
+		return c.client.Issues.ListByRepo(ctx, owner, repo, opts)
+	})
+
+	return func(yield func(*github.Issue, error) bool) {
+		for issue, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			// The issues API lists pull requests too
+			if issue.IsPullRequest() {
+				continue
+			}
+			if len(filter.Authors) > 1 && !filter.matchesAuthor(issue.GetUser().GetLogin()) {
+				continue
+			}
+			if !filter.inWindow(issue.GetCreatedAt(), issue.GetClosedAt()) {
+				continue
+			}
+			if !yield(issue, nil) {
+				return
+			}
+		}
+	}
+}
+
+// apiState returns the state understood by the pull request and issue listings
+func (f ActivityFilter) apiState() string {
+	switch f.State {
+	case "open", "closed":
+		return f.State
+	case "merged":
+		return "closed"
+	default:
+		return "all"
+	}
+}
+
+// windowed reports whether the filter restricts activity to a time window
+func (f ActivityFilter) windowed() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero()
+}
+
+// listLimit returns how many items a query reads at most. Without keywords, a time window
+// returns all of its items up to maxActivityScan and other queries the maxActivityResults
+// most recent ones; keyword queries scan up to maxActivityScan for matches.
+func (f ActivityFilter) listLimit(keywords []string) int {
+	if len(keywords) == 0 && !f.windowed() {
+		return maxActivityResults
+	}
+	return maxActivityScan
+}
+
+// inWindow reports whether any of the non-zero times falls within the filter's time window
+func (f ActivityFilter) inWindow(times ...time.Time) bool {
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+	for _, t := range times {
+		if t.IsZero() {
+			continue
+		}
+		if (f.Since.IsZero() || !t.Before(f.Since)) && (f.Until.IsZero() || !t.After(f.Until)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAuthor reports whether any of the identities matches one of the filter's authors
+func (f ActivityFilter) matchesAuthor(identities ...string) bool {
+	if len(f.Authors) == 0 {
+		return true
+	}
+	for _, author := range f.Authors {
+		for _, identity := range identities {
+			if identity != "" && strings.EqualFold(strings.TrimPrefix(author, "@"), identity) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasLabels reports whether the labels include all of the filter's labels
+func (f ActivityFilter) hasLabels(labels []*github.Label) bool {
+	for _, want := range f.Labels {
+		found := false
+		for _, label := range labels {
+			if strings.EqualFold(label.GetName(), want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,386 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/llm"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+	"gopkg.in/yaml.v3"
+)
+
+// AgentConfigFiles are the paths an agent configuration is read from, the first found wins
+var AgentConfigFiles = []string{".github-agent.yml", ".github-agent.yaml"}
+
+// agentConfigVersion is the only version of the configuration format
+const agentConfigVersion = 1
+
+// Limits of the free text of an agent configuration
+const (
+	maxToneLength         = 200
+	maxInstructionsLength = 2000
+)
+
+// docStyles are the documentation comment conventions a configuration may ask for
+var docStyles = []string{
+	"doxygen", "godoc", "google", "javadoc", "jsdoc", "kdoc", "numpy", "phpdoc", "rdoc", "rustdoc", "sphinx", "tsdoc", "xmldoc", "yard",
+}
+
+// AgentConfig is the optional configuration a repository gives the agent in .github-agent.yml:
+//
+//	version: 1
+//	ignore: ["docs/generated/**", "*.snap"]
+//	entry_points: [cmd/server/main.go]
+//	layers:
+//	  - name: handlers
+//	    paths: [internal/api/**]
+//	    may_import: [services, models]
+//	documentation:
+//	  style: godoc
+//	push:
+//	  mode: pull_request
+//	prompts:
+//	  tone: concise and friendly
+//	  instructions: Mention the Makefile targets when describing how to build.
+type AgentConfig struct {
+	Version       int                 `yaml:"version"`
+	Ignore        []string            `yaml:"ignore"`       // Globs of files every pipeline skips
+	EntryPoints   []string            `yaml:"entry_points"` // Files walkthroughs start from
+	Layers        []LayerRule         `yaml:"layers"`
+	Documentation DocumentationConfig `yaml:"documentation"`
+	Push          PushConfig          `yaml:"push"`
+	Prompts       PromptConfig        `yaml:"prompts"`
+
+	Path    string           `yaml:"-"` // Where the configuration was read from, empty for the defaults
+	ignored []*regexp.Regexp // Compiled Ignore
+	layers  [][]*regexp.Regexp
+}
+
+// LayerRule declares a layer of the codebase and the layers it may import
+type LayerRule struct {
+	Name      string   `yaml:"name"`
+	Paths     []string `yaml:"paths"`      // Globs of the files in the layer
+	MayImport []string `yaml:"may_import"` // Layers the layer may import, "*" for any
+}
+
+// DocumentationConfig sets how generated documentation comments are written
+type DocumentationConfig struct {
+	Style string `yaml:"style"`
+}
+
+// PushConfig sets how generated changes reach the repository when a request doesn't say
+type PushConfig struct {
+	Mode PushMode `yaml:"mode"`
+}
+
+// PromptConfig adds the repository's voice and instructions to generated text
+type PromptConfig struct {
+	Tone         string `yaml:"tone"`
+	Instructions string `yaml:"instructions"`
+}
+
+// AgentConfigError lists every problem of an invalid agent configuration
+type AgentConfigError struct {
+	Path     string
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *AgentConfigError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// LoadAgentConfig reads the agent configuration of a repository at a ref from the files of its
+// tree. A repository without one gets the defaults; an invalid one is an *AgentConfigError.
+func LoadAgentConfig(ctx context.Context, src RepoSource, ref string, files []models.GitHubFile) (*AgentConfig, error) {
+	present := make(map[string]bool)
+	for _, file := range files {
+		if file.Type == "file" {
+			present[file.Path] = true
+		}
+	}
+	for _, configPath := range AgentConfigFiles {
+		if !present[configPath] {
+			continue
+		}
+		content, err := src.ReadFile(ctx, ref, configPath)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to read "+configPath)
+		}
+		return ParseAgentConfig(configPath, content.Content)
+	}
+	return &AgentConfig{}, nil
+}
+
+// LoadAgentConfigAt reads the agent configuration of a repository at a ref, the default
+// branch when empty, reading each of AgentConfigFiles directly rather than listing the tree
+func LoadAgentConfigAt(ctx context.Context, src RepoSource, ref string) (*AgentConfig, error) {
+	if ref == "" {
+		info, err := src.Info(ctx)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to get repository info")
+		}
+		ref = info.DefaultBranch
+	}
+	for _, configPath := range AgentConfigFiles {
+		content, err := src.ReadFile(ctx, ref, configPath)
+		if errors.Is(err, ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, common.WrapError(err, "failed to read "+configPath)
+		}
+		return ParseAgentConfig(configPath, content.Content)
+	}
+	return &AgentConfig{}, nil
+}
+
+// ParseAgentConfig parses and validates the content of an agent configuration file
+func ParseAgentConfig(configPath, content string) (*AgentConfig, error) {
+	config := &AgentConfig{}
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, &AgentConfigError{Path: configPath, Problems: yamlProblems(err)}
+	}
+	config.Path = configPath
+
+	if problems := config.validate(); len(problems) > 0 {
+		return nil, &AgentConfigError{Path: configPath, Problems: problems}
+	}
+	return config, nil
+}
+
+// yamlProblems turns a YAML decoding error into problems without Go type names
+func yamlProblems(err error) []string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	problems := make([]string, len(typeErr.Errors))
+	for i, problem := range typeErr.Errors {
+		if at := strings.Index(problem, " in type "); at >= 0 {
+			problem = problem[:at]
+		}
+		problems[i] = strings.Replace(problem, "cannot unmarshal", "cannot read", 1)
+	}
+	return problems
+}
+
+// validate checks the values of a decoded configuration and compiles its globs, returning
+// every problem found
+func (c *AgentConfig) validate() []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Version != 0 && c.Version != agentConfigVersion {
+		add("version: unsupported version %d; expected %d", c.Version, agentConfigVersion)
+	}
+
+	for i, pattern := range c.Ignore {
+		re, err := utils.CompileGlob(pattern)
+		if err != nil {
+			add("ignore[%d]: %v", i, err)
+			continue
+		}
+		c.ignored = append(c.ignored, re)
+	}
+
+	for i, entryPoint := range c.EntryPoints {
+		clean := path.Clean(entryPoint)
+		switch {
+		case strings.TrimSpace(entryPoint) == "":
+			add("entry_points[%d]: empty path", i)
+		case strings.HasPrefix(entryPoint, "/") || clean == ".." || strings.HasPrefix(clean, "../"):
+			add("entry_points[%d]: %q must be a path relative to the repository root", i, entryPoint)
+		case strings.ContainsAny(entryPoint, "*?["):
+			add("entry_points[%d]: %q must name a file, not a pattern", i, entryPoint)
+		default:
+			c.EntryPoints[i] = clean
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, layer := range c.Layers {
+		switch {
+		case layer.Name == "":
+			add("layers[%d].name: required", i)
+		case layer.Name == "*":
+			add("layers[%d].name: \"*\" is reserved for may_import", i)
+		case names[layer.Name]:
+			add("layers[%d].name: duplicate layer %q", i, layer.Name)
+		}
+		names[layer.Name] = true
+	}
+	for i, layer := range c.Layers {
+		if len(layer.Paths) == 0 {
+			add("layers[%d].paths: a layer needs at least one glob", i)
+		}
+		var patterns []*regexp.Regexp
+		for j, pattern := range layer.Paths {
+			re, err := utils.CompileGlob(pattern)
+			if err != nil {
+				add("layers[%d].paths[%d]: %v", i, j, err)
+				continue
+			}
+			patterns = append(patterns, re)
+		}
+		c.layers = append(c.layers, patterns)
+		for j, name := range layer.MayImport {
+			if name != "*" && !names[name] {
+				add("layers[%d].may_import[%d]: unknown layer %q; declared layers are %s", i, j, name, strings.Join(c.layerNames(), ", "))
+			}
+		}
+	}
+
+	if style := c.Documentation.Style; style != "" {
+		c.Documentation.Style = strings.ToLower(style)
+		if i := sort.SearchStrings(docStyles, c.Documentation.Style); i == len(docStyles) || docStyles[i] != c.Documentation.Style {
+			add("documentation.style: unsupported style %q; expected one of %s", style, strings.Join(docStyles, ", "))
+		}
+	}
+
+	if c.Push.Mode != "" {
+		mode, err := ParsePushMode(string(c.Push.Mode))
+		if err != nil {
+			add("push.mode: %v", err)
+		}
+		c.Push.Mode = mode
+	}
+
+	if len(c.Prompts.Tone) > maxToneLength {
+		add("prompts.tone: %d characters; at most %d are allowed", len(c.Prompts.Tone), maxToneLength)
+	}
+	if len(c.Prompts.Instructions) > maxInstructionsLength {
+		add("prompts.instructions: %d characters; at most %d are allowed", len(c.Prompts.Instructions), maxInstructionsLength)
+	}
+	return problems
+}
+
+// layerNames returns the distinct names of the declared layers
+func (c *AgentConfig) layerNames() []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0, len(c.Layers))
+	for _, layer := range c.Layers {
+		if layer.Name != "" && !seen[layer.Name] {
+			seen[layer.Name] = true
+			names = append(names, layer.Name)
+		}
+	}
+	return names
+}
+
+// IgnorePatterns returns the compiled ignore globs, for utils.FileClassifier.Ignore
+func (c *AgentConfig) IgnorePatterns() []*regexp.Regexp {
+	return c.ignored
+}
+
+// IsIgnored reports whether a file matches an ignore glob
+func (c *AgentConfig) IsIgnored(filePath string) bool {
+	for _, pattern := range c.ignored {
+		if pattern.MatchString(filePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// PushModeFor returns the requested push mode, or the configured one when none is requested
+func (c *AgentConfig) PushModeFor(requested PushMode) PushMode {
+	switch {
+	case requested != "":
+		return requested
+	case c.Push.Mode != "":
+		return c.Push.Mode
+	default:
+		return PushModeDirect
+	}
+}
+
+// LayerOf returns the first declared layer a file belongs to, or "" if none
+func (c *AgentConfig) LayerOf(filePath string) string {
+	for i, patterns := range c.layers {
+		for _, pattern := range patterns {
+			if pattern.MatchString(filePath) {
+				return c.Layers[i].Name
+			}
+		}
+	}
+	return ""
+}
+
+// mayImport reports whether a layer may import another
+func (c *AgentConfig) mayImport(from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, layer := range c.Layers {
+		if layer.Name != from {
+			continue
+		}
+		for _, name := range layer.MayImport {
+			if name == "*" || name == to {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// LayerViolations returns the imports between files of declared layers the rules don't allow,
+// sorted by importing file
+func (c *AgentConfig) LayerViolations(imports map[string][]string) []models.LayerViolation {
+	if len(c.Layers) == 0 {
+		return nil
+	}
+	var violations []models.LayerViolation
+	for source, targets := range imports {
+		from := c.LayerOf(source)
+		if from == "" {
+			continue
+		}
+		for _, target := range targets {
+			if to := c.LayerOf(target); to != "" && !c.mayImport(from, to) {
+				violations = append(violations, models.LayerViolation{From: source, To: target, FromLayer: from, ToLayer: to})
+			}
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].From != violations[j].From {
+			return violations[i].From < violations[j].From
+		}
+		return violations[i].To < violations[j].To
+	})
+	return violations
+}
+
+// Guidance returns the tone, instructions, documentation style and layering rules of the
+// configuration for prompts
+func (c *AgentConfig) Guidance() llm.Guidance {
+	guidance := llm.Guidance{
+		Tone:         c.Prompts.Tone,
+		Instructions: c.Prompts.Instructions,
+		DocStyle:     c.Documentation.Style,
+	}
+	for _, layer := range c.Layers {
+		rule := layer.Name + " (" + strings.Join(layer.Paths, ", ") + ")"
+		if len(layer.MayImport) > 0 {
+			rule += " may import " + strings.Join(layer.MayImport, ", ")
+		} else {
+			rule += " imports no other layer"
+		}
+		guidance.Layers = append(guidance.Layers, rule)
+	}
+	return guidance
+}
@@ -0,0 +1,215 @@
+// internal/github/api.go
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+	"golang.org/x/oauth2"
+)
+
+// RepositoriesAPI is the subset of the repositories API used by Client:
+// contents, commits, releases, tags and statistics
+type RepositoriesAPI interface {
+	Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
+	GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
+	GetArchiveLink(ctx context.Context, owner, repo string, archiveformat github.ArchiveFormat, opts *github.RepositoryContentGetOptions, followRedirects bool) (*url.URL, *github.Response, error)
+	GetCommit(ctx context.Context, owner, repo, sha string, opts *github.ListOptions) (*github.RepositoryCommit, *github.Response, error)
+	GetCommitSHA1(ctx context.Context, owner, repo, ref, lastSHA string) (string, *github.Response, error)
+	ListCommits(ctx context.Context, owner, repo string, opts *github.CommitsListOptions) ([]*github.RepositoryCommit, *github.Response, error)
+	ListTags(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.RepositoryTag, *github.Response, error)
+	ListReleases(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.RepositoryRelease, *github.Response, error)
+	ListLanguages(ctx context.Context, owner, repo string) (map[string]int, *github.Response, error)
+	ListAllTopics(ctx context.Context, owner, repo string) ([]string, *github.Response, error)
+	ListContributors(ctx context.Context, owner, repo string, opts *github.ListContributorsOptions) ([]*github.Contributor, *github.Response, error)
+	ListContributorsStats(ctx context.Context, owner, repo string) ([]*github.ContributorStats, *github.Response, error)
+	ListCommitActivity(ctx context.Context, owner, repo string) ([]*github.WeeklyCommitActivity, *github.Response, error)
+	ListCodeFrequency(ctx context.Context, owner, repo string) ([]*github.WeeklyStats, *github.Response, error)
+	ListParticipation(ctx context.Context, owner, repo string) (*github.RepositoryParticipation, *github.Response, error)
+	ListPunchCard(ctx context.Context, owner, repo string) ([]*github.PunchCard, *github.Response, error)
+}
+
+// GitAPI is the subset of the Git data API used by Client: trees for reads,
+// and blobs, trees, commits and refs for commits
+type GitAPI interface {
+	GetTree(ctx context.Context, owner, repo, sha string, recursive bool) (*github.Tree, *github.Response, error)
+	GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error)
+	GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, *github.Response, error)
+	CreateBlob(ctx context.Context, owner, repo string, blob *github.Blob) (*github.Blob, *github.Response, error)
+	CreateTree(ctx context.Context, owner, repo, baseTree string, entries []*github.TreeEntry) (*github.Tree, *github.Response, error)
+	CreateCommit(ctx context.Context, owner, repo string, commit *github.Commit) (*github.Commit, *github.Response, error)
+	UpdateRef(ctx context.Context, owner, repo string, ref *github.Reference, force bool) (*github.Reference, *github.Response, error)
+	CreateRef(ctx context.Context, owner, repo string, ref *github.Reference) (*github.Reference, *github.Response, error)
+	DeleteRef(ctx context.Context, owner, repo, ref string) (*github.Response, error)
+}
+
+// SearchAPI is the subset of the search API used by Client
+type SearchAPI interface {
+	Code(ctx context.Context, query string, opts *github.SearchOptions) (*github.CodeSearchResult, *github.Response, error)
+}
+
+// PullRequestsAPI is the subset of the pull requests API used by Client
+type PullRequestsAPI interface {
+	Get(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error)
+	List(ctx context.Context, owner, repo string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error)
+	ListFiles(ctx context.Context, owner, repo string, number int, opts *github.ListOptions) ([]*github.CommitFile, *github.Response, error)
+	Create(ctx context.Context, owner, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
+	Edit(ctx context.Context, owner, repo string, number int, pull *github.PullRequest) (*github.PullRequest, *github.Response, error)
+}
+
+// IssuesAPI is the subset of the issues API used by Client
+type IssuesAPI interface {
+	ListByRepo(ctx context.Context, owner, repo string, opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error)
+}
+
+// API groups the GitHub operations Client depends on. The go-github services satisfy
+// each interface, and tests can replace any of them with a fake.
+type API struct {
+	Repositories RepositoriesAPI
+	Git          GitAPI
+	Search       SearchAPI
+	PullRequests PullRequestsAPI
+	Issues       IssuesAPI
+	RateLimits   func(ctx context.Context) (*github.RateLimits, *github.Response, error)
+	GraphQL      GraphQLFunc // Nil when GraphQL is unavailable, as for unauthenticated clients
+
+	httpClient *http.Client
+}
+
+// NewAPI wraps a go-github client
+func NewAPI(client *github.Client) *API {
+	return &API{
+		Repositories: client.Repositories,
+		Git:          client.Git,
+		Search:       client.Search,
+		PullRequests: client.PullRequests,
+		Issues:       client.Issues,
+		RateLimits:   client.RateLimits,
+		GraphQL:      newGraphQL(client.Client(), graphQLURL(client.BaseURL.String())),
+		httpClient:   client.Client(),
+	}
+}
+
+// Client returns the HTTP client used for downloads outside the REST API
+func (a *API) Client() *http.Client {
+	if a.httpClient == nil {
+		return http.DefaultClient
+	}
+	return a.httpClient
+}
+
+// NewClientWithAPI creates a Client over the given API implementation
+func NewClientWithAPI(api *API) *Client {
+	return &Client{
+		client: api,
+		hosts:  DefaultHosts(),
+		users:  newUserClients(),
+		logger: common.NewLogger(),
+	}
+}
+
+// NewClientWithBaseURL creates a Client that talks to the REST API at baseURL,
+// such as a local fake server. An empty token sends unauthenticated requests.
+func NewClientWithBaseURL(token, baseURL string) (*Client, error) {
+	parsed, err := parseBaseURL(baseURL)
+	if err != nil {
+		return nil, common.WrapError(err, "invalid GitHub API URL")
+	}
+
+	opts := DefaultClientOptions()
+	opts.Hosts = Hosts{APIURL: parsed.String(), UploadURL: parsed.String(), WebURL: DefaultWebURL}
+
+	httpClient, limiter := newHTTPClient(token, opts)
+	client, err := newGitHubClient(httpClient, opts.Hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	api := NewAPI(client)
+	if token == "" {
+		api.GraphQL = nil
+	}
+
+	c := NewClientWithAPI(api)
+	c.limiter = limiter
+	c.opts = opts
+	c.hosts = opts.Hosts
+	return c, nil
+}
+
+// newHTTPClient builds the HTTP client used for the REST API: authentication on top of
+// the response cache, on top of rate limit handling
+func newHTTPClient(token string, opts ClientOptions) (*http.Client, *rateLimitTransport) {
+	limiter := newRateLimitTransport(http.DefaultTransport, opts, token)
+
+	var transport http.RoundTripper = limiter
+	if opts.CacheEntries > 0 {
+		transport = newCacheTransport(transport, opts.CacheEntries)
+	}
+	base := &http.Client{Transport: transport}
+
+	if opts.App != nil {
+		opts.App.withHTTPClient(&http.Client{Transport: limiter})
+		return &http.Client{Transport: &appTransport{base: transport, app: opts.App, fallbackToken: token}}, limiter
+	}
+	if token == "" {
+		return base, limiter
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	return oauth2.NewClient(ctx, ts), limiter
+}
+
+// RateLimits returns the current quota of each API resource. It asks the rate limit
+// endpoint, which does not count against the quota, and falls back to the last quota
+// seen in response headers.
+func (c *Client) RateLimits(ctx context.Context) ([]RateLimitStatus, error) {
+	if c.client.RateLimits != nil {
+		limits, _, err := c.client.RateLimits(ctx)
+		if err == nil {
+			var statuses []RateLimitStatus
+			for resource, rate := range map[string]*github.Rate{
+				"core":   limits.GetCore(),
+				"search": limits.GetSearch(),
+			} {
+				if rate == nil {
+					continue
+				}
+				statuses = append(statuses, RateLimitStatus{
+					Resource:  resource,
+					Limit:     rate.Limit,
+					Remaining: rate.Remaining,
+					Reset:     rate.Reset.Time,
+				})
+			}
+			if len(statuses) > 0 || c.limiter == nil {
+				sort.Slice(statuses, func(i, j int) bool { return statuses[i].Resource < statuses[j].Resource })
+				return statuses, nil
+			}
+		} else if c.limiter == nil {
+			return nil, common.WrapError(err, "failed to get rate limits")
+		} else {
+			c.logger.WithError(err).Warning("Failed to get rate limits, using last known quota")
+		}
+	}
+
+	if c.limiter == nil {
+		return nil, common.NewError("rate limits are not tracked for this client")
+	}
+	return c.ObservedRateLimits(), nil
+}
+
+// ObservedRateLimits returns the last quota seen in response headers for each API resource,
+// without making a request. It is empty until the client has talked to the API.
+func (c *Client) ObservedRateLimits() []RateLimitStatus {
+	if c.limiter == nil {
+		return nil
+	}
+	statuses := c.limiter.snapshot()
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Resource < statuses[j].Resource })
+	return statuses
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v43/github"
@@ -106,104 +107,62 @@ type ContributorInfo struct {
 // LanguageInfo maps language to byte count
 type LanguageInfo map[string]int
 
-// GetCommits retrieves commits based on keywords
-func (c *Client) GetCommits(ctx context.Context, owner, repo, branch string, keywords []string) ([]CommitInfo, error) {
-	// Set up options for the commit list
-	opts := &github.CommitsListOptions{
-		SHA: branch, // Limit to specific branch if provided
-		ListOptions: github.ListOptions{
-			PerPage: 100, // Get a good number of commits
-		},
-	}
-
-	// Get commits from the GitHub API
-	commits, _, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
-	if err != nil {
-		return nil, common.WrapError(err, "failed to list commits")
+// GetCommits retrieves commits based on keywords and a filter, reading as many pages as needed.
+// Commits are matched on their message first; file details are only fetched for the commits
+// that are returned. Without keywords a time window returns all of its commits up to
+// maxActivityScan, without file details. The flag reports whether a cap cut the listing short.
+func (c *Client) GetCommits(ctx context.Context, owner, repo, branch string, keywords []string, filter ActivityFilter) ([]CommitInfo, bool, error) {
+	lowerKeywords := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		lowerKeywords[i] = strings.ToLower(keyword)
 	}
 
-	// Convert to our simplified commit info structure
+	// Collect commits until enough match by message or the limit is reached
+	limit := filter.listLimit(keywords)
+	truncated := false
 	var commitInfos []CommitInfo
 	var filteredCommits []CommitInfo
-
-	for _, commit := range commits {
+	for commit, err := range c.Commits(ctx, owner, repo, branch, filter) {
+		if err != nil {
+			return nil, false, err
+		}
 		if commit.SHA == nil || commit.Commit == nil || commit.Commit.Message == nil {
 			continue // Skip invalid commits
 		}
-
-		// Create a CommitInfo object
-		commitInfo := CommitInfo{
-			SHA:     *commit.SHA,
-			Message: *commit.Commit.Message,
-		}
-
-		// Extract author information if available
-		if commit.Commit.Author != nil {
-			if commit.Commit.Author.Name != nil {
-				commitInfo.Author = *commit.Commit.Author.Name
-			}
-			if commit.Commit.Author.Email != nil {
-				commitInfo.AuthorEmail = *commit.Commit.Author.Email
-			}
-			if commit.Commit.Author.Date != nil {
-				commitInfo.CommitDate = *commit.Commit.Author.Date
-			}
-		}
-
-		// Add URL if available
-		if commit.HTMLURL != nil {
-			commitInfo.URL = *commit.HTMLURL
-		}
-
-		// Get the commit details to find changed files
-		if commit.SHA != nil {
-			commitDetail, _, err := c.client.Repositories.GetCommit(ctx, owner, repo, *commit.SHA, nil)
-			if err == nil && commitDetail.Files != nil {
-				for _, file := range commitDetail.Files {
-					if file.Filename != nil {
-						commitInfo.FilesChanged = append(commitInfo.FilesChanged, *file.Filename)
-					}
-					if file.Additions != nil {
-						commitInfo.LinesAdded += *file.Additions
-					}
-					if file.Deletions != nil {
-						commitInfo.LinesDeleted += *file.Deletions
-					}
-				}
-			}
+		if len(commitInfos) >= limit || len(filteredCommits) >= maxCommitDetails {
+			truncated = true
+			break
 		}
 
+		commitInfo := toCommitInfo(commit)
 		commitInfos = append(commitInfos, commitInfo)
+		if len(keywords) > 0 && containsAny(strings.ToLower(commitInfo.Message), lowerKeywords) {
+			filteredCommits = append(filteredCommits, commitInfo)
+		}
 	}
 
-	// If no keywords provided, return all commits
+	// If no keywords provided, return the most recent commits, or the whole window
 	if len(keywords) == 0 {
-		// Limit to 50 most recent commits
-		if len(commitInfos) > 50 {
-			return commitInfos[:50], nil
+		if !filter.windowed() {
+			c.fillCommitDetails(ctx, owner, repo, commitInfos[:min(len(commitInfos), maxCommitDetails)])
 		}
-		return commitInfos, nil
+		return commitInfos, truncated, nil
+	}
+
+	// Commits matched by message need no extra requests to find
+	if len(filteredCommits) > 0 {
+		c.fillCommitDetails(ctx, owner, repo, filteredCommits)
+		return filteredCommits, truncated, nil
 	}
 
-	// Filter commits by keywords
-	for _, commit := range commitInfos {
-		// Check if any keyword matches the commit message or files
-		for _, keyword := range keywords {
-			lowerKeyword := strings.ToLower(keyword)
-			lowerMessage := strings.ToLower(commit.Message)
-			
-			if strings.Contains(lowerMessage, lowerKeyword) {
+	// Otherwise match the files changed by the most recent commits
+	recent := commitInfos[:min(len(commitInfos), maxCommitDetails)]
+	c.fillCommitDetails(ctx, owner, repo, recent)
+	for _, commit := range recent {
+		for _, file := range commit.FilesChanged {
+			if containsAny(strings.ToLower(file), lowerKeywords) {
 				filteredCommits = append(filteredCommits, commit)
-				break // Once we've matched, no need to check other keywords
-			}
-			
-			// Also check files changed
-			for _, file := range commit.FilesChanged {
-				lowerFile := strings.ToLower(file)
-				if strings.Contains(lowerFile, lowerKeyword) {
-					filteredCommits = append(filteredCommits, commit)
-					break // Once we've matched, no need to check other files
-				}
+				break // Once we've matched, no need to check other files
 			}
 		}
 	}
@@ -211,150 +170,139 @@ func (c *Client) GetCommits(ctx context.Context, owner, repo, branch string, key
 	// If no commits matched the keywords, return the most recent commits
 	if len(filteredCommits) == 0 {
 		c.logger.Info("No commits matched the keywords, returning most recent commits")
-		// Limit to 20 most recent commits
-		if len(commitInfos) > 20 {
-			return commitInfos[:20], nil
-		}
-		return commitInfos, nil
+		return recent, truncated, nil
 	}
-
-	// Limit to 50 matching commits
-	if len(filteredCommits) > 50 {
-		return filteredCommits[:50], nil
-	}
-
-	return filteredCommits, nil
+	return filteredCommits, truncated, nil
 }
 
-// GetPullRequests retrieves pull requests based on keywords
-func (c *Client) GetPullRequests(ctx context.Context, owner, repo string, keywords []string) ([]PullRequestInfo, error) {
-	// Set up options for the PR list
-	opts := &github.PullRequestListOptions{
-		State: "all", // Get both open and closed PRs
-		ListOptions: github.ListOptions{
-			PerPage: 100, // Get a good number of PRs
-		},
+// toCommitInfo converts a listed commit to a CommitInfo without file details
+func toCommitInfo(commit *github.RepositoryCommit) CommitInfo {
+	commitInfo := CommitInfo{
+		SHA:     commit.GetSHA(),
+		Message: commit.GetCommit().GetMessage(),
+		URL:     commit.GetHTMLURL(),
 	}
 
-	// Get PRs from the GitHub API
-	prs, _, err := c.client.PullRequests.List(ctx, owner, repo, opts)
-	if err != nil {
-		return nil, common.WrapError(err, "failed to list pull requests")
+	// Extract author information if available
+	if author := commit.GetCommit().GetAuthor(); author != nil {
+		commitInfo.Author = author.GetName()
+		commitInfo.AuthorEmail = author.GetEmail()
+		commitInfo.CommitDate = author.GetDate()
 	}
+	return commitInfo
+}
 
-	// Convert to our simplified PR info structure
-	var prInfos []PullRequestInfo
-	var filteredPRs []PullRequestInfo
+// maxActivityScan caps the commits, pull requests or issues read to answer one query
+const maxActivityScan = 500
 
-	for _, pr := range prs {
-		if pr.Number == nil || pr.Title == nil || pr.State == nil {
-			continue // Skip invalid PRs
-		}
+// maxActivityResults caps the pull requests or issues returned for one query
+const maxActivityResults = 50
 
-		// Create a PullRequestInfo object
-		prInfo := PullRequestInfo{
-			Number: *pr.Number,
-			Title:  *pr.Title,
-			State:  *pr.State,
-		}
+// maxCommitDetails caps the commits GetCommits fetches file details for, one request each
+const maxCommitDetails = 20
 
-		// Extract dates if available
-		if pr.CreatedAt != nil {
-			prInfo.CreatedAt = *pr.CreatedAt
-		}
-		if pr.ClosedAt != nil && !pr.ClosedAt.IsZero() {
-			prInfo.ClosedAt = *pr.ClosedAt
-		}
-		if pr.MergedAt != nil && !pr.MergedAt.IsZero() {
-			prInfo.MergedAt = *pr.MergedAt
-		}
+// commitDetailWorkers is the number of commit details fetched concurrently
+const commitDetailWorkers = 5
 
-		// Extract author if available
-		if pr.User != nil && pr.User.Login != nil {
-			prInfo.Author = *pr.User.Login
-		}
+// fillCommitDetails adds the changed files and line counts to each commit.
+// Commits whose details cannot be fetched are left as they are.
+func (c *Client) fillCommitDetails(ctx context.Context, owner, repo string, commits []CommitInfo) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, commitDetailWorkers)
 
-		// Add URL if available
-		if pr.HTMLURL != nil {
-			prInfo.URL = *pr.HTMLURL
-		}
+	for i := range commits {
+		wg.Add(1)
+		go func(commit *CommitInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-		// Extract labels if available
-		if pr.Labels != nil {
-			for _, label := range pr.Labels {
-				if label.Name != nil {
-					prInfo.Labels = append(prInfo.Labels, *label.Name)
+			commitDetail, _, err := c.client.Repositories.GetCommit(ctx, owner, repo, commit.SHA, nil)
+			if err != nil {
+				c.logger.WithError(err).Warning(fmt.Sprintf("Failed to get details of commit %s", commit.SHA))
+				return
+			}
+			for _, file := range commitDetail.Files {
+				if file.Filename != nil {
+					commit.FilesChanged = append(commit.FilesChanged, *file.Filename)
 				}
+				commit.LinesAdded += file.GetAdditions()
+				commit.LinesDeleted += file.GetDeletions()
 			}
-		}
+		}(&commits[i])
+	}
+	wg.Wait()
+}
 
-		// Extract description if available
-		if pr.Body != nil {
-			prInfo.Description = *pr.Body
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Get reviewers if available
-		if pr.RequestedReviewers != nil {
-			for _, reviewer := range pr.RequestedReviewers {
-				if reviewer.Login != nil {
-					prInfo.Reviewers = append(prInfo.Reviewers, *reviewer.Login)
-				}
-			}
+// GetPullRequests retrieves pull requests based on keywords and a filter, reading as many pages
+// as needed. Pull requests are matched on title, description and labels first, and on their
+// changed files only if nothing else matched. Without keywords a time window returns all of its
+// pull requests up to maxActivityScan, without their files. When GraphQL is available, files
+// are left to GetPullRequestContexts except to match keywords, and are then read in bulk. The
+// flag reports whether a cap cut the listing short.
+func (c *Client) GetPullRequests(ctx context.Context, owner, repo string, keywords []string, filter ActivityFilter) ([]PullRequestInfo, bool, error) {
+	lowerKeywords := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		lowerKeywords[i] = strings.ToLower(keyword)
+	}
+
+	// Collect pull requests until enough match or the limit is reached
+	limit := filter.listLimit(keywords)
+	truncated := false
+	var prInfos []PullRequestInfo
+	var filteredPRs []PullRequestInfo
+	for pr, err := range c.PullRequests(ctx, owner, repo, filter) {
+		if err != nil {
+			return nil, false, err
 		}
-
-		// Get files changed if available
-		if pr.Number != nil {
-			files, _, err := c.client.PullRequests.ListFiles(ctx, owner, repo, *pr.Number, nil)
-			if err == nil {
-				for _, file := range files {
-					if file.Filename != nil {
-						prInfo.Files = append(prInfo.Files, *file.Filename)
-					}
-				}
-			}
+		if pr.Number == nil || pr.Title == nil || pr.State == nil {
+			continue // Skip invalid PRs
+		}
+		if len(prInfos) >= limit || len(filteredPRs) >= maxActivityResults {
+			truncated = true
+			break
 		}
 
+		prInfo := toPullRequestInfo(pr)
 		prInfos = append(prInfos, prInfo)
+		if len(keywords) > 0 && matchesPullRequest(prInfo, lowerKeywords) {
+			filteredPRs = append(filteredPRs, prInfo)
+		}
 	}
 
-	// If no keywords provided, return all PRs
+	// If no keywords provided, return the most recent PRs, or the whole window
 	if len(keywords) == 0 {
-		// Limit to 50 most recent PRs
-		if len(prInfos) > 50 {
-			return prInfos[:50], nil
-		}
-		return prInfos, nil
-	}
-
-	// Filter PRs by keywords
-	for _, pr := range prInfos {
-		// Check if any keyword matches the PR title, description, or files
-		for _, keyword := range keywords {
-			lowerKeyword := strings.ToLower(keyword)
-			lowerTitle := strings.ToLower(pr.Title)
-			lowerDesc := strings.ToLower(pr.Description)
-			
-			if strings.Contains(lowerTitle, lowerKeyword) || strings.Contains(lowerDesc, lowerKeyword) {
+		if !filter.windowed() && !c.GraphQLAvailable() {
+			c.fillPullRequestFiles(ctx, owner, repo, prInfos)
+		}
+		return prInfos, truncated, nil
+	}
+
+	if len(filteredPRs) > 0 {
+		if !c.GraphQLAvailable() {
+			c.fillPullRequestFiles(ctx, owner, repo, filteredPRs)
+		}
+		return filteredPRs, truncated, nil
+	}
+
+	// Otherwise match the files changed by the most recent PRs
+	recent := prInfos[:min(len(prInfos), maxCommitDetails)]
+	c.fillPullRequestFiles(ctx, owner, repo, recent)
+	for _, pr := range recent {
+		for _, file := range pr.Files {
+			if containsAny(strings.ToLower(file), lowerKeywords) {
 				filteredPRs = append(filteredPRs, pr)
-				break // Once we've matched, no need to check other keywords
-			}
-			
-			// Also check files changed
-			for _, file := range pr.Files {
-				lowerFile := strings.ToLower(file)
-				if strings.Contains(lowerFile, lowerKeyword) {
-					filteredPRs = append(filteredPRs, pr)
-					break // Once we've matched, no need to check other files
-				}
-			}
-			
-			// Also check labels
-			for _, label := range pr.Labels {
-				lowerLabel := strings.ToLower(label)
-				if strings.Contains(lowerLabel, lowerKeyword) {
-					filteredPRs = append(filteredPRs, pr)
-					break // Once we've matched, no need to check other labels
-				}
+				break // Once we've matched, no need to check other files
 			}
 		}
 	}
@@ -362,151 +310,182 @@ func (c *Client) GetPullRequests(ctx context.Context, owner, repo string, keywor
 	// If no PRs matched the keywords, return the most recent PRs
 	if len(filteredPRs) == 0 {
 		c.logger.Info("No PRs matched the keywords, returning most recent PRs")
-		// Limit to 20 most recent PRs
-		if len(prInfos) > 20 {
-			return prInfos[:20], nil
-		}
-		return prInfos, nil
+		return recent, truncated, nil
 	}
-
-	// Limit to 50 matching PRs
-	if len(filteredPRs) > 50 {
-		return filteredPRs[:50], nil
-	}
-
-	return filteredPRs, nil
+	return filteredPRs, truncated, nil
 }
 
-// GetIssues retrieves issues based on keywords
-func (c *Client) GetIssues(ctx context.Context, owner, repo string, keywords []string) ([]IssueInfo, error) {
-	// Set up options for the issue list
-	opts := &github.IssueListByRepoOptions{
-		State: "all", // Get both open and closed issues
-		ListOptions: github.ListOptions{
-			PerPage: 100, // Get a good number of issues
-		},
+// toPullRequestInfo converts a listed pull request to a PullRequestInfo without its files
+func toPullRequestInfo(pr *github.PullRequest) PullRequestInfo {
+	prInfo := PullRequestInfo{
+		Number:      pr.GetNumber(),
+		Title:       pr.GetTitle(),
+		State:       pr.GetState(),
+		CreatedAt:   pr.GetCreatedAt(),
+		ClosedAt:    pr.GetClosedAt(),
+		MergedAt:    pr.GetMergedAt(),
+		Author:      pr.GetUser().GetLogin(),
+		URL:         pr.GetHTMLURL(),
+		Description: pr.GetBody(),
 	}
-
-	// Get issues from the GitHub API
-	issues, _, err := c.client.Issues.ListByRepo(ctx, owner, repo, opts)
-	if err != nil {
-		return nil, common.WrapError(err, "failed to list issues")
-	}
-
-	// Convert to our simplified issue info structure
-	var issueInfos []IssueInfo
-	var filteredIssues []IssueInfo
-
-	for _, issue := range issues {
-		// Skip pull requests (GitHub considers PRs as issues in the API)
-		if issue.IsPullRequest() {
-			continue
+	for _, label := range pr.Labels {
+		if label.Name != nil {
+			prInfo.Labels = append(prInfo.Labels, *label.Name)
 		}
-
-		if issue.Number == nil || issue.Title == nil || issue.State == nil {
-			continue // Skip invalid issues
+	}
+	for _, reviewer := range pr.RequestedReviewers {
+		if reviewer.Login != nil {
+			prInfo.Reviewers = append(prInfo.Reviewers, *reviewer.Login)
 		}
+	}
+	return prInfo
+}
 
-		// Create an IssueInfo object
-		issueInfo := IssueInfo{
-			Number: *issue.Number,
-			Title:  *issue.Title,
-			State:  *issue.State,
+// matchesPullRequest reports whether any keyword appears in the title, description or labels
+func matchesPullRequest(pr PullRequestInfo, lowerKeywords []string) bool {
+	if containsAny(strings.ToLower(pr.Title), lowerKeywords) || containsAny(strings.ToLower(pr.Description), lowerKeywords) {
+		return true
+	}
+	for _, label := range pr.Labels {
+		if containsAny(strings.ToLower(label), lowerKeywords) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Extract dates if available
-		if issue.CreatedAt != nil {
-			issueInfo.CreatedAt = *issue.CreatedAt
-		}
-		if issue.ClosedAt != nil && !issue.ClosedAt.IsZero() {
-			issueInfo.ClosedAt = *issue.ClosedAt
+// fillPullRequestFiles adds the changed files to each pull request, in bulk through GraphQL
+// when available and with one REST request per pull request otherwise.
+// Pull requests whose files cannot be listed are left as they are.
+func (c *Client) fillPullRequestFiles(ctx context.Context, owner, repo string, prs []PullRequestInfo) {
+	if c.GraphQLAvailable() {
+		numbers := make([]int, len(prs))
+		for i, pr := range prs {
+			numbers[i] = pr.Number
+		}
+		contexts, err := c.GetPullRequestContexts(ctx, owner, repo, numbers)
+		if err == nil {
+			files := make(map[int][]string, len(contexts))
+			for _, prContext := range contexts {
+				for _, file := range prContext.Files {
+					files[prContext.Number] = append(files[prContext.Number], file.Filename)
+				}
+			}
+			for i := range prs {
+				prs[i].Files = files[prs[i].Number]
+			}
+			return
 		}
+		c.logger.WithError(err).Warning("Failed to get pull request files through GraphQL, listing them one by one")
+	}
 
-		// Extract author if available
-		if issue.User != nil && issue.User.Login != nil {
-			issueInfo.Author = *issue.User.Login
-		}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, commitDetailWorkers)
 
-		// Add URL if available
-		if issue.HTMLURL != nil {
-			issueInfo.URL = *issue.HTMLURL
-		}
+	for i := range prs {
+		wg.Add(1)
+		go func(pr *PullRequestInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
 
-		// Extract labels if available
-		if issue.Labels != nil {
-			for _, label := range issue.Labels {
-				if label.Name != nil {
-					issueInfo.Labels = append(issueInfo.Labels, *label.Name)
-				}
+			files, _, err := c.client.PullRequests.ListFiles(ctx, owner, repo, pr.Number, &github.ListOptions{PerPage: pageSize})
+			if err != nil {
+				c.logger.WithError(err).Warning(fmt.Sprintf("Failed to list files of pull request #%d", pr.Number))
+				return
 			}
-		}
-
-		// Extract assignees if available
-		if issue.Assignees != nil {
-			for _, assignee := range issue.Assignees {
-				if assignee.Login != nil {
-					issueInfo.Assignees = append(issueInfo.Assignees, *assignee.Login)
+			for _, file := range files {
+				if file.Filename != nil {
+					pr.Files = append(pr.Files, *file.Filename)
 				}
 			}
-		}
+		}(&prs[i])
+	}
+	wg.Wait()
+}
 
-		// Extract description if available
-		if issue.Body != nil {
-			issueInfo.Description = *issue.Body
+// GetIssues retrieves issues based on keywords and a filter, reading as many pages as needed.
+// Without keywords a time window returns all of its issues up to maxActivityScan. The flag
+// reports whether a cap cut the listing short.
+func (c *Client) GetIssues(ctx context.Context, owner, repo string, keywords []string, filter ActivityFilter) ([]IssueInfo, bool, error) {
+	lowerKeywords := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		lowerKeywords[i] = strings.ToLower(keyword)
+	}
+
+	// Collect issues until enough match or the limit is reached
+	limit := filter.listLimit(keywords)
+	truncated := false
+	var issueInfos []IssueInfo
+	var filteredIssues []IssueInfo
+	for issue, err := range c.Issues(ctx, owner, repo, filter) {
+		if err != nil {
+			return nil, false, err
+		}
+		if issue.Number == nil || issue.Title == nil || issue.State == nil {
+			continue // Skip invalid issues
+		}
+		if len(issueInfos) >= limit || len(filteredIssues) >= maxActivityResults {
+			truncated = true
+			break
 		}
 
+		issueInfo := toIssueInfo(issue)
 		issueInfos = append(issueInfos, issueInfo)
+		if len(keywords) > 0 && matchesIssue(issueInfo, lowerKeywords) {
+			filteredIssues = append(filteredIssues, issueInfo)
+		}
 	}
 
-	// If no keywords provided, return all issues
+	// If no keywords provided, return the most recent issues, or the whole window
 	if len(keywords) == 0 {
-		// Limit to 50 most recent issues
-		if len(issueInfos) > 50 {
-			return issueInfos[:50], nil
-		}
-		return issueInfos, nil
-	}
-
-	// Filter issues by keywords
-	for _, issue := range issueInfos {
-		// Check if any keyword matches the issue title, description, or labels
-		for _, keyword := range keywords {
-			lowerKeyword := strings.ToLower(keyword)
-			lowerTitle := strings.ToLower(issue.Title)
-			lowerDesc := strings.ToLower(issue.Description)
-			
-			if strings.Contains(lowerTitle, lowerKeyword) || strings.Contains(lowerDesc, lowerKeyword) {
-				filteredIssues = append(filteredIssues, issue)
-				break // Once we've matched, no need to check other keywords
-			}
-			
-			// Also check labels
-			for _, label := range issue.Labels {
-				lowerLabel := strings.ToLower(label)
-				if strings.Contains(lowerLabel, lowerKeyword) {
-					filteredIssues = append(filteredIssues, issue)
-					break // Once we've matched, no need to check other labels
-				}
-			}
-		}
+		return issueInfos, truncated, nil
 	}
 
 	// If no issues matched the keywords, return the most recent issues
 	if len(filteredIssues) == 0 {
 		c.logger.Info("No issues matched the keywords, returning most recent issues")
-		// Limit to 20 most recent issues
-		if len(issueInfos) > 20 {
-			return issueInfos[:20], nil
-		}
-		return issueInfos, nil
+		return issueInfos[:min(len(issueInfos), maxCommitDetails)], truncated, nil
 	}
+	return filteredIssues, truncated, nil
+}
 
-	// Limit to 50 matching issues
-	if len(filteredIssues) > 50 {
-		return filteredIssues[:50], nil
+// toIssueInfo converts a listed issue to an IssueInfo
+func toIssueInfo(issue *github.Issue) IssueInfo {
+	issueInfo := IssueInfo{
+		Number:      issue.GetNumber(),
+		Title:       issue.GetTitle(),
+		State:       issue.GetState(),
+		CreatedAt:   issue.GetCreatedAt(),
+		ClosedAt:    issue.GetClosedAt(),
+		Author:      issue.GetUser().GetLogin(),
+		URL:         issue.GetHTMLURL(),
+		Description: issue.GetBody(),
 	}
+	for _, label := range issue.Labels {
+		if label.Name != nil {
+			issueInfo.Labels = append(issueInfo.Labels, *label.Name)
+		}
+	}
+	for _, assignee := range issue.Assignees {
+		if assignee.Login != nil {
+			issueInfo.Assignees = append(issueInfo.Assignees, *assignee.Login)
+		}
+	}
+	return issueInfo
+}
 
-	return filteredIssues, nil
+// matchesIssue reports whether any keyword appears in the title, description or labels
+func matchesIssue(issue IssueInfo, lowerKeywords []string) bool {
+	if containsAny(strings.ToLower(issue.Title), lowerKeywords) || containsAny(strings.ToLower(issue.Description), lowerKeywords) {
+		return true
+	}
+	for _, label := range issue.Labels {
+		if containsAny(strings.ToLower(label), lowerKeywords) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetReleases retrieves repository releases
@@ -0,0 +1,347 @@
+// internal/github/app.go
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbearc/github-agent/backend/pkg/common"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// appJWTLifetime is how long a minted app JWT is valid; GitHub allows at most 10 minutes
+	appJWTLifetime = 9 * time.Minute
+	// tokenRefreshMargin refreshes installation tokens this long before they expire
+	tokenRefreshMargin = 5 * time.Minute
+	// missingInstallationTTL is how long an owner without an installation is remembered
+	missingInstallationTTL = 10 * time.Minute
+)
+
+// installationToken is a cached installation access token
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// installationLookup is the cached installation for a repository. id is 0 when the app is not installed.
+type installationLookup struct {
+	id        int64
+	checkedAt time.Time
+}
+
+// AppAuth authenticates as a GitHub App and exchanges its JWT for installation tokens.
+// Installations are looked up per repository and their tokens cached until shortly before expiry.
+type AppAuth struct {
+	appID      int64
+	key        *rsa.PrivateKey
+	baseURL    string
+	httpClient *http.Client
+	logger     *common.Logger
+
+	mu            sync.Mutex
+	installations map[string]installationLookup // Keyed by lower-case owner/repo
+	tokens        map[int64]installationToken   // Keyed by installation ID
+	inflight      singleflight.Group            // Concurrent lookups and token requests share one call
+}
+
+// NewAppAuth creates a GitHub App authenticator from the app ID and its PEM private key
+func NewAppAuth(appID int64, privateKeyPEM []byte) (*AppAuth, error) {
+	if appID == 0 {
+		return nil, common.NewError("GitHub App ID is required")
+	}
+	key, err := parsePrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppAuth{
+		appID:         appID,
+		key:           key,
+		baseURL:       DefaultAPIBaseURL,
+		httpClient:    http.DefaultClient,
+		logger:        common.NewLogger(),
+		installations: make(map[string]installationLookup),
+		tokens:        make(map[int64]installationToken),
+	}, nil
+}
+
+// WithBaseURL sets the REST API the app authenticates against
+func (a *AppAuth) WithBaseURL(baseURL string) *AppAuth {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	a.baseURL = baseURL
+	return a
+}
+
+// withHTTPClient sets the client used for token exchange
+func (a *AppAuth) withHTTPClient(client *http.Client) *AppAuth {
+	a.httpClient = client
+	return a
+}
+
+// InstallationToken returns an access token for the app installation covering owner/repo.
+// It returns an empty token when the app is not installed for the owner.
+func (a *AppAuth) InstallationToken(ctx context.Context, owner, repo string) (string, error) {
+	installationID, err := a.installationID(ctx, owner, repo)
+	if err != nil || installationID == 0 {
+		return "", err
+	}
+
+	if token, ok := a.cachedToken(installationID); ok {
+		return token, nil
+	}
+
+	// Requests arriving together for the same installation mint a single token
+	token, err, _ := a.inflight.Do(fmt.Sprintf("token/%d", installationID), func() (interface{}, error) {
+		if token, ok := a.cachedToken(installationID); ok {
+			return token, nil
+		}
+
+		var response struct {
+			Token     string    `json:"token"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		path := fmt.Sprintf("app/installations/%d/access_tokens", installationID)
+		if _, err := a.appRequest(ctx, http.MethodPost, path, &response); err != nil {
+			return "", common.WrapError(err, "failed to create installation token")
+		}
+
+		a.mu.Lock()
+		a.tokens[installationID] = installationToken{token: response.Token, expiresAt: response.ExpiresAt}
+		a.mu.Unlock()
+		return response.Token, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.(string), nil
+}
+
+// cachedToken returns the cached token of an installation unless it is about to expire
+func (a *AppAuth) cachedToken(installationID int64) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	cached, ok := a.tokens[installationID]
+	if !ok || time.Until(cached.expiresAt) <= tokenRefreshMargin {
+		return "", false
+	}
+	return cached.token, true
+}
+
+// Invalidate drops the cached token for the installation covering owner/repo, so the next
+// request mints a new one
+func (a *AppAuth) Invalidate(owner, repo string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if lookup, ok := a.installations[strings.ToLower(owner+"/"+repo)]; ok {
+		delete(a.tokens, lookup.id)
+	}
+}
+
+// installationID returns the installation of the app covering owner/repo, or 0 if there is none.
+// Without a repository the owner's installation is used, looked up as an organization first
+// and as a user otherwise.
+func (a *AppAuth) installationID(ctx context.Context, owner, repo string) (int64, error) {
+	key := strings.ToLower(owner + "/" + repo)
+
+	a.mu.Lock()
+	lookup, ok := a.installations[key]
+	a.mu.Unlock()
+	if ok && (lookup.id != 0 || time.Since(lookup.checkedAt) < missingInstallationTTL) {
+		return lookup.id, nil
+	}
+
+	id, err, _ := a.inflight.Do("installation/"+key, func() (interface{}, error) {
+		paths := []string{
+			fmt.Sprintf("orgs/%s/installation", url.PathEscape(owner)),
+			fmt.Sprintf("users/%s/installation", url.PathEscape(owner)),
+		}
+		if repo != "" {
+			paths = []string{fmt.Sprintf("repos/%s/%s/installation", url.PathEscape(owner), url.PathEscape(repo))}
+		}
+
+		var response struct {
+			ID int64 `json:"id"`
+		}
+		for _, path := range paths {
+			status, err := a.appRequest(ctx, http.MethodGet, path, &response)
+			if err != nil && status != http.StatusNotFound {
+				return int64(0), common.WrapError(err, "failed to look up app installation")
+			}
+			if status != http.StatusNotFound {
+				break
+			}
+		}
+		if response.ID == 0 {
+			a.logger.Info(fmt.Sprintf("GitHub App is not installed for %s", strings.TrimSuffix(owner+"/"+repo, "/")))
+		}
+
+		a.mu.Lock()
+		a.installations[key] = installationLookup{id: response.ID, checkedAt: time.Now()}
+		a.mu.Unlock()
+		return response.ID, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id.(int64), nil
+}
+
+// appRequest sends a request authenticated as the app and decodes the JSON response
+func (a *AppAuth) appRequest(ctx context.Context, method, path string, v interface{}) (int, error) {
+	jwt, err := a.jwt(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, json.Unmarshal(body, v)
+}
+
+// jwt mints an RS256 JSON Web Token identifying the app
+func (a *AppAuth) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// Backdate the issue time to allow for clock drift
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": fmt.Sprint(a.appID),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", common.WrapError(err, "failed to sign app JWT")
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey reads a PKCS#1 or PKCS#8 RSA private key in PEM format
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, common.NewError("GitHub App private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, common.WrapError(err, "invalid GitHub App private key")
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, common.NewError("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// appTransport authenticates each request with the installation token for the repository it
+// targets, falling back to a static token when the app is not installed there
+type appTransport struct {
+	base          http.RoundTripper
+	app           *AppAuth
+	fallbackToken string
+}
+
+// RoundTrip sends a request with the installation or fallback token
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	owner, repo := requestRepo(req)
+
+	token := t.fallbackToken
+	if owner != "" {
+		installationToken, err := t.app.InstallationToken(req.Context(), owner, repo)
+		if err != nil {
+			t.app.logger.WithError(err).Warning(fmt.Sprintf("Failed to get installation token for %s/%s", owner, repo))
+		}
+		if installationToken != "" {
+			token = installationToken
+		}
+	}
+
+	if token != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && owner != "" {
+		// The token was revoked or expired early
+		t.app.Invalidate(owner, repo)
+	}
+	return resp, err
+}
+
+// requestRepo returns the repository an API request targets, from the path of repository
+// endpoints, the repo: qualifier of search queries or the context of GraphQL queries
+func requestRepo(req *http.Request) (string, string) {
+	if target, ok := req.Context().Value(requestRepoKey{}).([2]string); ok {
+		return target[0], target[1]
+	}
+
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, segment := range segments {
+		if segment != "repos" || i+1 >= len(segments) {
+			continue
+		}
+		repo := ""
+		if i+2 < len(segments) {
+			repo = segments[i+2]
+		}
+		return segments[i+1], repo
+	}
+
+	for _, term := range strings.Fields(req.URL.Query().Get("q")) {
+		if full, ok := strings.CutPrefix(term, "repo:"); ok {
+			if owner, repo, ok := strings.Cut(full, "/"); ok {
+				return owner, repo
+			}
+		}
+	}
+	return "", ""
+}
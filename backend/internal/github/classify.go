@@ -0,0 +1,131 @@
+package github
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// maxAttributesFiles caps how many .gitattributes files a classifier reads
+const maxAttributesFiles = 50
+
+// LoadFileClassifier returns a classifier with the .gitattributes files of a repository tree.
+// Files are added from the root down, so nested ones take precedence; unreadable ones are
+// ignored.
+func LoadFileClassifier(ctx context.Context, src RepoSource, ref string, files []models.GitHubFile) *utils.FileClassifier {
+	var attributes []string
+	for _, file := range files {
+		if file.Type == "file" && path.Base(file.Path) == ".gitattributes" {
+			attributes = append(attributes, file.Path)
+		}
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		di, dj := strings.Count(attributes[i], "/"), strings.Count(attributes[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return attributes[i] < attributes[j]
+	})
+	if len(attributes) > maxAttributesFiles {
+		attributes = attributes[:maxAttributesFiles]
+	}
+
+	classifier := utils.NewFileClassifier()
+	for _, attributesPath := range attributes {
+		content, err := src.ReadFile(ctx, ref, attributesPath)
+		if err != nil {
+			continue
+		}
+		classifier.AddAttributes(attributesPath, content.Content)
+	}
+	return classifier
+}
+
+// ClassifiedTree returns a repository tree without the files the classifier skips by path
+// and size, and without the directories left empty, counting the skipped files by class
+func ClassifiedTree(classifier *utils.FileClassifier, files []models.GitHubFile, skipped utils.SkipCounts) []models.GitHubFile {
+	skippedPaths := make(map[string]bool)
+	for _, file := range files {
+		if file.Type != "file" {
+			continue
+		}
+		if class := classifier.ClassifyPath(file.Path, file.Size); class != utils.FileSource {
+			skipped.Add(class)
+			skippedPaths[file.Path] = true
+		}
+	}
+	return withoutFiles(files, skippedPaths)
+}
+
+// withoutFiles returns a tree without some of its files and the directories left empty
+func withoutFiles(files []models.GitHubFile, removed map[string]bool) []models.GitHubFile {
+	occupied := make(map[string]bool)
+	for _, file := range files {
+		if file.Type != "file" || removed[file.Path] {
+			continue
+		}
+		for dir := path.Dir(file.Path); dir != "." && !occupied[dir]; dir = path.Dir(dir) {
+			occupied[dir] = true
+		}
+	}
+
+	var kept []models.GitHubFile
+	for _, file := range files {
+		if removed[file.Path] || file.Type == "dir" && !occupied[file.Path] {
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept
+}
+
+// SourceTree is a repository tree with the files the classifier skips left out
+type SourceTree struct {
+	Files      []models.GitHubFile
+	Classifier *utils.FileClassifier
+	Config     *AgentConfig
+	Skipped    utils.SkipCounts
+}
+
+// ListSourceTree lists a repository tree through src, loads its .gitattributes and agent
+// configuration, and leaves out ignored, generated, vendored, binary and oversized files.
+// Files found to be generated or binary once read are for callers to skip with Skip.
+func ListSourceTree(ctx context.Context, src RepoSource, ref string) (*SourceTree, error) {
+	files, err := src.ListTree(ctx, ref)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list repository files")
+	}
+	config, err := LoadAgentConfig(ctx, src, ref, files)
+	if err != nil {
+		return nil, err
+	}
+	tree := &SourceTree{
+		Classifier: LoadFileClassifier(ctx, src, ref, files),
+		Config:     config,
+		Skipped:    make(utils.SkipCounts),
+	}
+	tree.Classifier.Ignore(config.IgnorePatterns()...)
+	tree.Files = ClassifiedTree(tree.Classifier, files, tree.Skipped)
+	return tree, nil
+}
+
+// Structure returns the tree as an indented list, like BuildRepositoryStructure
+func (t *SourceTree) Structure() string {
+	return formatStructure(t.Files)
+}
+
+// Skip reports whether a file that was read is not source. Files skipped for their content
+// are counted; those skipped for their path were counted when the tree was listed.
+func (t *SourceTree) Skip(filePath, content string) bool {
+	if t.Classifier.ClassifyPath(filePath, len(content)) != utils.FileSource {
+		return true
+	}
+	class := t.Classifier.Classify(filePath, content)
+	t.Skipped.Add(class)
+	return class != utils.FileSource
+}
@@ -1,40 +1,166 @@
 package github
 
 import (
+	"container/list"
 	"context"
 	"fmt"
+	"net/http"
 	"go/parser"
 	"go/token"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/go-github/v43/github"
 	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
-	"golang.org/x/oauth2"
 )
 
+// maxUserClients is the number of per-user clients WithToken keeps for reuse
+const maxUserClients = 256
+
 // Client wraps the GitHub API client
 type Client struct {
-	client *github.Client
-	logger *common.Logger
+	client  *API
+	limiter *rateLimitTransport // Nil for clients built over a custom API
+	opts    ClientOptions
+	hosts   Hosts
+	users   *userClients // Clients returned by WithToken
+	logger  *common.Logger
+}
+
+// userClients is a least recently used cache of the clients acting as users, keyed by
+// their token, so a user's requests share one rate limit state
+type userClients struct {
+	mu       sync.Mutex
+	order    *list.List // Tokens, most recently used first
+	elements map[string]*list.Element
+	clients  map[string]*Client
+}
+
+// newUserClients creates an empty cache of per-user clients
+func newUserClients() *userClients {
+	return &userClients{
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+		clients:  make(map[string]*Client),
+	}
+}
+
+// get returns the client cached for a token, marking it as recently used
+func (u *userClients) get(token string) (*Client, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	client, ok := u.clients[token]
+	if ok {
+		u.order.MoveToFront(u.elements[token])
+	}
+	return client, ok
+}
+
+// add caches the client of a token, forgetting the least recently used one beyond
+// maxUserClients. A client cached concurrently for the same token is kept and returned.
+func (u *userClients) add(token string, client *Client) *Client {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if existing, ok := u.clients[token]; ok {
+		u.order.MoveToFront(u.elements[token])
+		return existing
+	}
+	u.elements[token] = u.order.PushFront(token)
+	u.clients[token] = client
+	for u.order.Len() > maxUserClients {
+		oldest := u.order.Remove(u.order.Back()).(string)
+		delete(u.elements, oldest)
+		delete(u.clients, oldest)
+	}
+	return client
 }
 
 // NewClient creates a new GitHub client with authentication
 func NewClient(token string) (*Client, error) {
-	if token == "" {
-		return nil, common.NewError("GitHub token is required")
+	return NewClientWithOptions(token, DefaultClientOptions())
+}
+
+// NewClientWithOptions creates a new GitHub client with rate limit, caching and GitHub App
+// options. The token may be empty when a GitHub App is configured.
+func NewClientWithOptions(token string, opts ClientOptions) (*Client, error) {
+	if token == "" && opts.App == nil {
+		return nil, common.NewError("GitHub token or GitHub App is required")
 	}
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-	return &Client{
-		client: client,
-		logger: common.NewLogger(),
-	}, nil
+	if opts.Hosts == (Hosts{}) {
+		opts.Hosts = DefaultHosts()
+	}
+	if opts.App != nil {
+		opts.App.WithBaseURL(opts.Hosts.APIURL)
+	}
+
+	tc, limiter := newHTTPClient(token, opts)
+	gh, err := newGitHubClient(tc, opts.Hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClientWithAPI(NewAPI(gh))
+	client.limiter = limiter
+	client.opts = opts
+	client.hosts = opts.Hosts
+	return client, nil
+}
+
+// Hosts returns the GitHub deployment the client talks to
+func (c *Client) Hosts() Hosts {
+	return c.hosts
+}
+
+// WithToken returns a client that acts as the owner of token, for requests made on behalf
+// of a user. It keeps the rate limit policy but neither the response cache nor the GitHub App.
+// Clients are reused per token, so the quota observed for a user carries across requests.
+func (c *Client) WithToken(token string) *Client {
+	if client, ok := c.users.get(token); ok {
+		return client
+	}
+
+	opts := c.opts
+	opts.CacheEntries = 0
+	opts.App = nil
+
+	tc, limiter := newHTTPClient(token, opts)
+	gh, err := newGitHubClient(tc, c.hosts)
+	if err != nil {
+		// The hosts were validated when c was created
+		gh = github.NewClient(tc)
+	}
+
+	client := NewClientWithAPI(NewAPI(gh))
+	client.limiter = limiter
+	client.opts = opts
+	client.hosts = c.hosts
+	return c.users.add(token, client)
+}
+
+// newGitHubClient creates a go-github client for the API of hosts
+func newGitHubClient(httpClient *http.Client, hosts Hosts) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if hosts.APIURL == DefaultAPIBaseURL && hosts.UploadURL == DefaultUploadURL {
+		return client, nil
+	}
+
+	baseURL, err := parseBaseURL(hosts.APIURL)
+	if err != nil {
+		return nil, common.WrapError(err, "invalid GitHub API URL")
+	}
+	uploadURL, err := parseBaseURL(hosts.UploadURL)
+	if err != nil {
+		return nil, common.WrapError(err, "invalid GitHub upload URL")
+	}
+	client.BaseURL = baseURL
+	client.UploadURL = uploadURL
+	return client, nil
 }
 
 // --- Core GitHub Interaction Methods ---
@@ -60,7 +186,7 @@ func (c *Client) GetFileContentText(ctx context.Context, owner, repo, path, ref
 	)
 	if err != nil {
 		if resp != nil && resp.StatusCode == 404 {
-			return nil, common.NewError("file not found: " + path)
+			return nil, fileNotFound(path)
 		}
 		return nil, common.WrapError(err, fmt.Sprintf("failed to get file content for %s", path))
 	}
@@ -108,51 +234,6 @@ func (c *Client) GetFileContentText(ctx context.Context, owner, repo, path, ref
 }
 
 
-// CreateOrUpdateFile creates or updates a file in a repository
-func (c *Client) CreateOrUpdateFile(
-	ctx context.Context,
-	owner, repo, path, message, content string,
-	branch string,
-) error {
-	var sha string
-	// Use GetFileContentText which returns *models.FileContent now
-	fileInfo, err := c.GetFileContentText(ctx, owner, repo, path, branch)
-
-	// If file exists (no error or specifically not a 'not found' error)
-	var isNotFoundError bool
-	if err != nil {
-		// Check if the error indicates 'not found'
-		if commonErr, ok := err.(*common.AppError); ok && strings.Contains(commonErr.Message, "file not found") {
-				isNotFoundError = true
-		} else {
-			// Log other errors but proceed (attempt create)
-			c.logger.WithError(err).Warnf("Could not get existing file info for %s, attempting create/update", path)
-		}
-	}
-
-	if err == nil && fileInfo != nil {
-		sha = fileInfo.SHA // Get SHA from our model struct
-	} else if !isNotFoundError {
-		 // Log if error wasn't 'not found' but we still proceed
-		 c.logger.WithError(err).Warnf("Proceeding without SHA for %s", path)
-	}
-
-
-	opts := &github.RepositoryContentFileOptions{
-		Message: github.String(message),
-		Content: []byte(content),
-		Branch:  github.String(branch),
-		SHA:     github.String(sha), // Pass SHA if non-empty (update), empty string is ignored (create)
-	}
-
-	_, _, err = c.client.Repositories.CreateFile(ctx, owner, repo, path, opts) // CreateFile handles both create/update based on SHA
-	if err != nil {
-		return common.WrapError(err, fmt.Sprintf("failed to create or update file %s", path))
-	}
-	return nil
-}
-
-
 // ListFiles lists files in a directory in a repository
 func (c *Client) ListFiles(
 	ctx context.Context,
@@ -198,7 +279,7 @@ func (c *Client) GetAllFiles(ctx context.Context, owner, repo, ref string) ([]mo
 			Size:        entry.GetSize(), // GetSize handles nil pointer
 			Type:        fileType,
 			// SHA:      entry.GetSHA(), // REMOVED - Compiler indicated this field doesn't exist in models.GitHubFile
-			HTMLURL:     fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s", owner, repo, ref, entryPath), // Construct HTML URL
+			HTMLURL:     c.hosts.BlobURL(owner, repo, ref, entryPath), // Construct HTML URL
 			DownloadURL: "", // Not available directly from GetTree, set to empty
 		}
 
@@ -216,30 +297,12 @@ func (c *Client) GetAllFiles(ctx context.Context, owner, repo, ref string) ([]mo
 
 // GetRepositoryStructure retrieves the repository structure as a formatted string
 func (c *Client) GetRepositoryStructure(ctx context.Context, owner, repo, ref string) (string, error) {
-	// Reuses logic from GetAllFiles for efficiency
-	allFiles, err := c.GetAllFiles(ctx, owner, repo, ref) // Use the improved GetAllFiles
-	if err != nil {
-		return "", common.WrapError(err, "failed to get file list for structure")
-	}
-	if len(allFiles) == 0 { return "Repository is empty.", nil }
-
-	var sb strings.Builder
-	structureMap := make(map[string][]string) // dir path -> list of children names (file or dir/)
-
-	for _, file := range allFiles {
-		dir := filepath.Dir(file.Path); if dir == "." { dir = "" } // Root is ""
-		childName := file.Name
-		if file.Type == "dir" { childName += "/" }
-		structureMap[dir] = append(structureMap[dir], childName)
-	}
-
-	c.buildStructureString(&sb, structureMap, "", 0)
-	return sb.String(), nil
+	return BuildRepositoryStructure(ctx, c.NewAPISource(owner, repo), ref)
 }
 
 
 // buildStructureString recursively builds the structure string from the map
-func (c *Client) buildStructureString(sb *strings.Builder, structureMap map[string][]string, currentDir string, depth int) {
+func buildStructureString(sb *strings.Builder, structureMap map[string][]string, currentDir string, depth int) {
     children, ok := structureMap[currentDir]
     if !ok { return }
     // Consider sorting children alphabetically here if desired
@@ -251,7 +314,7 @@ func (c *Client) buildStructureString(sb *strings.Builder, structureMap map[stri
 			nextDirPath := filepath.Join(currentDir, childDirName)
 			// Prevent infinite loops in case of weird data
 			if depth < 20 { // Limit recursion depth for safety
-				c.buildStructureString(sb, structureMap, nextDirPath, depth+1)
+				buildStructureString(sb, structureMap, nextDirPath, depth+1)
 			}
 		}
     }
@@ -261,182 +324,177 @@ func (c *Client) buildStructureString(sb *strings.Builder, structureMap map[stri
 
 // Enhanced GetImportMap to better handle Go imports and other relationships
 func (c *Client) GetImportMap(ctx context.Context, owner, repo, ref string) (map[string][]string, error) {
-    c.logger.Info(fmt.Sprintf("Starting GetImportMap for %s/%s @ %s", owner, repo, ref))
+    return BuildImportMap(ctx, c.NewAPISource(owner, repo), ref)
+}
+
+// trimRepoImportPath returns the path within the repository of a Go import path of the form
+// <host>/owner/repo/..., on github.com or an Enterprise Server host
+func trimRepoImportPath(imp, owner, repo string) (string, bool) {
+    _, rest, ok := strings.Cut(imp, "/")
+    if !ok || !strings.HasPrefix(rest, owner+"/"+repo) {
+        return "", false
+    }
+    rest = strings.TrimPrefix(rest, owner+"/"+repo)
+    if rest != "" && !strings.HasPrefix(rest, "/") {
+        return "", false // A different repository sharing the prefix
+    }
+    return strings.TrimPrefix(rest, "/"), true
+}
+
+// ImportGraph is the import graph of a repository: imports between its files, and the
+// external dependencies each file imports
+type ImportGraph struct {
+    Imports      map[string][]string           // File -> imported files
+    External     map[string][]string           // File -> names of imported external dependencies
+    Dependencies map[string]models.ExternalDependency // Name -> external dependency
+    Files        []models.GitHubFile           // Tree without generated, vendored, binary and oversized files
+    Skipped      utils.SkipCounts              // Files left out, by class
+}
+
+// addExternal records that a file imports an external dependency
+func (g *ImportGraph) addExternal(file string, dep models.ExternalDependency) {
+    if _, ok := g.Dependencies[dep.Name]; !ok {
+        g.Dependencies[dep.Name] = dep
+    }
+    for _, name := range g.External[file] {
+        if name == dep.Name {
+            return
+        }
+    }
+    g.External[file] = append(g.External[file], dep.Name)
+}
+
+// BuildImportMap builds the file import map of a repository, reading files through src
+func BuildImportMap(ctx context.Context, src RepoSource, ref string) (map[string][]string, error) {
+    importGraph, err := BuildImportGraph(ctx, src, ref)
+    if err != nil {
+        return nil, err
+    }
+    return importGraph.Imports, nil
+}
+
+// BuildImportGraph builds the import graph of a repository, reading files through src.
+// Go imports are resolved against every go.mod of the repository, nested modules and
+// replace directives included, to all files of the imported package. Each language in
+// importLanguages has its own resolver; see importers.go.
+func BuildImportGraph(ctx context.Context, src RepoSource, ref string) (*ImportGraph, error) {
+    logger := common.NewLogger()
+    owner, repo := src.Repo()
+    logger.Info(fmt.Sprintf("Starting GetImportMap for %s/%s @ %s", owner, repo, ref))
     
-    // Get all files first
-    allFiles, err := c.GetAllFiles(ctx, owner, repo, ref)
+    // Get all files first, leaving out generated, vendored, binary and oversized ones
+    tree, err := ListSourceTree(ctx, src, ref)
     if err != nil { 
         return nil, common.WrapError(err, "failed to get all files for import map") 
     }
+    allFiles := tree.Files
 
     // Build a map of file paths to file types for quick lookups
     existingFiles := make(map[string]string)
-    existingPaths := make(map[string]bool) // For exact path matching
-    
     for _, f := range allFiles {
         existingFiles[f.Path] = f.Type
-        existingPaths[f.Path] = true
     }
     
-    c.logger.Info(fmt.Sprintf("Found %d total files/dirs for import analysis.", len(existingFiles)))
-
-    // Initialize the import map
-    importMap := make(map[string][]string)
+    logger.Info(fmt.Sprintf("Found %d total files/dirs for import analysis.", len(existingFiles)))
+
+    // Initialize the import graph
+    importGraph := &ImportGraph{
+        Imports:      make(map[string][]string),
+        External:     make(map[string][]string),
+        Dependencies: make(map[string]models.ExternalDependency),
+        Skipped:      tree.Skipped,
+    }
+    importMap := importGraph.Imports
+    skippedFiles := make(map[string]bool) // Found to be generated or binary once read
     filesProcessed := 0
     
-    // First pass: analyze Go imports using AST
-    for _, file := range allFiles {
-        // Skip directories and non-source files
-        if file.Type != "file" || !isGoSourceFile(file.Path) {
-            continue
+    // Extract and resolve imports with the resolver of each language. Files of languages
+    // without a resolver get no edges rather than guessed ones.
+    ic := &importContext{ctx: ctx, src: src, ref: ref, files: allFiles, existing: existingFiles, logger: logger}
+    for _, language := range importLanguages {
+        var sources []string
+        for _, file := range allFiles {
+            if file.Type == "file" && language.handles(file.Path) {
+                sources = append(sources, file.Path)
+            }
         }
-        
-        filesProcessed++
-        
-        // Get the file content
-        content, err := c.GetFileContentText(ctx, owner, repo, file.Path, ref)
-        if err != nil {
-            c.logger.WithField("error", err).Warning("Skipping import extraction: Failed to get content for file: " + file.Path)
+        if len(sources) == 0 {
             continue
         }
         
-        // Extract Go imports using AST
-        imports := extractGoImportsUsingAST(content.Content, c.logger)
-        if len(imports) == 0 {
-            continue
+        // Read every file first, so resolvers can index what each declares
+        resolver := language.setup(ic)
+        contents := make(map[string]string, len(sources))
+        for _, filePath := range sources {
+            content, err := src.ReadFile(ctx, ref, filePath)
+            if err != nil {
+                logger.WithField("error", err).Warning("Skipping import extraction: Failed to get content for file: " + filePath)
+                continue
+            }
+            if tree.Skip(filePath, content.Content) {
+                skippedFiles[filePath] = true
+                continue
+            }
+            contents[filePath] = content.Content
+            resolver.index(filePath, content.Content)
         }
         
-        c.logger.Debug(fmt.Sprintf("Found %d imports in %s (Go)", len(imports), file.Path))
-        
-        // Resolve import paths to actual files in the repository
-        resolvedImports := []string{}
-        
-        // For each import, try to resolve it to a file in the repository
-        for _, imp := range imports {
-            // Skip standard library imports and third-party packages
-            if !strings.HasPrefix(imp, ".") && !strings.HasPrefix(imp, "github.com/"+owner+"/"+repo) {
+        for _, filePath := range sources {
+            content, ok := contents[filePath]
+            if !ok {
                 continue
             }
+            filesProcessed++
             
-            // If it's an internal import (from the same repo), try to resolve it
-            if strings.HasPrefix(imp, "github.com/"+owner+"/"+repo) {
-                // Remove the repository prefix to get the relative path
-                relPath := strings.TrimPrefix(imp, "github.com/"+owner+"/"+repo+"/")
-                
-                // Check if the file exists in our list
-                for existingPath := range existingPaths {
-                    if strings.HasPrefix(existingPath, relPath) && existingFiles[existingPath] == "file" {
-                        resolvedImports = append(resolvedImports, existingPath)
-                        break
+            imports := resolver.extract(filePath, content)
+            logger.Debug(fmt.Sprintf("Found %d imports in %s (%s)", len(imports), filePath, language.name))
+            
+            // Resolve import paths to actual files in the repository, without duplicates
+            seen := make(map[string]bool)
+            for _, imp := range imports {
+                targets := resolver.resolve(filePath, imp)
+                for _, target := range targets {
+                    if target != filePath && !seen[target] {
+                        seen[target] = true
+                        importMap[filePath] = append(importMap[filePath], target)
                     }
                 }
-            } else if strings.HasPrefix(imp, ".") {
-                // For relative imports
-                sourceDir := filepath.Dir(file.Path)
-                if sourceDir == "." {
-                    sourceDir = ""
+                if len(targets) > 0 {
+                    continue
                 }
                 
-                // Construct potential paths
-                potentialPath := filepath.Clean(filepath.Join(sourceDir, imp))
-                
-                // Check if it's a directory
-                if _, ok := existingFiles[potentialPath]; ok && existingFiles[potentialPath] == "dir" {
-                    // Look for a Go file in this directory
-                    for existingPath := range existingPaths {
-                        dirPath := filepath.Dir(existingPath)
-                        if dirPath == potentialPath && filepath.Ext(existingPath) == ".go" {
-                            resolvedImports = append(resolvedImports, existingPath)
-                            break
-                        }
-                    }
-                } else {
-                    // Try with .go extension
-                    potentialPath = potentialPath + ".go"
-                    if _, ok := existingPaths[potentialPath]; ok {
-                        resolvedImports = append(resolvedImports, potentialPath)
-                    }
-                }
-            }
-        }
-        
-        // Add the resolved imports to the import map
-        if len(resolvedImports) > 0 {
-            // Remove duplicates
-            uniqueImports := make(map[string]bool)
-            var finalImports []string
-            
-            for _, imp := range resolvedImports {
-                if !uniqueImports[imp] {
-                    uniqueImports[imp] = true
-                    finalImports = append(finalImports, imp)
+                // Standard library and third-party packages
+                if dep, ok := resolver.dependency(filePath, imp); ok {
+                    importGraph.addExternal(filePath, dep)
                 }
             }
-            
-            importMap[file.Path] = finalImports
         }
     }
     
-    // Second pass: analyze other languages and infer relationships based on naming patterns
-    // (This is a heuristic approach for other file types)
-    for _, file := range allFiles {
-        // Skip directories
-        if file.Type != "file" {
-            continue
-        }
-        
-        // Skip files already processed
-        if _, ok := importMap[file.Path]; ok {
-            continue
-        }
-        
-        // Try to infer relationships based on naming patterns
-        // For example, model.go might be related to model_test.go
-        baseName := strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
-        dirPath := filepath.Dir(file.Path)
-        
-        // Check for test files
-        if strings.HasSuffix(baseName, "_test") {
-            // This is a test file, find the corresponding implementation file
-            implName := strings.TrimSuffix(baseName, "_test") + filepath.Ext(file.Path)
-            implPath := filepath.Join(dirPath, implName)
-            
-            if _, ok := existingPaths[implPath]; ok {
-                if _, ok := importMap[file.Path]; !ok {
-                    importMap[file.Path] = []string{implPath}
-                } else {
-                    importMap[file.Path] = append(importMap[file.Path], implPath)
-                }
-            }
-        }
-        
-        // Check if it's a Go file in a package directory
-        if filepath.Ext(file.Path) == ".go" {
-            // Find other Go files in the same directory
-            sameDirFiles := []string{}
-            
-            for existingPath := range existingPaths {
-                if filepath.Dir(existingPath) == dirPath && 
-                   existingPath != file.Path && 
-                   filepath.Ext(existingPath) == ".go" {
-                    sameDirFiles = append(sameDirFiles, existingPath)
-                }
-            }
-            
-            if len(sameDirFiles) > 0 {
-                if _, ok := importMap[file.Path]; !ok {
-                    importMap[file.Path] = sameDirFiles
-                } else {
-                    importMap[file.Path] = append(importMap[file.Path], sameDirFiles...)
+    // Drop the files found to be generated or binary from the graph
+    if len(skippedFiles) > 0 {
+        for source, targets := range importMap {
+            kept := targets[:0]
+            for _, target := range targets {
+                if !skippedFiles[target] {
+                    kept = append(kept, target)
                 }
             }
+            importMap[source] = kept
         }
+        importGraph.Files = withoutFiles(allFiles, skippedFiles)
+    } else {
+        importGraph.Files = allFiles
     }
     
-    c.logger.Info(fmt.Sprintf("Processed %d source files for imports.", filesProcessed))
-    c.logger.Info(fmt.Sprintf("Final import map contains %d source files with resolved imports.", len(importMap)))
+    logger.Info(fmt.Sprintf("Processed %d source files for imports.", filesProcessed))
+    if len(importGraph.Skipped) > 0 {
+        logger.Info(fmt.Sprintf("Skipped %s files.", importGraph.Skipped))
+    }
+    logger.Info(fmt.Sprintf("Final import map contains %d source files with resolved imports.", len(importMap)))
+    logger.Info(fmt.Sprintf("Found %d external dependencies.", len(importGraph.Dependencies)))
     
-    return importMap, nil
+    return importGraph, nil
 }
 
 // Helper function to check if a file is a Go source file
@@ -444,65 +502,6 @@ func isGoSourceFile(path string) bool {
     return filepath.Ext(path) == ".go"
 }
 
-// resolveImportPath tries to resolve a raw import string to a full file path within the repo.
-func (c *Client) resolveImportPath(sourceDir, rawImport, language string, existingFiles map[string]string) string {
-	rawImport = strings.TrimSpace(rawImport)
-	if rawImport == "" { return "" }
-	if !strings.HasPrefix(rawImport, ".") { return "" } // Ignore non-relative for now
-
-	potentialTargetPath := filepath.Join(sourceDir, rawImport)
-	potentialTargetPath = filepath.Clean(potentialTargetPath)
-
-	var possibleExtensions []string; var indexFileNames []string
-	switch language {
-	case "JavaScript", "TypeScript":
-		possibleExtensions = []string{".js", ".jsx", ".ts", ".tsx", ".json"}
-		indexFileNames = []string{"index.js", "index.jsx", "index.ts", "index.tsx"}
-	case "Python":
-		possibleExtensions = []string{".py"}
-		indexFileNames = []string{"__init__.py"}
-	case "C", "C++":
-		possibleExtensions = []string{".h", ".hpp"} // Primarily headers are included relatively
-	} // Add others if needed
-
-	// A. Direct file match
-	if ftype, ok := existingFiles[potentialTargetPath]; ok && ftype == "file" { return potentialTargetPath }
-	// B. Match with extension
-	for _, ext := range possibleExtensions {
-		pathWithExt := potentialTargetPath + ext
-		if ftype, ok := existingFiles[pathWithExt]; ok && ftype == "file" { return pathWithExt }
-	}
-	// C. Match directory + index file
-	if dtype, ok := existingFiles[potentialTargetPath]; ok && dtype == "dir" {
-		for _, indexFile := range indexFileNames {
-			indexPath := filepath.Join(potentialTargetPath, indexFile)
-			if ftype, ok := existingFiles[indexPath]; ok && ftype == "file" { return indexPath }
-		}
-	}
-	return "" // No resolution found
-}
-
-
-// extractImports uses AST for Go, basic regex for others.
-func extractImports(content, language string, logger *common.Logger) []string {
-	switch language {
-	case "Go":
-		return extractGoImportsUsingAST(content, logger)
-	case "JavaScript", "TypeScript":
-		logger.Debug("Using basic Regex extraction for JavaScript/TypeScript")
-		return extractJSImportsRegex(content)
-	case "Python":
-		logger.Debug("Using basic Regex extraction for Python")
-		return extractPythonImportsRegex(content)
-	case "C", "C++":
-		logger.Debug("Using basic Regex extraction for C/C++")
-		return extractCImportsRegex(content)
-	default:
-		logger.Debug(fmt.Sprintf("Import extraction not implemented for language: %s", language))
-		return []string{}
-	}
-}
-
 // --- AST Parsing for Go ---
 
 func extractGoImportsUsingAST(content string, logger *common.Logger) []string {
@@ -531,119 +530,8 @@ func extractGoImportsUsingAST(content string, logger *common.Logger) []string {
 	return imports
 }
 
-// --- Basic Regex Import Extraction (Placeholders) ---
-
-var (
-    // Fix for jsImportRegex - replace backreference with character class
-    jsImportRegex    = regexp.MustCompile(`(?m)^\s*import(?:["'\s]*(?:[\w*{}\n\r\s,./[\]]+from\s*)?)(["'])(?P<path>.*?[^\\])(["'])`)
-    
-    // Fix for jsRequireRegex
-    jsRequireRegex   = regexp.MustCompile(`(?m)require\((["'])(?P<path>.*?[^\\])(["'])\)`)
-    
-    // Fix for jsDynImportRegex
-    jsDynImportRegex = regexp.MustCompile(`(?m)import\((["'])(?P<path>.*?[^\\])(["'])\)`)
-    
-    // These regexes don't use backreferences, so they're fine
-    pyImportRegex    = regexp.MustCompile(`(?m)^\s*import\s+((?:\.?\w+)(?:\s*,\s*\.?\w+)*)`)
-    pyFromImportRegex= regexp.MustCompile(`(?m)^\s*from\s+((?:\.+)?[\w\.]+)\s+import`)
-    cIncludeRegex    = regexp.MustCompile(`(?m)^\s*#include\s*(["<])(?P<path>[^>"]+)[">]`)
-)
-
-
-func removeComments(content, language string) string { // Basic comment removal
-	var singleLineComment string
-	switch language {
-	case "Go", "JavaScript", "TypeScript", "Java", "C", "C++", "C#", "Swift", "Kotlin": singleLineComment = "//"
-	case "Python", "Ruby": singleLineComment = "#"
-	case "PHP": singleLineComment = "//"
-	default: return content
-	}
-
-	var cleanedLines []string; lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if singleLineComment != "" && strings.HasPrefix(trimmedLine, singleLineComment) { continue }
-		if language == "PHP" && strings.HasPrefix(trimmedLine, "#") { continue }
-		if singleLineComment != "" {
-			if idx := strings.Index(line, singleLineComment); idx != -1 {
-				quoteCountBefore := strings.Count(line[:idx], "\"") + strings.Count(line[:idx], "'") + strings.Count(line[:idx], "`")
-				if quoteCountBefore%2 == 0 { line = line[:idx] }
-			}
-		}
-		// Simplified block comment removal (very basic)
-		if language == "JavaScript" || language == "TypeScript" || language == "Go" || language == "Java" || language == "C" || language == "C++" || language == "C#" {
-			if startIdx := strings.Index(line, "/*"); startIdx != -1 {
-				if endIdx := strings.Index(line, "*/"); endIdx > startIdx { line = line[:startIdx] + line[endIdx+2:] } else { line = line[:startIdx] }
-			}
-		}
-		cleanedLines = append(cleanedLines, line)
-	}
-	return strings.Join(cleanedLines, "\n")
-}
-
-func extractJSImportsRegex(content string) []string { // Basic JS/TS extraction
-	content = removeComments(content, "JavaScript"); imports := make(map[string]bool)
-	processMatches := func(re *regexp.Regexp) {
-		matches := re.FindAllStringSubmatch(content, -1)
-		pathIndex := re.SubexpIndex("path")
-		if pathIndex == -1 { pathIndex = len(re.SubexpNames())-1 } // Fallback to last group
-		for _, match := range matches { if len(match) > pathIndex && match[pathIndex] != "" { imports[match[pathIndex]] = true } }
-	}
-	processMatches(jsImportRegex); processMatches(jsRequireRegex); processMatches(jsDynImportRegex)
-	result := []string{}; for imp := range imports { result = append(result, imp) }
-	return result
-}
-
-func extractPythonImportsRegex(content string) []string { // Basic Python extraction
-	content = removeComments(content, "Python"); imports := make(map[string]bool)
-	matches := pyImportRegex.FindAllStringSubmatch(content, -1)
-	for _, match := range matches { if len(match) > 1 { for _, mod := range strings.Split(match[1], ",") { imports[strings.TrimSpace(mod)] = true } } }
-	matches = pyFromImportRegex.FindAllStringSubmatch(content, -1)
-	for _, match := range matches { if len(match) > 1 { imports[match[1]] = true } } // Stores 'x' or '.x' from 'from x import y'
-
-	result := []string{};
-	for imp := range imports { // Convert module paths like 'a.b' or '.a.b' to relative paths
-		leadingDots := 0; tempImp := imp
-		for strings.HasPrefix(tempImp, ".") { leadingDots++; tempImp = tempImp[1:] }
-		pathParts := strings.Split(tempImp, ".")
-		// Reconstruct path: add leading "../" for each dot, join parts with "/"
-		finalPath := strings.Repeat("../", leadingDots) + strings.Join(pathParts, "/")
-		// Remove trailing slash if it was just dots (e.g., "from . import x" -> "../")
-		if finalPath == strings.Repeat("../", leadingDots) && len(pathParts) == 1 && pathParts[0] == "" {
-				if leadingDots > 0 { finalPath = strings.Repeat("../", leadingDots-1) } else { continue } // Skip empty result
-		}
-		if finalPath != "" {	result = append(result, finalPath) }
-	}
-	return result
-}
-
-
-func extractCImportsRegex(content string) []string { // Basic C/C++ extraction
-	content = removeComments(content, "C"); imports := make(map[string]bool)
-	matches := cIncludeRegex.FindAllStringSubmatch(content, -1)
-	pathIndex := cIncludeRegex.SubexpIndex("path")
-	if pathIndex == -1 { pathIndex = len(cIncludeRegex.SubexpNames())-1 }
-	for _, match := range matches { if len(match) > pathIndex && match[pathIndex] != "" { imports[match[pathIndex]] = true } }
-	result := []string{}; for imp := range imports { result = append(result, imp) }
-	return result
-}
-
 // --- Other Helper Functions ---
 
-func isSourceFile(path string) bool {
-	ext := strings.ToLower(filepath.Ext(path))
-	sourceExts := map[string]bool{ ".go": true, ".java": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".py": true, ".rb": true, ".php": true, ".c": true, ".cpp": true, ".h": true, ".hpp": true, ".cs": true, ".swift": true, ".kt": true, ".kts": true, }
-	return sourceExts[ext]
-}
-
-func getLanguageFromPath(path string) string {
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".go": return "Go"; case ".java": return "Java"; case ".js", ".jsx": return "JavaScript"; case ".ts", ".tsx": return "TypeScript"; case ".py": return "Python"; case ".rb": return "Ruby"; case ".php": return "PHP"; case ".c", ".h": return "C"; case ".cpp", ".hpp": return "C++"; case ".cs": return "C#"; case ".swift": return "Swift"; case ".kt", ".kts": return "Kotlin"
-	default: return "Unknown"
-	}
-}
-
 // GetRepositoryInfo fetches combined repository information
 // NOTE: Uses the imported models.RepositoryInfo
 func (c *Client) GetRepositoryInfo(ctx context.Context, owner, repo string) (*models.RepositoryInfo, error) {
@@ -661,29 +549,19 @@ func (c *Client) GetRepositoryInfo(ctx context.Context, owner, repo string) (*mo
 }
 
 
-// GetFunctionCode extracts a function's code (remains basic)
+// GetFunctionCode extracts a function's code and 1-based line range from a file
 func (c *Client) GetFunctionCode(ctx context.Context, owner, repo, path, functionName, ref string) (string, int, int, error) {
-	// Keeping the previous basic (non-AST) implementation for brevity
 	content, err := c.GetFileContentText(ctx, owner, repo, path, ref)
 	if err != nil { return "", 0, 0, common.WrapError(err, "failed to get file content") }
+	return ExtractFunctionCode(path, content.Content, functionName)
+}
 
-	lines := strings.Split(content.Content, "\n"); startLine, endLine := -1, -1; inFunction := false; braceCount := 0
-	for i, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if !inFunction && (strings.Contains(trimmedLine, "func "+functionName+"(") || strings.Contains(trimmedLine, "function "+functionName+"(") || strings.Contains(trimmedLine, functionName+" = function(") || strings.Contains(trimmedLine, "def "+functionName+"(")) {
-			startLine = i; inFunction = true; braceCount = strings.Count(trimmedLine, "{") - strings.Count(trimmedLine, "}"); continue
-		}
-		if inFunction {
-			braceCount += strings.Count(trimmedLine, "{") - strings.Count(trimmedLine, "}")
-			if braceCount <= 0 && (strings.Contains(trimmedLine, "}") || (startLine == i && !strings.Contains(trimmedLine,"{")) ){ endLine = i; break }
-			if startLine != -1 && i > startLine && trimmedLine != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
-				 if i > 0 { prevTrimmed := strings.TrimSpace(lines[i-1]); if prevTrimmed == "" || !strings.HasPrefix(lines[i-1], " ") && !strings.HasPrefix(lines[i-1], "\t") { endLine = i - 1; break }
-				 } else { endLine = startLine; break }
-			}
-		}
-	}
-	if startLine == -1 { return "", 0, 0, common.NewError("function not found: " + functionName) }
-	if endLine == -1 { endLine = len(lines) - 1 }
-	functionLines := lines[startLine : endLine+1]
-	return strings.Join(functionLines, "\n"), startLine + 1, endLine + 1, nil
+// ExtractFunctionCode finds a function in file content by name, which may be qualified as
+// Type.Method, and returns its code and 1-based line range
+func ExtractFunctionCode(path, content, functionName string) (string, int, int, error) {
+	symbols, err := utils.ExtractSymbols(path, content)
+	if err != nil { return "", 0, 0, common.WrapError(err, "failed to extract symbols") }
+	symbol, ok := utils.FindSymbol(symbols, functionName, 0)
+	if !ok { return "", 0, 0, common.NewError("function not found: " + functionName) }
+	return utils.SymbolCode(content, symbol), symbol.StartLine, symbol.EndLine, nil
 }
\ No newline at end of file
@@ -0,0 +1,238 @@
+// internal/github/commit.go
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// ErrBranchMoved is returned when the branch no longer points at the commit a change was based on
+var ErrBranchMoved = errors.New("branch was updated by another commit")
+
+// defaultFileMode is the tree mode of new files
+const defaultFileMode = "100644"
+
+// CommitResult describes a commit created by a CommitBuilder
+type CommitResult struct {
+	SHA     string   `json:"sha"`
+	URL     string   `json:"url"`
+	Branch  string   `json:"branch"`
+	Changed []string `json:"changed,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+
+	// Set when the change was proposed in a pull request
+	PullRequestNumber int    `json:"pull_request_number,omitempty"`
+	PullRequestURL    string `json:"pull_request_url,omitempty"`
+}
+
+// CommitBuilder stages file changes and deletions and commits them to a branch as a single
+// commit through the Git Data API. The branch is fast-forwarded, never forced, so the commit
+// fails with ErrBranchMoved if another commit lands first.
+type CommitBuilder struct {
+	client      *Client
+	owner, repo string
+	branch      string
+	expectedSHA string
+
+	changes map[string]*string // Path to new content; nil deletes the file
+}
+
+// NewCommit starts a commit to a branch of owner/repo
+func (c *Client) NewCommit(owner, repo, branch string) *CommitBuilder {
+	return &CommitBuilder{
+		client:  c,
+		owner:   owner,
+		repo:    repo,
+		branch:  branch,
+		changes: make(map[string]*string),
+	}
+}
+
+// Put stages the new content of a file, creating it if needed
+func (b *CommitBuilder) Put(path, content string) *CommitBuilder {
+	b.changes[cleanTreePath(path)] = &content
+	return b
+}
+
+// Delete stages the removal of a file
+func (b *CommitBuilder) Delete(path string) *CommitBuilder {
+	b.changes[cleanTreePath(path)] = nil
+	return b
+}
+
+// ExpectHead makes the commit fail with ErrBranchMoved unless the branch still points at sha
+func (b *CommitBuilder) ExpectHead(sha string) *CommitBuilder {
+	b.expectedSHA = sha
+	return b
+}
+
+// AgentConfig reads the agent configuration of the repository at the target branch
+func (b *CommitBuilder) AgentConfig(ctx context.Context) (*AgentConfig, error) {
+	return LoadAgentConfigAt(ctx, b.client.NewAPISource(b.owner, b.repo), b.branch)
+}
+
+// Len returns the number of staged changes
+func (b *CommitBuilder) Len() int {
+	return len(b.changes)
+}
+
+// Commit creates the blobs, tree and commit for the staged changes and moves the branch to it.
+// A commit that would not change the tree is skipped and the current head is returned.
+func (b *CommitBuilder) Commit(ctx context.Context, message string) (*CommitResult, error) {
+	if len(b.changes) == 0 {
+		return nil, common.NewError("no changes staged for commit")
+	}
+	if _, ok := b.changes[""]; ok {
+		return nil, common.NewError("invalid file path staged for commit")
+	}
+	git := b.client.client.Git
+
+	// Resolve the head the commit builds on
+	ref, _, err := git.GetRef(ctx, b.owner, b.repo, "heads/"+b.branch)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get branch "+b.branch)
+	}
+	headSHA := ref.GetObject().GetSHA()
+	if b.expectedSHA != "" && headSHA != b.expectedSHA {
+		return nil, common.WrapError(ErrBranchMoved, fmt.Sprintf("%s is at %s, expected %s", b.branch, shortSHA(headSHA), shortSHA(b.expectedSHA)))
+	}
+
+	head, _, err := git.GetCommit(ctx, b.owner, b.repo, headSHA)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get head commit")
+	}
+	baseTreeSHA := head.GetTree().GetSHA()
+
+	entries, result, err := b.treeEntries(ctx, baseTreeSHA)
+	if err != nil {
+		return nil, err
+	}
+	result.Branch = b.branch
+	if len(entries) == 0 {
+		result.SHA = headSHA
+		return result, nil
+	}
+
+	tree, _, err := git.CreateTree(ctx, b.owner, b.repo, baseTreeSHA, entries)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to create tree")
+	}
+	if tree.GetSHA() == baseTreeSHA {
+		// The staged content matches the branch already
+		result.SHA = headSHA
+		result.Changed, result.Deleted = nil, nil
+		return result, nil
+	}
+
+	commit, _, err := git.CreateCommit(ctx, b.owner, b.repo, &github.Commit{
+		Message: github.String(message),
+		Tree:    &github.Tree{SHA: tree.SHA},
+		Parents: []*github.Commit{{SHA: github.String(headSHA)}},
+	})
+	if err != nil {
+		return nil, common.WrapError(err, "failed to create commit")
+	}
+
+	// Fast-forward only, so a concurrent push is never overwritten
+	ref.Object = &github.GitObject{SHA: commit.SHA}
+	_, resp, err := git.UpdateRef(ctx, b.owner, b.repo, ref, false)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+			return nil, common.WrapError(ErrBranchMoved, fmt.Sprintf("%s moved while committing", b.branch))
+		}
+		return nil, common.WrapError(err, "failed to update branch "+b.branch)
+	}
+
+	result.SHA = commit.GetSHA()
+	result.URL = commit.GetHTMLURL()
+	if result.URL == "" {
+		result.URL = fmt.Sprintf("%s/commit/%s", b.client.hosts.RepoURL(b.owner, b.repo), result.SHA)
+	}
+	return result, nil
+}
+
+// treeEntries creates a blob for each staged file and returns the tree entries of the change.
+// Modes of existing files are kept and deletions of missing files are dropped.
+func (b *CommitBuilder) treeEntries(ctx context.Context, baseTreeSHA string) ([]*github.TreeEntry, *CommitResult, error) {
+	git := b.client.client.Git
+
+	modes := make(map[string]string)
+	baseTree, _, err := git.GetTree(ctx, b.owner, b.repo, baseTreeSHA, true)
+	if err != nil {
+		return nil, nil, common.WrapError(err, "failed to get base tree")
+	}
+	for _, entry := range baseTree.Entries {
+		if entry.GetType() == "blob" {
+			modes[entry.GetPath()] = entry.GetMode()
+		}
+	}
+	complete := !baseTree.GetTruncated()
+
+	paths := make([]string, 0, len(b.changes))
+	for path := range b.changes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	result := &CommitResult{}
+	var entries []*github.TreeEntry
+	for _, path := range paths {
+		content := b.changes[path]
+		mode, exists := modes[path]
+
+		if content == nil {
+			if complete && !exists {
+				continue
+			}
+			// A nil SHA and content delete the path
+			entries = append(entries, &github.TreeEntry{
+				Path: github.String(path),
+				Mode: github.String(defaultFileMode),
+				Type: github.String("blob"),
+			})
+			result.Deleted = append(result.Deleted, path)
+			continue
+		}
+
+		blob, _, err := git.CreateBlob(ctx, b.owner, b.repo, &github.Blob{
+			Content:  github.String(base64.StdEncoding.EncodeToString([]byte(*content))),
+			Encoding: github.String("base64"),
+		})
+		if err != nil {
+			return nil, nil, common.WrapError(err, "failed to create blob for "+path)
+		}
+
+		if !exists {
+			mode = defaultFileMode
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path: github.String(path),
+			Mode: github.String(mode),
+			Type: github.String("blob"),
+			SHA:  blob.SHA,
+		})
+		result.Changed = append(result.Changed, path)
+	}
+	return entries, result, nil
+}
+
+// cleanTreePath normalizes a path to the form used in git trees; invalid paths become ""
+func cleanTreePath(path string) string {
+	clean, _ := cleanRelativePath(path)
+	return clean
+}
+
+// shortSHA abbreviates a commit SHA for messages
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/base64"
 	"path/filepath"
-	"strings"
 
 	"github.com/google/go-github/v43/github"
 	"github.com/pbearc/github-agent/backend/pkg/common"
@@ -32,18 +31,6 @@ func (c *Client) GetDirectoryContent(ctx context.Context, owner, repo, path, ref
 	return directoryContent, nil
 }
 
-// CreateReadmeFile creates or updates the README.md file in a repository
-func (c *Client) CreateReadmeFile(ctx context.Context, owner, repo, content, branch string) error {
-	message := "Add README.md via GitHub Agent"
-	return c.CreateOrUpdateFile(ctx, owner, repo, "README.md", message, content, branch)
-}
-
-// CreateDockerfile creates or updates a Dockerfile in a repository
-func (c *Client) CreateDockerfile(ctx context.Context, owner, repo, content, branch string) error {
-	message := "Add Dockerfile via GitHub Agent"
-	return c.CreateOrUpdateFile(ctx, owner, repo, "Dockerfile", message, content, branch)
-}
-
 // FindFilesByExtension finds all files with a specific extension in a repository
 func (c *Client) FindFilesByExtension(ctx context.Context, owner, repo, extension, ref string) ([]string, error) {
 	tree, _, err := c.client.Git.GetTree(ctx, owner, repo, ref, true)
@@ -63,51 +50,6 @@ func (c *Client) FindFilesByExtension(ctx context.Context, owner, repo, extensio
 	return files, nil
 }
 
-// AddCommentToFile adds a comment to a file and commits the changes
-func (c *Client) AddCommentToFile(
-	ctx context.Context,
-	owner, repo, path, comment, branch string,
-) error {
-	// Get current file content
-	fileContent, err := c.GetFileContentText(ctx, owner, repo, path, branch)
-	if err != nil {
-		return err
-	}
-
-	// Determine the file type and appropriate comment syntax
-	var commentPrefix, commentSuffix string
-	ext := filepath.Ext(path)
-	switch strings.ToLower(ext) {
-	case ".py":
-		commentPrefix = "# "
-		// Handle multiline comments for Python
-		if strings.Contains(comment, "\n") {
-			commentPrefix = "\"\"\"\n"
-			commentSuffix = "\n\"\"\"\n"
-		}
-	case ".js", ".ts", ".jsx", ".tsx", ".go", ".java", ".c", ".cpp", ".cs":
-		commentPrefix = "// "
-		// Handle multiline comments
-		if strings.Contains(comment, "\n") {
-			commentPrefix = "/*\n * "
-			commentSuffix = "\n */\n"
-			comment = strings.ReplaceAll(comment, "\n", "\n * ")
-		}
-	case ".html", ".xml":
-		commentPrefix = "<!-- "
-		commentSuffix = " -->\n"
-	default:
-		commentPrefix = "# "
-	}
-
-	// Add the comment to the beginning of the file
-	updatedContent := commentPrefix + comment + commentSuffix + fileContent.Content
-
-	// Commit the changes
-	message := "Add comments via GitHub Agent"
-	return c.CreateOrUpdateFile(ctx, owner, repo, path, message, updatedContent, branch)
-}
-
 // EncodeContent encodes content to base64
 func EncodeContent(content string) string {
 	return base64.StdEncoding.EncodeToString([]byte(content))
@@ -0,0 +1,90 @@
+// internal/github/csresolve.go
+package github
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+var (
+	// namespace A.B { ... } and file-scoped namespace A.B;
+	csNamespaceRegex = regexp.MustCompile(`(?m)^\s*namespace\s+([\w.]+)`)
+
+	// using A.B;, global using A.B;, using static A.B.C; and using Alias = A.B.C;
+	csUsingRegex = regexp.MustCompile(`(?m)^\s*(?:global\s+)?using\s+(?:static\s+)?(?:\w+\s*=\s*)?([\w.]+)\s*;`)
+)
+
+// csFrameworkPrefixes are the namespaces of the .NET base class library
+var csFrameworkPrefixes = []string{"System", "Microsoft"}
+
+// csharpImports maps C# namespaces, read from namespace declarations, to the files
+// declaring them
+type csharpImports struct {
+	namespaces map[string][]string // Namespace -> files
+	types      map[string][]string // Namespace.FileName -> files
+	roots      map[string]bool     // First element of every namespace
+}
+
+func newCSharpImports(ic *importContext) importResolver {
+	return &csharpImports{
+		namespaces: make(map[string][]string),
+		types:      make(map[string][]string),
+		roots:      make(map[string]bool),
+	}
+}
+
+func (c *csharpImports) index(filePath, content string) {
+	name := strings.TrimSuffix(path.Base(filePath), ".cs")
+	for _, match := range csNamespaceRegex.FindAllStringSubmatch(stripJSComments(content), -1) {
+		namespace := match[1]
+		c.namespaces[namespace] = append(c.namespaces[namespace], filePath)
+		c.types[namespace+"."+name] = append(c.types[namespace+"."+name], filePath)
+		root, _, _ := strings.Cut(namespace, ".")
+		c.roots[root] = true
+	}
+}
+
+func (c *csharpImports) extract(filePath, content string) []string {
+	var imports []string
+	for _, match := range csUsingRegex.FindAllStringSubmatch(stripJSComments(content), -1) {
+		imports = append(imports, match[1])
+	}
+	return imports
+}
+
+func (c *csharpImports) resolve(filePath, imp string) []string {
+	// A namespace, or a type named after its file for static and alias directives
+	if files, ok := c.namespaces[imp]; ok {
+		return files
+	}
+	if files, ok := c.types[imp]; ok {
+		return files
+	}
+	if i := strings.LastIndex(imp, "."); i > 0 {
+		return c.namespaces[imp[:i]]
+	}
+	return nil
+}
+
+func (c *csharpImports) dependency(filePath, imp string) (models.ExternalDependency, bool) {
+	root, _, _ := strings.Cut(imp, ".")
+	for _, prefix := range csFrameworkPrefixes {
+		if root == prefix {
+			return models.ExternalDependency{Name: root, Kind: models.DependencyStdlib}, true
+		}
+	}
+	if c.roots[root] {
+		return models.ExternalDependency{}, false // A namespace of the repository
+	}
+
+	// NuGet packages are usually named by the first two elements of their namespaces
+	parts := strings.SplitN(imp, ".", 3)
+	name := parts[0]
+	if len(parts) > 1 {
+		name += "." + parts[1]
+	}
+	return models.ExternalDependency{Name: name, Kind: models.DependencyPackage}, true
+}
@@ -0,0 +1,44 @@
+package github
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// csharpFixture declares namespaces in block and file-scoped form, independently of directories
+var csharpFixture = map[string]string{
+	"src/App/Program.cs":               "using System;\nglobal using MyApp.Services;\nusing static MyApp.Utils.Strings;\nusing Json = Newtonsoft.Json.JsonConvert;\n\nnamespace MyApp;\n",
+	"src/App/Services/UserService.cs":  "namespace MyApp.Services\n{\n    class UserService {}\n}\n",
+	"src/App/Services/OrderService.cs": "namespace MyApp.Services;\n",
+	"src/App/Strings.cs":               "/* namespace Wrong; */\nnamespace MyApp.Utils;\n",
+}
+
+func TestCSharpExtract(t *testing.T) {
+	resolver := newFixtureResolver(t, newCSharpImports, csharpFixture)
+	got := resolver.extract("", csharpFixture["src/App/Program.cs"])
+	want := []string{"System", "MyApp.Services", "MyApp.Utils.Strings", "Newtonsoft.Json.JsonConvert"}
+	if !slices.Equal(got, want) {
+		t.Errorf("extract = %v, want %v", got, want)
+	}
+}
+
+func TestCSharpResolve(t *testing.T) {
+	resolver := newFixtureResolver(t, newCSharpImports, csharpFixture)
+	const program = "src/App/Program.cs"
+	services := []string{"src/App/Services/OrderService.cs", "src/App/Services/UserService.cs"}
+	checkResolve(t, resolver, []resolveCase{
+		// Namespaces resolve to every file declaring them
+		{program, "MyApp.Services", services},
+		// Static and alias directives name a type, found by file name or its namespace
+		{program, "MyApp.Utils.Strings", []string{"src/App/Strings.cs"}},
+		{program, "MyApp.Services.Missing", services},
+		{program, "Wrong", nil},
+		{program, "System", nil},
+	})
+
+	checkDependency(t, resolver, program, "System", models.ExternalDependency{Name: "System", Kind: models.DependencyStdlib})
+	checkDependency(t, resolver, program, "Newtonsoft.Json.JsonConvert", models.ExternalDependency{Name: "Newtonsoft.Json", Kind: models.DependencyPackage})
+	checkDependency(t, resolver, program, "MyApp.Other", models.ExternalDependency{})
+}
@@ -0,0 +1,257 @@
+// Package githubtest provides a fake GitHub REST API that serves fixture files, so code
+// built on github.Client can run without network access.
+//
+// In replay mode the server answers each request from a fixture file. In record mode it
+// forwards requests to the real API, saves every response as a fixture and returns it.
+// Set GITHUB_FIXTURES=record (and GITHUB_TOKEN) to refresh the fixtures of a test.
+// Fixtures written by hand rather than recorded are marked synthetic; recording replaces
+// them with real responses.
+package githubtest
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+)
+
+// Mode selects whether the server replays or records fixtures
+type Mode string
+
+const (
+	// ModeReplay serves responses from fixture files only
+	ModeReplay Mode = "replay"
+	// ModeRecord proxies requests to the real API and saves the responses
+	ModeRecord Mode = "record"
+
+	// DefaultUpstream is the API recorded from in record mode
+	DefaultUpstream = "https://api.github.com"
+
+	// baseURLPlaceholder replaces the API host in stored fixtures so they replay against any server URL
+	baseURLPlaceholder = "{{BASE_URL}}"
+)
+
+// recordedHeaders are the response headers kept in fixtures
+var recordedHeaders = []string{"Content-Type", "Link", "Location", "ETag", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Fixture is a single request and response, recorded or written by hand
+type Fixture struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   string            `json:"query,omitempty"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"` // JSON responses
+	Text    string            `json:"text,omitempty"` // Any other response body
+
+	// Synthetic marks a fixture written by hand, whose IDs, ETags and headers were not
+	// returned by the real API
+	Synthetic bool `json:"synthetic,omitempty"`
+}
+
+// Server is a fake GitHub REST API backed by fixture files
+type Server struct {
+	*httptest.Server
+
+	tb       testing.TB
+	dir      string
+	mode     Mode
+	upstream string
+	token    string
+
+	mu       sync.Mutex
+	fixtures map[string]Fixture // Fixtures added in code, keyed like the files
+}
+
+// ModeFromEnv returns ModeRecord when GITHUB_FIXTURES=record and ModeReplay otherwise
+func ModeFromEnv() Mode {
+	if strings.EqualFold(os.Getenv("GITHUB_FIXTURES"), string(ModeRecord)) {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// NewServer starts a fake API serving fixtures from dir in the mode selected by the environment.
+// The server is closed when the test finishes.
+func NewServer(tb testing.TB, dir string) *Server {
+	return NewServerWithMode(tb, dir, ModeFromEnv())
+}
+
+// NewServerWithMode starts a fake API serving fixtures from dir in the given mode
+func NewServerWithMode(tb testing.TB, dir string, mode Mode) *Server {
+	tb.Helper()
+
+	s := &Server{
+		tb:       tb,
+		dir:      dir,
+		mode:     mode,
+		upstream: DefaultUpstream,
+		token:    os.Getenv("GITHUB_TOKEN"),
+		fixtures: make(map[string]Fixture),
+	}
+	if upstream := os.Getenv("GITHUB_FIXTURES_UPSTREAM"); upstream != "" {
+		s.upstream = strings.TrimSuffix(upstream, "/")
+	}
+	if mode == ModeRecord && s.token == "" {
+		tb.Fatalf("githubtest: GITHUB_TOKEN is required to record fixtures")
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a github.Client that talks to the fake server
+func (s *Server) Client() *github.Client {
+	s.tb.Helper()
+
+	client, err := github.NewClientWithBaseURL("", s.URL)
+	if err != nil {
+		s.tb.Fatalf("githubtest: %v", err)
+	}
+	return client
+}
+
+// AddFixture serves a response for a request without a fixture file.
+// Fixtures added this way take precedence over files and are never recorded.
+func (s *Server) AddFixture(f Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures[fixtureName(f.Method, f.Path, f.Query)] = f
+}
+
+// serve answers a single API request
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	name := fixtureName(r.Method, r.URL.Path, r.URL.RawQuery)
+
+	s.mu.Lock()
+	fixture, ok := s.fixtures[name]
+	s.mu.Unlock()
+
+	if !ok {
+		var err error
+		if s.mode == ModeRecord {
+			fixture, err = s.record(r, name)
+		} else {
+			fixture, err = s.load(name)
+		}
+		if err != nil {
+			s.tb.Logf("githubtest: %s %s: %v", r.Method, r.URL.RequestURI(), err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"message":%q}`, fmt.Sprintf("no fixture for %s %s", r.Method, r.URL.RequestURI()))
+			return
+		}
+	}
+
+	for key, value := range fixture.Headers {
+		w.Header().Set(key, strings.ReplaceAll(value, baseURLPlaceholder, s.URL))
+	}
+	status := fixture.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	if len(fixture.Body) > 0 {
+		w.Write(bytes.ReplaceAll(fixture.Body, []byte(baseURLPlaceholder), []byte(s.URL)))
+	} else {
+		io.WriteString(w, strings.ReplaceAll(fixture.Text, baseURLPlaceholder, s.URL))
+	}
+}
+
+// load reads a fixture file
+func (s *Server) load(name string) (Fixture, error) {
+	var fixture Fixture
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return fixture, err
+	}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return fixture, fmt.Errorf("invalid fixture %s: %w", name, err)
+	}
+	return fixture, nil
+}
+
+// record forwards a request to the real API and stores the response as a fixture
+func (s *Server) record(r *http.Request, name string) (Fixture, error) {
+	var fixture Fixture
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, s.upstream+r.URL.RequestURI(), r.Body)
+	if err != nil {
+		return fixture, err
+	}
+	req.Header = r.Header.Clone()
+	req.Header.Set("Authorization", "token "+s.token)
+
+	// Redirects such as archive links are recorded as-is
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fixture, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fixture, err
+	}
+
+	fixture = Fixture{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.RawQuery,
+		Status:  resp.StatusCode,
+		Headers: make(map[string]string),
+	}
+	for _, key := range recordedHeaders {
+		if value := resp.Header.Get(key); value != "" {
+			fixture.Headers[key] = strings.ReplaceAll(value, s.upstream, baseURLPlaceholder)
+		}
+	}
+
+	body = bytes.ReplaceAll(body, []byte(s.upstream), []byte(baseURLPlaceholder))
+	if json.Valid(body) {
+		fixture.Body = body
+	} else {
+		fixture.Text = string(body)
+	}
+
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fixture, err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fixture, err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0o644); err != nil {
+		return fixture, err
+	}
+	return fixture, nil
+}
+
+// fixtureName returns the file name a request is stored under, for example
+// "GET_repos_owner_repo_contents_README.md_1a2b3c4d.json" when the request has a query
+func fixtureName(method, path, query string) string {
+	name := method + "_" + unsafeNameChars.ReplaceAllString(strings.Trim(path, "/"), "_")
+	if query != "" {
+		sum := sha1.Sum([]byte(query))
+		name += "_" + hex.EncodeToString(sum[:4])
+	}
+	return name + ".json"
+}
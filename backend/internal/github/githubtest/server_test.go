@@ -0,0 +1,89 @@
+package githubtest_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/github/githubtest"
+)
+
+// TestClientReplaysFixtures drives github.Client through the fake API with synthetic
+// fixtures modelled on octocat/Hello-World; record them to test against real responses
+func TestClientReplaysFixtures(t *testing.T) {
+	ctx := context.Background()
+	server := githubtest.NewServer(t, "testdata/hello-world")
+	client := server.Client()
+
+	info, err := client.GetRepositoryInfo(ctx, "octocat", "Hello-World")
+	if err != nil {
+		t.Fatalf("GetRepositoryInfo: %v", err)
+	}
+	if info.DefaultBranch != "master" || info.Description != "My first repository on GitHub!" {
+		t.Errorf("GetRepositoryInfo = %+v", info)
+	}
+
+	src := client.NewAPISource("octocat", "Hello-World")
+	sha, err := src.ResolveRef(ctx, "")
+	if err != nil {
+		t.Fatalf("ResolveRef: %v", err)
+	}
+	if sha != "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d" {
+		t.Errorf("ResolveRef = %s", sha)
+	}
+
+	files, err := src.ListTree(ctx, "master")
+	if err != nil {
+		t.Fatalf("ListTree: %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "README" || files[0].Type != "file" || files[0].Size != 13 {
+		t.Errorf("ListTree = %+v", files)
+	}
+	if !strings.HasPrefix(files[0].HTMLURL, "https://github.com/octocat/Hello-World/blob/master/") {
+		t.Errorf("ListTree HTMLURL = %s", files[0].HTMLURL)
+	}
+
+	readme, err := src.ReadFile(ctx, "master", "README")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if readme.Content != "Hello World!\n" || readme.SHA != "980a0d5f19a64b4b30a87d4206aade58726b60e3" {
+		t.Errorf("ReadFile = %+v", readme)
+	}
+
+	if _, err := src.ReadFile(ctx, "master", "missing.txt"); !errors.Is(err, github.ErrFileNotFound) {
+		t.Errorf("ReadFile of a missing file = %v, want file not found", err)
+	}
+
+	commits, err := src.ListRecentCommits(ctx, "master", 2)
+	if err != nil {
+		t.Fatalf("ListRecentCommits: %v", err)
+	}
+	if len(commits) != 2 || commits[0].SHA != sha || commits[0].Date.Year() != 2012 {
+		t.Errorf("ListRecentCommits = %+v", commits)
+	}
+}
+
+// TestAddFixture serves a fixture added in code ahead of the files
+func TestAddFixture(t *testing.T) {
+	server := githubtest.NewServerWithMode(t, t.TempDir(), githubtest.ModeReplay)
+	server.AddFixture(githubtest.Fixture{
+		Method: "GET",
+		Path:   "/repos/octocat/Spoon-Knife",
+		Body:   []byte(`{"name":"Spoon-Knife","default_branch":"main","html_url":"https://github.com/octocat/Spoon-Knife"}`),
+	})
+
+	repository, err := server.Client().GetRepository(context.Background(), "octocat", "Spoon-Knife")
+	if err != nil {
+		t.Fatalf("GetRepository: %v", err)
+	}
+	if repository.GetDefaultBranch() != "main" {
+		t.Errorf("default branch = %q, want main", repository.GetDefaultBranch())
+	}
+
+	if _, err := server.Client().GetRepository(context.Background(), "octocat", "missing"); err == nil {
+		t.Error("GetRepository without a fixture succeeded")
+	}
+}
@@ -0,0 +1,324 @@
+// internal/github/gomod.go
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// goModule is a go.mod file of the repository
+type goModule struct {
+	Dir      string            // Directory of go.mod within the repository, "" at the root
+	Path     string            // Module path
+	Requires map[string]string // Required module path -> version
+	Replaces []goReplace
+}
+
+// goReplace is a replace directive of a go.mod file
+type goReplace struct {
+	Old     string
+	New     string
+	Version string // Version of New, empty for local replacements
+}
+
+// local reports whether the replacement is a directory rather than a module
+func (r goReplace) local() bool {
+	return strings.HasPrefix(r.New, "./") || strings.HasPrefix(r.New, "../") || r.New == "." || r.New == ".."
+}
+
+// goModules resolves Go import paths against the go.mod files of a repository
+type goModules struct {
+	modules []goModule // Longest module path first
+}
+
+// loadGoModules reads every go.mod file of the repository, nested modules included.
+// Files that cannot be read or declare no module are skipped.
+func loadGoModules(ctx context.Context, src RepoSource, ref string, files []models.GitHubFile, logger *common.Logger) *goModules {
+	mods := &goModules{}
+	for _, file := range files {
+		if file.Type != "file" || path.Base(file.Path) != "go.mod" {
+			continue
+		}
+		content, err := src.ReadFile(ctx, ref, file.Path)
+		if err != nil {
+			logger.WithField("error", err).Warning("Failed to read " + file.Path)
+			continue
+		}
+		mod := parseGoMod(content.Content)
+		if mod.Path == "" {
+			logger.Warning("No module directive in " + file.Path)
+			continue
+		}
+		mod.Dir = repoDir(file.Path)
+		mods.modules = append(mods.modules, mod)
+	}
+
+	sort.Slice(mods.modules, func(i, j int) bool {
+		return len(mods.modules[i].Path) > len(mods.modules[j].Path)
+	})
+	return mods
+}
+
+// parseGoMod reads the module, require and replace directives of a go.mod file
+func parseGoMod(content string) goModule {
+	mod := goModule{Requires: make(map[string]string)}
+
+	block := ""
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := goModFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			mod.addDirective(block, fields)
+			continue
+		}
+
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		mod.addDirective(fields[0], fields[1:])
+	}
+	return mod
+}
+
+// addDirective adds a module, require or replace directive; others are ignored
+func (m *goModule) addDirective(verb string, args []string) {
+	switch verb {
+	case "module":
+		if len(args) > 0 {
+			m.Path = args[0]
+		}
+	case "require":
+		if len(args) >= 2 {
+			m.Requires[args[0]] = args[1]
+		}
+	case "replace":
+		arrow := -1
+		for i, arg := range args {
+			if arg == "=>" {
+				arrow = i
+			}
+		}
+		if arrow < 1 || arrow+1 >= len(args) {
+			return
+		}
+		replace := goReplace{Old: args[0], New: args[arrow+1]}
+		if arrow+2 < len(args) {
+			replace.Version = args[arrow+2]
+		}
+		m.Replaces = append(m.Replaces, replace)
+	}
+}
+
+// goModFields splits a go.mod line into fields, unquoting quoted ones
+func goModFields(line string) []string {
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		if strings.HasPrefix(field, `"`) || strings.HasPrefix(field, "`") {
+			if unquoted, err := strconv.Unquote(field); err == nil {
+				fields[i] = unquoted
+			}
+		}
+	}
+	return fields
+}
+
+// moduleOf returns the module containing a file: the one with the deepest directory
+func (m *goModules) moduleOf(filePath string) *goModule {
+	var best *goModule
+	for i := range m.modules {
+		mod := &m.modules[i]
+		if mod.Dir != "" && !strings.HasPrefix(filePath, mod.Dir+"/") {
+			continue
+		}
+		if best == nil || len(mod.Dir) > len(best.Dir) {
+			best = mod
+		}
+	}
+	return best
+}
+
+// resolve returns the repository directory of the package a file imports. Local replace
+// directives of the file's module come first, then the modules of the repository.
+func (m *goModules) resolve(filePath, imp string) (string, bool) {
+	if mod := m.moduleOf(filePath); mod != nil {
+		for _, replace := range mod.Replaces {
+			rest, ok := trimModulePath(imp, replace.Old)
+			if ok && replace.local() {
+				dir := path.Join(mod.Dir, replace.New, rest)
+				if dir == ".." || strings.HasPrefix(dir, "../") {
+					return "", false // Outside the repository
+				}
+				return rootDir(dir), true
+			}
+		}
+	}
+
+	for _, mod := range m.modules {
+		if rest, ok := trimModulePath(imp, mod.Path); ok {
+			return rootDir(path.Join(mod.Dir, rest)), true
+		}
+	}
+	return "", false
+}
+
+// dependency describes an import that does not resolve within the repository
+func (m *goModules) dependency(filePath, imp string) models.ExternalDependency {
+	if isGoStdlib(imp) {
+		return models.ExternalDependency{Name: imp, Kind: models.DependencyStdlib}
+	}
+
+	// Attribute the package to the longest required module path it falls under
+	dep := models.ExternalDependency{Name: imp, Kind: models.DependencyModule}
+	if mod := m.moduleOf(filePath); mod != nil {
+		best := ""
+		for required := range mod.Requires {
+			if _, ok := trimModulePath(imp, required); ok && len(required) > len(best) {
+				best = required
+			}
+		}
+		if best != "" {
+			dep.Name, dep.Version = best, mod.Requires[best]
+		}
+		for _, replace := range mod.Replaces {
+			if replace.Old == dep.Name && !replace.local() && replace.Version != "" {
+				dep.Version = replace.Version
+			}
+		}
+	}
+	return dep
+}
+
+// trimModulePath returns the path of a package within a module, if the package belongs to it
+func trimModulePath(imp, modulePath string) (string, bool) {
+	if imp == modulePath {
+		return "", true
+	}
+	rest, ok := strings.CutPrefix(imp, modulePath+"/")
+	return rest, ok
+}
+
+// isGoStdlib reports whether an import path belongs to the standard library, whose
+// first element, unlike that of a module path, has no dot
+func isGoStdlib(imp string) bool {
+	first, _, _ := strings.Cut(imp, "/")
+	return !strings.Contains(first, ".")
+}
+
+// goPackageFiles groups the non-test Go files of a repository by directory
+func goPackageFiles(files []models.GitHubFile) map[string][]string {
+	packages := make(map[string][]string)
+	for _, file := range files {
+		if file.Type != "file" || !isGoSourceFile(file.Path) || strings.HasSuffix(file.Path, "_test.go") {
+			continue
+		}
+		dir := repoDir(file.Path)
+		packages[dir] = append(packages[dir], file.Path)
+	}
+	for dir := range packages {
+		sort.Strings(packages[dir])
+	}
+	return packages
+}
+
+// repoDir returns the directory of a repository path, "" at the root
+func repoDir(filePath string) string {
+	return rootDir(path.Dir(filePath))
+}
+
+// rootDir returns "" for the root directory "." and dir otherwise
+func rootDir(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// goImports resolves Go imports to all files of the imported package
+type goImports struct {
+	ic          *importContext
+	modules     *goModules
+	packages    map[string][]string // Directory -> non-test Go files
+	owner, repo string
+}
+
+// newGoImports reads the go.mod files of the repository
+func newGoImports(ic *importContext) importResolver {
+	owner, repo := ic.src.Repo()
+	g := &goImports{
+		ic:       ic,
+		modules:  loadGoModules(ic.ctx, ic.src, ic.ref, ic.files, ic.logger),
+		packages: goPackageFiles(ic.files),
+		owner:    owner,
+		repo:     repo,
+	}
+	ic.logger.Info(fmt.Sprintf("Found %d Go modules and %d Go packages.", len(g.modules.modules), len(g.packages)))
+	return g
+}
+
+func (g *goImports) index(filePath, content string) {}
+
+func (g *goImports) extract(filePath, content string) []string {
+	var imports []string
+	for _, imp := range extractGoImportsUsingAST(content, g.ic.logger) {
+		if imp != "C" { // cgo pseudo-package
+			imports = append(imports, imp)
+		}
+	}
+	return imports
+}
+
+func (g *goImports) resolve(filePath, imp string) []string {
+	if strings.HasPrefix(imp, ".") {
+		// Relative imports name a package directory, or a file without its extension
+		target := rootDir(path.Join(repoDir(filePath), imp))
+		if g.ic.existing[target] == "dir" {
+			return g.packages[target]
+		}
+		if g.ic.isFile(target + ".go") {
+			return []string{target + ".go"}
+		}
+		return nil
+	}
+
+	if dir, ok := g.internalDir(filePath, imp); ok {
+		if files, ok := g.packages[dir]; ok {
+			return files
+		}
+		g.ic.logger.Debug(fmt.Sprintf("No Go files for %s imported by %s", imp, filePath))
+	}
+	return nil
+}
+
+// internalDir returns the directory of a package of the repository's modules, falling back
+// to <host>/owner/repo paths for repositories without go.mod
+func (g *goImports) internalDir(filePath, imp string) (string, bool) {
+	if dir, ok := g.modules.resolve(filePath, imp); ok {
+		return dir, true
+	}
+	return trimRepoImportPath(imp, g.owner, g.repo)
+}
+
+func (g *goImports) dependency(filePath, imp string) (models.ExternalDependency, bool) {
+	if _, internal := g.internalDir(filePath, imp); internal || strings.HasPrefix(imp, ".") {
+		return models.ExternalDependency{}, false
+	}
+	return g.modules.dependency(filePath, imp), true
+}
@@ -0,0 +1,563 @@
+// internal/github/gotypes.go
+package github
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// maxGoReferencePackages caps how many packages FindGoReferences type-checks
+const maxGoReferencePackages = 200
+
+// maxGoImportScanFiles caps how many files FindGoReferences reads looking for importers
+const maxGoImportScanFiles = 2000
+
+// goStdlib imports the standard library for every checker, so its export data is read once
+var goStdlib = &lockedImporter{importer: importer.Default()}
+
+// lockedImporter serializes the imports of an importer shared between requests
+type lockedImporter struct {
+	mu       sync.Mutex
+	importer types.Importer
+}
+
+// Import satisfies types.Importer
+func (i *lockedImporter) Import(importPath string) (*types.Package, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.importer.Import(importPath)
+}
+
+// GoNavigation is what type checking found for an identifier
+type GoNavigation struct {
+	Definition models.CodeLocation
+	References []models.CodeLocation
+	Truncated  bool // Not every package that could use the identifier was checked
+	Degraded   bool // The standard library could not be loaded, so its types are unknown
+}
+
+// goTypeChecker type-checks the Go packages of a repository from source. Packages of the
+// repository are loaded when first imported; the standard library comes from the export
+// data of the local toolchain, and other dependencies are left empty so that checking
+// continues past them. Without a toolchain the standard library is left empty too and the
+// checker is marked degraded.
+type goTypeChecker struct {
+	ctx         context.Context
+	src         RepoSource
+	ref         string
+	modules     *goModules
+	packages    map[string][]string // Directory -> non-test Go files
+	owner, repo string
+	fset        *token.FileSet
+	info        *types.Info
+	checked     map[string]*types.Package // Import path -> package
+	contents    map[string]string         // File -> content
+	asts        map[string]*ast.File
+	degraded    bool // A standard library package failed to load
+	logger      *common.Logger
+}
+
+// newGoTypeChecker lists the repository tree and reads its go.mod files
+func newGoTypeChecker(ctx context.Context, src RepoSource, ref string, logger *common.Logger) (*goTypeChecker, error) {
+	files, err := src.ListTree(ctx, ref)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list repository files")
+	}
+
+	owner, repo := src.Repo()
+	fset := token.NewFileSet()
+	return &goTypeChecker{
+		ctx:      ctx,
+		src:      src,
+		ref:      ref,
+		modules:  loadGoModules(ctx, src, ref, files, logger),
+		packages: goPackageFiles(files),
+		owner:    owner,
+		repo:     repo,
+		fset:     fset,
+		info: &types.Info{
+			Defs: make(map[*ast.Ident]types.Object),
+			Uses: make(map[*ast.Ident]types.Object),
+		},
+		checked:  make(map[string]*types.Package),
+		contents: make(map[string]string),
+		asts:     make(map[string]*ast.File),
+		logger:   logger,
+	}, nil
+}
+
+// FindGoDefinition type-checks the package of a Go file and returns where the identifier
+// at a 1-based line and column, in characters, is declared. Declarations outside the
+// repository are returned with their package and no file.
+func FindGoDefinition(ctx context.Context, src RepoSource, ref, filePath string, line, column int, logger *common.Logger) (*GoNavigation, error) {
+	checker, err := newGoTypeChecker(ctx, src, ref, logger)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := checker.objectAt(filePath, line, column)
+	if err != nil {
+		return nil, err
+	}
+	return &GoNavigation{Definition: checker.declaration(obj), Degraded: checker.degraded}, nil
+}
+
+// FindGoReferences returns the declaration of the identifier at a position of a Go file and
+// every use of it in the packages of the repository that can see it. Test files are only
+// checked when the position is in one.
+func FindGoReferences(ctx context.Context, src RepoSource, ref, filePath string, line, column int, logger *common.Logger) (*GoNavigation, error) {
+	checker, err := newGoTypeChecker(ctx, src, ref, logger)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := checker.objectAt(filePath, line, column)
+	if err != nil {
+		return nil, err
+	}
+	definition := checker.declaration(obj)
+
+	// Local objects are only visible in their own package; package-level objects, methods
+	// and fields may be used by any package importing theirs
+	truncated := false
+	if obj.Pkg() != nil && (obj.Parent() == obj.Pkg().Scope() || isGoMember(obj)) {
+		dirs, scanTruncated := checker.importingDirs(obj.Pkg().Path())
+		truncated = scanTruncated
+		if len(dirs) > maxGoReferencePackages {
+			dirs, truncated = dirs[:maxGoReferencePackages], true
+		}
+		for _, dir := range dirs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			checker.importDir(dir)
+		}
+	}
+
+	target := goOrigin(obj)
+	var references []models.CodeLocation
+	for ident, used := range checker.info.Uses {
+		if goOrigin(used) != target {
+			continue
+		}
+		location := checker.location(ident.Pos(), ident.Name)
+		location.Kind = definition.Kind
+		references = append(references, location)
+	}
+	sort.Slice(references, func(i, j int) bool {
+		a, b := references[i], references[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return &GoNavigation{
+		Definition: definition,
+		References: references,
+		Truncated:  truncated,
+		Degraded:   checker.degraded,
+	}, nil
+}
+
+// objectAt checks the package of a file and returns the object the identifier at a
+// position of it declares or uses
+func (c *goTypeChecker) objectAt(filePath string, line, column int) (types.Object, error) {
+	if err := c.checkFilePackage(filePath); err != nil {
+		return nil, err
+	}
+	file := c.asts[filePath]
+	lines := strings.Split(c.contents[filePath], "\n")
+	if line < 1 || line > len(lines) {
+		return nil, common.NewError(fmt.Sprintf("line %d is outside %s", line, filePath))
+	}
+	byteColumn := utils.ByteColumn(lines[line-1], column)
+
+	var found *ast.Ident
+	ast.Inspect(file, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok || found != nil {
+			return found == nil
+		}
+		start := c.fset.Position(ident.Pos())
+		// A cursor just after an identifier still names it
+		if start.Line == line && byteColumn >= start.Column && byteColumn <= start.Column+len(ident.Name) {
+			found = ident
+		}
+		return true
+	})
+	if found == nil {
+		return nil, common.NewError(fmt.Sprintf("no identifier at line %d, column %d of %s", line, column, filePath))
+	}
+
+	if obj := c.info.Defs[found]; obj != nil {
+		return obj, nil
+	}
+	if obj := c.info.Uses[found]; obj != nil {
+		return obj, nil
+	}
+	return nil, common.NewError(fmt.Sprintf("%s could not be resolved; the package may not type-check", found.Name))
+}
+
+// checkFilePackage checks the package a file belongs to. Test files are checked together with
+// the package they test, or on their own when they declare an external _test package.
+func (c *goTypeChecker) checkFilePackage(filePath string) error {
+	if !isGoSourceFile(filePath) {
+		return common.NewError(filePath + " is not a Go file")
+	}
+	dir := repoDir(filePath)
+	if !strings.HasSuffix(filePath, "_test.go") {
+		c.importDir(dir)
+		if c.asts[filePath] == nil {
+			return common.NewError("failed to parse " + filePath)
+		}
+		return nil
+	}
+
+	file, err := c.parse(filePath)
+	if file == nil {
+		return common.WrapError(err, "failed to parse "+filePath)
+	}
+	// External test packages import the package under test like any other
+	importPath := c.importPath(dir)
+	if strings.HasSuffix(file.Name.Name, "_test") {
+		c.check(importPath+"_test", []*ast.File{file})
+		return nil
+	}
+
+	// In-package tests are checked with the package, which importers then see
+	files := []*ast.File{file}
+	for _, name := range c.packages[dir] {
+		if f, _ := c.parse(name); f != nil && f.Name.Name == file.Name.Name {
+			files = append(files, f)
+		}
+	}
+	c.checked[importPath] = types.NewPackage(importPath, file.Name.Name)
+	c.checked[importPath] = c.check(importPath, files)
+	return nil
+}
+
+// importDir checks the package in a repository directory, once
+func (c *goTypeChecker) importDir(dir string) *types.Package {
+	importPath := c.importPath(dir)
+	if pkg, ok := c.checked[importPath]; ok {
+		return pkg
+	}
+	// Mark the package first so that import cycles end
+	c.checked[importPath] = types.NewPackage(importPath, path.Base(importPath))
+
+	var parsed []*ast.File
+	counts := make(map[string]int)
+	for _, name := range c.packages[dir] {
+		file, err := c.parse(name)
+		if file == nil {
+			c.logger.WithField("error", err).Warning("Failed to parse " + name)
+			continue
+		}
+		parsed = append(parsed, file)
+		counts[file.Name.Name]++
+	}
+
+	// Keep the package most files of the directory declare, skipping main helpers
+	// guarded by build tags and similar strays; ties go to the first file's package
+	var name string
+	for _, file := range parsed {
+		if counts[file.Name.Name] > counts[name] {
+			name = file.Name.Name
+		}
+	}
+	var files []*ast.File
+	for _, file := range parsed {
+		if file.Name.Name == name {
+			files = append(files, file)
+		}
+	}
+	pkg := c.check(importPath, files)
+	c.checked[importPath] = pkg
+	return pkg
+}
+
+// check type-checks files as one package, recording what it can despite errors
+func (c *goTypeChecker) check(importPath string, files []*ast.File) *types.Package {
+	config := &types.Config{
+		Importer:    c,
+		Error:       func(error) {},
+		FakeImportC: true,
+	}
+	pkg, _ := config.Check(importPath, c.fset, files, c.info)
+	return pkg
+}
+
+// Import satisfies types.Importer
+func (c *goTypeChecker) Import(importPath string) (*types.Package, error) {
+	return c.ImportFrom(importPath, "", 0)
+}
+
+// ImportFrom satisfies types.ImporterFrom, resolving imports of the repository's modules to
+// their directories and leaving unknown dependencies empty
+func (c *goTypeChecker) ImportFrom(importPath, dir string, mode types.ImportMode) (*types.Package, error) {
+	if dir, ok := c.internalDir(dir, importPath); ok {
+		if _, exists := c.packages[dir]; exists {
+			return c.importDir(dir), nil
+		}
+	}
+	if pkg, ok := c.checked[importPath]; ok {
+		return pkg, nil
+	}
+
+	// Only the standard library has export data; asking the importer for anything else
+	// would run the go command for nothing
+	var pkg *types.Package
+	if isGoStdlib(importPath) {
+		var err error
+		if pkg, err = goStdlib.Import(importPath); err != nil && !c.degraded {
+			c.logger.WithField("error", err).Warning("Failed to load the Go standard library; is GOROOT set?")
+			c.degraded = true
+		}
+	}
+	if pkg == nil {
+		pkg = types.NewPackage(importPath, goPackageName(importPath))
+		pkg.MarkComplete()
+	}
+	c.checked[importPath] = pkg
+	return pkg, nil
+}
+
+// internalDir returns the repository directory of a package imported from a file in dir
+func (c *goTypeChecker) internalDir(dir, importPath string) (string, bool) {
+	fromFile := path.Join(dir, "x.go")
+	if resolved, ok := c.modules.resolve(fromFile, importPath); ok {
+		return resolved, true
+	}
+	return trimRepoImportPath(importPath, c.owner, c.repo)
+}
+
+// importPath returns the import path of a repository directory
+func (c *goTypeChecker) importPath(dir string) string {
+	if mod := c.modules.moduleOf(path.Join(dir, "x.go")); mod != nil {
+		rest := strings.TrimPrefix(strings.TrimPrefix(dir, mod.Dir), "/")
+		return path.Join(mod.Path, rest)
+	}
+	return path.Join("github.com", c.owner, c.repo, dir)
+}
+
+// importingDirs returns the package directories of the repository whose files import a
+// package, the package's own directory first. Only files that mention the import path are
+// parsed, and it reports whether it stopped after reading maxGoImportScanFiles files.
+func (c *goTypeChecker) importingDirs(importPath string) ([]string, bool) {
+	all := make([]string, 0, len(c.packages))
+	for dir := range c.packages {
+		all = append(all, dir)
+	}
+	sort.Strings(all)
+
+	var own, dirs []string
+	reads := 0
+	for _, dir := range all {
+		if c.importPath(dir) == importPath {
+			own = append(own, dir)
+			continue
+		}
+		for _, name := range c.packages[dir] {
+			if _, read := c.contents[name]; !read {
+				if reads == maxGoImportScanFiles {
+					return append(own, dirs...), true
+				}
+				reads++
+			}
+			content, err := c.read(name)
+			if err != nil || !strings.Contains(content, importPath) {
+				continue
+			}
+			if file, _ := c.parse(name); file != nil && goFileImports(file, importPath) {
+				dirs = append(dirs, dir)
+				break
+			}
+		}
+	}
+	return append(own, dirs...), false
+}
+
+// read returns the content of a file, reading it once
+func (c *goTypeChecker) read(filePath string) (string, error) {
+	if content, ok := c.contents[filePath]; ok {
+		return content, nil
+	}
+	content, err := c.src.ReadFile(c.ctx, c.ref, filePath)
+	if err != nil {
+		return "", err
+	}
+	c.contents[filePath] = content.Content
+	return content.Content, nil
+}
+
+// parse reads and parses a file once
+func (c *goTypeChecker) parse(filePath string) (*ast.File, error) {
+	if file, ok := c.asts[filePath]; ok {
+		return file, nil
+	}
+	content, err := c.read(filePath)
+	if err != nil {
+		c.asts[filePath] = nil
+		return nil, err
+	}
+	// Keep the partial AST of files with syntax errors
+	file, err := parser.ParseFile(c.fset, filePath, content, parser.SkipObjectResolution)
+	if file != nil && file.Name == nil {
+		file = nil
+	}
+	c.asts[filePath] = file
+	return file, err
+}
+
+// declaration describes where an object is declared
+func (c *goTypeChecker) declaration(obj types.Object) models.CodeLocation {
+	name := obj.Name()
+	if recv := goReceiverName(obj); recv != "" {
+		name = recv + "." + name
+	}
+
+	position := c.fset.Position(obj.Pos())
+	if _, inRepo := c.contents[position.Filename]; !obj.Pos().IsValid() || !inRepo {
+		location := models.CodeLocation{Symbol: name, Kind: goObjectKind(obj)}
+		if obj.Pkg() != nil {
+			location.Package = obj.Pkg().Path()
+		}
+		return location
+	}
+
+	location := c.location(obj.Pos(), name)
+	location.Kind = goObjectKind(obj)
+	return location
+}
+
+// location returns the repository position of a token with the line it is on
+func (c *goTypeChecker) location(pos token.Pos, symbol string) models.CodeLocation {
+	position := c.fset.Position(pos)
+	lines := strings.Split(c.contents[position.Filename], "\n")
+	location := models.CodeLocation{
+		FilePath: position.Filename,
+		Line:     position.Line,
+		Column:   position.Column,
+		Symbol:   symbol,
+	}
+	if position.Line >= 1 && position.Line <= len(lines) {
+		text := lines[position.Line-1]
+		location.Column = utils.RuneColumn(text, position.Column)
+		location.Snippet = strings.TrimSpace(text)
+	}
+	return location
+}
+
+// goFileImports reports whether a file imports a package
+func goFileImports(file *ast.File, importPath string) bool {
+	for _, spec := range file.Imports {
+		if strings.Trim(spec.Path.Value, "`\"") == importPath {
+			return true
+		}
+	}
+	return false
+}
+
+// goOrigin returns the generic object an instantiated function, method or field comes from
+func goOrigin(obj types.Object) types.Object {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Origin()
+	case *types.Var:
+		return obj.Origin()
+	}
+	return obj
+}
+
+// isGoMember reports whether an object is a method or field, which may be used from other
+// packages through their type even when declared in a local scope
+func isGoMember(obj types.Object) bool {
+	if fn, ok := obj.(*types.Func); ok {
+		return goReceiverName(fn) != ""
+	}
+	v, ok := obj.(*types.Var)
+	return ok && v.IsField()
+}
+
+// goReceiverName returns the receiver type name of a method
+func goReceiverName(obj types.Object) string {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return ""
+	}
+	recv := sig.Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	switch recv := recv.(type) {
+	case *types.Named:
+		return recv.Obj().Name()
+	case *types.Interface:
+		return "interface"
+	}
+	return ""
+}
+
+// goObjectKind names the kind of declaration an object is
+func goObjectKind(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Func:
+		if goReceiverName(obj) != "" {
+			return string(utils.SymbolMethod)
+		}
+		return string(utils.SymbolFunction)
+	case *types.Var:
+		if obj.IsField() {
+			return "field"
+		}
+		return string(utils.SymbolVariable)
+	case *types.Const:
+		return string(utils.SymbolConstant)
+	case *types.TypeName:
+		return string(utils.SymbolType)
+	case *types.PkgName:
+		return "package"
+	case *types.Label:
+		return "label"
+	case *types.Builtin, *types.Nil:
+		return "builtin"
+	}
+	return ""
+}
+
+// goPackageName guesses the name of a package from its import path, skipping major
+// version suffixes such as /v2 and .v3
+func goPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if isGoMajorVersion(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	if base, version, ok := strings.Cut(name, "."); ok && isGoMajorVersion(version) {
+		name = base
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
+
+// isGoMajorVersion reports whether a path element is a major version such as v2
+func isGoMajorVersion(s string) bool {
+	return len(s) > 1 && s[0] == 'v' && strings.Trim(s[1:], "0123456789") == ""
+}
@@ -0,0 +1,111 @@
+// internal/github/graphql.go
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// GraphQLFunc runs a GraphQL query and decodes its data into out
+type GraphQLFunc func(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error
+
+// graphQLError is one entry of the errors of a GraphQL response
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphQLURL returns the GraphQL endpoint of a REST API base URL: api.github.com/graphql,
+// or /api/graphql on GitHub Enterprise Server
+func graphQLURL(apiURL string) string {
+	if base, ok := strings.CutSuffix(apiURL, "/api/v3/"); ok {
+		return base + "/api/graphql"
+	}
+	return strings.TrimSuffix(apiURL, "/") + "/graphql"
+}
+
+// newGraphQL returns a GraphQLFunc that posts queries to endpoint with httpClient
+func newGraphQL(httpClient *http.Client, endpoint string) GraphQLFunc {
+	return func(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+		body, err := json.Marshal(map[string]interface{}{
+			"query":     query,
+			"variables": variables,
+		})
+		if err != nil {
+			return common.WrapError(err, "failed to encode GraphQL query")
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return common.WrapError(err, "failed to create GraphQL request")
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return common.WrapError(err, "GraphQL request failed")
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return common.NewError(fmt.Sprintf("GraphQL request failed with %s: %s", resp.Status, strings.TrimSpace(string(message))))
+		}
+
+		var result struct {
+			Data   json.RawMessage `json:"data"`
+			Errors []graphQLError  `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return common.WrapError(err, "failed to decode GraphQL response")
+		}
+		// Fields that do not exist come back null with a NOT_FOUND error, leaving the rest usable
+		var messages []string
+		for _, e := range result.Errors {
+			if e.Type != "NOT_FOUND" {
+				messages = append(messages, e.Message)
+			}
+		}
+		if len(messages) > 0 || len(result.Data) == 0 || string(result.Data) == "null" {
+			if len(messages) == 0 {
+				messages = append(messages, "no data")
+			}
+			return common.NewError("GraphQL query failed: " + strings.Join(messages, "; "))
+		}
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			return common.WrapError(err, "failed to decode GraphQL data")
+		}
+		return nil
+	}
+}
+
+// GraphQLAvailable reports whether the client can use the GraphQL API, which unlike the
+// REST API always requires authentication
+func (c *Client) GraphQLAvailable() bool {
+	return c.client.GraphQL != nil
+}
+
+// graphQL runs a query about owner/repo, so GitHub App authentication picks the installation
+// of that repository
+func (c *Client) graphQL(ctx context.Context, owner, repo, query string, variables map[string]interface{}, out interface{}) error {
+	if c.client.GraphQL == nil {
+		return common.NewError("GraphQL API is not available without authentication")
+	}
+	return c.client.GraphQL(withRequestRepo(ctx, owner, repo), query, variables, out)
+}
+
+// requestRepoKey is the context key of the repository a request without a repository path targets
+type requestRepoKey struct{}
+
+// withRequestRepo records the repository a request targets
+func withRequestRepo(ctx context.Context, owner, repo string) context.Context {
+	return context.WithValue(ctx, requestRepoKey{}, [2]string{owner, repo})
+}
@@ -0,0 +1,467 @@
+// internal/github/graphql_context.go
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// graphQLBatchSize is the number of pull requests or issues fetched per GraphQL query
+const graphQLBatchSize = 10
+
+// PullRequestContext is a pull request with its files, reviews, review threads, comments,
+// linked issues and check runs, fetched in one GraphQL query. The GraphQL API has no
+// patches, so Files carry none.
+type PullRequestContext struct {
+	PullRequest
+	BaseBranch    string         `json:"base_branch"`
+	HeadBranch    string         `json:"head_branch"`
+	MergedAt      string         `json:"merged_at,omitempty"`
+	Labels        []string       `json:"labels,omitempty"`
+	Reviews       []Review       `json:"reviews,omitempty"`
+	ReviewThreads []ReviewThread `json:"review_threads,omitempty"`
+	Comments      []Comment      `json:"comments,omitempty"`
+	LinkedIssues  []LinkedItem   `json:"linked_issues,omitempty"`
+	CheckRuns     []CheckRun     `json:"check_runs,omitempty"`
+}
+
+// IssueContext is an issue with its comments and timeline, fetched in one GraphQL query
+type IssueContext struct {
+	IssueInfo
+	Comments           []Comment       `json:"comments,omitempty"`
+	Timeline           []TimelineEvent `json:"timeline,omitempty"`
+	LinkedPullRequests []LinkedItem    `json:"linked_pull_requests,omitempty"`
+}
+
+// Review is a pull request review
+type Review struct {
+	Author      string `json:"author"`
+	State       string `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, ...
+	Body        string `json:"body,omitempty"`
+	SubmittedAt string `json:"submitted_at,omitempty"`
+}
+
+// ReviewThread is a thread of review comments on a line of a file
+type ReviewThread struct {
+	Path     string    `json:"path"`
+	Resolved bool      `json:"resolved"`
+	Comments []Comment `json:"comments"`
+}
+
+// Comment is a comment on an issue, pull request or review thread
+type Comment struct {
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+// LinkedItem is an issue or pull request linked from another
+type LinkedItem struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+}
+
+// CheckRun is a check run on the head commit of a pull request
+type CheckRun struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion,omitempty"`
+}
+
+// TimelineEvent is an event in the timeline of an issue
+type TimelineEvent struct {
+	Type      string `json:"type"` // e.g. ClosedEvent, LabeledEvent
+	Actor     string `json:"actor,omitempty"`
+	CreatedAt string `json:"created_at"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// pullRequestFragment selects the fields of a PullRequestContext
+const pullRequestFragment = `
+fragment PullRequestContext on PullRequest {
+  number title body state url createdAt updatedAt mergedAt
+  additions deletions changedFiles baseRefName headRefName
+  author { login }
+  commits(last: 1) {
+    totalCount
+    nodes { commit { checkSuites(first: 20) { nodes { checkRuns(first: 50) { nodes { name status conclusion } } } } } }
+  }
+  labels(first: 20) { nodes { name } }
+  files(first: 100) { nodes { path additions deletions changeType } }
+  reviews(first: 50) { nodes { author { login } state body submittedAt } }
+  reviewThreads(first: 50) {
+    nodes { path isResolved comments(first: 10) { nodes { author { login } body createdAt } } }
+  }
+  comments(first: 50) { nodes { author { login } body createdAt } }
+  closingIssuesReferences(first: 10) { nodes { number title state url } }
+}`
+
+// issueFragment selects the fields of an IssueContext
+const issueFragment = `
+fragment IssueContext on Issue {
+  number title body state url createdAt closedAt
+  author { login }
+  labels(first: 20) { nodes { name } }
+  assignees(first: 10) { nodes { login } }
+  comments(first: 50) { nodes { author { login } body createdAt } }
+  timelineItems(first: 100, itemTypes: [CLOSED_EVENT, REOPENED_EVENT, LABELED_EVENT, UNLABELED_EVENT, ASSIGNED_EVENT, CROSS_REFERENCED_EVENT, REFERENCED_EVENT]) {
+    nodes {
+      __typename
+      ... on ClosedEvent { createdAt actor { login } }
+      ... on ReopenedEvent { createdAt actor { login } }
+      ... on LabeledEvent { createdAt actor { login } label { name } }
+      ... on UnlabeledEvent { createdAt actor { login } label { name } }
+      ... on AssignedEvent { createdAt actor { login } assignee { ... on User { login } } }
+      ... on ReferencedEvent { createdAt actor { login } commit { oid } }
+      ... on CrossReferencedEvent {
+        createdAt actor { login }
+        source { __typename ... on PullRequest { number title state url } ... on Issue { number title state url } }
+      }
+    }
+  }
+}`
+
+// GetPullRequestContext fetches one pull request with its context in a single GraphQL query
+func (c *Client) GetPullRequestContext(ctx context.Context, owner, repo string, number int) (*PullRequestContext, error) {
+	contexts, err := c.GetPullRequestContexts(ctx, owner, repo, []int{number})
+	if err != nil {
+		return nil, err
+	}
+	if len(contexts) == 0 {
+		return nil, common.NewError(fmt.Sprintf("pull request #%d not found", number))
+	}
+	return &contexts[0], nil
+}
+
+// GetPullRequestContexts fetches pull requests with their context, several per GraphQL query.
+// Pull requests that do not exist are left out.
+func (c *Client) GetPullRequestContexts(ctx context.Context, owner, repo string, numbers []int) ([]PullRequestContext, error) {
+	var contexts []PullRequestContext
+	err := c.fetchByNumber(ctx, owner, repo, "pullRequest", "PullRequestContext", pullRequestFragment, numbers, func(raw json.RawMessage) error {
+		var node gqlPullRequest
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return err
+		}
+		contexts = append(contexts, node.toContext())
+		return nil
+	})
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get pull requests")
+	}
+	return contexts, nil
+}
+
+// GetIssueContexts fetches issues with their comments and timeline, several per GraphQL query.
+// Issues that do not exist are left out.
+func (c *Client) GetIssueContexts(ctx context.Context, owner, repo string, numbers []int) ([]IssueContext, error) {
+	var contexts []IssueContext
+	err := c.fetchByNumber(ctx, owner, repo, "issue", "IssueContext", issueFragment, numbers, func(raw json.RawMessage) error {
+		var node gqlIssue
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return err
+		}
+		contexts = append(contexts, node.toContext())
+		return nil
+	})
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get issues")
+	}
+	return contexts, nil
+}
+
+// fetchByNumber queries a repository field such as pullRequest(number:) for each number, in
+// batches of aliased fields, and passes each non-null result to decode in the order requested
+func (c *Client) fetchByNumber(ctx context.Context, owner, repo, field, fragmentName, fragment string, numbers []int, decode func(json.RawMessage) error) error {
+	for start := 0; start < len(numbers); start += graphQLBatchSize {
+		batch := numbers[start:min(start+graphQLBatchSize, len(numbers))]
+
+		var query strings.Builder
+		query.WriteString("query($owner: String!, $name: String!) {\n  repository(owner: $owner, name: $name) {\n")
+		for i, number := range batch {
+			fmt.Fprintf(&query, "    n%d: %s(number: %d) { ...%s }\n", i, field, number, fragmentName)
+		}
+		query.WriteString("  }\n}\n")
+		query.WriteString(fragment)
+
+		var data struct {
+			Repository map[string]json.RawMessage `json:"repository"`
+		}
+		variables := map[string]interface{}{"owner": owner, "name": repo}
+		if err := c.graphQL(ctx, owner, repo, query.String(), variables, &data); err != nil {
+			return err
+		}
+
+		for i := range batch {
+			raw, ok := data.Repository[fmt.Sprintf("n%d", i)]
+			if !ok || string(raw) == "null" {
+				continue
+			}
+			if err := decode(raw); err != nil {
+				return common.WrapError(err, "failed to decode GraphQL data")
+			}
+		}
+	}
+	return nil
+}
+
+// gqlActor is the author or actor of a GraphQL node; it is null for deleted accounts
+type gqlActor struct {
+	Login string `json:"login"`
+}
+
+// gqlLogin returns the login of an actor, or "ghost" for deleted accounts as on github.com
+func gqlLogin(actor *gqlActor) string {
+	if actor == nil {
+		return "ghost"
+	}
+	return actor.Login
+}
+
+type gqlNames struct {
+	Nodes []struct {
+		Name  string `json:"name"`
+		Login string `json:"login"`
+	} `json:"nodes"`
+}
+
+type gqlComments struct {
+	Nodes []struct {
+		Author    *gqlActor `json:"author"`
+		Body      string    `json:"body"`
+		CreatedAt string    `json:"createdAt"`
+	} `json:"nodes"`
+}
+
+func (c gqlComments) toComments() []Comment {
+	var comments []Comment
+	for _, node := range c.Nodes {
+		comments = append(comments, Comment{Author: gqlLogin(node.Author), Body: node.Body, CreatedAt: node.CreatedAt})
+	}
+	return comments
+}
+
+type gqlLinked struct {
+	Typename string `json:"__typename"`
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	URL      string `json:"url"`
+}
+
+func (l gqlLinked) toLinkedItem() LinkedItem {
+	return LinkedItem{Number: l.Number, Title: l.Title, State: strings.ToLower(l.State), URL: l.URL}
+}
+
+type gqlPullRequest struct {
+	Number       int       `json:"number"`
+	Title        string    `json:"title"`
+	Body         string    `json:"body"`
+	State        string    `json:"state"`
+	URL          string    `json:"url"`
+	CreatedAt    string    `json:"createdAt"`
+	UpdatedAt    string    `json:"updatedAt"`
+	MergedAt     string    `json:"mergedAt"`
+	Additions    int       `json:"additions"`
+	Deletions    int       `json:"deletions"`
+	ChangedFiles int       `json:"changedFiles"`
+	BaseRefName  string    `json:"baseRefName"`
+	HeadRefName  string    `json:"headRefName"`
+	Author       *gqlActor `json:"author"`
+	Commits      struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Commit struct {
+				CheckSuites struct {
+					Nodes []struct {
+						CheckRuns struct {
+							Nodes []CheckRun `json:"nodes"`
+						} `json:"checkRuns"`
+					} `json:"nodes"`
+				} `json:"checkSuites"`
+			} `json:"commit"`
+		} `json:"nodes"`
+	} `json:"commits"`
+	Labels gqlNames `json:"labels"`
+	Files  struct {
+		Nodes []struct {
+			Path       string `json:"path"`
+			Additions  int    `json:"additions"`
+			Deletions  int    `json:"deletions"`
+			ChangeType string `json:"changeType"`
+		} `json:"nodes"`
+	} `json:"files"`
+	Reviews struct {
+		Nodes []struct {
+			Author      *gqlActor `json:"author"`
+			State       string    `json:"state"`
+			Body        string    `json:"body"`
+			SubmittedAt string    `json:"submittedAt"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+	ReviewThreads struct {
+		Nodes []struct {
+			Path       string      `json:"path"`
+			IsResolved bool        `json:"isResolved"`
+			Comments   gqlComments `json:"comments"`
+		} `json:"nodes"`
+	} `json:"reviewThreads"`
+	Comments                gqlComments `json:"comments"`
+	ClosingIssuesReferences struct {
+		Nodes []gqlLinked `json:"nodes"`
+	} `json:"closingIssuesReferences"`
+}
+
+// gqlFileStatus maps a GraphQL change type to the file status of the REST API
+var gqlFileStatus = map[string]string{
+	"ADDED":    "added",
+	"DELETED":  "removed",
+	"MODIFIED": "modified",
+	"RENAMED":  "renamed",
+	"COPIED":   "copied",
+	"CHANGED":  "changed",
+}
+
+func (p gqlPullRequest) toContext() PullRequestContext {
+	context := PullRequestContext{
+		PullRequest: PullRequest{
+			Number:       p.Number,
+			Title:        p.Title,
+			Description:  p.Body,
+			State:        strings.ToLower(p.State),
+			User:         gqlLogin(p.Author),
+			CreatedAt:    p.CreatedAt,
+			UpdatedAt:    p.UpdatedAt,
+			Commits:      p.Commits.TotalCount,
+			Additions:    p.Additions,
+			Deletions:    p.Deletions,
+			ChangedFiles: p.ChangedFiles,
+			URL:          p.URL,
+		},
+		BaseBranch: p.BaseRefName,
+		HeadBranch: p.HeadRefName,
+		MergedAt:   p.MergedAt,
+	}
+
+	for _, file := range p.Files.Nodes {
+		context.Files = append(context.Files, FileChange{
+			Filename:    file.Path,
+			Status:      gqlFileStatus[file.ChangeType],
+			Additions:   file.Additions,
+			Deletions:   file.Deletions,
+			Changes:     file.Additions + file.Deletions,
+			ContentType: utils.LanguageID(file.Path, ""),
+		})
+	}
+	for _, label := range p.Labels.Nodes {
+		context.Labels = append(context.Labels, label.Name)
+	}
+	for _, review := range p.Reviews.Nodes {
+		context.Reviews = append(context.Reviews, Review{
+			Author:      gqlLogin(review.Author),
+			State:       review.State,
+			Body:        review.Body,
+			SubmittedAt: review.SubmittedAt,
+		})
+	}
+	for _, thread := range p.ReviewThreads.Nodes {
+		context.ReviewThreads = append(context.ReviewThreads, ReviewThread{
+			Path:     thread.Path,
+			Resolved: thread.IsResolved,
+			Comments: thread.Comments.toComments(),
+		})
+	}
+	context.Comments = p.Comments.toComments()
+	for _, issue := range p.ClosingIssuesReferences.Nodes {
+		context.LinkedIssues = append(context.LinkedIssues, issue.toLinkedItem())
+	}
+	for _, commit := range p.Commits.Nodes {
+		for _, suite := range commit.Commit.CheckSuites.Nodes {
+			context.CheckRuns = append(context.CheckRuns, suite.CheckRuns.Nodes...)
+		}
+	}
+	return context
+}
+
+type gqlIssue struct {
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	State     string      `json:"state"`
+	URL       string      `json:"url"`
+	CreatedAt time.Time   `json:"createdAt"`
+	ClosedAt  *time.Time  `json:"closedAt"`
+	Author    *gqlActor   `json:"author"`
+	Labels    gqlNames    `json:"labels"`
+	Assignees gqlNames    `json:"assignees"`
+	Comments  gqlComments `json:"comments"`
+
+	TimelineItems struct {
+		Nodes []struct {
+			Typename  string    `json:"__typename"`
+			CreatedAt string    `json:"createdAt"`
+			Actor     *gqlActor `json:"actor"`
+			Label     *struct {
+				Name string `json:"name"`
+			} `json:"label"`
+			Assignee *gqlActor `json:"assignee"`
+			Commit   *struct {
+				OID string `json:"oid"`
+			} `json:"commit"`
+			Source *gqlLinked `json:"source"`
+		} `json:"nodes"`
+	} `json:"timelineItems"`
+}
+
+func (i gqlIssue) toContext() IssueContext {
+	context := IssueContext{
+		IssueInfo: IssueInfo{
+			Number:      i.Number,
+			Title:       i.Title,
+			State:       strings.ToLower(i.State),
+			CreatedAt:   i.CreatedAt,
+			Author:      gqlLogin(i.Author),
+			URL:         i.URL,
+			Description: i.Body,
+		},
+		Comments: i.Comments.toComments(),
+	}
+	if i.ClosedAt != nil {
+		context.ClosedAt = *i.ClosedAt
+	}
+	for _, label := range i.Labels.Nodes {
+		context.Labels = append(context.Labels, label.Name)
+	}
+	for _, assignee := range i.Assignees.Nodes {
+		context.Assignees = append(context.Assignees, assignee.Login)
+	}
+
+	for _, item := range i.TimelineItems.Nodes {
+		event := TimelineEvent{Type: item.Typename, CreatedAt: item.CreatedAt}
+		if item.Actor != nil {
+			event.Actor = item.Actor.Login
+		}
+		switch {
+		case item.Label != nil:
+			event.Detail = item.Label.Name
+		case item.Assignee != nil:
+			event.Detail = item.Assignee.Login
+		case item.Commit != nil:
+			event.Detail = item.Commit.OID
+		case item.Source != nil && item.Source.Number != 0:
+			event.Detail = fmt.Sprintf("#%d %s", item.Source.Number, item.Source.Title)
+			if item.Source.Typename == "PullRequest" {
+				context.LinkedPullRequests = append(context.LinkedPullRequests, item.Source.toLinkedItem())
+			}
+		}
+		context.Timeline = append(context.Timeline, event)
+	}
+	return context
+}
@@ -0,0 +1,97 @@
+// internal/github/history.go
+package github
+
+import (
+	"context"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// ListRecentCommits returns up to limit commits on a branch, newest first
+func (c *Client) ListRecentCommits(ctx context.Context, owner, repo, branch string, limit int) ([]models.GraphCommit, error) {
+	opts := &github.CommitsListOptions{
+		SHA: branch,
+		ListOptions: github.ListOptions{
+			PerPage: min(limit, 100),
+		},
+	}
+
+	var result []models.GraphCommit
+	for len(result) < limit {
+		commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to list commits")
+		}
+
+		for _, commit := range commits {
+			if len(result) >= limit {
+				break
+			}
+			result = append(result, toGraphCommit(commit, ""))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return result, nil
+}
+
+// ListRecentTags returns up to limit tags with the commit each one points at.
+// Tags are returned in the order the API lists them, which is newest first for most repositories.
+func (c *Client) ListRecentTags(ctx context.Context, owner, repo string, limit int) ([]models.GraphCommit, error) {
+	opts := &github.ListOptions{PerPage: min(limit, 100)}
+
+	var result []models.GraphCommit
+	for len(result) < limit {
+		tags, resp, err := c.client.Repositories.ListTags(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to list tags")
+		}
+
+		for _, tag := range tags {
+			if len(result) >= limit {
+				break
+			}
+			if tag.Commit == nil || tag.Commit.SHA == nil {
+				continue
+			}
+
+			// The tag listing omits commit dates, which the time series needs
+			commit, _, err := c.client.Repositories.GetCommit(ctx, owner, repo, tag.Commit.GetSHA(), nil)
+			if err != nil {
+				c.logger.WithError(err).Warning("Failed to get commit for tag " + tag.GetName())
+				continue
+			}
+			result = append(result, toGraphCommit(commit, tag.GetName()))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return result, nil
+}
+
+// toGraphCommit converts a GitHub commit into a graph history commit
+func toGraphCommit(commit *github.RepositoryCommit, ref string) models.GraphCommit {
+	graphCommit := models.GraphCommit{
+		SHA: commit.GetSHA(),
+		Ref: ref,
+	}
+	if commit.Commit != nil {
+		graphCommit.Message = commit.Commit.GetMessage()
+		if commit.Commit.Committer != nil {
+			graphCommit.Date = commit.Commit.Committer.GetDate()
+		} else if commit.Commit.Author != nil {
+			graphCommit.Date = commit.Commit.Author.GetDate()
+		}
+	}
+	return graphCommit
+}
@@ -0,0 +1,185 @@
+// internal/github/hosts.go
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+const (
+	// DefaultAPIBaseURL is the REST API of github.com
+	DefaultAPIBaseURL = "https://api.github.com/"
+	// DefaultUploadURL is the upload API of github.com
+	DefaultUploadURL = "https://uploads.github.com/"
+	// DefaultWebURL is the web interface of github.com
+	DefaultWebURL = "https://github.com/"
+)
+
+// Hosts are the base URLs of a GitHub deployment: github.com or a GitHub Enterprise Server instance
+type Hosts struct {
+	APIURL    string // REST API, e.g. https://ghe.example.com/api/v3/
+	UploadURL string // Upload API, e.g. https://ghe.example.com/api/uploads/
+	WebURL    string // Web interface, e.g. https://ghe.example.com/
+}
+
+// DefaultHosts returns the hosts of github.com
+func DefaultHosts() Hosts {
+	return Hosts{
+		APIURL:    DefaultAPIBaseURL,
+		UploadURL: DefaultUploadURL,
+		WebURL:    DefaultWebURL,
+	}
+}
+
+// NewHosts builds the hosts of a deployment from its configured URLs. Empty URLs default to
+// github.com, or for a GitHub Enterprise Server web URL to the /api/v3/ and /api/uploads/
+// paths on the same host, as go-github's enterprise client does.
+func NewHosts(apiURL, uploadURL, webURL string) (Hosts, error) {
+	hosts := DefaultHosts()
+	if webURL != "" {
+		hosts.WebURL = webURL
+	}
+
+	web, err := parseBaseURL(hosts.WebURL)
+	if err != nil {
+		return Hosts{}, common.WrapError(err, "invalid GitHub web URL")
+	}
+	hosts.WebURL = web.String()
+
+	if hosts.WebURL != DefaultWebURL {
+		hosts.APIURL = hosts.WebURL + "api/v3/"
+		hosts.UploadURL = hosts.WebURL + "api/uploads/"
+	}
+	if apiURL != "" {
+		hosts.APIURL = apiURL
+	}
+	if uploadURL != "" {
+		hosts.UploadURL = uploadURL
+	}
+
+	for _, base := range []*string{&hosts.APIURL, &hosts.UploadURL} {
+		parsed, err := parseBaseURL(*base)
+		if err != nil {
+			return Hosts{}, common.WrapError(err, "invalid GitHub API URL")
+		}
+		*base = parsed.String()
+	}
+	return hosts, nil
+}
+
+// IsDefault reports whether the hosts are those of github.com
+func (h Hosts) IsDefault() bool {
+	return h.APIURL == DefaultAPIBaseURL && h.WebURL == DefaultWebURL
+}
+
+// WebHost returns the host name of the web interface, e.g. "github.com"
+func (h Hosts) WebHost() string {
+	parsed, err := url.Parse(h.WebURL)
+	if err != nil || parsed.Host == "" {
+		return "github.com"
+	}
+	return parsed.Host
+}
+
+// RepoURL returns the web URL of a repository
+func (h Hosts) RepoURL(owner, repo string) string {
+	return fmt.Sprintf("%s%s/%s", h.WebURL, owner, repo)
+}
+
+// BlobURL returns the web URL of a file at a ref
+func (h Hosts) BlobURL(owner, repo, ref, path string) string {
+	return fmt.Sprintf("%s/blob/%s/%s", h.RepoURL(owner, repo), ref, path)
+}
+
+// ParseRepoURL parses a repository URL on these hosts into owner and repo
+func (h Hosts) ParseRepoURL(url string) (string, string, error) {
+	host := h.WebHost()
+
+	// Remove trailing slashes and whitespace
+	url = strings.TrimSpace(url)
+	url = strings.TrimSuffix(url, "/")
+
+	// Simple owner/repo format (e.g., "owner/repo")
+	if strings.Count(url, "/") == 1 && !strings.Contains(url, host) && !strings.Contains(url, ":") {
+		parts := strings.Split(url, "/")
+		return parts[0], parts[1], nil
+	}
+
+	// SSH URL format (e.g., "git@github.com:owner/repo.git")
+	if strings.Contains(url, "git@"+host+":") {
+		parts := strings.Split(url, "git@"+host+":")
+		return splitOwnerRepo(parts[len(parts)-1], "invalid GitHub SSH URL format")
+	}
+
+	// Web URL format (e.g., "https://github.com/owner/repo")
+	if strings.Contains(url, host+"/") {
+		parts := strings.SplitN(url, host+"/", 2)
+		return splitOwnerRepo(parts[1], "invalid GitHub URL format")
+	}
+
+	return "", "", common.NewError(fmt.Sprintf("unsupported GitHub URL format; expected a %s repository", host))
+}
+
+// ParsePullRequestURL extracts owner, repo, and PR number from a pull request URL on these hosts
+func (h Hosts) ParsePullRequestURL(url string) (string, string, int, error) {
+	// Match patterns like:
+	// https://github.com/owner/repo/pull/123
+	// https://github.com/owner/repo/pulls/123
+	host := h.WebHost()
+
+	parts := strings.Split(strings.TrimSpace(url), "/")
+	if len(parts) < 7 {
+		return "", "", 0, common.NewError("invalid GitHub pull request URL format")
+	}
+
+	// Check that it points at the configured host itself, not a subdomain of it
+	if parts[2] != host {
+		return "", "", 0, common.NewError(fmt.Sprintf("URL must be a %s pull request URL", host))
+	}
+
+	// Check if it contains pull or pulls
+	if parts[5] != "pull" && parts[5] != "pulls" {
+		return "", "", 0, common.NewError("URL must be a GitHub pull request URL")
+	}
+
+	owner := parts[3]
+	repo := parts[4]
+
+	// Parse PR number
+	var prNumber int
+	_, err := fmt.Sscanf(parts[6], "%d", &prNumber)
+	if err != nil {
+		return "", "", 0, common.WrapError(err, "invalid pull request number")
+	}
+
+	return owner, repo, prNumber, nil
+}
+
+// splitOwnerRepo reads owner and repo from the path after the host
+func splitOwnerRepo(path, message string) (string, string, error) {
+	ownerRepo := strings.Split(path, "/")
+	if len(ownerRepo) < 2 || ownerRepo[0] == "" || ownerRepo[1] == "" {
+		return "", "", common.NewError(message)
+	}
+
+	// Remove any suffix like .git
+	return ownerRepo[0], strings.TrimSuffix(ownerRepo[1], ".git"), nil
+}
+
+// parseBaseURL parses an absolute URL and ensures its path ends with a slash
+func parseBaseURL(raw string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", raw)
+	}
+	if !strings.HasSuffix(parsed.Path, "/") {
+		parsed.Path += "/"
+	}
+	return parsed, nil
+}
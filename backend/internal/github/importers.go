@@ -0,0 +1,110 @@
+// internal/github/importers.go
+package github
+
+import (
+	"context"
+	"path"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// importResolver extracts and resolves the imports of one language
+type importResolver interface {
+	// index records what a file declares, such as its package, before any import is resolved
+	index(filePath, content string)
+	// extract returns the imports of a file as written, in the language's own syntax
+	extract(filePath, content string) []string
+	// resolve returns the repository files an import refers to, or nil
+	resolve(filePath, imp string) []string
+	// dependency describes an import that resolve could not place in the repository,
+	// or reports false if it names no external package
+	dependency(filePath, imp string) (models.ExternalDependency, bool)
+}
+
+// importLanguage registers the import resolver of a language
+type importLanguage struct {
+	name    string
+	handles func(filePath string) bool
+	setup   func(ic *importContext) importResolver
+}
+
+// importLanguages are the languages BuildImportGraph resolves imports of. A resolver is only
+// set up when the repository has files it handles.
+var importLanguages = []importLanguage{
+	{name: "Go", handles: inLanguages("go"), setup: newGoImports},
+	{name: "JavaScript/TypeScript", handles: inLanguages("javascript", "typescript"), setup: newJSImports},
+	{name: "Python", handles: inLanguages("python"), setup: newPythonImports},
+	{name: "Java/Kotlin", handles: inLanguages("java", "kotlin"), setup: newJVMImports},
+	{name: "Rust", handles: inLanguages("rust"), setup: newRustImports},
+	{name: "C#", handles: inLanguages("csharp"), setup: newCSharpImports},
+	{name: "Ruby", handles: inLanguages("ruby"), setup: newRubyImports},
+}
+
+// inLanguages returns a matcher for files the language registry places in one of the
+// languages, outside vendored dependency directories
+func inLanguages(ids ...string) func(string) bool {
+	return func(filePath string) bool {
+		if utils.IsVendoredPath(filePath) {
+			return false
+		}
+		lang := utils.GetLanguageFromPath(filePath)
+		if lang == nil {
+			return false
+		}
+		for _, id := range ids {
+			if lang.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// importContext is what import resolvers read while they are set up
+type importContext struct {
+	ctx      context.Context
+	src      RepoSource
+	ref      string
+	files    []models.GitHubFile
+	existing map[string]string // Path -> file or dir
+	logger   *common.Logger
+}
+
+// read returns the content of a repository file, logging failures
+func (ic *importContext) read(filePath string) (string, bool) {
+	content, err := ic.src.ReadFile(ic.ctx, ic.ref, filePath)
+	if err != nil {
+		ic.logger.WithField("error", err).Warning("Failed to read " + filePath)
+		return "", false
+	}
+	return content.Content, true
+}
+
+// isFile reports whether a repository path is a file
+func (ic *importContext) isFile(filePath string) bool {
+	return ic.existing[filePath] == "file"
+}
+
+// firstFile returns the first of the candidate paths that is a file
+func (ic *importContext) firstFile(candidates ...string) (string, bool) {
+	for _, candidate := range candidates {
+		if candidate = rootDir(path.Clean(candidate)); ic.isFile(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// nearestFile returns the closest file named name in the directory of filePath or above it
+func (ic *importContext) nearestFile(filePath, name string) (string, bool) {
+	for dir := repoDir(filePath); ; dir = repoDir(dir) {
+		if candidate := path.Join(dir, name); ic.isFile(candidate) {
+			return candidate, true
+		}
+		if dir == "" {
+			return "", false
+		}
+	}
+}
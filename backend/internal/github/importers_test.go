@@ -0,0 +1,108 @@
+package github
+
+import (
+	"context"
+	"path"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// fakeSource is an in-memory RepoSource over file contents keyed by path
+type fakeSource map[string]string
+
+func (s fakeSource) Repo() (string, string) {
+	return "octo", "fixture"
+}
+
+func (s fakeSource) Info(ctx context.Context) (*models.RepositoryInfo, error) {
+	return &models.RepositoryInfo{Owner: "octo", Name: "fixture", DefaultBranch: "main"}, nil
+}
+
+func (s fakeSource) ResolveRef(ctx context.Context, ref string) (string, error) {
+	return "main", nil
+}
+
+// ListTree returns the files and every directory above them
+func (s fakeSource) ListTree(ctx context.Context, ref string) ([]models.GitHubFile, error) {
+	dirs := make(map[string]bool)
+	var files []models.GitHubFile
+	for filePath, content := range s {
+		files = append(files, models.GitHubFile{Name: path.Base(filePath), Path: filePath, Type: "file", Size: len(content)})
+		for dir := repoDir(filePath); dir != "" && !dirs[dir]; dir = repoDir(dir) {
+			dirs[dir] = true
+			files = append(files, models.GitHubFile{Name: path.Base(dir), Path: dir, Type: "dir"})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func (s fakeSource) ReadFile(ctx context.Context, ref, filePath string) (*models.FileContent, error) {
+	content, ok := s[filePath]
+	if !ok {
+		return nil, fileNotFound(filePath)
+	}
+	return &models.FileContent{Path: filePath, Content: content}, nil
+}
+
+// newFixtureResolver sets up the resolver of a language over a fake repository and indexes
+// every file, as BuildImportGraph does
+func newFixtureResolver(t *testing.T, setup func(ic *importContext) importResolver, files map[string]string) importResolver {
+	t.Helper()
+	ctx := context.Background()
+	src := fakeSource(files)
+
+	tree, err := src.ListTree(ctx, "main")
+	if err != nil {
+		t.Fatalf("ListTree: %v", err)
+	}
+	existing := make(map[string]string, len(tree))
+	for _, file := range tree {
+		existing[file.Path] = file.Type
+	}
+
+	resolver := setup(&importContext{ctx: ctx, src: src, ref: "main", files: tree, existing: existing, logger: common.NewLogger()})
+	for filePath, content := range files {
+		resolver.index(filePath, content)
+	}
+	return resolver
+}
+
+// resolveCase is an import of a file and the files it should resolve to
+type resolveCase struct {
+	from string
+	imp  string
+	want []string
+}
+
+// checkResolve resolves each case, comparing the files regardless of order
+func checkResolve(t *testing.T, resolver importResolver, cases []resolveCase) {
+	t.Helper()
+	for _, tc := range cases {
+		got := slices.Clone(resolver.resolve(tc.from, tc.imp))
+		sort.Strings(got)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("resolve(%q, %q) = %v, want %v", tc.from, tc.imp, got, tc.want)
+		}
+	}
+}
+
+// checkDependency checks the external dependency an import is reported as; an empty name
+// means the import should not be reported
+func checkDependency(t *testing.T, resolver importResolver, from, imp string, want models.ExternalDependency) {
+	t.Helper()
+	got, ok := resolver.dependency(from, imp)
+	if want.Name == "" {
+		if ok {
+			t.Errorf("dependency(%q, %q) = %+v, want none", from, imp, got)
+		}
+		return
+	}
+	if !ok || got != want {
+		t.Errorf("dependency(%q, %q) = %+v, %v, want %+v", from, imp, got, ok, want)
+	}
+}
@@ -0,0 +1,605 @@
+// internal/github/jsresolve.go
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// jsResolveExtensions are tried in order when an import leaves out the extension
+var jsResolveExtensions = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts", ".json"}
+
+// jsCompiledExtensions maps the extension TypeScript sources import each other by to
+// the extensions of the sources themselves
+var jsCompiledExtensions = map[string][]string{
+	".js":  {".ts", ".tsx"},
+	".jsx": {".tsx"},
+	".mjs": {".mts"},
+	".cjs": {".cts"},
+}
+
+// jsExportConditions are the package.json export conditions tried, in order
+var jsExportConditions = []string{"source", "types", "import", "module", "require", "node", "browser", "default"}
+
+// nodeBuiltins are the Node.js core modules, imported with or without the node: prefix
+var nodeBuiltins = map[string]bool{
+	"assert": true, "async_hooks": true, "buffer": true, "child_process": true, "cluster": true,
+	"console": true, "constants": true, "crypto": true, "dgram": true, "diagnostics_channel": true,
+	"dns": true, "domain": true, "events": true, "fs": true, "http": true, "http2": true,
+	"https": true, "inspector": true, "module": true, "net": true, "os": true, "path": true,
+	"perf_hooks": true, "process": true, "punycode": true, "querystring": true, "readline": true,
+	"repl": true, "stream": true, "string_decoder": true, "test": true, "timers": true, "tls": true,
+	"tty": true, "url": true, "util": true, "v8": true, "vm": true, "wasi": true,
+	"worker_threads": true, "zlib": true,
+}
+
+var (
+	// import x from 'y', import type { x } from 'y', export { x } from 'y', export * from 'y'
+	// at the start of a statement. The clause before from holds no quotes or semicolons.
+	jsFromRegex = regexp.MustCompile("(?m)(?:^|[;}])\\s*(?:import|export)\\b[^'\"`;]*?\\bfrom\\s*['\"]([^'\"\\n]+)['\"]")
+
+	// import 'y' for side effects
+	jsSideEffectRegex = regexp.MustCompile(`(?m)(?:^|[;}])\s*import\s*['"]([^'"\n]+)['"]`)
+
+	// require('y'), import('y') and require.resolve('y')
+	jsCallRegex = regexp.MustCompile(`\b(?:require|require\.resolve|import)\s*\(\s*['"]([^'"\n]+)['"]\s*\)`)
+
+	// A valid npm package name, scoped or not, at the start of a specifier
+	jsPackageNameRegex = regexp.MustCompile(`^(?:@[\w.-]+/)?[\w.-]+`)
+)
+
+// extractJSImports returns the module specifiers a JavaScript or TypeScript file imports,
+// re-exports, requires or imports dynamically
+func extractJSImports(content string) []string {
+	content = stripJSComments(content)
+
+	seen := make(map[string]bool)
+	var imports []string
+	for _, re := range []*regexp.Regexp{jsFromRegex, jsSideEffectRegex, jsCallRegex} {
+		for _, match := range re.FindAllStringSubmatch(content, -1) {
+			if spec := strings.TrimSpace(match[1]); spec != "" && !seen[spec] {
+				seen[spec] = true
+				imports = append(imports, spec)
+			}
+		}
+	}
+	return imports
+}
+
+// stripJSComments blanks out the line and block comments of JavaScript source, JSON with
+// comments included, leaving strings and template literals alone
+func stripJSComments(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+
+	var quote byte // Quote of the string being read, 0 outside strings
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		switch {
+		case quote != 0:
+			b.WriteByte(ch)
+			if ch == '\\' && i+1 < len(content) {
+				i++
+				b.WriteByte(content[i])
+			} else if ch == quote || (ch == '\n' && quote != '`') {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'' || ch == '`':
+			quote = ch
+			b.WriteByte(ch)
+		case ch == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			if i < len(content) {
+				b.WriteByte('\n')
+			}
+		case ch == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			// Keep the line breaks so matches stay on their lines
+			b.WriteString(strings.Repeat("\n", strings.Count(content[i:i+2+end], "\n")))
+			b.WriteByte(' ')
+			i += end + 3
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// tsConfig is the module resolution part of a tsconfig.json or jsconfig.json, with
+// extended configurations applied
+type tsConfig struct {
+	Dir       string // Directory of the configuration, "" at the root
+	BaseURL   string // Repository directory bare specifiers resolve against
+	HasBase   bool
+	Paths     map[string][]string
+	PathsBase string // Repository directory paths targets resolve against
+}
+
+// tsConfigFile is the JSON of a tsconfig.json or jsconfig.json
+type tsConfigFile struct {
+	Extends         json.RawMessage `json:"extends"`
+	CompilerOptions struct {
+		BaseURL *string             `json:"baseUrl"`
+		Paths   map[string][]string `json:"paths"`
+	} `json:"compilerOptions"`
+}
+
+// jsPackage is a package.json of the repository
+type jsPackage struct {
+	Dir          string
+	Name         string
+	Main         string
+	Module       string
+	Types        string
+	Source       string
+	Exports      interface{}
+	Imports      interface{}
+	Dependencies map[string]string // Every kind of dependency -> version range
+}
+
+// jsPackageFile is the JSON of a package.json
+type jsPackageFile struct {
+	Name                 string            `json:"name"`
+	Main                 string            `json:"main"`
+	Module               string            `json:"module"`
+	Types                string            `json:"types"`
+	Typings              string            `json:"typings"`
+	Source               string            `json:"source"`
+	Exports              interface{}       `json:"exports"`
+	Imports              interface{}       `json:"imports"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+// jsResolver resolves JavaScript and TypeScript module specifiers to files of the
+// repository, following tsconfig paths, workspace packages and index files
+type jsResolver struct {
+	files     map[string]string     // Path -> file or dir
+	configs   map[string]*tsConfig  // Directory -> configuration
+	manifests map[string]*jsPackage // Directory -> package.json
+	packages  map[string]*jsPackage // Package name -> package.json, for workspaces
+}
+
+// newJSImports reads the tsconfig.json, jsconfig.json and package.json files of a repository
+func newJSImports(ic *importContext) importResolver {
+	return newJSResolver(ic.ctx, ic.src, ic.ref, ic.files, ic.existing, ic.logger)
+}
+
+// newJSResolver reads the tsconfig.json, jsconfig.json and package.json files of a repository
+func newJSResolver(ctx context.Context, src RepoSource, ref string, allFiles []models.GitHubFile, existingFiles map[string]string, logger *common.Logger) *jsResolver {
+	r := &jsResolver{
+		files:     existingFiles,
+		configs:   make(map[string]*tsConfig),
+		manifests: make(map[string]*jsPackage),
+		packages:  make(map[string]*jsPackage),
+	}
+
+	read := func(filePath string) ([]byte, bool) {
+		content, err := src.ReadFile(ctx, ref, filePath)
+		if err != nil {
+			logger.WithField("error", err).Warning("Failed to read " + filePath)
+			return nil, false
+		}
+		return []byte(content.Content), true
+	}
+
+	for _, file := range allFiles {
+		if file.Type != "file" || strings.Contains("/"+file.Path, "/node_modules/") {
+			continue
+		}
+		dir := repoDir(file.Path)
+		switch path.Base(file.Path) {
+		case "tsconfig.json", "jsconfig.json":
+			if _, ok := r.configs[dir]; ok && path.Base(file.Path) == "jsconfig.json" {
+				continue // tsconfig.json wins
+			}
+			if config := loadTSConfig(file.Path, read, logger, 0); config != nil {
+				r.configs[dir] = config
+			}
+		case "package.json":
+			content, ok := read(file.Path)
+			if !ok {
+				continue
+			}
+			var manifest jsPackageFile
+			if err := json.Unmarshal(content, &manifest); err != nil {
+				logger.WithField("error", err).Warning("Failed to parse " + file.Path)
+				continue
+			}
+			pkg := &jsPackage{
+				Dir:          dir,
+				Name:         manifest.Name,
+				Main:         manifest.Main,
+				Module:       manifest.Module,
+				Types:        manifest.Types,
+				Source:       manifest.Source,
+				Exports:      manifest.Exports,
+				Imports:      manifest.Imports,
+				Dependencies: make(map[string]string),
+			}
+			if pkg.Types == "" {
+				pkg.Types = manifest.Typings
+			}
+			for _, deps := range []map[string]string{manifest.OptionalDependencies, manifest.PeerDependencies, manifest.DevDependencies, manifest.Dependencies} {
+				for name, version := range deps {
+					pkg.Dependencies[name] = version
+				}
+			}
+			r.manifests[dir] = pkg
+			if pkg.Name != "" {
+				r.packages[pkg.Name] = pkg
+			}
+		}
+	}
+	return r
+}
+
+// maxTSConfigExtends bounds chains of extended configurations
+const maxTSConfigExtends = 5
+
+// loadTSConfig reads a configuration and the relative configurations it extends. Options
+// of a configuration override those it extends, and resolve against its own directory.
+func loadTSConfig(configPath string, read func(string) ([]byte, bool), logger *common.Logger, depth int) *tsConfig {
+	content, ok := read(configPath)
+	if !ok {
+		return nil
+	}
+	var file tsConfigFile
+	if err := json.Unmarshal([]byte(trailingCommaRegex.ReplaceAllString(stripJSComments(string(content)), "$1")), &file); err != nil {
+		logger.WithField("error", err).Warning("Failed to parse " + configPath)
+		return nil
+	}
+
+	dir := repoDir(configPath)
+	config := &tsConfig{Dir: dir, PathsBase: dir}
+
+	// Apply the extended configurations first; TypeScript 5 allows several
+	var extends []string
+	if err := json.Unmarshal(file.Extends, &extends); err != nil {
+		var single string
+		if json.Unmarshal(file.Extends, &single) == nil && single != "" {
+			extends = []string{single}
+		}
+	}
+	for _, parent := range extends {
+		if !strings.HasPrefix(parent, ".") || depth >= maxTSConfigExtends {
+			continue // Configurations from packages are not part of the repository
+		}
+		parentPath := path.Join(dir, parent)
+		if path.Ext(parentPath) != ".json" {
+			parentPath += ".json"
+		}
+		if base := loadTSConfig(parentPath, read, logger, depth+1); base != nil {
+			config.BaseURL, config.HasBase = base.BaseURL, base.HasBase
+			config.Paths, config.PathsBase = base.Paths, base.PathsBase
+		}
+	}
+
+	if file.CompilerOptions.BaseURL != nil {
+		config.BaseURL = rootDir(path.Join(dir, *file.CompilerOptions.BaseURL))
+		config.HasBase = true
+	}
+	if file.CompilerOptions.Paths != nil {
+		config.Paths = file.CompilerOptions.Paths
+		config.PathsBase = dir
+	}
+	// Paths resolve against baseUrl when there is one
+	if config.HasBase {
+		config.PathsBase = config.BaseURL
+	}
+	return config
+}
+
+// trailingCommaRegex matches the trailing commas JSON with comments allows
+var trailingCommaRegex = regexp.MustCompile(`,(\s*[}\]])`)
+
+// configFor returns the configuration of the nearest directory containing a file
+func (r *jsResolver) configFor(filePath string) *tsConfig {
+	for dir := repoDir(filePath); ; dir = repoDir(dir) {
+		if config, ok := r.configs[dir]; ok {
+			return config
+		}
+		if dir == "" {
+			return nil
+		}
+	}
+}
+
+// manifestFor returns the package.json of the nearest directory containing a file
+func (r *jsResolver) manifestFor(filePath string) *jsPackage {
+	for dir := repoDir(filePath); ; dir = repoDir(dir) {
+		if pkg, ok := r.manifests[dir]; ok {
+			return pkg
+		}
+		if dir == "" {
+			return nil
+		}
+	}
+}
+
+func (r *jsResolver) index(filePath, content string) {}
+
+func (r *jsResolver) extract(filePath, content string) []string {
+	return extractJSImports(content)
+}
+
+func (r *jsResolver) resolve(fromFile, spec string) []string {
+	if target, ok := r.resolveSpec(fromFile, spec); ok {
+		return []string{target}
+	}
+	return nil
+}
+
+// resolveSpec returns the repository file a specifier imported by a file refers to
+func (r *jsResolver) resolveSpec(fromFile, spec string) (string, bool) {
+	spec, _, _ = strings.Cut(spec, "?") // Bundler queries such as ?raw
+	switch {
+	case strings.Contains(spec, "://") || strings.HasPrefix(spec, "/"):
+		return "", false
+	case strings.HasPrefix(spec, "."):
+		return r.resolveFile(path.Join(repoDir(fromFile), spec))
+	case strings.HasPrefix(spec, "#"):
+		if pkg := r.manifestFor(fromFile); pkg != nil {
+			return r.resolveTargets(pkg.Dir, exportTargets(pkg.Imports, spec))
+		}
+		return "", false
+	}
+
+	if config := r.configFor(fromFile); config != nil {
+		if target, ok := r.resolveTSPaths(config, spec); ok {
+			return target, true
+		}
+		if config.HasBase {
+			if target, ok := r.resolveFile(path.Join(config.BaseURL, spec)); ok {
+				return target, true
+			}
+		}
+	}
+
+	return r.resolveWorkspace(spec)
+}
+
+// resolveTSPaths resolves a specifier through the paths of a configuration. The pattern
+// with the longest prefix before its wildcard wins, and its targets are tried in order.
+func (r *jsResolver) resolveTSPaths(config *tsConfig, spec string) (string, bool) {
+	best, bestPrefix, wildcard := "", -1, ""
+	for pattern := range config.Paths {
+		prefix, suffix, hasStar := strings.Cut(pattern, "*")
+		switch {
+		case !hasStar && pattern == spec:
+			best, bestPrefix, wildcard = pattern, len(pattern)+1, ""
+		case hasStar && len(prefix) > bestPrefix && len(spec) >= len(prefix)+len(suffix) &&
+			strings.HasPrefix(spec, prefix) && strings.HasSuffix(spec, suffix):
+			best, bestPrefix, wildcard = pattern, len(prefix), spec[len(prefix):len(spec)-len(suffix)]
+		}
+	}
+	if bestPrefix < 0 {
+		return "", false
+	}
+
+	for _, target := range config.Paths[best] {
+		target = strings.Replace(target, "*", wildcard, 1)
+		if resolved, ok := r.resolveFile(path.Join(config.PathsBase, target)); ok {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// resolveWorkspace resolves a bare specifier to a package of the repository
+func (r *jsResolver) resolveWorkspace(spec string) (string, bool) {
+	name := jsPackageName(spec)
+	pkg, ok := r.packages[name]
+	if !ok {
+		return "", false
+	}
+	subpath := strings.TrimPrefix(spec, name)
+
+	if pkg.Exports != nil {
+		if target, ok := r.resolveTargets(pkg.Dir, exportTargets(pkg.Exports, "."+subpath)); ok {
+			return target, true
+		}
+	}
+	if subpath != "" {
+		return r.resolveFile(path.Join(pkg.Dir, subpath))
+	}
+
+	// Entry points may name build output that isn't committed, so try the sources too
+	entries := []string{pkg.Source, pkg.Module, pkg.Main, pkg.Types, "src/index", "index"}
+	for _, entry := range entries {
+		if entry == "" {
+			continue
+		}
+		if target, ok := r.resolveFile(path.Join(pkg.Dir, entry)); ok {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+// resolveTargets returns the first target, relative to dir, that resolves to a file
+func (r *jsResolver) resolveTargets(dir string, targets []string) (string, bool) {
+	for _, target := range targets {
+		if resolved, ok := r.resolveFile(path.Join(dir, target)); ok {
+			return resolved, true
+		}
+	}
+	return "", false
+}
+
+// resolveFile resolves a repository path the way bundlers and TypeScript do: the file
+// itself, the file with an extension, the TypeScript source of a .js path, then an index
+// file or package entry when the path is a directory
+func (r *jsResolver) resolveFile(target string) (string, bool) {
+	target = rootDir(path.Clean(target))
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return "", false // Outside the repository
+	}
+
+	if r.files[target] == "file" {
+		return target, true
+	}
+	for _, ext := range jsResolveExtensions {
+		if r.files[target+ext] == "file" {
+			return target + ext, true
+		}
+	}
+	if ext := path.Ext(target); len(jsCompiledExtensions[ext]) > 0 {
+		base := strings.TrimSuffix(target, ext)
+		for _, sourceExt := range jsCompiledExtensions[ext] {
+			if r.files[base+sourceExt] == "file" {
+				return base + sourceExt, true
+			}
+		}
+	}
+
+	if target == "" || r.files[target] == "dir" {
+		if pkg, ok := r.manifests[target]; ok && target != "" {
+			for _, entry := range []string{pkg.Source, pkg.Module, pkg.Main} {
+				if entry == "" {
+					continue
+				}
+				if resolved, ok := r.resolveFile(path.Join(target, entry)); ok && resolved != target {
+					return resolved, true
+				}
+			}
+		}
+		for _, ext := range jsResolveExtensions {
+			index := path.Join(target, "index"+ext)
+			if r.files[index] == "file" {
+				return index, true
+			}
+		}
+	}
+	return "", false
+}
+
+// exportTargets returns the candidate targets of a subpath such as "." or "./utils" in a
+// package.json exports or imports field, in order of preference
+func exportTargets(field interface{}, subpath string) []string {
+	switch value := field.(type) {
+	case string:
+		if subpath == "." {
+			return []string{value}
+		}
+		return nil
+	case []interface{}:
+		var targets []string
+		for _, item := range value {
+			targets = append(targets, exportTargets(item, subpath)...)
+		}
+		return targets
+	case map[string]interface{}:
+		// A map of subpaths, or of conditions for the "." subpath
+		isSubpaths := false
+		for key := range value {
+			if strings.HasPrefix(key, ".") || strings.HasPrefix(key, "#") {
+				isSubpaths = true
+			}
+			break
+		}
+		if !isSubpaths {
+			if subpath != "." {
+				return nil
+			}
+			return conditionTargets(value, "")
+		}
+
+		if entry, ok := value[subpath]; ok {
+			return conditionTargets(entry, "")
+		}
+		// Subpath patterns: the longest prefix before the wildcard wins
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+		for _, key := range keys {
+			prefix, suffix, hasStar := strings.Cut(key, "*")
+			if hasStar && strings.HasPrefix(subpath, prefix) && strings.HasSuffix(subpath, suffix) && len(subpath) >= len(prefix)+len(suffix) {
+				return conditionTargets(value[key], subpath[len(prefix):len(subpath)-len(suffix)])
+			}
+		}
+	}
+	return nil
+}
+
+// conditionTargets returns the targets of an export entry, trying conditions in the order
+// of jsExportConditions and substituting wildcard for the * of pattern targets
+func conditionTargets(entry interface{}, wildcard string) []string {
+	switch value := entry.(type) {
+	case string:
+		return []string{strings.ReplaceAll(value, "*", wildcard)}
+	case []interface{}:
+		var targets []string
+		for _, item := range value {
+			targets = append(targets, conditionTargets(item, wildcard)...)
+		}
+		return targets
+	case map[string]interface{}:
+		var targets []string
+		for _, condition := range jsExportConditions {
+			if nested, ok := value[condition]; ok {
+				targets = append(targets, conditionTargets(nested, wildcard)...)
+			}
+		}
+		return targets
+	}
+	return nil
+}
+
+// jsPackageName returns the package a bare specifier imports from: its first element,
+// or its first two for scoped packages
+func jsPackageName(spec string) string {
+	parts := strings.SplitN(spec, "/", 3)
+	if strings.HasPrefix(spec, "@") && len(parts) >= 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// dependency describes a bare specifier that does not resolve within the repository, or
+// reports false for specifiers that name no package, such as unresolved aliases
+func (r *jsResolver) dependency(fromFile, spec string) (models.ExternalDependency, bool) {
+	if strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasPrefix(spec, "#") || strings.Contains(spec, "://") {
+		return models.ExternalDependency{}, false
+	}
+
+	if builtin, ok := strings.CutPrefix(spec, "node:"); ok || nodeBuiltins[jsPackageName(spec)] {
+		return models.ExternalDependency{Name: jsPackageName(builtin), Kind: models.DependencyStdlib}, true
+	}
+
+	name := jsPackageName(spec)
+	if !jsPackageNameRegex.MatchString(name) || jsPackageNameRegex.FindString(name) != name {
+		return models.ExternalDependency{}, false
+	}
+	dep := models.ExternalDependency{Name: name, Kind: models.DependencyPackage}
+
+	// Take the version from the nearest package.json that lists it
+	for dir := repoDir(fromFile); ; dir = repoDir(dir) {
+		if pkg, ok := r.manifests[dir]; ok {
+			if version, ok := pkg.Dependencies[name]; ok {
+				dep.Version = version
+				break
+			}
+		}
+		if dir == "" {
+			break
+		}
+	}
+	return dep, true
+}
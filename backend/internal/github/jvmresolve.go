@@ -0,0 +1,105 @@
+// internal/github/jvmresolve.go
+package github
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+var (
+	// package com.example.app; in Java, package com.example.app in Kotlin
+	jvmPackageRegex = regexp.MustCompile("(?m)^\\s*package\\s+([\\w.`]+)")
+
+	// import com.example.Type;, import static com.example.Type.member;, import com.example.*
+	// and Kotlin's import com.example.Type as Alias
+	jvmImportRegex = regexp.MustCompile("(?m)^\\s*import\\s+(?:static\\s+)?([\\w`]+(?:\\.[\\w`]+)*(?:\\.\\*)?)")
+)
+
+// jvmStdlibPrefixes are the package prefixes of the JDK and the Kotlin standard library
+var jvmStdlibPrefixes = []string{"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin."}
+
+// jvmImports maps Java and Kotlin packages, read from package declarations, to the files
+// declaring them
+type jvmImports struct {
+	packages map[string][]string // Package -> files
+	types    map[string][]string // Package.FileName -> files
+}
+
+func newJVMImports(ic *importContext) importResolver {
+	return &jvmImports{
+		packages: make(map[string][]string),
+		types:    make(map[string][]string),
+	}
+}
+
+func (j *jvmImports) index(filePath, content string) {
+	pkg := ""
+	if match := jvmPackageRegex.FindStringSubmatch(content); match != nil {
+		pkg = strings.ReplaceAll(match[1], "`", "")
+	}
+	j.packages[pkg] = append(j.packages[pkg], filePath)
+
+	// Java names files after their public class; Kotlin usually does for its main class
+	name := strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	typeName := name
+	if pkg != "" {
+		typeName = pkg + "." + name
+	}
+	j.types[typeName] = append(j.types[typeName], filePath)
+}
+
+func (j *jvmImports) extract(filePath, content string) []string {
+	var imports []string
+	for _, match := range jvmImportRegex.FindAllStringSubmatch(stripJSComments(content), -1) {
+		imports = append(imports, strings.ReplaceAll(match[1], "`", ""))
+	}
+	return imports
+}
+
+func (j *jvmImports) resolve(filePath, imp string) []string {
+	if pkg, ok := strings.CutSuffix(imp, ".*"); ok {
+		// Every file of the package, or the nested types of a class
+		if files, ok := j.packages[pkg]; ok {
+			return files
+		}
+		return j.types[pkg]
+	}
+
+	// The longest prefix naming a type: imports may name nested types and static members
+	for name := imp; strings.Contains(name, "."); name = name[:strings.LastIndex(name, ".")] {
+		if files, ok := j.types[name]; ok {
+			return files
+		}
+	}
+
+	// Kotlin imports top-level functions and properties by package
+	if i := strings.LastIndex(imp, "."); i > 0 {
+		return j.packages[imp[:i]]
+	}
+	return nil
+}
+
+func (j *jvmImports) dependency(filePath, imp string) (models.ExternalDependency, bool) {
+	imp = strings.TrimSuffix(imp, ".*")
+	for _, prefix := range jvmStdlibPrefixes {
+		if strings.HasPrefix(imp+".", prefix) {
+			return models.ExternalDependency{Name: strings.TrimSuffix(prefix, "."), Kind: models.DependencyStdlib}, true
+		}
+	}
+
+	// Libraries are named by the first two elements of their reverse-domain packages
+	parts := strings.Split(imp, ".")
+	if len(parts) < 2 {
+		return models.ExternalDependency{}, false
+	}
+	name := parts[0] + "." + parts[1]
+	for pkg := range j.packages {
+		if pkg == name || strings.HasPrefix(pkg, name+".") {
+			return models.ExternalDependency{}, false // A package of the repository
+		}
+	}
+	return models.ExternalDependency{Name: name, Kind: models.DependencyPackage}, true
+}
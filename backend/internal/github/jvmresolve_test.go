@@ -0,0 +1,48 @@
+package github
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// jvmFixture is a Java and Kotlin project whose packages mostly follow their directories,
+// with one file declaring a package outside its directory
+var jvmFixture = map[string]string{
+	"src/main/java/com/example/app/App.java":        "package com.example.app;\n\nimport com.example.util.Strings;\nimport com.example.model.*;\nimport static com.example.util.Strings.slugify;\n// import com.example.hidden.Gone;\nimport java.util.List;\n",
+	"src/main/java/com/example/util/Strings.java":   "package com.example.util;\n",
+	"src/main/java/com/example/model/User.java":     "package com.example.model;\n",
+	"src/main/java/com/example/model/Group.java":    "package com.example.model;\n",
+	"legacy/Helper.java":                            "package com.example.util;\n",
+	"src/main/kotlin/com/example/ext/Extensions.kt": "package com.example.ext\n\nimport com.example.util.Strings as S\n",
+}
+
+func TestJVMExtract(t *testing.T) {
+	resolver := newFixtureResolver(t, newJVMImports, jvmFixture)
+	got := resolver.extract("", jvmFixture["src/main/java/com/example/app/App.java"])
+	want := []string{"com.example.util.Strings", "com.example.model.*", "com.example.util.Strings.slugify", "java.util.List"}
+	if !slices.Equal(got, want) {
+		t.Errorf("extract = %v, want %v", got, want)
+	}
+}
+
+func TestJVMResolve(t *testing.T) {
+	resolver := newFixtureResolver(t, newJVMImports, jvmFixture)
+	const app = "src/main/java/com/example/app/App.java"
+	checkResolve(t, resolver, []resolveCase{
+		// Types resolve to the file named after them in the declared package
+		{app, "com.example.util.Strings", []string{"src/main/java/com/example/util/Strings.java"}},
+		{app, "com.example.util.Strings.slugify", []string{"src/main/java/com/example/util/Strings.java"}},
+		// Wildcards take every file declaring the package, wherever it lives
+		{app, "com.example.model.*", []string{"src/main/java/com/example/model/Group.java", "src/main/java/com/example/model/User.java"}},
+		{app, "com.example.util.*", []string{"legacy/Helper.java", "src/main/java/com/example/util/Strings.java"}},
+		// Kotlin top-level functions resolve to their package
+		{app, "com.example.ext.shout", []string{"src/main/kotlin/com/example/ext/Extensions.kt"}},
+		{app, "java.util.List", nil},
+	})
+
+	checkDependency(t, resolver, app, "java.util.List", models.ExternalDependency{Name: "java", Kind: models.DependencyStdlib})
+	checkDependency(t, resolver, app, "org.junit.Test", models.ExternalDependency{Name: "org.junit", Kind: models.DependencyPackage})
+	checkDependency(t, resolver, app, "com.example.missing.Thing", models.ExternalDependency{})
+}
@@ -0,0 +1,220 @@
+// internal/github/propose.go
+package github
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// PushMode selects how a change reaches its target branch
+type PushMode string
+
+const (
+	// PushModeDirect commits straight to the target branch
+	PushModeDirect PushMode = "direct"
+	// PushModePullRequest commits to a feature branch and opens a pull request against the target
+	PushModePullRequest PushMode = "pull_request"
+)
+
+// pullRequestBranchPrefix namespaces the feature branches created for pull requests
+const pullRequestBranchPrefix = "github-agent/"
+
+// maxBranchSlug bounds the length of generated feature branch names
+const maxBranchSlug = 60
+
+// ParsePushMode validates a requested push mode. Empty leaves the mode to the repository's
+// agent configuration, see AgentConfig.PushModeFor, and is direct otherwise.
+func ParsePushMode(mode string) (PushMode, error) {
+	switch PushMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case "":
+		return "", nil
+	case PushModeDirect:
+		return PushModeDirect, nil
+	case PushModePullRequest:
+		return PushModePullRequest, nil
+	default:
+		return "", common.NewError(fmt.Sprintf("unsupported push mode %q; expected %q or %q", mode, PushModeDirect, PushModePullRequest))
+	}
+}
+
+// PullRequestBranch returns the feature branch for an operation against a base branch, e.g.
+// "github-agent/main-readme" for PullRequestBranch("main", "readme"). The name only depends on
+// its parts, so re-running an operation against the same base reuses the branch and its pull request.
+func PullRequestBranch(base string, parts ...string) string {
+	key := strings.ToLower(strings.Join(append([]string{base}, parts...), "-"))
+
+	var slug strings.Builder
+	dash := false
+	for _, r := range key {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' {
+			slug.WriteRune(r)
+			dash = false
+		} else if !dash && slug.Len() > 0 {
+			slug.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.Trim(slug.String(), "-.")
+
+	// Long names are cut and suffixed with a hash so distinct operations keep distinct branches
+	if len(name) > maxBranchSlug {
+		sum := sha1.Sum([]byte(key))
+		name = strings.Trim(name[:maxBranchSlug-9], "-.") + "-" + hex.EncodeToString(sum[:4])
+	}
+	if name == "" {
+		name = "update"
+	}
+	return pullRequestBranchPrefix + name
+}
+
+// PullRequestOptions describes the pull request a change is proposed in
+type PullRequestOptions struct {
+	HeadBranch string // Feature branch, see PullRequestBranch
+	Title      string
+	Body       string
+}
+
+// CommitPullRequest commits the staged changes to a feature branch and opens a pull request from it
+// against the builder's branch. If the feature branch already has an open pull request the commit
+// is added to it and its title and body are updated, so re-runs update the same pull request.
+// A feature branch with an open pull request into another base is left alone, and a change that
+// matches the base opens nothing and leaves no branch behind.
+func (b *CommitBuilder) CommitPullRequest(ctx context.Context, message string, opts PullRequestOptions) (*CommitResult, error) {
+	if opts.HeadBranch == "" || opts.HeadBranch == b.branch {
+		return nil, common.NewError("pull request needs a feature branch other than " + b.branch)
+	}
+	git := b.client.client.Git
+
+	// Resolve the base the feature branch starts from
+	baseRef, _, err := git.GetRef(ctx, b.owner, b.repo, "heads/"+b.branch)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get branch "+b.branch)
+	}
+	baseSHA := baseRef.GetObject().GetSHA()
+	if b.expectedSHA != "" && baseSHA != b.expectedSHA {
+		return nil, common.WrapError(ErrBranchMoved, fmt.Sprintf("%s is at %s, expected %s", b.branch, shortSHA(baseSHA), shortSHA(b.expectedSHA)))
+	}
+
+	existing, err := b.openPullRequest(ctx, opts.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.GetBase().GetRef() != b.branch {
+		return nil, common.NewError(fmt.Sprintf("branch %s already has open pull request #%d into %s", opts.HeadBranch, existing.GetNumber(), existing.GetBase().GetRef()))
+	}
+	if err := b.prepareHeadBranch(ctx, opts.HeadBranch, baseSHA, existing != nil); err != nil {
+		return nil, err
+	}
+
+	feature := &CommitBuilder{
+		client:  b.client,
+		owner:   b.owner,
+		repo:    b.repo,
+		branch:  opts.HeadBranch,
+		changes: b.changes,
+	}
+	result, err := feature.Commit(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = message
+	}
+
+	var pr *github.PullRequest
+	switch {
+	case existing != nil:
+		pr, _, err = b.client.client.PullRequests.Edit(ctx, b.owner, b.repo, existing.GetNumber(), &github.PullRequest{
+			Title: github.String(title),
+			Body:  github.String(opts.Body),
+		})
+		if err != nil {
+			return nil, common.WrapError(err, "failed to update pull request")
+		}
+	case result.SHA == baseSHA:
+		// Nothing differs from the base, so there is nothing to review and the branch is not kept
+		if _, err := b.client.client.Git.DeleteRef(ctx, b.owner, b.repo, "heads/"+opts.HeadBranch); err != nil {
+			return nil, common.WrapError(err, "failed to delete branch "+opts.HeadBranch)
+		}
+		result.Branch = b.branch
+		return result, nil
+	default:
+		pr, _, err = b.client.client.PullRequests.Create(ctx, b.owner, b.repo, &github.NewPullRequest{
+			Title: github.String(title),
+			Head:  github.String(opts.HeadBranch),
+			Base:  github.String(b.branch),
+			Body:  github.String(opts.Body),
+		})
+		if err != nil {
+			return nil, common.WrapError(err, "failed to create pull request")
+		}
+	}
+
+	result.PullRequestNumber = pr.GetNumber()
+	result.PullRequestURL = pr.GetHTMLURL()
+	return result, nil
+}
+
+// openPullRequest returns the open pull request from head, preferring one into the builder's
+// branch, or nil. The head is checked against every base, since forcing it would rewrite
+// a pull request into any of them.
+func (b *CommitBuilder) openPullRequest(ctx context.Context, head string) (*github.PullRequest, error) {
+	prs, _, err := b.client.client.PullRequests.List(ctx, b.owner, b.repo, &github.PullRequestListOptions{
+		State:       "open",
+		Head:        b.owner + ":" + head,
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list pull requests")
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	for _, pr := range prs {
+		if pr.GetBase().GetRef() == b.branch {
+			return pr, nil
+		}
+	}
+	return prs[0], nil
+}
+
+// prepareHeadBranch creates the feature branch at baseSHA. A branch left over from a closed or
+// merged pull request is reset to baseSHA so the new pull request only holds the new change.
+func (b *CommitBuilder) prepareHeadBranch(ctx context.Context, head, baseSHA string, hasOpenPR bool) error {
+	git := b.client.client.Git
+
+	ref, resp, err := git.GetRef(ctx, b.owner, b.repo, "heads/"+head)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return common.WrapError(err, "failed to get branch "+head)
+		}
+		_, _, err = git.CreateRef(ctx, b.owner, b.repo, &github.Reference{
+			Ref:    github.String("refs/heads/" + head),
+			Object: &github.GitObject{SHA: github.String(baseSHA)},
+		})
+		if err != nil {
+			return common.WrapError(err, "failed to create branch "+head)
+		}
+		return nil
+	}
+
+	if hasOpenPR || ref.GetObject().GetSHA() == baseSHA {
+		return nil
+	}
+
+	// The branch belongs to the agent, so forcing it is safe
+	ref.Object = &github.GitObject{SHA: github.String(baseSHA)}
+	if _, _, err := git.UpdateRef(ctx, b.owner, b.repo, ref, true); err != nil {
+		return common.WrapError(err, "failed to reset branch "+head)
+	}
+	return nil
+}
@@ -2,10 +2,9 @@ package github
 
 import (
 	"context"
-	"fmt"
-	"strings"
 
 	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -45,6 +44,30 @@ func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number
 		return nil, common.WrapError(err, "failed to get pull request")
 	}
 
+	fileChanges, err := c.ListPullRequestFiles(ctx, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PullRequest{
+		Number:       *pr.Number,
+		Title:        *pr.Title,
+		Description:  *pr.Body,
+		State:        *pr.State,
+		User:         *pr.User.Login,
+		CreatedAt:    pr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:    pr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Commits:      *pr.Commits,
+		Additions:    *pr.Additions,
+		Deletions:    *pr.Deletions,
+		ChangedFiles: *pr.ChangedFiles,
+		Files:        fileChanges,
+		URL:          *pr.HTMLURL,
+	}, nil
+}
+
+// ListPullRequestFiles lists the files changed in a pull request, with their patches
+func (c *Client) ListPullRequestFiles(ctx context.Context, owner, repo string, number int) ([]FileChange, error) {
 	// Get the files changed in this PR
 	opts := &github.ListOptions{
 		PerPage: 100,
@@ -83,106 +106,15 @@ func (c *Client) GetPullRequest(ctx context.Context, owner, repo string, number
 		}
 		
 		// Detect file type
-		fileChange.ContentType = detectContentType(fileChange.Filename)
+		fileChange.ContentType = utils.LanguageID(fileChange.Filename, "")
 		
 		fileChanges = append(fileChanges, fileChange)
 	}
 
-	return &PullRequest{
-		Number:       *pr.Number,
-		Title:        *pr.Title,
-		Description:  *pr.Body,
-		State:        *pr.State,
-		User:         *pr.User.Login,
-		CreatedAt:    pr.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:    pr.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		Commits:      *pr.Commits,
-		Additions:    *pr.Additions,
-		Deletions:    *pr.Deletions,
-		ChangedFiles: *pr.ChangedFiles,
-		Files:        fileChanges,
-		URL:          *pr.HTMLURL,
-	}, nil
-}
-
-// detectContentType tries to determine the content type of a file based on its extension
-func detectContentType(filename string) string {
-	if !strings.Contains(filename, ".") {
-		return "unknown"
-	}
-	
-	ext := strings.ToLower(filename[strings.LastIndex(filename, ".")+1:])
-	
-	switch ext {
-	case "go":
-		return "go"
-	case "js", "jsx", "ts", "tsx":
-		return "javascript"
-	case "py":
-		return "python"
-	case "java":
-		return "java"
-	case "c", "cpp", "h", "hpp":
-		return "c++"
-	case "cs":
-		return "c#"
-	case "php":
-		return "php"
-	case "rb":
-		return "ruby"
-	case "html", "htm":
-		return "html"
-	case "css":
-		return "css"
-	case "json":
-		return "json"
-	case "yml", "yaml":
-		return "yaml"
-	case "md":
-		return "markdown"
-	case "sql":
-		return "sql"
-	case "sh", "bash":
-		return "shell"
-	case "xml":
-		return "xml"
-	case "txt":
-		return "text"
-	default:
-		return "other"
-	}
+	return fileChanges, nil
 }
 
-// ParsePullRequestURL extracts owner, repo, and PR number from a GitHub pull request URL
+// ParsePullRequestURL extracts owner, repo, and PR number from a github.com pull request URL
 func ParsePullRequestURL(url string) (string, string, int, error) {
-	// Match patterns like:
-	// https://github.com/owner/repo/pull/123
-	// https://github.com/owner/repo/pulls/123
-	
-	parts := strings.Split(url, "/")
-	if len(parts) < 7 {
-		return "", "", 0, common.NewError("invalid GitHub pull request URL format")
-	}
-	
-	// Check if it contains github.com
-	if !strings.Contains(parts[2], "github.com") {
-		return "", "", 0, common.NewError("URL must be a GitHub pull request URL")
-	}
-	
-	// Check if it contains pull or pulls
-	if parts[5] != "pull" && parts[5] != "pulls" {
-		return "", "", 0, common.NewError("URL must be a GitHub pull request URL")
-	}
-	
-	owner := parts[3]
-	repo := parts[4]
-	
-	// Parse PR number
-	var prNumber int
-	_, err := fmt.Sscanf(parts[6], "%d", &prNumber)
-	if err != nil {
-		return "", "", 0, common.WrapError(err, "invalid pull request number")
-	}
-	
-	return owner, repo, prNumber, nil
+	return DefaultHosts().ParsePullRequestURL(url)
 }
\ No newline at end of file
@@ -0,0 +1,232 @@
+// internal/github/pyresolve.go
+package github
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+var (
+	// import a.b, c as d
+	pyImportLineRegex = regexp.MustCompile(`^import\s+(.+)$`)
+
+	// from .a.b import c, d and from . import (c, d)
+	pyFromLineRegex = regexp.MustCompile(`^from\s+(\.*[\w.]*)\s+import\s+(.+)$`)
+)
+
+// pythonStdlib are the top-level modules of the Python standard library
+var pythonStdlib = map[string]bool{
+	"__future__": true, "abc": true, "argparse": true, "array": true, "ast": true, "asyncio": true,
+	"atexit": true, "base64": true, "bisect": true, "builtins": true, "bz2": true, "calendar": true,
+	"cmath": true, "code": true, "codecs": true, "collections": true, "colorsys": true,
+	"concurrent": true, "configparser": true, "contextlib": true, "contextvars": true, "copy": true,
+	"copyreg": true, "csv": true, "ctypes": true, "dataclasses": true, "datetime": true, "dbm": true,
+	"decimal": true, "difflib": true, "dis": true, "doctest": true, "email": true, "encodings": true,
+	"enum": true, "errno": true, "faulthandler": true, "fcntl": true, "filecmp": true, "fileinput": true,
+	"fnmatch": true, "fractions": true, "ftplib": true, "functools": true, "gc": true, "getopt": true,
+	"getpass": true, "gettext": true, "glob": true, "graphlib": true, "gzip": true, "hashlib": true,
+	"heapq": true, "hmac": true, "html": true, "http": true, "imaplib": true, "importlib": true,
+	"inspect": true, "io": true, "ipaddress": true, "itertools": true, "json": true, "keyword": true,
+	"linecache": true, "locale": true, "logging": true, "lzma": true, "mailbox": true, "marshal": true,
+	"math": true, "mimetypes": true, "mmap": true, "multiprocessing": true, "netrc": true,
+	"numbers": true, "operator": true, "os": true, "pathlib": true, "pdb": true, "pickle": true,
+	"pkgutil": true, "platform": true, "plistlib": true, "poplib": true, "posixpath": true,
+	"pprint": true, "profile": true, "pstats": true, "pty": true, "queue": true, "quopri": true,
+	"random": true, "re": true, "readline": true, "reprlib": true, "resource": true, "runpy": true,
+	"sched": true, "secrets": true, "select": true, "selectors": true, "shelve": true, "shlex": true,
+	"shutil": true, "signal": true, "site": true, "smtplib": true, "socket": true,
+	"socketserver": true, "sqlite3": true, "ssl": true, "stat": true, "statistics": true,
+	"string": true, "stringprep": true, "struct": true, "subprocess": true, "symtable": true,
+	"sys": true, "sysconfig": true, "syslog": true, "tarfile": true, "tempfile": true,
+	"termios": true, "textwrap": true, "threading": true, "time": true, "timeit": true,
+	"tkinter": true, "token": true, "tokenize": true, "tomllib": true, "trace": true,
+	"traceback": true, "tracemalloc": true, "tty": true, "turtle": true, "types": true,
+	"typing": true, "unicodedata": true, "unittest": true, "urllib": true, "uuid": true,
+	"venv": true, "warnings": true, "wave": true, "weakref": true, "webbrowser": true,
+	"wsgiref": true, "xml": true, "xmlrpc": true, "zipapp": true, "zipfile": true, "zipimport": true,
+	"zlib": true, "zoneinfo": true,
+}
+
+// extractPythonImports returns the modules a Python file imports, as dotted names with
+// their leading dots. For from imports it also returns each imported name under the module,
+// since the name may be a submodule.
+func extractPythonImports(content string) []string {
+	seen := make(map[string]bool)
+	var imports []string
+	add := func(module string) {
+		if module != "" && !seen[module] {
+			seen[module] = true
+			imports = append(imports, module)
+		}
+	}
+
+	for _, statement := range pythonStatements(content) {
+		if match := pyImportLineRegex.FindStringSubmatch(statement); match != nil {
+			for _, name := range strings.Split(match[1], ",") {
+				module, _, _ := strings.Cut(strings.TrimSpace(name), " ")
+				add(module)
+			}
+			continue
+		}
+		if match := pyFromLineRegex.FindStringSubmatch(statement); match != nil {
+			module := match[1]
+			add(module)
+			names := strings.Trim(strings.TrimSpace(match[2]), "()")
+			for _, name := range strings.Split(names, ",") {
+				name, _, _ = strings.Cut(strings.TrimSpace(name), " ")
+				if name == "" || name == "*" {
+					continue
+				}
+				if strings.HasSuffix(module, ".") {
+					add(module + name)
+				} else {
+					add(module + "." + name)
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// pythonStatements returns the import statements of Python source, one per string, with
+// comments removed and parenthesized and backslash continuations joined
+func pythonStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	depth := 0
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if current.Len() == 0 && !strings.HasPrefix(line, "import ") && !strings.HasPrefix(line, "from ") {
+			continue
+		}
+
+		continued := strings.HasSuffix(line, "\\")
+		line = strings.TrimSuffix(line, "\\")
+		current.WriteString(line)
+		current.WriteByte(' ')
+		depth += strings.Count(line, "(") - strings.Count(line, ")")
+
+		if depth <= 0 && !continued {
+			statements = append(statements, strings.Join(strings.Fields(current.String()), " "))
+			current.Reset()
+			depth = 0
+		}
+	}
+	return statements
+}
+
+// pythonImports resolves Python modules to files, following relative imports, packages
+// with __init__.py and src layouts
+type pythonImports struct {
+	ic    *importContext
+	roots []string // Directories absolute imports resolve against
+}
+
+// newPythonImports finds the source roots of the repository: the root, and each directory
+// with project metadata, along with their src directories
+func newPythonImports(ic *importContext) importResolver {
+	p := &pythonImports{ic: ic}
+	seen := make(map[string]bool)
+	addRoot := func(dir string) {
+		if !seen[dir] && (dir == "" || ic.existing[dir] == "dir") {
+			seen[dir] = true
+			p.roots = append(p.roots, dir)
+		}
+	}
+
+	addRoot("")
+	addRoot("src")
+	for _, file := range ic.files {
+		switch path.Base(file.Path) {
+		case "setup.py", "setup.cfg", "pyproject.toml":
+			dir := repoDir(file.Path)
+			addRoot(dir)
+			addRoot(path.Join(dir, "src"))
+		}
+	}
+	return p
+}
+
+func (p *pythonImports) index(filePath, content string) {}
+
+func (p *pythonImports) extract(filePath, content string) []string {
+	return extractPythonImports(content)
+}
+
+func (p *pythonImports) resolve(filePath, imp string) []string {
+	dots := len(imp) - len(strings.TrimLeft(imp, "."))
+	modulePath := strings.ReplaceAll(imp[dots:], ".", "/")
+
+	if dots > 0 {
+		// One dot is the file's own package, each further dot its parent
+		dir := repoDir(filePath)
+		for i := 1; i < dots; i++ {
+			if dir == "" {
+				return nil
+			}
+			dir = repoDir(dir)
+		}
+		if file, ok := p.moduleFile(dir, modulePath); ok {
+			return []string{file}
+		}
+		return nil
+	}
+
+	for _, root := range p.rootsFor(filePath) {
+		if file, ok := p.moduleFile(root, modulePath); ok {
+			return []string{file}
+		}
+	}
+	return nil
+}
+
+// moduleFile returns the file of a module under a directory: a module file or a package
+func (p *pythonImports) moduleFile(dir, modulePath string) (string, bool) {
+	if modulePath == "" {
+		return p.ic.firstFile(path.Join(dir, "__init__.py"))
+	}
+	base := path.Join(dir, modulePath)
+	return p.ic.firstFile(base+".py", base+".pyi", path.Join(base, "__init__.py"))
+}
+
+// rootsFor returns the roots absolute imports of a file resolve against. The parent of the
+// file's outermost package is one too, for packages outside the known roots.
+func (p *pythonImports) rootsFor(filePath string) []string {
+	roots := make([]string, 0, len(p.roots)+1)
+	return append(append(roots, p.roots...), p.packageRoot(filePath))
+}
+
+// packageRoot returns the directory above the outermost package, one with __init__.py,
+// containing a file
+func (p *pythonImports) packageRoot(filePath string) string {
+	dir := repoDir(filePath)
+	for dir != "" && p.ic.isFile(path.Join(dir, "__init__.py")) {
+		dir = repoDir(dir)
+	}
+	return dir
+}
+
+func (p *pythonImports) dependency(filePath, imp string) (models.ExternalDependency, bool) {
+	if strings.HasPrefix(imp, ".") {
+		return models.ExternalDependency{}, false
+	}
+	top, _, _ := strings.Cut(imp, ".")
+
+	// Names imported from a package of the repository are its attributes
+	for _, root := range p.rootsFor(filePath) {
+		if _, ok := p.moduleFile(root, top); ok || p.ic.existing[path.Join(root, top)] == "dir" {
+			return models.ExternalDependency{}, false
+		}
+	}
+
+	if pythonStdlib[top] {
+		return models.ExternalDependency{Name: top, Kind: models.DependencyStdlib}, true
+	}
+	return models.ExternalDependency{Name: top, Kind: models.DependencyPackage}, true
+}
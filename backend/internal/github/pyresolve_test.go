@@ -0,0 +1,58 @@
+package github
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// pythonFixture is a src-layout project with nested packages, a script outside the package
+// and a package outside the source roots
+var pythonFixture = map[string]string{
+	"pyproject.toml":             "[project]\nname = \"app\"\n",
+	"src/app/__init__.py":        "",
+	"src/app/main.py":            "from . import utils\nfrom .models import (\n    User,  # the account\n    Group as G,\n)\nimport os.path, requests\n",
+	"src/app/utils.py":           "",
+	"src/app/models/__init__.py": "",
+	"src/app/models/user.py":     "",
+	"src/app/api/__init__.py":    "",
+	"src/app/api/routes.py":      "from ..models import user\nfrom . import *\n",
+	"tools/release.py":           "import app.utils\n",
+	"libs/pkg/__init__.py":       "",
+	"libs/pkg/a.py":              "",
+	"libs/pkg/b.py":              "import pkg.a\n",
+	"top.py":                     "from ... import nothing\n",
+}
+
+func TestExtractPythonImports(t *testing.T) {
+	got := extractPythonImports(pythonFixture["src/app/main.py"])
+	want := []string{".", ".utils", ".models", ".models.User", ".models.Group", "os.path", "requests"}
+	if !slices.Equal(got, want) {
+		t.Errorf("extractPythonImports = %v, want %v", got, want)
+	}
+}
+
+func TestPythonResolve(t *testing.T) {
+	resolver := newFixtureResolver(t, newPythonImports, pythonFixture)
+	checkResolve(t, resolver, []resolveCase{
+		// Relative imports start at the file's package, one parent per extra dot
+		{"src/app/main.py", ".utils", []string{"src/app/utils.py"}},
+		{"src/app/main.py", ".models", []string{"src/app/models/__init__.py"}},
+		{"src/app/main.py", ".", []string{"src/app/__init__.py"}},
+		{"src/app/api/routes.py", "..models.user", []string{"src/app/models/user.py"}},
+		{"src/app/api/routes.py", ".", []string{"src/app/api/__init__.py"}},
+		{"top.py", "...nothing", nil},
+		// Names imported from a module are attributes unless they are submodules
+		{"src/app/main.py", ".models.User", nil},
+		// Absolute imports resolve against the src layout and the parent of the outermost package
+		{"tools/release.py", "app.utils", []string{"src/app/utils.py"}},
+		{"libs/pkg/b.py", "pkg.a", []string{"libs/pkg/a.py"}},
+		{"src/app/main.py", "requests", nil},
+	})
+
+	checkDependency(t, resolver, "src/app/main.py", "os.path", models.ExternalDependency{Name: "os", Kind: models.DependencyStdlib})
+	checkDependency(t, resolver, "src/app/main.py", "requests", models.ExternalDependency{Name: "requests", Kind: models.DependencyPackage})
+	checkDependency(t, resolver, "tools/release.py", "app.missing", models.ExternalDependency{})
+	checkDependency(t, resolver, "src/app/main.py", ".models.User", models.ExternalDependency{})
+}
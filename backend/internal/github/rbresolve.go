@@ -0,0 +1,99 @@
+// internal/github/rbresolve.go
+package github
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// require 'x', require_relative 'x' and load 'x.rb', with or without parentheses
+var rbRequireRegex = regexp.MustCompile(`(?m)^\s*(require|require_relative|load)\s*\(?\s*['"]([^'"]+)['"]`)
+
+// rubyStdlib are common libraries of the Ruby standard library
+var rubyStdlib = map[string]bool{
+	"base64": true, "benchmark": true, "bigdecimal": true, "cgi": true, "csv": true, "date": true,
+	"digest": true, "erb": true, "etc": true, "fileutils": true, "find": true, "forwardable": true,
+	"io": true, "json": true, "logger": true, "net": true, "observer": true, "open3": true,
+	"openssl": true, "optparse": true, "ostruct": true, "pathname": true, "pp": true, "prettyprint": true,
+	"securerandom": true, "set": true, "shellwords": true, "singleton": true, "socket": true,
+	"stringio": true, "strscan": true, "tempfile": true, "time": true, "timeout": true, "tmpdir": true,
+	"uri": true, "yaml": true, "zlib": true,
+}
+
+// rubyImports resolves Ruby requires against the requiring file and the lib directories
+// of the repository
+type rubyImports struct {
+	ic       *importContext
+	loadPath []string // Directories require searches, like $LOAD_PATH
+}
+
+// newRubyImports puts the lib directory of the repository and of each gem on the load path
+func newRubyImports(ic *importContext) importResolver {
+	r := &rubyImports{ic: ic}
+	seen := make(map[string]bool)
+	for _, file := range ic.files {
+		dir := repoDir(file.Path)
+		if file.Type != "file" || (path.Base(file.Path) != "Gemfile" && path.Ext(file.Path) != ".gemspec") || seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if lib := path.Join(dir, "lib"); ic.existing[lib] == "dir" {
+			r.loadPath = append(r.loadPath, lib)
+		}
+	}
+	if !seen[""] && ic.existing["lib"] == "dir" {
+		r.loadPath = append(r.loadPath, "lib")
+	}
+	return r
+}
+
+func (r *rubyImports) index(filePath, content string) {}
+
+// extract returns requires as relative paths prefixed with ./ and others as written
+func (r *rubyImports) extract(filePath, content string) []string {
+	var imports []string
+	for _, match := range rbRequireRegex.FindAllStringSubmatch(content, -1) {
+		target := match[2]
+		if match[1] == "require_relative" && !strings.HasPrefix(target, ".") {
+			target = "./" + target
+		}
+		imports = append(imports, target)
+	}
+	return imports
+}
+
+func (r *rubyImports) resolve(filePath, imp string) []string {
+	withExt := func(base string) []string {
+		if path.Ext(base) == ".rb" {
+			return []string{base}
+		}
+		return []string{base + ".rb", base}
+	}
+
+	if strings.HasPrefix(imp, ".") {
+		if file, ok := r.ic.firstFile(withExt(path.Join(repoDir(filePath), imp))...); ok {
+			return []string{file}
+		}
+		return nil
+	}
+	for _, dir := range append([]string{repoDir(filePath)}, r.loadPath...) {
+		if file, ok := r.ic.firstFile(withExt(path.Join(dir, imp))...); ok {
+			return []string{file}
+		}
+	}
+	return nil
+}
+
+func (r *rubyImports) dependency(filePath, imp string) (models.ExternalDependency, bool) {
+	if strings.HasPrefix(imp, ".") || strings.HasPrefix(imp, "/") {
+		return models.ExternalDependency{}, false
+	}
+	name, _, _ := strings.Cut(strings.TrimSuffix(imp, ".rb"), "/")
+	if rubyStdlib[name] {
+		return models.ExternalDependency{Name: name, Kind: models.DependencyStdlib}, true
+	}
+	return models.ExternalDependency{Name: name, Kind: models.DependencyPackage}, true
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// rubyFixture is a gem at the root with an application beside it and a second gem
+var rubyFixture = map[string]string{
+	"Gemfile":                   "source \"https://rubygems.org\"\n",
+	"lib/mygem.rb":              "require 'mygem/version'\nrequire_relative 'mygem/parser'\nrequire(\"json\")\n",
+	"lib/mygem/version.rb":      "",
+	"lib/mygem/parser.rb":       "",
+	"app/models/user.rb":        "require_relative '../helpers/format'\n",
+	"app/helpers/format.rb":     "",
+	"gems/tool/tool.gemspec":    "",
+	"gems/tool/lib/tool.rb":     "",
+	"gems/tool/lib/tool/cli.rb": "",
+	"bin/run.rb":                "load 'tool/cli.rb'\n",
+}
+
+func TestRubyExtract(t *testing.T) {
+	resolver := newFixtureResolver(t, newRubyImports, rubyFixture)
+	got := resolver.extract("lib/mygem.rb", rubyFixture["lib/mygem.rb"])
+	want := []string{"mygem/version", "./mygem/parser", "json"}
+	if !slices.Equal(got, want) {
+		t.Errorf("extract = %v, want %v", got, want)
+	}
+}
+
+func TestRubyResolve(t *testing.T) {
+	resolver := newFixtureResolver(t, newRubyImports, rubyFixture)
+	checkResolve(t, resolver, []resolveCase{
+		// require searches the lib directory of each gem
+		{"lib/mygem.rb", "mygem/version", []string{"lib/mygem/version.rb"}},
+		{"bin/run.rb", "tool/cli.rb", []string{"gems/tool/lib/tool/cli.rb"}},
+		{"bin/run.rb", "tool", []string{"gems/tool/lib/tool.rb"}},
+		// require_relative starts at the requiring file
+		{"lib/mygem.rb", "./mygem/parser", []string{"lib/mygem/parser.rb"}},
+		{"app/models/user.rb", "../helpers/format", []string{"app/helpers/format.rb"}},
+		{"lib/mygem.rb", "json", nil},
+	})
+
+	checkDependency(t, resolver, "lib/mygem.rb", "json", models.ExternalDependency{Name: "json", Kind: models.DependencyStdlib})
+	checkDependency(t, resolver, "lib/mygem.rb", "active_support/core_ext", models.ExternalDependency{Name: "active_support", Kind: models.DependencyPackage})
+	checkDependency(t, resolver, "lib/mygem.rb", "./mygem/parser", models.ExternalDependency{})
+}
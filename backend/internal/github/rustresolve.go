@@ -0,0 +1,341 @@
+// internal/github/rustresolve.go
+package github
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+var (
+	// mod name; declares a child module in its own file, unlike mod name { ... }
+	rustModRegex = regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?mod\s+(\w+)\s*;`)
+
+	// use tree; possibly spanning lines
+	rustUseRegex = regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?use\s+([^;]+);`)
+
+	// extern crate name;
+	rustExternCrateRegex = regexp.MustCompile(`(?m)^\s*extern\s+crate\s+(\w+)`)
+
+	// [section] headers and key = value lines of Cargo.toml
+	tomlSectionRegex = regexp.MustCompile(`^\[+([^\]]+)\]+$`)
+	tomlKeyRegex     = regexp.MustCompile(`^([\w-]+)\s*=\s*(.+)$`)
+	tomlVersionRegex = regexp.MustCompile(`version\s*=\s*"([^"]*)"`)
+)
+
+// rustStdCrates are the crates that ship with Rust
+var rustStdCrates = map[string]bool{"std": true, "core": true, "alloc": true, "proc_macro": true, "test": true}
+
+// cargoManifest is a Cargo.toml of the repository
+type cargoManifest struct {
+	Dir          string
+	Name         string            // Crate name, with hyphens as underscores
+	Dependencies map[string]string // Crate name, with hyphens as underscores -> version
+}
+
+// extractRustImports returns the paths a Rust file uses, with use trees expanded, and
+// self::name for each mod name; declaration
+func extractRustImports(content string) []string {
+	content = stripJSComments(content)
+
+	seen := make(map[string]bool)
+	var imports []string
+	add := func(imp string) {
+		if imp != "" && !seen[imp] {
+			seen[imp] = true
+			imports = append(imports, imp)
+		}
+	}
+
+	for _, match := range rustModRegex.FindAllStringSubmatch(content, -1) {
+		add("self::" + match[1])
+	}
+	for _, match := range rustExternCrateRegex.FindAllStringSubmatch(content, -1) {
+		add(match[1])
+	}
+	for _, match := range rustUseRegex.FindAllStringSubmatch(content, -1) {
+		for _, imp := range expandUseTree(strings.Join(strings.Fields(match[1]), " ")) {
+			add(imp)
+		}
+	}
+	return imports
+}
+
+// expandUseTree expands a use tree such as a::{b, c::{self, d as e}, f::*} into the paths
+// it names: a::b, a::c, a::c::d and a::f
+func expandUseTree(tree string) []string {
+	tree = strings.TrimPrefix(strings.TrimSpace(tree), "::")
+
+	open := strings.Index(tree, "{")
+	if open < 0 {
+		tree, _, _ = strings.Cut(tree, " as ")
+		tree = strings.TrimSuffix(strings.TrimSpace(tree), "::*")
+		if tree == "*" {
+			return nil
+		}
+		return []string{tree}
+	}
+	if !strings.HasSuffix(tree, "}") {
+		return nil
+	}
+
+	prefix := strings.TrimSuffix(strings.TrimSpace(tree[:open]), "::")
+	var paths []string
+	for _, item := range splitTopLevel(tree[open+1:len(tree)-1], ',') {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case item == "self" || strings.HasPrefix(item, "self "):
+			paths = append(paths, prefix)
+		default:
+			for _, sub := range expandUseTree(item) {
+				if prefix == "" {
+					paths = append(paths, sub)
+				} else {
+					paths = append(paths, prefix+"::"+sub)
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// splitTopLevel splits s at each sep outside braces
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// rustImports resolves Rust module paths to the files of the modules, following crate
+// roots, mod declarations and the crates of a workspace
+type rustImports struct {
+	ic        *importContext
+	manifests map[string]*cargoManifest // Directory -> Cargo.toml
+	crates    map[string]*cargoManifest // Crate name -> Cargo.toml
+}
+
+// newRustImports reads the Cargo.toml files of the repository
+func newRustImports(ic *importContext) importResolver {
+	r := &rustImports{
+		ic:        ic,
+		manifests: make(map[string]*cargoManifest),
+		crates:    make(map[string]*cargoManifest),
+	}
+	for _, file := range ic.files {
+		if file.Type != "file" || path.Base(file.Path) != "Cargo.toml" {
+			continue
+		}
+		content, ok := ic.read(file.Path)
+		if !ok {
+			continue
+		}
+		manifest := parseCargoManifest(content)
+		manifest.Dir = repoDir(file.Path)
+		r.manifests[manifest.Dir] = manifest
+		if manifest.Name != "" {
+			r.crates[manifest.Name] = manifest
+		}
+	}
+	return r
+}
+
+// parseCargoManifest reads the crate name and dependency versions of a Cargo.toml
+func parseCargoManifest(content string) *cargoManifest {
+	manifest := &cargoManifest{Dependencies: make(map[string]string)}
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if match := tomlSectionRegex.FindStringSubmatch(line); match != nil {
+			section = strings.TrimSpace(match[1])
+			// [dependencies.name] tables hold one dependency
+			if dep, ok := cutDependencySection(section); ok && dep != "" {
+				manifest.Dependencies[crateName(dep)] = ""
+			}
+			continue
+		}
+		match := tomlKeyRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key, value := match[1], strings.TrimSpace(match[2])
+
+		switch dep, isDeps := cutDependencySection(section); {
+		case (section == "package" || section == "lib") && key == "name":
+			manifest.Name = crateName(strings.Trim(value, `"'`))
+		case isDeps && dep == "":
+			version := strings.Trim(value, `"'`)
+			if match := tomlVersionRegex.FindStringSubmatch(value); match != nil {
+				version = match[1]
+			} else if strings.HasPrefix(value, "{") {
+				version = ""
+			}
+			manifest.Dependencies[crateName(key)] = version
+		case isDeps && key == "version":
+			manifest.Dependencies[crateName(dep)] = strings.Trim(value, `"'`)
+		}
+	}
+	return manifest
+}
+
+// cutDependencySection reports whether a Cargo.toml section lists dependencies, returning
+// the dependency of [dependencies.name] sections
+func cutDependencySection(section string) (string, bool) {
+	for _, kind := range []string{"dependencies", "dev-dependencies", "build-dependencies"} {
+		if i := strings.Index(section, kind); i >= 0 && (i == 0 || section[i-1] == '.') {
+			rest := section[i+len(kind):]
+			if rest == "" {
+				return "", true
+			}
+			if dep, ok := strings.CutPrefix(rest, "."); ok {
+				return dep, true
+			}
+		}
+	}
+	return "", false
+}
+
+// crateName returns the name code refers to a crate by, with hyphens as underscores
+func crateName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func (r *rustImports) index(filePath, content string) {}
+
+func (r *rustImports) extract(filePath, content string) []string {
+	return extractRustImports(content)
+}
+
+// crateRoot returns the crate root file containing a file, lib.rs or main.rs, along with the
+// directory its child modules live in
+func (r *rustImports) crateRoot(filePath string) (string, string, bool) {
+	if cargo, ok := r.ic.nearestFile(filePath, "Cargo.toml"); ok {
+		src := path.Join(repoDir(cargo), "src")
+		if root, ok := r.ic.firstFile(path.Join(src, "lib.rs"), path.Join(src, "main.rs")); ok {
+			return root, rootDir(src), true
+		}
+	}
+	for _, name := range []string{"lib.rs", "main.rs"} {
+		if root, ok := r.ic.nearestFile(filePath, name); ok {
+			return root, repoDir(root), true
+		}
+	}
+	return "", "", false
+}
+
+// moduleDir returns the directory the child modules of a file live in
+func moduleDir(filePath string) string {
+	switch path.Base(filePath) {
+	case "lib.rs", "main.rs", "mod.rs":
+		return repoDir(filePath)
+	}
+	return strings.TrimSuffix(filePath, ".rs")
+}
+
+// moduleFile returns the file of the module whose children live in dir
+func (r *rustImports) moduleFile(dir string) (string, bool) {
+	return r.ic.firstFile(dir+".rs", path.Join(dir, "mod.rs"), path.Join(dir, "lib.rs"), path.Join(dir, "main.rs"))
+}
+
+// resolveIn resolves module path segments under a module directory to the file of the
+// deepest module they name; the rest of the path names items of that module
+func (r *rustImports) resolveIn(dir string, segments []string) (string, bool) {
+	for i := len(segments); i > 0; i-- {
+		base := path.Join(dir, strings.Join(segments[:i], "/"))
+		if file, ok := r.ic.firstFile(base+".rs", path.Join(base, "mod.rs")); ok {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+func (r *rustImports) resolve(filePath, imp string) []string {
+	segments := strings.Split(imp, "::")
+	first, rest := segments[0], segments[1:]
+
+	var dir, fallback string
+	switch {
+	case first == "crate":
+		root, src, ok := r.crateRoot(filePath)
+		if !ok {
+			return nil
+		}
+		dir, fallback = src, root
+	case first == "self":
+		dir = moduleDir(filePath)
+	case first == "super":
+		dir = moduleDir(filePath)
+		for len(segments) > 0 && segments[0] == "super" {
+			dir = repoDir(dir)
+			segments = segments[1:]
+		}
+		rest = segments
+		fallback, _ = r.moduleFile(dir)
+	case r.crates[first] != nil:
+		src := path.Join(r.crates[first].Dir, "src")
+		dir = rootDir(src)
+		fallback, _ = r.ic.firstFile(path.Join(src, "lib.rs"), path.Join(src, "main.rs"))
+	default:
+		// Paths may start at a child module of the file, in the 2018 edition
+		if file, ok := r.resolveIn(moduleDir(filePath), segments); ok {
+			return []string{file}
+		}
+		return nil
+	}
+
+	if file, ok := r.resolveIn(dir, rest); ok {
+		return []string{file}
+	}
+	if fallback != "" && fallback != filePath {
+		return []string{fallback}
+	}
+	return nil
+}
+
+func (r *rustImports) dependency(filePath, imp string) (models.ExternalDependency, bool) {
+	first, _, _ := strings.Cut(imp, "::")
+	switch {
+	case first == "crate" || first == "self" || first == "super" || r.crates[first] != nil:
+		return models.ExternalDependency{}, false
+	case rustStdCrates[first]:
+		return models.ExternalDependency{Name: first, Kind: models.DependencyStdlib}, true
+	}
+
+	// Take the version from the nearest Cargo.toml that lists the crate
+	dep := models.ExternalDependency{Name: first, Kind: models.DependencyPackage}
+	for dir := repoDir(filePath); ; dir = repoDir(dir) {
+		if manifest, ok := r.manifests[dir]; ok {
+			if version, ok := manifest.Dependencies[first]; ok {
+				dep.Version = version
+				return dep, true
+			}
+		}
+		if dir == "" {
+			break
+		}
+	}
+
+	// Names that aren't dependencies are items of the file, such as enum variants
+	if len(r.manifests) > 0 {
+		return models.ExternalDependency{}, false
+	}
+	return dep, true
+}
@@ -0,0 +1,60 @@
+package github
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// rustFixture is a binary crate with nested modules and a workspace library crate
+var rustFixture = map[string]string{
+	"Cargo.toml":                 "[package]\nname = \"my-app\"\n\n[dependencies]\nserde = { version = \"1.0\", features = [\"derive\"] }\nmy-util = { path = \"crates/util\" }\n",
+	"src/main.rs":                "mod config;\nmod net;\n\nuse crate::net::client::Client;\nuse serde::Deserialize;\nuse my_util::strings::slugify;\n",
+	"src/config.rs":              "use crate::Thing;\n",
+	"src/net/mod.rs":             "pub mod client;\n",
+	"src/net/client.rs":          "use super::super::config;\nuse super::Server;\n",
+	"crates/util/Cargo.toml":     "[package]\nname = \"my-util\"\n",
+	"crates/util/src/lib.rs":     "pub mod strings;\n",
+	"crates/util/src/strings.rs": "use std::collections::HashMap;\n",
+}
+
+func TestExtractRustImports(t *testing.T) {
+	got := extractRustImports(rustFixture["src/main.rs"])
+	want := []string{"self::config", "self::net", "crate::net::client::Client", "serde::Deserialize", "my_util::strings::slugify"}
+	if !slices.Equal(got, want) {
+		t.Errorf("extractRustImports = %v, want %v", got, want)
+	}
+
+	got = expandUseTree("a::{b, c::{self, d as e}, f::*}")
+	want = []string{"a::b", "a::c", "a::c::d", "a::f"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expandUseTree = %v, want %v", got, want)
+	}
+}
+
+func TestRustResolve(t *testing.T) {
+	resolver := newFixtureResolver(t, newRustImports, rustFixture)
+	checkResolve(t, resolver, []resolveCase{
+		// mod declarations name files beside the crate root and under mod.rs directories
+		{"src/main.rs", "self::config", []string{"src/config.rs"}},
+		{"src/main.rs", "self::net", []string{"src/net/mod.rs"}},
+		{"src/net/mod.rs", "self::client", []string{"src/net/client.rs"}},
+		// crate:: paths start at the crate root; items fall back to the root itself
+		{"src/main.rs", "crate::net::client::Client", []string{"src/net/client.rs"}},
+		{"src/config.rs", "crate::Thing", []string{"src/main.rs"}},
+		// Each super:: climbs one module; items fall back to the parent module
+		{"src/net/client.rs", "super::super::config", []string{"src/config.rs"}},
+		{"src/net/client.rs", "super::Server", []string{"src/net/mod.rs"}},
+		// Crates of the workspace resolve by their name in code
+		{"src/main.rs", "my_util::strings::slugify", []string{"crates/util/src/strings.rs"}},
+		// Edition 2018 paths may start at a child module
+		{"src/main.rs", "config::Settings", []string{"src/config.rs"}},
+		{"src/main.rs", "serde::Deserialize", nil},
+	})
+
+	checkDependency(t, resolver, "src/main.rs", "serde::Deserialize", models.ExternalDependency{Name: "serde", Kind: models.DependencyPackage, Version: "1.0"})
+	checkDependency(t, resolver, "crates/util/src/strings.rs", "std::collections::HashMap", models.ExternalDependency{Name: "std", Kind: models.DependencyStdlib})
+	checkDependency(t, resolver, "src/main.rs", "my_util::strings", models.ExternalDependency{})
+	checkDependency(t, resolver, "src/net/client.rs", "super::Server", models.ExternalDependency{})
+}
@@ -0,0 +1,192 @@
+// internal/github/source.go
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// ErrFileNotFound is returned by RepoSource.ReadFile for a path that doesn't exist at the ref
+var ErrFileNotFound = errors.New("file not found")
+
+// fileNotFound returns ErrFileNotFound for a path
+func fileNotFound(path string) error {
+	return fmt.Errorf("%w: %s", ErrFileNotFound, path)
+}
+
+// RepoSource provides read access to the files of a single repository.
+// Implementations read from the GitHub API, a cached archive snapshot or a local directory.
+type RepoSource interface {
+	// Repo returns the owner and name the source is identified by
+	Repo() (owner, name string)
+	// Info returns repository metadata such as the default branch and primary language
+	Info(ctx context.Context) (*models.RepositoryInfo, error)
+	// ResolveRef resolves a branch, tag or SHA to the commit it points at.
+	// An empty ref resolves to the default branch.
+	ResolveRef(ctx context.Context, ref string) (string, error)
+	// ListTree returns every file and directory at a ref
+	ListTree(ctx context.Context, ref string) ([]models.GitHubFile, error)
+	// ReadFile returns the content of a file at a ref, or an ErrFileNotFound error
+	ReadFile(ctx context.Context, ref, path string) (*models.FileContent, error)
+}
+
+// HistorySource is implemented by sources that can list past commits and tags
+type HistorySource interface {
+	// ListRecentCommits returns up to limit commits on a branch, newest first
+	ListRecentCommits(ctx context.Context, branch string, limit int) ([]models.GraphCommit, error)
+	// ListRecentTags returns up to limit tags with the commit each one points at
+	ListRecentTags(ctx context.Context, limit int) ([]models.GraphCommit, error)
+}
+
+// APISource reads repository files through the GitHub REST API
+type APISource struct {
+	client *Client
+	owner  string
+	repo   string
+}
+
+// NewAPISource creates a RepoSource backed by the GitHub API
+func (c *Client) NewAPISource(owner, repo string) *APISource {
+	return &APISource{
+		client: c,
+		owner:  owner,
+		repo:   repo,
+	}
+}
+
+// Repo returns the owner and name of the repository
+func (s *APISource) Repo() (string, string) {
+	return s.owner, s.repo
+}
+
+// Info returns repository metadata from the API
+func (s *APISource) Info(ctx context.Context) (*models.RepositoryInfo, error) {
+	return s.client.GetRepositoryInfo(ctx, s.owner, s.repo)
+}
+
+// ResolveRef resolves a ref to a commit SHA
+func (s *APISource) ResolveRef(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		repository, err := s.client.GetRepository(ctx, s.owner, s.repo)
+		if err != nil {
+			return "", err
+		}
+		ref = repository.GetDefaultBranch()
+	}
+
+	sha, _, err := s.client.client.Repositories.GetCommitSHA1(ctx, s.owner, s.repo, ref, "")
+	if err != nil {
+		return "", common.WrapError(err, "failed to resolve ref "+ref)
+	}
+	return sha, nil
+}
+
+// ListTree returns all files and directories using the recursive tree endpoint
+func (s *APISource) ListTree(ctx context.Context, ref string) ([]models.GitHubFile, error) {
+	return s.client.GetAllFiles(ctx, s.owner, s.repo, ref)
+}
+
+// ReadFile returns a file's content from the contents endpoint
+func (s *APISource) ReadFile(ctx context.Context, ref, path string) (*models.FileContent, error) {
+	return s.client.GetFileContentText(ctx, s.owner, s.repo, path, ref)
+}
+
+// ListRecentCommits returns up to limit commits on a branch, newest first
+func (s *APISource) ListRecentCommits(ctx context.Context, branch string, limit int) ([]models.GraphCommit, error) {
+	return s.client.ListRecentCommits(ctx, s.owner, s.repo, branch, limit)
+}
+
+// ListRecentTags returns up to limit tags with the commit each one points at
+func (s *APISource) ListRecentTags(ctx context.Context, limit int) ([]models.GraphCommit, error) {
+	return s.client.ListRecentTags(ctx, s.owner, s.repo, limit)
+}
+
+// BuildRepositoryStructure returns the repository structure as an indented list
+func BuildRepositoryStructure(ctx context.Context, src RepoSource, ref string) (string, error) {
+	allFiles, err := src.ListTree(ctx, ref)
+	if err != nil {
+		return "", common.WrapError(err, "failed to get file list for structure")
+	}
+	return formatStructure(allFiles), nil
+}
+
+// ListDirectory returns the direct children of a directory, like the contents API listing
+func ListDirectory(ctx context.Context, src RepoSource, ref, dir string) ([]models.GitHubFile, error) {
+	allFiles, err := src.ListTree(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	dir = strings.Trim(dir, "/")
+	var children []models.GitHubFile
+	for _, file := range allFiles {
+		parent := filepath.Dir(file.Path)
+		if parent == "." {
+			parent = ""
+		}
+		if parent == dir {
+			children = append(children, file)
+		}
+	}
+	return children, nil
+}
+
+// detectPrimaryLanguage returns the language with the most bytes among the files, plus the per-language totals
+func detectPrimaryLanguage(files []models.GitHubFile) (string, map[string]int) {
+	languages := make(map[string]int)
+	for _, file := range files {
+		if file.Type != "file" {
+			continue
+		}
+		if language := utils.GetLanguageFromPath(file.Path); language != nil && language.Type == utils.LanguageProgramming {
+			languages[language.Name] += file.Size
+		}
+	}
+
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	primary, maxBytes := "", -1
+	for _, name := range names {
+		if languages[name] > maxBytes {
+			primary, maxBytes = name, languages[name]
+		}
+	}
+	return primary, languages
+}
+
+// formatStructure renders a file listing as an indented tree
+func formatStructure(allFiles []models.GitHubFile) string {
+	if len(allFiles) == 0 {
+		return "Repository is empty."
+	}
+
+	var sb strings.Builder
+	structureMap := make(map[string][]string) // dir path -> list of children names (file or dir/)
+
+	for _, file := range allFiles {
+		dir := filepath.Dir(file.Path)
+		if dir == "." {
+			dir = ""
+		}
+		childName := file.Name
+		if file.Type == "dir" {
+			childName += "/"
+		}
+		structureMap[dir] = append(structureMap[dir], childName)
+	}
+
+	buildStructureString(&sb, structureMap, "", 0)
+	return sb.String()
+}
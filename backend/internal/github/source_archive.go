@@ -0,0 +1,241 @@
+// internal/github/source_archive.go
+package github
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v43/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// maxArchiveFileSize skips archive entries larger than this when extracting
+const maxArchiveFileSize = 10 * 1024 * 1024
+
+// archiveLocks serialises downloads of the same snapshot directory
+var archiveLocks sync.Map
+
+// ArchiveSource reads repository files from a tarball snapshot cached on disk per commit SHA.
+// Each ref is resolved to a commit once per source, downloaded once and then read locally.
+type ArchiveSource struct {
+	client   *Client
+	owner    string
+	repo     string
+	cacheDir string
+	mu       sync.Mutex
+	resolved map[string]string // ref -> commit SHA
+	logger   *common.Logger
+}
+
+// NewArchiveSource creates a RepoSource that caches repository tarballs under cacheDir
+func NewArchiveSource(client *Client, owner, repo, cacheDir string) *ArchiveSource {
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "github-agent", "archives")
+	}
+	return &ArchiveSource{
+		client:   client,
+		owner:    owner,
+		repo:     repo,
+		cacheDir: cacheDir,
+		resolved: make(map[string]string),
+		logger:   common.NewLogger(),
+	}
+}
+
+// Repo returns the owner and name of the repository
+func (s *ArchiveSource) Repo() (string, string) {
+	return s.owner, s.repo
+}
+
+// Info returns repository metadata from the API
+func (s *ArchiveSource) Info(ctx context.Context) (*models.RepositoryInfo, error) {
+	return s.client.GetRepositoryInfo(ctx, s.owner, s.repo)
+}
+
+// ResolveRef resolves a ref to a commit SHA through the API, remembering the result
+func (s *ArchiveSource) ResolveRef(ctx context.Context, ref string) (string, error) {
+	s.mu.Lock()
+	sha, ok := s.resolved[ref]
+	s.mu.Unlock()
+	if ok {
+		return sha, nil
+	}
+
+	sha, err := s.client.NewAPISource(s.owner, s.repo).ResolveRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.resolved[ref] = sha
+	s.mu.Unlock()
+	return sha, nil
+}
+
+// ListTree returns all files and directories of the snapshot for a ref
+func (s *ArchiveSource) ListTree(ctx context.Context, ref string) ([]models.GitHubFile, error) {
+	dir, sha, err := s.snapshot(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return listDirTree(dir, func(path string) string {
+		return s.client.hosts.BlobURL(s.owner, s.repo, sha, path)
+	})
+}
+
+// ReadFile returns a file from the snapshot for a ref
+func (s *ArchiveSource) ReadFile(ctx context.Context, ref, path string) (*models.FileContent, error) {
+	dir, _, err := s.snapshot(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return readDirFile(dir, path)
+}
+
+// ListRecentCommits returns up to limit commits on a branch, newest first
+func (s *ArchiveSource) ListRecentCommits(ctx context.Context, branch string, limit int) ([]models.GraphCommit, error) {
+	return s.client.ListRecentCommits(ctx, s.owner, s.repo, branch, limit)
+}
+
+// ListRecentTags returns up to limit tags with the commit each one points at
+func (s *ArchiveSource) ListRecentTags(ctx context.Context, limit int) ([]models.GraphCommit, error) {
+	return s.client.ListRecentTags(ctx, s.owner, s.repo, limit)
+}
+
+// snapshot returns the extracted snapshot directory for a ref, downloading it if needed
+func (s *ArchiveSource) snapshot(ctx context.Context, ref string) (string, string, error) {
+	sha, err := s.ResolveRef(ctx, ref)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir := filepath.Join(s.cacheDir, s.owner, s.repo, sha)
+
+	lock, _ := archiveLocks.LoadOrStore(dir, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, sha, nil
+	}
+
+	if err := s.download(ctx, sha, dir); err != nil {
+		return "", "", err
+	}
+	return dir, sha, nil
+}
+
+// download fetches the tarball for a commit and extracts it into dir
+func (s *ArchiveSource) download(ctx context.Context, sha, dir string) error {
+	s.logger.Info(fmt.Sprintf("Downloading archive for %s/%s @ %s", s.owner, s.repo, sha))
+
+	link, _, err := s.client.client.Repositories.GetArchiveLink(ctx, s.owner, s.repo, github.Tarball,
+		&github.RepositoryContentGetOptions{Ref: sha}, true)
+	if err != nil {
+		return common.WrapError(err, "failed to get archive link")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.String(), nil)
+	if err != nil {
+		return common.WrapError(err, "failed to create archive request")
+	}
+	resp, err := s.client.client.Client().Do(req)
+	if err != nil {
+		return common.WrapError(err, "failed to download archive")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return common.NewError(fmt.Sprintf("failed to download archive: status %d", resp.StatusCode))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return common.WrapError(err, "failed to create archive cache directory")
+	}
+
+	// Extract next to the final location and rename, so a partial download is never visible
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), "."+sha+"-")
+	if err != nil {
+		return common.WrapError(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := extractTarball(resp.Body, tmpDir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return common.WrapError(err, "failed to move archive into cache")
+	}
+	return nil
+}
+
+// extractTarball extracts a GitHub tarball into dir, dropping the top-level "owner-repo-sha" directory
+func extractTarball(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return common.WrapError(err, "failed to read archive")
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return common.WrapError(err, "failed to read archive entry")
+		}
+
+		_, name, ok := strings.Cut(header.Name, "/")
+		if !ok || name == "" {
+			continue
+		}
+		clean, err := cleanRelativePath(name)
+		if err != nil {
+			continue // Entries that would escape the snapshot directory
+		}
+		target := filepath.Join(dir, filepath.FromSlash(clean))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return common.WrapError(err, "failed to create directory")
+			}
+		case tar.TypeReg:
+			if header.Size > maxArchiveFileSize {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return common.WrapError(err, "failed to create directory")
+			}
+			if err := writeArchiveFile(target, tr); err != nil {
+				return err
+			}
+		default:
+			// Symlinks and other special entries are not needed for analysis
+		}
+	}
+}
+
+// writeArchiveFile writes a single archive entry to disk
+func writeArchiveFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return common.WrapError(err, "failed to create file")
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return common.WrapError(err, "failed to write file")
+	}
+	return f.Close()
+}
@@ -0,0 +1,445 @@
+// internal/github/source_local.go
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// LocalOwner is the owner reported for local sources that have no GitHub remote
+const LocalOwner = "local"
+
+// localNamespace prefixes the owner results of a local source are stored under, so a checkout,
+// which may hold uncommitted changes, never overwrites what was stored for its GitHub repository
+const localNamespace = LocalOwner + ":"
+
+// LocalSource reads repository files from a local directory or git checkout.
+// An empty ref or the checked-out branch reads the working tree; any other ref is read
+// from git history. Directories that are not git checkouts ignore the ref.
+type LocalSource struct {
+	root  string
+	owner string
+	name  string
+	head  string // Checked-out branch
+	isGit bool
+}
+
+// IsLocalPath reports whether a repository reference points at the local filesystem
+func IsLocalPath(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "/") ||
+		strings.HasPrefix(s, "./") ||
+		strings.HasPrefix(s, "../") ||
+		strings.HasPrefix(s, "~") ||
+		strings.HasPrefix(s, "file://")
+}
+
+// NewLocalSource creates a RepoSource for a local directory
+func NewLocalSource(path string) (*LocalSource, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "file://")
+	if strings.HasPrefix(path, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, common.WrapError(err, "failed to resolve home directory")
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, common.WrapError(err, "invalid local path")
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, common.WrapError(err, "local path not found")
+	}
+	if !info.IsDir() {
+		return nil, common.NewError("local path is not a directory: " + root)
+	}
+
+	src := &LocalSource{
+		root:  root,
+		owner: LocalOwner,
+		name:  filepath.Base(root),
+	}
+
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		if _, err := exec.LookPath("git"); err == nil {
+			src.isGit = true
+		}
+	}
+
+	// Identify the checkout by its GitHub remote so Go import paths and stored graphs line up
+	if src.isGit {
+		if out, err := src.git(context.Background(), "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+			src.head = strings.TrimSpace(string(out))
+		}
+		if out, err := src.git(context.Background(), "remote", "get-url", "origin"); err == nil {
+			if owner, name, ok := parseRemoteURL(strings.TrimSpace(string(out))); ok {
+				src.owner, src.name = owner, name
+			}
+		}
+	}
+
+	return src, nil
+}
+
+// parseRemoteURL reads owner and repository from a git remote on github.com or an Enterprise
+// Server host, such as git@host:owner/repo.git or https://host/owner/repo
+func parseRemoteURL(remote string) (string, string, bool) {
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax: [user@]host:owner/repo
+		_, path, ok := strings.Cut(remote, ":")
+		if !ok {
+			return "", "", false
+		}
+		remote = "ssh://host/" + path
+	}
+
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.Scheme == "file" {
+		return "", "", false
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(segments) != 2 || segments[0] == "" || segments[1] == "" {
+		return "", "", false
+	}
+	return segments[0], strings.TrimSuffix(segments[1], ".git"), true
+}
+
+// Root returns the absolute directory the source reads from
+func (s *LocalSource) Root() string {
+	return s.root
+}
+
+// Repo returns the GitHub owner and name of the checkout, or LocalOwner and the directory name
+func (s *LocalSource) Repo() (string, string) {
+	return s.owner, s.name
+}
+
+// Namespace returns the owner and name results of the checkout are stored under: its GitHub
+// owner prefixed with "local:", or LocalOwner without a remote
+func (s *LocalSource) Namespace() (string, string) {
+	if s.owner == LocalOwner {
+		return s.owner, s.name
+	}
+	return localNamespace + s.owner, s.name
+}
+
+// Info returns repository metadata derived from the working tree
+func (s *LocalSource) Info(ctx context.Context) (*models.RepositoryInfo, error) {
+	files, err := s.ListTree(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	primary, languages := detectPrimaryLanguage(files)
+	info := &models.RepositoryInfo{
+		Owner:     s.owner,
+		Name:      s.name,
+		URL:       "file://" + s.root,
+		Language:  primary,
+		Languages: languages,
+	}
+
+	for _, file := range files {
+		if file.Type == "file" && !strings.Contains(file.Path, "/") && strings.HasPrefix(strings.ToLower(file.Name), "readme") {
+			info.HasReadme = true
+			break
+		}
+	}
+
+	info.DefaultBranch = s.head
+	return info, nil
+}
+
+// ResolveRef resolves a ref to a commit SHA; without git the working tree is reported as "local"
+func (s *LocalSource) ResolveRef(ctx context.Context, ref string) (string, error) {
+	if !s.isGit {
+		return LocalOwner, nil
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return s.resolveCommit(ctx, ref)
+}
+
+// resolveCommit resolves a ref to the SHA of a commit. Refs come from requests, so ones that
+// git could read as options are refused, and only the resolved SHA is passed to other commands.
+func (s *LocalSource) resolveCommit(ctx context.Context, ref string) (string, error) {
+	if strings.HasPrefix(ref, "-") {
+		return "", common.NewError("invalid ref: " + ref)
+	}
+	sha, err := s.git(ctx, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", common.WrapError(err, "failed to resolve ref "+ref)
+	}
+	return strings.TrimSpace(string(sha)), nil
+}
+
+// ListTree returns all files and directories in the working tree or at a git ref
+func (s *LocalSource) ListTree(ctx context.Context, ref string) ([]models.GitHubFile, error) {
+	if s.isWorkingTree(ref) {
+		return listDirTree(s.root, s.fileURL)
+	}
+
+	sha, err := s.resolveCommit(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.git(ctx, "ls-tree", "-r", "-t", "-l", "-z", sha)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list tree at "+ref)
+	}
+
+	var files []models.GitHubFile
+	for _, entry := range strings.Split(string(out), "\x00") {
+		// Format: <mode> SP <type> SP <object> SP <size> TAB <path>
+		meta, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) < 4 {
+			continue
+		}
+
+		file := models.GitHubFile{
+			Name:    filepath.Base(path),
+			Path:    path,
+			HTMLURL: s.fileURL(path),
+		}
+		switch fields[1] {
+		case "blob":
+			file.Type = "file"
+			file.Size, _ = strconv.Atoi(fields[3])
+		case "tree":
+			file.Type = "dir"
+		default:
+			continue // Submodules
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// ReadFile returns a file from the working tree or from a git ref
+func (s *LocalSource) ReadFile(ctx context.Context, ref, path string) (*models.FileContent, error) {
+	if s.isWorkingTree(ref) {
+		return readDirFile(s.root, path)
+	}
+
+	clean, err := cleanRelativePath(path)
+	if err != nil {
+		return nil, err
+	}
+	sha, err := s.resolveCommit(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.git(ctx, "show", sha+":"+clean)
+	if err != nil {
+		return nil, fileNotFound(path)
+	}
+	return &models.FileContent{
+		Path:    clean,
+		Content: string(content),
+		SHA:     gitBlobSHA(content),
+	}, nil
+}
+
+// ListRecentCommits returns up to limit commits on a branch from git log, newest first
+func (s *LocalSource) ListRecentCommits(ctx context.Context, branch string, limit int) ([]models.GraphCommit, error) {
+	if !s.isGit {
+		return nil, common.NewError("history requires a git checkout")
+	}
+	if branch == "" {
+		branch = "HEAD"
+	}
+
+	sha, err := s.resolveCommit(ctx, branch)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.git(ctx, "log", "-n", strconv.Itoa(limit), "--format=%H%x1f%cI%x1f%s", sha, "--")
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list commits")
+	}
+	return parseGitCommits(out, false), nil
+}
+
+// ListRecentTags returns up to limit tags, most recently committed first
+func (s *LocalSource) ListRecentTags(ctx context.Context, limit int) ([]models.GraphCommit, error) {
+	if !s.isGit {
+		return nil, common.NewError("history requires a git checkout")
+	}
+
+	out, err := s.git(ctx, "for-each-ref", "--sort=-committerdate", "--count="+strconv.Itoa(limit),
+		"--format=%(objectname) %(*objectname)%1f%(committerdate:iso-strict)%(*committerdate:iso-strict)%1f%(refname:short)", "refs/tags")
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list tags")
+	}
+	return parseGitCommits(out, true), nil
+}
+
+// isWorkingTree reports whether a ref is read from disk rather than from git history
+func (s *LocalSource) isWorkingTree(ref string) bool {
+	return !s.isGit || ref == "" || ref == s.head
+}
+
+// fileURL returns a file:// URL for a path in the source
+func (s *LocalSource) fileURL(path string) string {
+	return "file://" + filepath.Join(s.root, path)
+}
+
+// git runs a git command in the source directory and returns its standard output
+func (s *LocalSource) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", s.root}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// parseGitCommits parses "sha<US>date<US>subject" lines. For tags the third field is the tag
+// name and the first holds the tag object and, for annotated tags, the peeled commit.
+func parseGitCommits(out []byte, tags bool) []models.GraphCommit {
+	var commits []models.GraphCommit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		ids := strings.Fields(fields[0])
+		if len(ids) == 0 {
+			continue
+		}
+		commit := models.GraphCommit{SHA: ids[len(ids)-1]}
+		commit.Date, _ = time.Parse(time.RFC3339, fields[1])
+		if tags {
+			commit.Ref = fields[2]
+		} else {
+			commit.Message = fields[2]
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// listDirTree walks a directory and returns its files and directories, skipping .git
+func listDirTree(root string, htmlURL func(path string) string) ([]models.GitHubFile, error) {
+	var files []models.GitHubFile
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil // Symlinks, sockets and the like
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		file := models.GitHubFile{
+			Name:    d.Name(),
+			Path:    rel,
+			Type:    "file",
+			HTMLURL: htmlURL(rel),
+		}
+		if d.IsDir() {
+			file.Type = "dir"
+		} else if info, err := d.Info(); err == nil {
+			file.Size = int(info.Size())
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, common.WrapError(err, "failed to walk directory")
+	}
+	return files, nil
+}
+
+// readDirFile reads a file below root, refusing paths that escape it, also through symlinks
+func readDirFile(root, path string) (*models.FileContent, error) {
+	clean, err := cleanRelativePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fullPath, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(clean)))
+	if err != nil {
+		return nil, fileNotFound(path)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to resolve local path")
+	}
+	if rel, err := filepath.Rel(realRoot, fullPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, common.NewError("invalid file path: " + path)
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fileNotFound(path)
+	}
+	if info.IsDir() {
+		return nil, common.NewError(fmt.Sprintf("path points to a directory, not a file: %s", path))
+	}
+
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, common.WrapError(err, fmt.Sprintf("failed to read file %s", path))
+	}
+	return &models.FileContent{
+		Path:    clean,
+		Content: string(content),
+		SHA:     gitBlobSHA(content),
+	}, nil
+}
+
+// cleanRelativePath normalises a repository path and rejects paths outside the repository
+func cleanRelativePath(path string) (string, error) {
+	clean := filepath.ToSlash(filepath.Clean("/" + strings.TrimSpace(path)))
+	clean = strings.TrimPrefix(clean, "/")
+	if clean == "" || clean == "." || strings.HasPrefix(clean, "../") {
+		return "", common.NewError("invalid file path: " + path)
+	}
+	return clean, nil
+}
+
+// gitBlobSHA returns the git object ID of a blob, matching the SHA the API reports
+func gitBlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,442 @@
+// internal/github/transport.go
+package github
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// Rate limit policies
+const (
+	// RateLimitWait sleeps until the limit resets when the wait is short enough
+	RateLimitWait = "wait"
+	// RateLimitFail returns ErrRateLimited immediately once the quota is exhausted
+	RateLimitFail = "fail"
+)
+
+const (
+	// DefaultRateLimitMaxWait is the longest the transport sleeps for a rate limit reset
+	DefaultRateLimitMaxWait = time.Minute
+	// DefaultCacheEntries is the number of responses kept for conditional requests
+	DefaultCacheEntries = 1000
+	// maxCachedBodySize skips caching responses larger than this
+	maxCachedBodySize = 5 * 1024 * 1024
+	// maxTrackedCredentials is the number of tokens whose quota the transport remembers
+	maxTrackedCredentials = 1000
+)
+
+// ErrRateLimited is returned when a request would exceed the GitHub rate limit
+var ErrRateLimited = errors.New("github rate limit exceeded")
+
+// ClientOptions configures the HTTP behaviour of a Client
+type ClientOptions struct {
+	RateLimitPolicy  string        // RateLimitWait or RateLimitFail
+	RateLimitMaxWait time.Duration // Longest wait before failing under RateLimitWait
+	CacheEntries     int           // Responses cached for conditional requests; 0 disables the cache
+	App              *AppAuth      // Authenticates as a GitHub App; the token becomes the fallback
+	Hosts            Hosts         // GitHub deployment to talk to; zero means github.com
+}
+
+// DefaultClientOptions returns the options used by NewClient
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		RateLimitPolicy:  RateLimitWait,
+		RateLimitMaxWait: DefaultRateLimitMaxWait,
+		CacheEntries:     DefaultCacheEntries,
+	}
+}
+
+// RateLimitStatus is the last known quota for one API resource
+type RateLimitStatus struct {
+	Resource  string    `json:"resource"`
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// rateLimitTransport tracks rate limit headers and waits or fails before requests that would be rejected.
+// GitHub keeps a quota per token, so each credential is tracked on its own: one installation
+// running out never holds up requests made with another token.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	policy  string
+	maxWait time.Duration
+	primary string // Credential of the client's own token
+	logger  *common.Logger
+
+	mu          sync.Mutex
+	limits      map[string]map[string]RateLimitStatus // Keyed by credential, then resource
+	credentials *list.List                            // Credentials, most recently updated first
+	elements    map[string]*list.Element
+}
+
+// newRateLimitTransport wraps base with rate limit handling for a client authenticated with token
+func newRateLimitTransport(base http.RoundTripper, opts ClientOptions, token string) *rateLimitTransport {
+	if opts.RateLimitPolicy == "" {
+		opts.RateLimitPolicy = RateLimitWait
+	}
+	return &rateLimitTransport{
+		base:        base,
+		policy:      opts.RateLimitPolicy,
+		maxWait:     opts.RateLimitMaxWait,
+		primary:     tokenCredential(token),
+		logger:      common.NewLogger(),
+		limits:      make(map[string]map[string]RateLimitStatus),
+		credentials: list.New(),
+		elements:    make(map[string]*list.Element),
+	}
+}
+
+// RoundTrip sends a request, honouring primary and secondary rate limits
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := rateLimitResource(req)
+	credential := rateLimitCredential(req)
+
+	// The rate limit endpoint does not count against the quota
+	if !strings.HasSuffix(req.URL.Path, "/rate_limit") {
+		if err := t.waitForQuota(req.Context(), credential, resource); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.update(credential, resource, resp.Header)
+	hideExhaustedQuota(resp)
+
+	// Retry once after a primary or secondary limit if the wait is acceptable
+	wait, limited := retryDelay(resp)
+	if !limited || t.policy != RateLimitWait || wait > t.maxWait {
+		return resp, nil
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil // The body cannot be replayed
+	}
+
+	t.logger.Warn(fmt.Sprintf("GitHub rate limit hit for %s, retrying in %s", resource, wait.Round(time.Second)))
+	resp.Body.Close()
+	if err := sleepContext(req.Context(), wait); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+
+	resp, err = t.base.RoundTrip(retry)
+	if err != nil {
+		return nil, err
+	}
+	t.update(credential, resource, resp.Header)
+	hideExhaustedQuota(resp)
+	return resp, nil
+}
+
+// hideExhaustedQuota drops the quota headers from successful responses once the quota
+// reaches zero. go-github otherwise rejects the next request itself before it reaches the
+// transport, which would bypass the configured policy.
+func hideExhaustedQuota(resp *http.Response) {
+	if resp.StatusCode < 400 && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		resp.Header.Del("X-RateLimit-Remaining")
+		resp.Header.Del("X-RateLimit-Reset")
+	}
+}
+
+// waitForQuota blocks until the credential has quota left for the resource, or fails according to the policy
+func (t *rateLimitTransport) waitForQuota(ctx context.Context, credential, resource string) error {
+	t.mu.Lock()
+	status, ok := t.limits[credential][resource]
+	t.mu.Unlock()
+
+	if !ok || status.Remaining > 0 {
+		return nil
+	}
+	wait := time.Until(status.Reset)
+	if wait <= 0 {
+		return nil
+	}
+
+	if t.policy != RateLimitWait || wait > t.maxWait {
+		return fmt.Errorf("%w for %s, resets at %s", ErrRateLimited, resource, status.Reset.Format(time.RFC3339))
+	}
+
+	t.logger.Warn(fmt.Sprintf("GitHub %s quota exhausted, waiting %s", resource, wait.Round(time.Second)))
+	return sleepContext(ctx, wait)
+}
+
+// update records the quota of a credential reported by a response, forgetting the least
+// recently updated credential beyond maxTrackedCredentials
+func (t *rateLimitTransport) update(credential, resource string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if r := header.Get("X-RateLimit-Resource"); r != "" {
+		resource = r
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if element, ok := t.elements[credential]; ok {
+		t.credentials.MoveToFront(element)
+	} else {
+		t.elements[credential] = t.credentials.PushFront(credential)
+		t.limits[credential] = make(map[string]RateLimitStatus)
+		for t.credentials.Len() > maxTrackedCredentials {
+			oldest := t.credentials.Remove(t.credentials.Back()).(string)
+			delete(t.elements, oldest)
+			delete(t.limits, oldest)
+		}
+	}
+	t.limits[credential][resource] = RateLimitStatus{
+		Resource:  resource,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+// snapshot returns the last known quota of every resource seen so far for the client's own
+// token. Without one, as for a GitHub App, it returns the lowest quota of each resource
+// across the installations.
+func (t *rateLimitTransport) snapshot() []RateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lowest := t.limits[t.primary]
+	if t.primary == "" || lowest == nil {
+		lowest = make(map[string]RateLimitStatus)
+		for _, limits := range t.limits {
+			for resource, status := range limits {
+				if current, ok := lowest[resource]; !ok || status.Remaining < current.Remaining {
+					lowest[resource] = status
+				}
+			}
+		}
+	}
+
+	statuses := make([]RateLimitStatus, 0, len(lowest))
+	for _, status := range lowest {
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// rateLimitCredential returns the credential a request counts against, from its
+// Authorization header. App JWTs change each time one is minted, so they share one entry.
+func rateLimitCredential(req *http.Request) string {
+	_, token, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if strings.Count(token, ".") == 2 {
+		return "app"
+	}
+	return tokenCredential(token)
+}
+
+// tokenCredential identifies a token without keeping it, or returns "" for no token
+func tokenCredential(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// rateLimitResource returns the rate limit bucket a request counts against
+func rateLimitResource(req *http.Request) string {
+	path := req.URL.Path
+	switch {
+	case strings.Contains(path, "/search/code"):
+		return "code_search"
+	case strings.Contains(path, "/search/"):
+		return "search"
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	default:
+		return "core"
+	}
+}
+
+// retryDelay reports whether a response was rejected by a rate limit and how long to wait
+func retryDelay(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// Secondary limits send Retry-After
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	// Primary limits exhaust the remaining quota
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err == nil {
+			return time.Until(time.Unix(reset, 0)) + time.Second, true
+		}
+	}
+
+	return 0, false
+}
+
+// sleepContext sleeps for d or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cachedResponse is a stored GET response that can be revalidated
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// cacheTransport sends conditional requests for cached GET responses and serves 304s from the cache.
+// Revalidated requests do not count against the GitHub rate limit.
+type cacheTransport struct {
+	base       http.RoundTripper
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+	order   []string // Insertion order for eviction
+}
+
+// newCacheTransport wraps base with a response cache of maxEntries entries
+func newCacheTransport(base http.RoundTripper, maxEntries int) *cacheTransport {
+	return &cacheTransport{
+		base:       base,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*cachedResponse),
+	}
+}
+
+// RoundTrip sends a request, revalidating a cached response when one exists
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := cacheKey(req)
+	t.mu.Lock()
+	cached := t.entries[key]
+	t.mu.Unlock()
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		header := cached.header.Clone()
+		// Keep the fresh rate limit headers from the 304
+		for key, values := range resp.Header {
+			if strings.HasPrefix(key, "X-Ratelimit-") {
+				header[key] = values
+			}
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+	if resp.ContentLength > maxCachedBodySize {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodySize+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > maxCachedBodySize {
+		return resp, nil
+	}
+
+	t.store(key, &cachedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		header:       resp.Header.Clone(),
+		body:         body,
+	})
+	return resp, nil
+}
+
+// store adds a response to the cache, evicting the oldest entries beyond the limit
+func (t *cacheTransport) store(key string, entry *cachedResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.entries[key]; !exists {
+		t.order = append(t.order, key)
+	}
+	t.entries[key] = entry
+
+	for len(t.order) > t.maxEntries {
+		delete(t.entries, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// cacheKey identifies a response by URL, media type and credentials, so users never share entries
+func cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + "|" + req.Header.Get("Accept") + "|" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,474 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+)
+
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+
+	louvainMaxLevels = 10
+	louvainMaxPasses = 50
+)
+
+// Analysis holds the results of running graph algorithms over an import graph
+type Analysis struct {
+	// Clusters lists the members of each discovered module, largest first
+	Clusters [][]string
+	// ClusterOf maps each file to its index in Clusters
+	ClusterOf map[string]int
+	// Levels maps each cluster to its dependency level; 0 means it depends on no other cluster
+	Levels map[int]int
+	// MaxLevel is the highest level of any cluster
+	MaxLevel int
+
+	PageRank    map[string]float64
+	Betweenness map[string]float64
+
+	importance map[string]float64
+}
+
+// Analyze detects modules, ranks files by importance and infers cluster layers
+func Analyze(g *CodeGraph) *Analysis {
+	a := &Analysis{
+		PageRank:    PageRank(g),
+		Betweenness: Betweenness(g),
+	}
+
+	a.Clusters = DetectCommunities(g)
+	a.ClusterOf = make(map[string]int)
+	for i, members := range a.Clusters {
+		for _, member := range members {
+			a.ClusterOf[member] = i
+		}
+	}
+
+	a.Levels, a.MaxLevel = clusterLevels(g, a.ClusterOf, len(a.Clusters))
+	a.importance = combineScores(a.PageRank, a.Betweenness)
+
+	return a
+}
+
+// Importance returns a file's importance normalized to the range 0..1
+func (a *Analysis) Importance(filePath string) float64 {
+	return a.importance[filePath]
+}
+
+// Size maps a file's importance to a diagram size between 1 and 10
+func (a *Analysis) Size(filePath string) int {
+	return 1 + int(math.Round(a.Importance(filePath)*9))
+}
+
+// LayerName returns a human-readable layer for a cluster based on its dependency level
+func (a *Analysis) LayerName(cluster int) string {
+	level := a.Levels[cluster]
+	switch {
+	case level == 0:
+		return "foundation"
+	case level == a.MaxLevel:
+		return "entry"
+	default:
+		return fmt.Sprintf("tier-%d", level)
+	}
+}
+
+// RankedMembers returns a cluster's members ordered by importance
+func (a *Analysis) RankedMembers(cluster int) []string {
+	members := append([]string(nil), a.Clusters[cluster]...)
+	sort.SliceStable(members, func(i, j int) bool {
+		return a.Importance(members[i]) > a.Importance(members[j])
+	})
+	return members
+}
+
+// ClusterDependencies returns the clusters that a cluster imports from, in order
+func (a *Analysis) ClusterDependencies(g *CodeGraph, cluster int) []int {
+	deps := make(map[int]bool)
+	for _, member := range a.Clusters[cluster] {
+		for target := range g.Imports[member] {
+			if other, ok := a.ClusterOf[target]; ok && other != cluster {
+				deps[other] = true
+			}
+		}
+	}
+
+	result := make([]int, 0, len(deps))
+	for dep := range deps {
+		result = append(result, dep)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// DetectCommunities groups files into modules using the Louvain method over the
+// undirected import graph. Files with no import relationships are grouped by directory.
+// The result is deterministic and ordered by cluster size, then first member.
+func DetectCommunities(g *CodeGraph) [][]string {
+	nodes := g.SortedFiles()
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		index[node] = i
+	}
+
+	// Undirected weighted adjacency between node indices
+	adjacency := make([]map[int]float64, len(nodes))
+	for i := range adjacency {
+		adjacency[i] = make(map[int]float64)
+	}
+	connected := make(map[string]bool)
+	for source, targets := range g.Imports {
+		for target := range targets {
+			if source == target {
+				continue
+			}
+			i, j := index[source], index[target]
+			adjacency[i][j]++
+			adjacency[j][i]++
+			connected[source] = true
+			connected[target] = true
+		}
+	}
+
+	// membership maps each original node to its current community
+	membership := make([]int, len(nodes))
+	for i := range membership {
+		membership[i] = i
+	}
+
+	for level := 0; level < louvainMaxLevels; level++ {
+		communities, moved := louvainLocalMoves(adjacency)
+		if !moved {
+			break
+		}
+
+		// Renumber communities densely in order of first appearance
+		renumber := make(map[int]int)
+		for _, community := range communities {
+			if _, ok := renumber[community]; !ok {
+				renumber[community] = len(renumber)
+			}
+		}
+		for i := range membership {
+			membership[i] = renumber[communities[membership[i]]]
+		}
+
+		// Aggregate each community into a single node for the next level
+		aggregated := make([]map[int]float64, len(renumber))
+		for i := range aggregated {
+			aggregated[i] = make(map[int]float64)
+		}
+		for i, neighbors := range adjacency {
+			for j, weight := range neighbors {
+				aggregated[renumber[communities[i]]][renumber[communities[j]]] += weight
+			}
+		}
+		adjacency = aggregated
+	}
+
+	groups := make(map[string][]string)
+	for i, node := range nodes {
+		key := fmt.Sprintf("community:%d", membership[i])
+		if !connected[node] {
+			key = "dir:" + path.Dir(node)
+		}
+		groups[key] = append(groups[key], node)
+	}
+
+	clusters := make([][]string, 0, len(groups))
+	for _, members := range groups {
+		sort.Strings(members)
+		clusters = append(clusters, members)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+
+	return clusters
+}
+
+// louvainLocalMoves runs the first Louvain phase: each node repeatedly moves to the
+// neighboring community with the highest modularity gain until no move improves it.
+// Self-loop weights in adjacency represent edges inside an aggregated community.
+func louvainLocalMoves(adjacency []map[int]float64) ([]int, bool) {
+	n := len(adjacency)
+	communities := make([]int, n)
+	degree := make([]float64, n)
+	totals := make([]float64, n)
+	totalWeight := 0.0
+
+	for i, neighbors := range adjacency {
+		communities[i] = i
+		for _, weight := range neighbors {
+			degree[i] += weight
+		}
+		totals[i] = degree[i]
+		totalWeight += degree[i]
+	}
+	if totalWeight == 0 {
+		return communities, false
+	}
+
+	moved := false
+	for pass := 0; pass < louvainMaxPasses; pass++ {
+		changed := false
+		for i := 0; i < n; i++ {
+			current := communities[i]
+
+			// Weight from i into each neighboring community
+			links := make(map[int]float64)
+			for j, weight := range adjacency[i] {
+				if j != i {
+					links[communities[j]] += weight
+				}
+			}
+
+			totals[current] -= degree[i]
+
+			best := current
+			bestGain := links[current] - totals[current]*degree[i]/totalWeight
+			candidates := make([]int, 0, len(links))
+			for community := range links {
+				candidates = append(candidates, community)
+			}
+			sort.Ints(candidates)
+			for _, community := range candidates {
+				gain := links[community] - totals[community]*degree[i]/totalWeight
+				if gain > bestGain+1e-12 {
+					best, bestGain = community, gain
+				}
+			}
+
+			totals[best] += degree[i]
+			if best != current {
+				communities[i] = best
+				changed = true
+				moved = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return communities, moved
+}
+
+// PageRank ranks files by how much of the codebase transitively depends on them
+func PageRank(g *CodeGraph) map[string]float64 {
+	nodes := g.SortedFiles()
+	n := float64(len(nodes))
+	ranks := make(map[string]float64, len(nodes))
+	if len(nodes) == 0 {
+		return ranks
+	}
+
+	for _, node := range nodes {
+		ranks[node] = 1 / n
+	}
+
+	for iteration := 0; iteration < pageRankIterations; iteration++ {
+		next := make(map[string]float64, len(nodes))
+
+		// Rank held by files with no imports is spread evenly
+		dangling := 0.0
+		for _, node := range nodes {
+			if len(g.Imports[node]) == 0 {
+				dangling += ranks[node]
+			}
+		}
+
+		base := (1-pageRankDamping)/n + pageRankDamping*dangling/n
+		for _, node := range nodes {
+			next[node] = base
+		}
+
+		for _, node := range nodes {
+			targets := g.Imports[node]
+			if len(targets) == 0 {
+				continue
+			}
+			share := pageRankDamping * ranks[node] / float64(len(targets))
+			for target := range targets {
+				next[target] += share
+			}
+		}
+
+		delta := 0.0
+		for _, node := range nodes {
+			delta += math.Abs(next[node] - ranks[node])
+		}
+		ranks = next
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	return ranks
+}
+
+// Betweenness computes betweenness centrality for each file using Brandes' algorithm.
+// Files with high betweenness sit on many shortest import paths between other files.
+func Betweenness(g *CodeGraph) map[string]float64 {
+	nodes := g.SortedFiles()
+	centrality := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		centrality[node] = 0
+	}
+
+	adjacency := make(map[string][]string, len(nodes))
+	for _, node := range nodes {
+		adjacency[node] = sortedKeys(g.Imports[node])
+	}
+
+	for _, source := range nodes {
+		var stack []string
+		predecessors := make(map[string][]string)
+		sigma := map[string]float64{source: 1}
+		distance := map[string]int{source: 0}
+		queue := []string{source}
+
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+
+			for _, w := range adjacency[v] {
+				if _, seen := distance[w]; !seen {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					sigma[w] += sigma[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		dependency := make(map[string]float64)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				dependency[v] += sigma[v] / sigma[w] * (1 + dependency[w])
+			}
+			if w != source {
+				centrality[w] += dependency[w]
+			}
+		}
+	}
+
+	return centrality
+}
+
+// clusterLevels assigns each cluster a level in the condensed cluster dependency graph.
+// Clusters that import from no other cluster are level 0; every other cluster sits one
+// level above the highest cluster it depends on. Mutually dependent clusters share a level.
+func clusterLevels(g *CodeGraph, clusterOf map[string]int, clusterCount int) (map[int]int, int) {
+	clusterGraph := &CodeGraph{
+		Files:   make(map[string]bool),
+		Imports: make(map[string]map[string]bool),
+	}
+	key := func(cluster int) string { return fmt.Sprintf("%08d", cluster) }
+
+	for i := 0; i < clusterCount; i++ {
+		clusterGraph.Files[key(i)] = true
+	}
+	for source, targets := range g.Imports {
+		for target := range targets {
+			from, to := clusterOf[source], clusterOf[target]
+			if from != to {
+				clusterGraph.AddEdge(key(from), key(to))
+			}
+		}
+	}
+
+	// Collapse cycles between clusters so the remaining graph is acyclic
+	component := make(map[string]string)
+	for _, cycle := range clusterGraph.Cycles() {
+		for _, member := range cycle {
+			component[member] = cycle[0]
+		}
+	}
+	componentOf := func(node string) string {
+		if c, ok := component[node]; ok {
+			return c
+		}
+		return node
+	}
+
+	dependencies := make(map[string]map[string]bool)
+	for source, targets := range clusterGraph.Imports {
+		for target := range targets {
+			from, to := componentOf(source), componentOf(target)
+			if from == to {
+				continue
+			}
+			if dependencies[from] == nil {
+				dependencies[from] = make(map[string]bool)
+			}
+			dependencies[from][to] = true
+		}
+	}
+
+	memo := make(map[string]int)
+	var level func(node string) int
+	level = func(node string) int {
+		if value, ok := memo[node]; ok {
+			return value
+		}
+		result := 0
+		for dep := range dependencies[node] {
+			if l := level(dep) + 1; l > result {
+				result = l
+			}
+		}
+		memo[node] = result
+		return result
+	}
+
+	levels := make(map[int]int, clusterCount)
+	maxLevel := 0
+	for i := 0; i < clusterCount; i++ {
+		levels[i] = level(componentOf(key(i)))
+		if levels[i] > maxLevel {
+			maxLevel = levels[i]
+		}
+	}
+	return levels, maxLevel
+}
+
+// combineScores blends normalized PageRank and betweenness into a single 0..1 score
+func combineScores(pageRank, betweenness map[string]float64) map[string]float64 {
+	maxRank, maxBetween := 0.0, 0.0
+	for _, value := range pageRank {
+		maxRank = math.Max(maxRank, value)
+	}
+	for _, value := range betweenness {
+		maxBetween = math.Max(maxBetween, value)
+	}
+
+	rankWeight, betweenWeight := 0.6, 0.4
+	if maxBetween == 0 {
+		rankWeight, betweenWeight = 1, 0
+	}
+
+	scores := make(map[string]float64, len(pageRank))
+	for node, rank := range pageRank {
+		score := 0.0
+		if maxRank > 0 {
+			score += rankWeight * rank / maxRank
+		}
+		if maxBetween > 0 {
+			score += betweenWeight * betweenness[node] / maxBetween
+		}
+		scores[node] = score
+	}
+	return scores
+}
@@ -0,0 +1,213 @@
+package graph
+
+import (
+	"sort"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+)
+
+// CodeGraph is an in-memory view of a codebase import graph
+type CodeGraph struct {
+	Files   map[string]bool
+	Imports map[string]map[string]bool
+	Skipped utils.SkipCounts // Files left out when the graph was built; unknown for stored graphs
+}
+
+// NewCodeGraph builds a CodeGraph from a file listing and an import map
+func NewCodeGraph(files []models.GitHubFile, importMap map[string][]string) *CodeGraph {
+	g := &CodeGraph{
+		Files:   make(map[string]bool),
+		Imports: make(map[string]map[string]bool),
+	}
+
+	for _, file := range files {
+		if file.Type == "file" {
+			g.Files[file.Path] = true
+		}
+	}
+
+	for source, targets := range importMap {
+		for _, target := range targets {
+			g.AddEdge(source, target)
+		}
+	}
+
+	return g
+}
+
+// AddEdge adds an import edge, registering both endpoints as files
+func (g *CodeGraph) AddEdge(source, target string) {
+	if source == "" || target == "" {
+		return
+	}
+	g.Files[source] = true
+	g.Files[target] = true
+
+	if g.Imports[source] == nil {
+		g.Imports[source] = make(map[string]bool)
+	}
+	g.Imports[source][target] = true
+}
+
+// Filter returns the subgraph containing only the files accepted by keep
+func (g *CodeGraph) Filter(keep func(path string) bool) *CodeGraph {
+	filtered := &CodeGraph{
+		Files:   make(map[string]bool),
+		Imports: make(map[string]map[string]bool),
+	}
+
+	for path := range g.Files {
+		if keep(path) {
+			filtered.Files[path] = true
+		}
+	}
+	for source, targets := range g.Imports {
+		for target := range targets {
+			if filtered.Files[source] && filtered.Files[target] {
+				filtered.AddEdge(source, target)
+			}
+		}
+	}
+
+	return filtered
+}
+
+// HasEdge reports whether source imports target
+func (g *CodeGraph) HasEdge(source, target string) bool {
+	return g.Imports[source][target]
+}
+
+// SortedFiles returns all file paths in lexical order
+func (g *CodeGraph) SortedFiles() []string {
+	files := make([]string, 0, len(g.Files))
+	for path := range g.Files {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// SortedEdges returns all import edges ordered by source then target
+func (g *CodeGraph) SortedEdges() []models.GraphEdge {
+	var edges []models.GraphEdge
+	for source, targets := range g.Imports {
+		for target := range targets {
+			edges = append(edges, models.GraphEdge{Source: source, Target: target})
+		}
+	}
+	sortEdges(edges)
+	return edges
+}
+
+// EdgeCount returns the number of import edges
+func (g *CodeGraph) EdgeCount() int {
+	count := 0
+	for _, targets := range g.Imports {
+		count += len(targets)
+	}
+	return count
+}
+
+// FanOut returns the number of files each file imports
+func (g *CodeGraph) FanOut() map[string]int {
+	fanOut := make(map[string]int)
+	for source, targets := range g.Imports {
+		fanOut[source] = len(targets)
+	}
+	return fanOut
+}
+
+// FanIn returns the number of files importing each file
+func (g *CodeGraph) FanIn() map[string]int {
+	fanIn := make(map[string]int)
+	for _, targets := range g.Imports {
+		for target := range targets {
+			fanIn[target]++
+		}
+	}
+	return fanIn
+}
+
+// Cycles returns every import cycle as a sorted list of its members.
+// Cycles are the strongly connected components with more than one file,
+// plus files that import themselves.
+func (g *CodeGraph) Cycles() [][]string {
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	var strongConnect func(node string)
+	strongConnect = func(node string) {
+		indices[node] = index
+		lowLinks[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, target := range sortedKeys(g.Imports[node]) {
+			if _, visited := indices[target]; !visited {
+				strongConnect(target)
+				if lowLinks[target] < lowLinks[node] {
+					lowLinks[node] = lowLinks[target]
+				}
+			} else if onStack[target] && indices[target] < lowLinks[node] {
+				lowLinks[node] = indices[target]
+			}
+		}
+
+		if lowLinks[node] != indices[node] {
+			return
+		}
+
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == node {
+				break
+			}
+		}
+
+		if len(component) > 1 || g.HasEdge(node, node) {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range g.SortedFiles() {
+		if _, visited := indices[node]; !visited {
+			strongConnect(node)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycleKey(cycles[i]) < cycleKey(cycles[j])
+	})
+	return cycles
+}
+
+// sortedKeys returns the keys of a set in lexical order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortEdges orders edges by source then target
+func sortEdges(edges []models.GraphEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+}
@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// DefaultHotNodeLimit is the number of highest-degree files compared by DiffGraphs
+const DefaultHotNodeLimit = 15
+
+// DiffGraphs compares two graphs and reports how the architecture changed from base to head
+func DiffGraphs(base, head *CodeGraph, hotNodeLimit int) models.GraphDiff {
+	if hotNodeLimit <= 0 {
+		hotNodeLimit = DefaultHotNodeLimit
+	}
+
+	diff := models.GraphDiff{
+		AddedFiles:   []string{},
+		RemovedFiles: []string{},
+		AddedEdges:   []models.GraphEdge{},
+		RemovedEdges: []models.GraphEdge{},
+		NewCycles:    [][]string{},
+		HotNodes:     []models.NodeDegreeChange{},
+	}
+
+	// Files
+	for _, path := range head.SortedFiles() {
+		if !base.Files[path] {
+			diff.AddedFiles = append(diff.AddedFiles, path)
+		}
+	}
+	for _, path := range base.SortedFiles() {
+		if !head.Files[path] {
+			diff.RemovedFiles = append(diff.RemovedFiles, path)
+		}
+	}
+
+	// Import edges
+	for _, edge := range head.SortedEdges() {
+		if !base.HasEdge(edge.Source, edge.Target) {
+			diff.AddedEdges = append(diff.AddedEdges, edge)
+		}
+	}
+	for _, edge := range base.SortedEdges() {
+		if !head.HasEdge(edge.Source, edge.Target) {
+			diff.RemovedEdges = append(diff.RemovedEdges, edge)
+		}
+	}
+
+	// Cycles that did not exist in the base graph
+	baseCycles := make(map[string]bool)
+	for _, cycle := range base.Cycles() {
+		baseCycles[cycleKey(cycle)] = true
+	}
+	for _, cycle := range head.Cycles() {
+		if !baseCycles[cycleKey(cycle)] {
+			diff.NewCycles = append(diff.NewCycles, cycle)
+		}
+	}
+
+	diff.HotNodes = diffHotNodes(base, head, hotNodeLimit)
+
+	diff.Stats = models.GraphDiffStats{
+		BaseFiles: len(base.Files),
+		HeadFiles: len(head.Files),
+		BaseEdges: base.EdgeCount(),
+		HeadEdges: head.EdgeCount(),
+	}
+
+	return diff
+}
+
+// diffHotNodes reports fan-in/fan-out changes for the most connected files of either graph
+func diffHotNodes(base, head *CodeGraph, limit int) []models.NodeDegreeChange {
+	baseIn, baseOut := base.FanIn(), base.FanOut()
+	headIn, headOut := head.FanIn(), head.FanOut()
+
+	candidates := make(map[string]bool)
+	for _, path := range topByDegree(baseIn, baseOut, limit) {
+		candidates[path] = true
+	}
+	for _, path := range topByDegree(headIn, headOut, limit) {
+		candidates[path] = true
+	}
+
+	changes := []models.NodeDegreeChange{}
+	for _, path := range sortedKeys(candidates) {
+		change := models.NodeDegreeChange{
+			Path:         path,
+			FanInBefore:  baseIn[path],
+			FanInAfter:   headIn[path],
+			FanOutBefore: baseOut[path],
+			FanOutAfter:  headOut[path],
+		}
+		if change.FanInBefore == change.FanInAfter && change.FanOutBefore == change.FanOutAfter {
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	// Largest shifts first
+	sort.SliceStable(changes, func(i, j int) bool {
+		return degreeShift(changes[i]) > degreeShift(changes[j])
+	})
+
+	return changes
+}
+
+// topByDegree returns up to limit paths with the highest combined fan-in and fan-out
+func topByDegree(fanIn, fanOut map[string]int, limit int) []string {
+	degree := make(map[string]int)
+	for path, count := range fanIn {
+		degree[path] += count
+	}
+	for path, count := range fanOut {
+		degree[path] += count
+	}
+
+	paths := make([]string, 0, len(degree))
+	for path := range degree {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if degree[paths[i]] != degree[paths[j]] {
+			return degree[paths[i]] > degree[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+
+	if len(paths) > limit {
+		paths = paths[:limit]
+	}
+	return paths
+}
+
+// degreeShift returns the absolute total change in a node's degree
+func degreeShift(change models.NodeDegreeChange) int {
+	return abs(change.FanInAfter-change.FanInBefore) + abs(change.FanOutAfter-change.FanOutBefore)
+}
+
+// cycleKey returns a stable identifier for a sorted cycle
+func cycleKey(cycle []string) string {
+	return strings.Join(cycle, "\x00")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,465 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// Supported diagram export formats
+const (
+	FormatJSON     = "json"
+	FormatMermaid  = "mermaid"
+	FormatDOT      = "dot"
+	FormatGraphML  = "graphml"
+	FormatPlantUML = "plantuml"
+)
+
+// DefaultMaxDiagramNodes is the node count above which exports collapse directories into clusters
+const DefaultMaxDiagramNodes = 60
+
+// IsSupportedFormat reports whether format can be produced by Export
+func IsSupportedFormat(format string) bool {
+	switch strings.ToLower(format) {
+	case "", FormatJSON, FormatMermaid, FormatDOT, FormatGraphML, FormatPlantUML:
+		return true
+	}
+	return false
+}
+
+// Export renders diagram data in the given text format.
+// Output is deterministic: nodes and edges are sorted before rendering.
+func Export(data models.DiagramData, format string) (string, error) {
+	nodes, edges := sortedDiagram(data)
+
+	switch strings.ToLower(format) {
+	case FormatMermaid:
+		return exportMermaid(nodes, edges), nil
+	case FormatDOT:
+		return exportDOT(nodes, edges), nil
+	case FormatGraphML:
+		return exportGraphML(nodes, edges), nil
+	case FormatPlantUML:
+		return exportPlantUML(nodes, edges), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// DiagramData converts the import graph into diagram data
+func (g *CodeGraph) DiagramData() models.DiagramData {
+	data := models.DiagramData{
+		Nodes: []models.DiagramNode{},
+		Edges: []models.DiagramEdge{},
+	}
+
+	for _, filePath := range g.SortedFiles() {
+		data.Nodes = append(data.Nodes, models.DiagramNode{
+			ID:       filePath,
+			Label:    path.Base(filePath),
+			Type:     "file",
+			Size:     1,
+			Category: path.Dir(filePath),
+			Metadata: map[string]string{"path": filePath},
+		})
+	}
+
+	for _, edge := range g.SortedEdges() {
+		data.Edges = append(data.Edges, models.DiagramEdge{
+			Source: edge.Source,
+			Target: edge.Target,
+			Type:   "imports",
+			Weight: 1,
+		})
+	}
+
+	return data
+}
+
+// CollapseDirectories merges files into their parent directories until the diagram
+// has at most maxNodes nodes. It keeps as much directory depth as the limit allows.
+// Edges between collapsed nodes are merged and their weights summed.
+func CollapseDirectories(data models.DiagramData, maxNodes int) models.DiagramData {
+	if maxNodes <= 0 || len(data.Nodes) <= maxNodes {
+		return data
+	}
+
+	maxDepth := 0
+	for _, node := range data.Nodes {
+		if depth := len(dirSegments(node.ID)); depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+
+	// Find the deepest directory level that fits within the limit
+	depth := 1
+	for d := maxDepth; d >= 1; d-- {
+		if countCollapsed(data.Nodes, d) <= maxNodes {
+			depth = d
+			break
+		}
+	}
+
+	return collapseToDepth(data, depth)
+}
+
+// countCollapsed returns the node count after collapsing to the given directory depth
+func countCollapsed(nodes []models.DiagramNode, depth int) int {
+	ids := make(map[string]bool)
+	for _, node := range nodes {
+		ids[collapsedID(node.ID, depth)] = true
+	}
+	return len(ids)
+}
+
+// collapseToDepth merges nodes into directory clusters at the given depth
+func collapseToDepth(data models.DiagramData, depth int) models.DiagramData {
+	result := models.DiagramData{
+		Nodes: []models.DiagramNode{},
+		Edges: []models.DiagramEdge{},
+	}
+
+	mapping := make(map[string]string)
+	members := make(map[string][]models.DiagramNode)
+	for _, node := range data.Nodes {
+		id := collapsedID(node.ID, depth)
+		mapping[node.ID] = id
+		members[id] = append(members[id], node)
+	}
+
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		group := members[id]
+		if len(group) == 1 && group[0].ID == id {
+			result.Nodes = append(result.Nodes, group[0])
+			continue
+		}
+
+		size := len(group)
+		if size > 10 {
+			size = 10
+		}
+		result.Nodes = append(result.Nodes, models.DiagramNode{
+			ID:       id,
+			Label:    id + "/",
+			Type:     "directory",
+			Size:     size,
+			Category: path.Dir(id),
+			Layer:    commonLayer(group),
+			Metadata: map[string]string{"files": strconv.Itoa(len(group))},
+		})
+	}
+
+	merged := make(map[[2]string]*models.DiagramEdge)
+	var order [][2]string
+	for _, edge := range data.Edges {
+		source, okSource := mapping[edge.Source]
+		target, okTarget := mapping[edge.Target]
+		if !okSource || !okTarget || source == target {
+			continue
+		}
+
+		weight := edge.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+
+		key := [2]string{source, target}
+		if existing, ok := merged[key]; ok {
+			existing.Weight += weight
+			continue
+		}
+		merged[key] = &models.DiagramEdge{
+			Source: source,
+			Target: target,
+			Type:   "depends",
+			Weight: weight,
+		}
+		order = append(order, key)
+	}
+
+	for _, key := range order {
+		result.Edges = append(result.Edges, *merged[key])
+	}
+
+	return result
+}
+
+// collapsedID returns the directory a node collapses into at the given depth.
+// Nodes that already sit at or above that depth keep their own ID.
+func collapsedID(id string, depth int) string {
+	segments := dirSegments(id)
+	if len(segments) < depth {
+		return id
+	}
+	return strings.Join(segments[:depth], "/")
+}
+
+// dirSegments returns the directory components of a path
+func dirSegments(p string) []string {
+	dir := path.Dir(strings.Trim(p, "/"))
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// commonLayer returns the layer shared by all nodes in a group, if any
+func commonLayer(nodes []models.DiagramNode) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	layer := nodes[0].Layer
+	for _, node := range nodes[1:] {
+		if node.Layer != layer {
+			return ""
+		}
+	}
+	return layer
+}
+
+// sortedDiagram returns copies of the nodes and edges in a stable order
+func sortedDiagram(data models.DiagramData) ([]models.DiagramNode, []models.DiagramEdge) {
+	nodes := append([]models.DiagramNode(nil), data.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	known := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		known[node.ID] = true
+	}
+
+	var edges []models.DiagramEdge
+	for _, edge := range data.Edges {
+		if known[edge.Source] && known[edge.Target] {
+			edges = append(edges, edge)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		if edges[i].Target != edges[j].Target {
+			return edges[i].Target < edges[j].Target
+		}
+		return edges[i].Type < edges[j].Type
+	})
+
+	return nodes, edges
+}
+
+// nodeAliases assigns short, syntax-safe identifiers to nodes in sorted order
+func nodeAliases(nodes []models.DiagramNode) map[string]string {
+	aliases := make(map[string]string, len(nodes))
+	for i, node := range nodes {
+		aliases[node.ID] = "n" + strconv.Itoa(i)
+	}
+	return aliases
+}
+
+// clusterNodes groups nodes by their category, returning the cluster names in order
+func clusterNodes(nodes []models.DiagramNode) ([]string, map[string][]models.DiagramNode) {
+	clusters := make(map[string][]models.DiagramNode)
+	for _, node := range nodes {
+		clusters[node.Category] = append(clusters[node.Category], node)
+	}
+
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, clusters
+}
+
+// nodeLabel returns the display label for a node
+func nodeLabel(node models.DiagramNode) string {
+	if node.Label != "" {
+		return node.Label
+	}
+	return node.ID
+}
+
+// isTopLevelCluster reports whether a category should be rendered without a wrapping group
+func isTopLevelCluster(name string) bool {
+	return name == "" || name == "."
+}
+
+func exportMermaid(nodes []models.DiagramNode, edges []models.DiagramEdge) string {
+	aliases := nodeAliases(nodes)
+	escape := strings.NewReplacer(`"`, "#quot;")
+
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	names, clusters := clusterNodes(nodes)
+	for i, name := range names {
+		indent := "    "
+		if !isTopLevelCluster(name) {
+			sb.WriteString(fmt.Sprintf("    subgraph c%d[\"%s\"]\n", i, escape.Replace(name)))
+			indent = "        "
+		}
+		for _, node := range clusters[name] {
+			sb.WriteString(fmt.Sprintf("%s%s[\"%s\"]\n", indent, aliases[node.ID], escape.Replace(nodeLabel(node))))
+		}
+		if !isTopLevelCluster(name) {
+			sb.WriteString("    end\n")
+		}
+	}
+
+	for _, edge := range edges {
+		arrow := "-->"
+		if edge.Bidirectional {
+			arrow = "<-->"
+		}
+		if edge.Label != "" {
+			sb.WriteString(fmt.Sprintf("    %s %s|\"%s\"| %s\n", aliases[edge.Source], arrow, escape.Replace(edge.Label), aliases[edge.Target]))
+		} else {
+			sb.WriteString(fmt.Sprintf("    %s %s %s\n", aliases[edge.Source], arrow, aliases[edge.Target]))
+		}
+	}
+
+	return sb.String()
+}
+
+func exportDOT(nodes []models.DiagramNode, edges []models.DiagramEdge) string {
+	quote := func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+	}
+
+	var sb strings.Builder
+	sb.WriteString("digraph architecture {\n")
+	sb.WriteString("    rankdir=LR;\n")
+	sb.WriteString("    node [shape=box];\n")
+
+	names, clusters := clusterNodes(nodes)
+	for i, name := range names {
+		indent := "    "
+		if !isTopLevelCluster(name) {
+			sb.WriteString(fmt.Sprintf("    subgraph cluster_%d {\n", i))
+			sb.WriteString(fmt.Sprintf("        label=%s;\n", quote(name)))
+			indent = "        "
+		}
+		for _, node := range clusters[name] {
+			shape := ""
+			if node.Type == "directory" {
+				shape = ", shape=folder"
+			}
+			sb.WriteString(fmt.Sprintf("%s%s [label=%s%s];\n", indent, quote(node.ID), quote(nodeLabel(node)), shape))
+		}
+		if !isTopLevelCluster(name) {
+			sb.WriteString("    }\n")
+		}
+	}
+
+	for _, edge := range edges {
+		var attrs []string
+		if edge.Label != "" {
+			attrs = append(attrs, "label="+quote(edge.Label))
+		}
+		if edge.Weight > 1 {
+			attrs = append(attrs, "penwidth="+strconv.Itoa(min(edge.Weight, 5)))
+		}
+		if edge.Bidirectional {
+			attrs = append(attrs, "dir=both")
+		}
+		attrStr := ""
+		if len(attrs) > 0 {
+			attrStr = " [" + strings.Join(attrs, ", ") + "]"
+		}
+		sb.WriteString(fmt.Sprintf("    %s -> %s%s;\n", quote(edge.Source), quote(edge.Target), attrStr))
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func exportGraphML(nodes []models.DiagramNode, edges []models.DiagramEdge) string {
+	escape := strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `>`, "&gt;", `"`, "&quot;", `'`, "&apos;")
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	sb.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="cluster" for="node" attr.name="cluster" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="layer" for="node" attr.name="layer" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="size" for="node" attr.name="size" attr.type="int"/>` + "\n")
+	sb.WriteString(`  <key id="relation" for="edge" attr.name="relation" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="weight" for="edge" attr.name="weight" attr.type="int"/>` + "\n")
+	sb.WriteString(`  <graph id="architecture" edgedefault="directed">` + "\n")
+
+	for _, node := range nodes {
+		sb.WriteString(fmt.Sprintf("    <node id=\"%s\">\n", escape.Replace(node.ID)))
+		sb.WriteString(fmt.Sprintf("      <data key=\"label\">%s</data>\n", escape.Replace(nodeLabel(node))))
+		sb.WriteString(fmt.Sprintf("      <data key=\"type\">%s</data>\n", escape.Replace(node.Type)))
+		sb.WriteString(fmt.Sprintf("      <data key=\"cluster\">%s</data>\n", escape.Replace(node.Category)))
+		if node.Layer != "" {
+			sb.WriteString(fmt.Sprintf("      <data key=\"layer\">%s</data>\n", escape.Replace(node.Layer)))
+		}
+		sb.WriteString(fmt.Sprintf("      <data key=\"size\">%d</data>\n", node.Size))
+		sb.WriteString("    </node>\n")
+	}
+
+	for i, edge := range edges {
+		sb.WriteString(fmt.Sprintf("    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, escape.Replace(edge.Source), escape.Replace(edge.Target)))
+		sb.WriteString(fmt.Sprintf("      <data key=\"relation\">%s</data>\n", escape.Replace(edge.Type)))
+		sb.WriteString(fmt.Sprintf("      <data key=\"weight\">%d</data>\n", edge.Weight))
+		sb.WriteString("    </edge>\n")
+	}
+
+	sb.WriteString("  </graph>\n")
+	sb.WriteString("</graphml>\n")
+	return sb.String()
+}
+
+func exportPlantUML(nodes []models.DiagramNode, edges []models.DiagramEdge) string {
+	aliases := nodeAliases(nodes)
+	escape := strings.NewReplacer(`"`, `'`)
+
+	var sb strings.Builder
+	sb.WriteString("@startuml\n")
+	sb.WriteString("left to right direction\n")
+
+	names, clusters := clusterNodes(nodes)
+	for _, name := range names {
+		indent := ""
+		if !isTopLevelCluster(name) {
+			sb.WriteString(fmt.Sprintf("package \"%s\" {\n", escape.Replace(name)))
+			indent = "  "
+		}
+		for _, node := range clusters[name] {
+			kind := "component"
+			if node.Type == "directory" {
+				kind = "folder"
+			}
+			sb.WriteString(fmt.Sprintf("%s%s \"%s\" as %s\n", indent, kind, escape.Replace(nodeLabel(node)), aliases[node.ID]))
+		}
+		if !isTopLevelCluster(name) {
+			sb.WriteString("}\n")
+		}
+	}
+
+	for _, edge := range edges {
+		arrow := "-->"
+		if edge.Bidirectional {
+			arrow = "<-->"
+		}
+		label := ""
+		if edge.Label != "" {
+			label = " : " + escape.Replace(edge.Label)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s %s%s\n", aliases[edge.Source], arrow, aliases[edge.Target], label))
+	}
+
+	sb.WriteString("@enduml\n")
+	return sb.String()
+}
@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// Graph history is stored with structural sharing: every file path and every import
+// edge of a repository is stored once, and carries the list of commit SHAs in which
+// it exists. A snapshot for a commit is the set of files and edges whose list contains
+// its SHA, so unchanged parts of the graph are never duplicated between commits.
+//
+//	(Repository)-[:HAS_COMMIT]->(Commit {sha, date, message, ref})
+//	(HistoricalFile {owner, repo, path, commits})-[:DEPENDS_ON {commits}]->(HistoricalFile)
+
+// HasCommitSnapshot reports whether the graph for a commit has already been stored
+func (c *Neo4jClient) HasCommitSnapshot(ctx context.Context, owner, repo, sha string) (bool, error) {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		query := `
+			MATCH (r:Repository {owner: $owner, name: $repo})-[:HAS_COMMIT]->(c:Commit {sha: $sha})
+			RETURN count(c) > 0
+		`
+		records, err := tx.Run(query, map[string]interface{}{
+			"owner": owner,
+			"repo":  repo,
+			"sha":   sha,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if records.Next() {
+			return records.Record().Values[0], nil
+		}
+		return false, records.Err()
+	})
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check commit snapshot: %w", err)
+	}
+
+	exists, _ := result.(bool)
+	return exists, nil
+}
+
+// StoreCommitSnapshot stores the graph of a single commit, sharing file and edge
+// nodes with snapshots of other commits
+func (c *Neo4jClient) StoreCommitSnapshot(ctx context.Context, owner, repo string, commit models.GraphCommit, g *CodeGraph) error {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	files := g.SortedFiles()
+	edges := make([]map[string]interface{}, 0, g.EdgeCount())
+	for _, edge := range g.SortedEdges() {
+		edges = append(edges, map[string]interface{}{
+			"source": edge.Source,
+			"target": edge.Target,
+		})
+	}
+
+	params := map[string]interface{}{
+		"owner":   owner,
+		"repo":    repo,
+		"sha":     commit.SHA,
+		"ref":     commit.Ref,
+		"message": commit.Message,
+		"date":    commit.Date.UTC().Format(time.RFC3339),
+		"files":   files,
+		"edges":   edges,
+	}
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		queries := []string{
+			`
+			MERGE (r:Repository {owner: $owner, name: $repo})
+			MERGE (r)-[:HAS_COMMIT]->(c:Commit {sha: $sha})
+			SET c.ref = $ref, c.message = $message, c.date = $date
+			`,
+			`
+			UNWIND $files AS path
+			MERGE (f:HistoricalFile {owner: $owner, repo: $repo, path: path})
+			SET f.commits = CASE WHEN $sha IN coalesce(f.commits, []) THEN f.commits ELSE coalesce(f.commits, []) + $sha END
+			`,
+			`
+			UNWIND $edges AS edge
+			MATCH (s:HistoricalFile {owner: $owner, repo: $repo, path: edge.source})
+			MATCH (t:HistoricalFile {owner: $owner, repo: $repo, path: edge.target})
+			MERGE (s)-[d:DEPENDS_ON]->(t)
+			SET d.commits = CASE WHEN $sha IN coalesce(d.commits, []) THEN d.commits ELSE coalesce(d.commits, []) + $sha END
+			`,
+		}
+
+		for _, query := range queries {
+			if _, err := tx.Run(query, params); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to store commit snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetCommitGraph reconstructs the graph stored for a commit
+func (c *Neo4jClient) GetCommitGraph(ctx context.Context, owner, repo, sha string) (*CodeGraph, error) {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		query := `
+			MATCH (f:HistoricalFile {owner: $owner, repo: $repo})
+			WHERE $sha IN f.commits
+			OPTIONAL MATCH (f)-[d:DEPENDS_ON]->(t:HistoricalFile)
+			WHERE $sha IN d.commits
+			RETURN f.path AS path, collect(t.path) AS imports
+		`
+		records, err := tx.Run(query, map[string]interface{}{
+			"owner": owner,
+			"repo":  repo,
+			"sha":   sha,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		g := NewCodeGraph(nil, nil)
+		for records.Next() {
+			record := records.Record()
+			path, _ := record.Values[0].(string)
+			g.Files[path] = true
+
+			imports, _ := record.Values[1].([]interface{})
+			for _, target := range imports {
+				if targetPath, ok := target.(string); ok {
+					g.AddEdge(path, targetPath)
+				}
+			}
+		}
+		return g, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit graph: %w", err)
+	}
+
+	return result.(*CodeGraph), nil
+}
+
+// HistoricalEdge is an import edge together with the commits in which it exists
+type HistoricalEdge struct {
+	models.GraphEdge
+	Commits map[string]bool
+}
+
+// GetHistoricalEdges returns every stored edge between files under the two path prefixes
+func (c *Neo4jClient) GetHistoricalEdges(ctx context.Context, owner, repo, fromPrefix, toPrefix string) ([]HistoricalEdge, error) {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		query := `
+			MATCH (s:HistoricalFile {owner: $owner, repo: $repo})-[d:DEPENDS_ON]->(t:HistoricalFile)
+			WHERE s.path STARTS WITH $from AND t.path STARTS WITH $to
+			RETURN s.path AS source, t.path AS target, d.commits AS commits
+			ORDER BY source, target
+		`
+		records, err := tx.Run(query, map[string]interface{}{
+			"owner": owner,
+			"repo":  repo,
+			"from":  fromPrefix,
+			"to":    toPrefix,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var edges []HistoricalEdge
+		for records.Next() {
+			record := records.Record()
+			edge := HistoricalEdge{Commits: make(map[string]bool)}
+			edge.Source, _ = record.Values[0].(string)
+			edge.Target, _ = record.Values[1].(string)
+
+			commits, _ := record.Values[2].([]interface{})
+			for _, sha := range commits {
+				if s, ok := sha.(string); ok {
+					edge.Commits[s] = true
+				}
+			}
+			edges = append(edges, edge)
+		}
+		return edges, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get historical edges: %w", err)
+	}
+
+	edges, _ := result.([]HistoricalEdge)
+	return edges, nil
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"path"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+)
+
+// ComputeMetrics returns size and coupling metrics for a graph
+func ComputeMetrics(g *CodeGraph) models.GraphMetrics {
+	metrics := models.GraphMetrics{
+		Files:  len(g.Files),
+		Edges:  g.EdgeCount(),
+		Cycles: len(g.Cycles()),
+	}
+
+	for _, count := range g.FanIn() {
+		if count > metrics.MaxFanIn {
+			metrics.MaxFanIn = count
+		}
+	}
+	for _, count := range g.FanOut() {
+		if count > metrics.MaxFanOut {
+			metrics.MaxFanOut = count
+		}
+	}
+
+	for source, targets := range g.Imports {
+		for target := range targets {
+			if path.Dir(source) != path.Dir(target) {
+				metrics.CrossDirectoryEdges++
+			}
+		}
+	}
+
+	if metrics.Files > 0 {
+		metrics.AverageFanOut = float64(metrics.Edges) / float64(metrics.Files)
+	}
+	if metrics.Edges > 0 {
+		metrics.CouplingRatio = float64(metrics.CrossDirectoryEdges) / float64(metrics.Edges)
+	}
+
+	return metrics
+}
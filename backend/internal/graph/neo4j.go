@@ -143,6 +143,69 @@ func (c *Neo4jClient) StoreCodebaseStructure(ctx context.Context, owner, repo, b
 	return nil
 }
 
+// StoreExternalDependencies stores the external dependencies of a stored branch as Dependency
+// nodes, linked from the files importing them. Call it after StoreCodebaseStructure, which
+// clears them along with the rest of the branch.
+func (c *Neo4jClient) StoreExternalDependencies(ctx context.Context, owner, repo, branch string,
+                                                dependencies map[string]models.ExternalDependency, external map[string][]string) error {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	// Create dependency nodes
+	for _, dep := range dependencies {
+		_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+			query := `
+				MATCH (b:Branch {name: $branch})-[:HAS_BRANCH]-(r:Repository {owner: $owner, name: $repo})
+				MERGE (b)-[:HAS_DEPENDENCY]->(d:Dependency {name: $name})
+				SET d.kind = $kind, d.version = $version
+				RETURN d
+			`
+			_, err := tx.Run(query, map[string]interface{}{
+				"owner":   owner,
+				"repo":    repo,
+				"branch":  branch,
+				"name":    dep.Name,
+				"kind":    dep.Kind,
+				"version": dep.Version,
+			})
+			return nil, err
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to create dependency node %s: %w", dep.Name, err)
+		}
+	}
+
+	// Create dependency relationships
+	for source, names := range external {
+		for _, name := range names {
+			_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+				query := `
+					MATCH (b:Branch {name: $branch})-[:HAS_BRANCH]-(r:Repository {owner: $owner, name: $repo})
+					MATCH (b)-[:CONTAINS]->(source:File {path: $source})
+					MATCH (b)-[:HAS_DEPENDENCY]->(d:Dependency {name: $name})
+					MERGE (source)-[:DEPENDS_ON]->(d)
+					RETURN source, d
+				`
+				_, err := tx.Run(query, map[string]interface{}{
+					"owner":  owner,
+					"repo":   repo,
+					"branch": branch,
+					"source": source,
+					"name":   name,
+				})
+				return nil, err
+			})
+
+			if err != nil {
+				c.logger.WithField("error", err).Warning(fmt.Sprintf("Failed to create dependency relationship: %s -> %s", source, name))
+			}
+		}
+	}
+
+	return nil
+}
+
 // GetCodebaseGraph retrieves the codebase graph from Neo4j
 func (c *Neo4jClient) GetCodebaseGraph(ctx context.Context, owner, repo, branch string) (map[string]interface{}, error) {
 	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
@@ -184,4 +247,66 @@ func (c *Neo4jClient) GetCodebaseGraph(ctx context.Context, owner, repo, branch
 	}
 
 	return result.(map[string]interface{}), nil
-}
\ No newline at end of file
+}
+// GetImportGraph retrieves the stored import graph for a branch as a CodeGraph.
+// It returns nil if nothing has been stored for the branch.
+func (c *Neo4jClient) GetImportGraph(ctx context.Context, owner, repo, branch string) (*CodeGraph, error) {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		query := `
+			MATCH (b:Branch {name: $branch})-[:HAS_BRANCH]-(r:Repository {owner: $owner, name: $repo})
+			MATCH (b)-[:CONTAINS]->(f:File)
+			OPTIONAL MATCH (f)-[:IMPORTS]->(f2:File)
+			RETURN f.path AS path, f.type AS type, collect(f2.path) AS imports
+		`
+		records, err := tx.Run(query, map[string]interface{}{
+			"owner":  owner,
+			"repo":   repo,
+			"branch": branch,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		g := &CodeGraph{
+			Files:   make(map[string]bool),
+			Imports: make(map[string]map[string]bool),
+		}
+		found := false
+
+		for records.Next() {
+			found = true
+			record := records.Record()
+
+			path, _ := record.Values[0].(string)
+			fileType, _ := record.Values[1].(string)
+			if fileType != "file" {
+				continue
+			}
+			g.Files[path] = true
+
+			imports, _ := record.Values[2].([]interface{})
+			for _, target := range imports {
+				if targetPath, ok := target.(string); ok {
+					g.AddEdge(path, targetPath)
+				}
+			}
+		}
+
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+		if !found {
+			return (*CodeGraph)(nil), nil
+		}
+		return g, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import graph: %w", err)
+	}
+
+	return result.(*CodeGraph), nil
+}
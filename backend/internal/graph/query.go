@@ -0,0 +1,411 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// MaxQueryRows is the maximum number of rows a generated graph query may return
+const MaxQueryRows = 200
+
+// ErrQueryNotAllowed is returned when a generated query fails validation
+var ErrQueryNotAllowed = errors.New("graph query not allowed")
+
+// GraphQuerySchema describes the part of the graph that generated queries may read
+const GraphQuerySchema = `
+(:Repository {owner, name})-[:HAS_BRANCH]->(:Branch {name})
+(:Branch)-[:CONTAINS]->(:File {path, name, type})
+(:File)-[:IMPORTS]->(:File)
+(:Repository)-[:HAS_COMMIT]->(:Commit {sha, date, message, ref})
+(:HistoricalFile {owner, repo, path, commits})-[:DEPENDS_ON {commits}]->(:HistoricalFile)
+
+Parameters always available: $owner, $repo, $branch
+
+Every MATCH pattern must include (:Repository {owner: $owner, name: $repo}) or
+(:HistoricalFile {owner: $owner, repo: $repo}), or a variable bound by an earlier pattern.
+`
+
+var (
+	allowedLabels = map[string]bool{
+		"Repository":     true,
+		"Branch":         true,
+		"File":           true,
+		"Commit":         true,
+		"HistoricalFile": true,
+	}
+
+	allowedRelationships = map[string]bool{
+		"HAS_BRANCH": true,
+		"CONTAINS":   true,
+		"IMPORTS":    true,
+		"HAS_COMMIT": true,
+		"DEPENDS_ON": true,
+	}
+
+	allowedFunctions = map[string]bool{
+		"count": true, "collect": true, "size": true, "length": true,
+		"min": true, "max": true, "sum": true, "avg": true,
+		"tolower": true, "toupper": true, "trim": true, "split": true,
+		"replace": true, "substring": true, "left": true, "right": true,
+		"coalesce": true, "exists": true, "head": true, "last": true,
+		"type": true, "labels": true, "keys": true, "id": true,
+		"nodes": true, "relationships": true, "reverse": true, "range": true,
+		"tostring": true, "tointeger": true, "tofloat": true,
+		"any": true, "all": true, "none": true, "single": true,
+	}
+
+	forbiddenKeywords = []string{
+		"CREATE", "MERGE", "DELETE", "DETACH", "SET", "REMOVE", "DROP",
+		"CALL", "LOAD", "FOREACH", "USING", "PERIODIC", "YIELD",
+		"UNION", "GRANT", "REVOKE", "DENY", "SHOW", "START", "STOP", "ALTER",
+	}
+
+	stringLiteralPattern = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	mapLiteralPattern    = regexp.MustCompile(`\{[^{}]*\}`)
+	labelPattern         = regexp.MustCompile(`:\s*([A-Za-z_]\w*)`)
+	relationshipPattern  = regexp.MustCompile(`\[\s*\w*\s*:\s*([\w|:\s]+?)\s*(?:\*[\d.]*)?\s*(?:\{|\])`)
+	functionPattern      = regexp.MustCompile(`([A-Za-z_][\w.]*)\s*\(`)
+	limitPattern         = regexp.MustCompile(`(?i)\bLIMIT\s+(\d+)\s*$`)
+	wordPattern          = regexp.MustCompile(`[A-Za-z_]+`)
+
+	subqueryPattern      = regexp.MustCompile(`(?i)\b(?:EXISTS|COUNT|COLLECT)\s*\{`)
+	comprehensionPattern = regexp.MustCompile(`\[\s*(?:[A-Za-z_]\w*\s*=\s*)?\(`)
+	clausePattern        = regexp.MustCompile(`(?i)\b(?:STARTS\s+WITH|ENDS\s+WITH|OPTIONAL\s+MATCH|MATCH|WHERE|WITH|UNWIND|RETURN|ORDER\s+BY|SKIP|LIMIT)\b`)
+	pathVariablePattern  = regexp.MustCompile(`^\s*([A-Za-z_]\w*)\s*=`)
+	nodePattern          = regexp.MustCompile(`^\s*([A-Za-z_]\w*)?\s*((?::\s*\w+\s*)*)(\{[^{}]*\})?\s*$`)
+	elementPattern       = regexp.MustCompile(`^[(\[]\s*([A-Za-z_]\w*)`)
+	aliasPattern         = regexp.MustCompile(`(?i)\bAS\s+([A-Za-z_]\w*)`)
+	predicatePattern     = regexp.MustCompile(`\([^()]*\)(?:\s*<?-(?:\[[^\[\]]*\])?->?\s*\([^()]*\))+`)
+)
+
+// scopedNodes are the labels whose nodes belong to one repository, with the properties and
+// parameters that select the requested one
+var scopedNodes = map[string]map[string]string{
+	"Repository":     {"owner": "$owner", "name": "$repo"},
+	"HistoricalFile": {"owner": "$owner", "repo": "$repo"},
+}
+
+// ValidateReadOnlyQuery checks a generated Cypher query against the allow-list and
+// returns the query to execute. The query must be a single read-only statement that
+// only touches known labels and relationships, and is scoped to the requested repository.
+// A LIMIT is appended or lowered so at most MaxQueryRows rows are returned.
+func ValidateReadOnlyQuery(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	query = strings.TrimPrefix(query, "```cypher")
+	query = strings.TrimPrefix(query, "```")
+	query = strings.TrimSuffix(query, "```")
+	query = strings.TrimSpace(query)
+	query = strings.TrimSuffix(query, ";")
+	query = strings.TrimSpace(query)
+
+	if query == "" {
+		return "", fmt.Errorf("%w: empty query", ErrQueryNotAllowed)
+	}
+
+	// Keywords hidden inside string literals are harmless, so check the query without them
+	stripped := stringLiteralPattern.ReplaceAllString(query, "''")
+
+	if strings.ContainsAny(stripped, ";`") {
+		return "", fmt.Errorf("%w: multiple statements and quoted identifiers are not allowed", ErrQueryNotAllowed)
+	}
+	if strings.Contains(stripped, "//") || strings.Contains(stripped, "/*") {
+		return "", fmt.Errorf("%w: comments are not allowed", ErrQueryNotAllowed)
+	}
+
+	upper := strings.ToUpper(stripped)
+	words := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(upper, -1) {
+		words[word] = true
+	}
+	for _, keyword := range forbiddenKeywords {
+		if words[keyword] {
+			return "", fmt.Errorf("%w: %s is not permitted in read-only queries", ErrQueryNotAllowed, keyword)
+		}
+	}
+
+	first := strings.Fields(upper)[0]
+	if first != "MATCH" && first != "OPTIONAL" && first != "WITH" && first != "UNWIND" {
+		return "", fmt.Errorf("%w: query must start with MATCH", ErrQueryNotAllowed)
+	}
+	if !strings.Contains(upper, "RETURN") {
+		return "", fmt.Errorf("%w: query must RETURN results", ErrQueryNotAllowed)
+	}
+
+	if subqueryPattern.MatchString(stripped) || comprehensionPattern.MatchString(stripped) {
+		return "", fmt.Errorf("%w: subqueries and pattern comprehensions are not allowed", ErrQueryNotAllowed)
+	}
+
+	// Labels appear in node patterns and in predicates such as WHERE n:File. Colons inside
+	// maps separate keys from values, so leave the maps out.
+	withoutMaps := stripped
+	for mapLiteralPattern.MatchString(withoutMaps) {
+		withoutMaps = mapLiteralPattern.ReplaceAllString(withoutMaps, "")
+	}
+	for _, match := range labelPattern.FindAllStringSubmatch(withoutMaps, -1) {
+		if !allowedLabels[match[1]] && !allowedRelationships[match[1]] {
+			return "", fmt.Errorf("%w: unknown label %s", ErrQueryNotAllowed, match[1])
+		}
+	}
+
+	for _, match := range relationshipPattern.FindAllStringSubmatch(stripped, -1) {
+		for _, relType := range strings.FieldsFunc(match[1], func(r rune) bool { return r == '|' || r == ':' }) {
+			relType = strings.TrimSpace(relType)
+			if relType != "" && !allowedRelationships[relType] {
+				return "", fmt.Errorf("%w: unknown relationship %s", ErrQueryNotAllowed, relType)
+			}
+		}
+	}
+
+	for _, match := range functionPattern.FindAllStringSubmatch(stripped, -1) {
+		name := strings.ToLower(match[1])
+		if isCypherKeyword(name) {
+			continue
+		}
+		if !allowedFunctions[name] {
+			return "", fmt.Errorf("%w: function %s is not permitted", ErrQueryNotAllowed, match[1])
+		}
+	}
+
+	// Queries must stay within the requested repository
+	if err := checkRepositoryScope(stripped); err != nil {
+		return "", err
+	}
+
+	if match := limitPattern.FindStringSubmatchIndex(query); match != nil {
+		limit, err := strconv.Atoi(query[match[2]:match[3]])
+		if err != nil || limit > MaxQueryRows {
+			query = query[:match[2]] + strconv.Itoa(MaxQueryRows)
+		}
+	} else {
+		query = fmt.Sprintf("%s\nLIMIT %d", query, MaxQueryRows)
+	}
+
+	return query, nil
+}
+
+// checkRepositoryScope checks that every MATCH pattern is anchored in the requested
+// repository. Stored graphs share no nodes between repositories, so a connected pattern
+// that includes a scoped node, or a variable bound by an earlier scoped pattern, cannot
+// reach another repository's graph.
+func checkRepositoryScope(query string) error {
+	bound := make(map[string]bool)
+	clauses := clausePattern.FindAllStringIndex(query, -1)
+	for i := 0; i < len(clauses); i++ {
+		keyword := strings.ToUpper(strings.Join(strings.Fields(query[clauses[i][0]:clauses[i][1]]), " "))
+		if keyword == "STARTS WITH" || keyword == "ENDS WITH" {
+			continue
+		}
+		// The clause runs to the next clause keyword that isn't part of an operator
+		end := len(query)
+		for j := i + 1; j < len(clauses); j++ {
+			next := strings.ToUpper(strings.Fields(query[clauses[j][0]:clauses[j][1]])[0])
+			if next != "STARTS" && next != "ENDS" {
+				end = clauses[j][0]
+				break
+			}
+		}
+		body := query[clauses[i][1]:end]
+
+		switch keyword {
+		case "MATCH", "OPTIONAL MATCH":
+			for _, pattern := range splitTopLevel(body, ',') {
+				if !patternIsScoped(pattern, bound) {
+					return fmt.Errorf("%w: MATCH %s must start from (:Repository {owner: $owner, name: $repo}), (:HistoricalFile {owner: $owner, repo: $repo}) or a variable bound by an earlier pattern", ErrQueryNotAllowed, strings.TrimSpace(pattern))
+				}
+				for _, name := range patternVariables(pattern) {
+					bound[name] = true
+				}
+			}
+		case "WITH", "UNWIND":
+			for _, match := range aliasPattern.FindAllStringSubmatch(body, -1) {
+				bound[match[1]] = true
+			}
+		default:
+			// Patterns used as predicates must be anchored the same way
+			for _, pattern := range predicatePattern.FindAllString(body, -1) {
+				if !patternIsScoped(pattern, bound) {
+					return fmt.Errorf("%w: pattern %s must include a variable bound by MATCH", ErrQueryNotAllowed, pattern)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// patternIsScoped reports whether a path pattern includes a node of the requested repository
+// or a variable bound by an earlier pattern
+func patternIsScoped(pattern string, bound map[string]bool) bool {
+	for _, node := range patternNodes(pattern) {
+		match := nodePattern.FindStringSubmatch(node)
+		if match == nil {
+			continue
+		}
+		if match[1] != "" && bound[match[1]] {
+			return true
+		}
+		labels := strings.FieldsFunc(match[2], func(r rune) bool { return r == ':' || r == ' ' || r == '\t' || r == '\n' })
+		if len(labels) != 1 || scopedNodes[labels[0]] == nil || match[3] == "" {
+			continue
+		}
+		properties := make(map[string]string)
+		for _, property := range splitTopLevel(strings.Trim(match[3], "{}"), ',') {
+			if key, value, ok := strings.Cut(property, ":"); ok {
+				properties[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+		scoped := true
+		for key, param := range scopedNodes[labels[0]] {
+			if properties[key] != param {
+				scoped = false
+			}
+		}
+		if scoped {
+			return true
+		}
+	}
+	return false
+}
+
+// patternNodes returns the contents of the node patterns of a path pattern
+func patternNodes(pattern string) []string {
+	var nodes []string
+	depth, start := 0, 0
+	for i, r := range pattern {
+		switch r {
+		case '(', '[', '{':
+			if depth == 0 && r == '(' {
+				start = i + 1
+			}
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 && r == ')' {
+				nodes = append(nodes, pattern[start:i])
+			}
+		}
+	}
+	return nodes
+}
+
+// patternVariables returns the path, node and relationship variables a pattern binds
+func patternVariables(pattern string) []string {
+	var names []string
+	if match := pathVariablePattern.FindStringSubmatch(pattern); match != nil {
+		names = append(names, match[1])
+	}
+	depth := 0
+	for i, r := range pattern {
+		switch r {
+		case '(', '[', '{':
+			if depth == 0 && r != '{' {
+				if match := elementPattern.FindStringSubmatch(pattern[i:]); match != nil {
+					names = append(names, match[1])
+				}
+			}
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+	return names
+}
+
+// splitTopLevel splits s at the separators outside parentheses, brackets and braces
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// isCypherKeyword reports whether a word followed by a parenthesis is a clause, not a function
+func isCypherKeyword(word string) bool {
+	switch word {
+	case "match", "where", "and", "or", "not", "xor", "in", "return", "with",
+		"unwind", "as", "distinct", "optional", "is", "null", "contains",
+		"starts", "ends", "case", "when", "then", "else", "end", "order", "by":
+		return true
+	}
+	return false
+}
+
+// RunReadQuery executes a validated query in a read-only session and returns its rows
+func (c *Neo4jClient) RunReadQuery(ctx context.Context, query string, params map[string]interface{}) ([]map[string]interface{}, error) {
+	session := c.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := []map[string]interface{}{}
+		for records.Next() {
+			record := records.Record()
+			row := make(map[string]interface{}, len(record.Keys))
+			for i, key := range record.Keys {
+				row[key] = plainValue(record.Values[i])
+			}
+			rows = append(rows, row)
+			if len(rows) >= MaxQueryRows {
+				break
+			}
+		}
+		return rows, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to run graph query: %w", err)
+	}
+
+	return result.([]map[string]interface{}), nil
+}
+
+// plainValue converts driver graph types into JSON-friendly values
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case neo4j.Node:
+		return v.Props
+	case neo4j.Relationship:
+		return map[string]interface{}{"type": v.Type, "properties": v.Props}
+	case neo4j.Path:
+		nodes := make([]interface{}, len(v.Nodes))
+		for i, node := range v.Nodes {
+			nodes[i] = node.Props
+		}
+		return nodes
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = plainValue(item)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			values[key] = plainValue(item)
+		}
+		return values
+	default:
+		return v
+	}
+}
@@ -11,8 +11,9 @@ import (
 // GeminiClient represents a Gemini API client
 type GeminiClient struct {
 	client *genai.Client
-	model  *genai.GenerativeModel
-	logger *common.Logger
+	model    *genai.GenerativeModel
+	logger   *common.Logger
+	guidance Guidance // Added to every prompt, see WithGuidance
 }
 
 // NewGeminiClient creates a new Gemini client
@@ -43,7 +44,7 @@ func (c *GeminiClient) GenerateText(ctx context.Context, prompt string) (string,
 		return "", common.NewError("prompt cannot be empty")
 	}
 
-	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
+	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt+c.guidance.prompt()))
 	if err != nil {
 		return "", common.WrapError(err, "failed to generate content")
 	}
@@ -155,4 +156,21 @@ func (c *GeminiClient) GenerateBestPracticesGuide(ctx context.Context, repoInfo
 func (c *GeminiClient) GenerateArchitectureExplanation(ctx context.Context, graphData map[string]interface{}) (string, error) {
     prompt := buildArchitectureExplanationPrompt(graphData)
     return c.GenerateCompletion(ctx, prompt, 0.7, 1024)
-}
\ No newline at end of file
+}
+// GenerateGraphDiffNarrative explains how the architecture changed between two refs
+func (c *GeminiClient) GenerateGraphDiffNarrative(ctx context.Context, baseRef, headRef string, diff interface{}) (string, error) {
+	prompt := buildGraphDiffNarrativePrompt(baseRef, headRef, diff)
+	return c.GenerateCompletion(ctx, prompt, 0.4, 1024)
+}
+
+// GenerateGraphQuery translates a natural-language question into a Cypher query over the code graph
+func (c *GeminiClient) GenerateGraphQuery(ctx context.Context, question, schema string, maxRows int) (string, error) {
+	prompt := buildGraphQueryPrompt(question, schema, maxRows)
+	return c.GenerateCompletion(ctx, prompt, 0.1, 512)
+}
+
+// AnswerGraphQuery explains the results of a code graph query in natural language
+func (c *GeminiClient) AnswerGraphQuery(ctx context.Context, question, query string, rows interface{}) (string, error) {
+	prompt := buildGraphQueryAnswerPrompt(question, query, rows)
+	return c.GenerateCompletion(ctx, prompt, 0.3, 1024)
+}
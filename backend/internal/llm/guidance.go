@@ -0,0 +1,43 @@
+package llm
+
+import "strings"
+
+// Guidance is what a repository asks of the text generated for it, from its agent
+// configuration. It is added to every prompt of a client made with WithGuidance.
+type Guidance struct {
+	Tone         string   // Voice of generated prose
+	Instructions string   // Extra instructions from the repository
+	DocStyle     string   // Documentation comment convention, for generated comments
+	Layers       []string // Layering rules, one per line
+}
+
+// WithGuidance returns a copy of the client that adds the guidance to its prompts
+func (c *GeminiClient) WithGuidance(guidance Guidance) *GeminiClient {
+	guided := *c
+	guided.guidance = guidance
+	return &guided
+}
+
+// prompt returns the guidance as a prompt section, empty when there is none
+func (g Guidance) prompt() string {
+	var b strings.Builder
+	if g.Tone != "" {
+		b.WriteString("- Tone: " + g.Tone + "\n")
+	}
+	if g.DocStyle != "" {
+		b.WriteString("- Documentation comments follow the " + g.DocStyle + " style\n")
+	}
+	if len(g.Layers) > 0 {
+		b.WriteString("- The codebase is layered; each layer may only import the layers listed:\n")
+		for _, layer := range g.Layers {
+			b.WriteString("  - " + layer + "\n")
+		}
+	}
+	if g.Instructions != "" {
+		b.WriteString("- " + strings.ReplaceAll(strings.TrimSpace(g.Instructions), "\n", "\n  ") + "\n")
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\nRepository guidelines, set by the maintainers in .github-agent.yml:\n" + b.String()
+}
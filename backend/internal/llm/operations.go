@@ -3,8 +3,8 @@ package llm
 import (
 	"context"
 	"fmt"
-	"strings"
 
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -118,23 +118,7 @@ func (c *GeminiClient) GetModel() string {
 	return "gemini-1.5-pro" // This is hardcoded for now, but could be made configurable
 }
 
-// DetectLanguage attempts to detect the programming language from code
+// DetectLanguage detects the language of code from its shebang and content heuristics
 func DetectLanguage(code string) string {
-	// This is a very basic implementation
-	// A more robust implementation would use language detection libraries or heuristics
-	
-	if strings.Contains(code, "function") && strings.Contains(code, "var") {
-		return "javascript"
-	}
-	if strings.Contains(code, "def") && strings.Contains(code, "import") {
-		return "python"
-	}
-	if strings.Contains(code, "func") && strings.Contains(code, "package") {
-		return "go"
-	}
-	if strings.Contains(code, "class") && strings.Contains(code, "public") {
-		return "java"
-	}
-	
-	return "unknown"
+	return utils.LanguageID("", code)
 }
\ No newline at end of file
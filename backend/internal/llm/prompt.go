@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/utils"
 )
 
 // buildReadmePrompt builds a prompt for generating a README.md file
@@ -87,6 +89,12 @@ Return the commented code in the same language and formatting as the original.
 
 // buildCodeRefactorPrompt builds a prompt for code refactoring
 func buildCodeRefactorPrompt(code string, language string, instructions string) string {
+	// Measured complexity backs the suggestions with evidence
+	var metrics string
+	if info, err := utils.ParseCode(code, language); err == nil && len(info.Metrics) > 0 {
+		metrics = "\nMeasured function metrics (use them as evidence; prioritize the flagged functions):\n" + formatFunctionMetrics("", info.Metrics) + "\n"
+	}
+
 	// Avoid triple backticks in the prompt
 	return fmt.Sprintf(`
 You are an expert developer in %s. Your task is to refactor the following code according to these instructions:
@@ -98,14 +106,14 @@ Here is the code to refactor:
 CODE START
 %s
 CODE END
-
+%s
 Please provide:
 1. The refactored code
 2. A brief explanation of the changes made
 3. Benefits of the refactoring
 
 Return the refactored code in the same language as the original.
-`, language, instructions, code)
+`, language, instructions, code, metrics)
 }
 
 // buildCodeSearchPrompt builds a prompt for analyzing code search results
@@ -124,4 +132,23 @@ Please provide:
 
 Format your response in a clear, structured manner with headings and bullet points where appropriate.
 `, query, searchResults)
-}
\ No newline at end of file
+}
+
+// formatFunctionMetrics lists the metrics of functions one per line, flagging those past a
+// threshold with what they exceed
+func formatFunctionMetrics(file string, metrics []utils.FunctionMetrics) string {
+	var b strings.Builder
+	for _, m := range metrics {
+		name := m.Name
+		if file != "" {
+			name = file + ": " + name
+		}
+		b.WriteString(fmt.Sprintf("- %s (lines %d-%d): cyclomatic %d, cognitive %d, nesting %d, %d parameters, %d lines, %.0f%% comments",
+			name, m.StartLine, m.EndLine, m.CyclomaticComplexity, m.CognitiveComplexity, m.MaxNesting, m.Parameters, m.Length, m.CommentRatio*100))
+		if findings := m.Findings(); len(findings) > 0 {
+			b.WriteString(" - FLAGGED: " + strings.Join(findings, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+// internal/llm/prompt_architecture.go
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxClusterFilesInPrompt limits how many files of each cluster are shown to the model
+const maxClusterFilesInPrompt = 8
+
+// ClusterInfo describes a discovered cluster of files to be named by the model
+type ClusterInfo struct {
+	ID           int
+	Layer        string
+	Files        []string // Ordered by importance
+	Dependencies []int
+}
+
+// ClusterLabel is the name and description the model returns for one cluster
+type ClusterLabel struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// buildClusterNamingPrompt builds a prompt for naming and describing discovered clusters
+func buildClusterNamingPrompt(clusters []ClusterInfo) string {
+	var sb strings.Builder
+
+	for _, cluster := range clusters {
+		sb.WriteString(fmt.Sprintf("Cluster %d (layer: %s, %d files)\n", cluster.ID, cluster.Layer, len(cluster.Files)))
+
+		files := cluster.Files
+		if len(files) > maxClusterFilesInPrompt {
+			files = files[:maxClusterFilesInPrompt]
+		}
+		for _, file := range files {
+			sb.WriteString("  - " + file + "\n")
+		}
+		if len(cluster.Files) > len(files) {
+			sb.WriteString(fmt.Sprintf("  - ... and %d more\n", len(cluster.Files)-len(files)))
+		}
+
+		if len(cluster.Dependencies) > 0 {
+			deps := make([]string, len(cluster.Dependencies))
+			for i, dep := range cluster.Dependencies {
+				deps[i] = fmt.Sprintf("%d", dep)
+			}
+			sb.WriteString("  Depends on clusters: " + strings.Join(deps, ", ") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	return fmt.Sprintf(`
+You are an expert software architect. The files of a codebase have been grouped into modules by running community detection over the import graph. Files are listed in order of importance.
+
+%s
+For each cluster, provide a short name (2-4 words) describing the module's responsibility and a one or two sentence description.
+Do not change the grouping or invent clusters.
+
+Respond with a JSON array only, in this format:
+[
+  {"id": 0, "name": "Module name", "description": "What this module does."}
+]
+`, sb.String())
+}
+
+// NameArchitectureClusters asks the model to name and describe each cluster.
+// The returned labels are keyed by cluster ID; clusters the model skipped are absent.
+func (c *GeminiClient) NameArchitectureClusters(ctx context.Context, clusters []ClusterInfo) (map[int]ClusterLabel, error) {
+	labels := make(map[int]ClusterLabel)
+	if len(clusters) == 0 {
+		return labels, nil
+	}
+
+	prompt := buildClusterNamingPrompt(clusters)
+
+	responseText, err := c.GenerateText(ctx, prompt)
+	if err != nil {
+		return labels, fmt.Errorf("failed to name clusters: %w", err)
+	}
+
+	// Strip code fences or surrounding prose
+	start := strings.Index(responseText, "[")
+	end := strings.LastIndex(responseText, "]")
+	if start == -1 || end <= start {
+		return labels, fmt.Errorf("failed to parse cluster names: no JSON array in response")
+	}
+
+	var parsed []ClusterLabel
+	if err := json.Unmarshal([]byte(responseText[start:end+1]), &parsed); err != nil {
+		return labels, fmt.Errorf("failed to parse cluster names: %w", err)
+	}
+
+	for _, label := range parsed {
+		label.Name = strings.TrimSpace(label.Name)
+		label.Description = strings.TrimSpace(label.Description)
+		if label.Name != "" {
+			labels[label.ID] = label
+		}
+	}
+
+	return labels, nil
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -25,6 +27,9 @@ Here is the repository information:
 The main entry points of the application are:
 %s
 
+Here is an outline of the declarations in the key files, parsed from the source with their line ranges:
+%s
+
 Please create a code walkthrough that:
 1. Identifies the key components and their purposes
 2. Maps the flow of control through the application
@@ -39,7 +44,7 @@ For each important file or component, provide:
 - Dependencies and relationships with other components
 
 Format your response as a structured walkthrough with clear sections and a logical progression.
-`, repoInfoStr, entryPointsStr)
+`, repoInfoStr, entryPointsStr, formatOutlines(codebase))
 }
 
 // buildFunctionExplainerPrompt builds a prompt for explaining a function
@@ -109,9 +114,12 @@ Format your response in a clear, structured manner suitable for generating an ar
 func buildCodebaseQAPrompt(question string, relevantCode map[string]string) string {
     var codeStr strings.Builder
     
-    // Include full files with line numbers for context
+    // Include full files with line numbers for context, each led by its outline
     for file, content := range relevantCode {
         codeStr.WriteString(fmt.Sprintf("FILE: %s\n\n", file))
+        if outline, err := utils.OutlineFile(file, content); err == nil && len(outline.Symbols) > 0 {
+            codeStr.WriteString("OUTLINE:\n" + outline.Format() + "\nCODE:\n")
+        }
         lines := strings.Split(content, "\n")
         for i, line := range lines {
             codeStr.WriteString(fmt.Sprintf("%d: %s\n", i+1, line))
@@ -143,11 +151,20 @@ return your answer in markdown format, do not wrap it in markdown blocks or any
 func buildBestPracticesPrompt(repoInfo map[string]interface{}, codebase map[string]string) string {
     repoInfoStr, _ := json.MarshalIndent(repoInfo, "", "  ")
     
-    var codeStr strings.Builder
+    var codeStr, metricsStr strings.Builder
     for file, content := range codebase {
         codeStr.WriteString(fmt.Sprintf("FILE: %s\n\n", file))
         codeStr.WriteString(content)
         codeStr.WriteString("\n\n---\n\n")
+
+        // Measured complexity of the flagged functions backs observations with evidence
+        if info, err := utils.ParseCode(content, utils.LanguageID(file, content)); err == nil {
+            metricsStr.WriteString(formatFunctionMetrics(file, info.FlaggedFunctions()))
+        }
+    }
+    metrics := metricsStr.String()
+    if metrics == "" {
+        metrics = "No function is past the complexity, nesting, length or parameter thresholds.\n"
     }
     
     return fmt.Sprintf(`
@@ -160,6 +177,8 @@ Here are code samples from the repository:
 
 %s
 
+Here are the functions of these samples that measured past a threshold (cyclomatic complexity %d, cognitive complexity %d, nesting depth %d, %d lines or %d parameters):
+%s
 Please provide a DETAILED analysis including:
 
 1. A comprehensive style guide with specific examples from the code, including:
@@ -182,8 +201,11 @@ Please provide a DETAILED analysis including:
 
 Your response should be extremely specific and detailed, based directly on the provided code samples, not generic advice. Each observation should cite specific code examples.
 
+Cite the measured metrics as evidence when discussing complexity or maintainability.
+
 Do not use placeholder text like "appears to follow standard conventions" without explaining exactly what those conventions are.
-`, repoInfoStr, codeStr.String())
+`, repoInfoStr, codeStr.String(), utils.MaxCyclomaticComplexity, utils.MaxCognitiveComplexity, utils.MaxNestingDepth,
+        utils.MaxFunctionLength, utils.MaxParameters, metrics)
 }
 
 // GenerateCompletion generates a simple text completion
@@ -230,7 +252,7 @@ func (c *GeminiClient) GenerateCompletion(ctx context.Context, prompt string, te
     }
     
     // Generate content using the Gemini model
-    resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
+    resp, err := c.model.GenerateContent(ctx, genai.Text(prompt+c.guidance.prompt()))
     if err != nil {
         return "", common.WrapError(err, "failed to generate completion")
     }
@@ -280,3 +302,91 @@ Please provide:
 Format your response in a clear, structured manner that would help a developer quickly understand the overall architecture and key components of the system.
 `, string(graphDataStr))
 }
+
+// buildGraphDiffNarrativePrompt builds a prompt for explaining an architecture graph diff
+func buildGraphDiffNarrativePrompt(baseRef, headRef string, diff interface{}) string {
+	diffStr, _ := json.MarshalIndent(diff, "", "  ")
+
+	return fmt.Sprintf(`
+You are an expert software architect reviewing how a codebase's dependency structure changed between two versions.
+
+Base ref: %s
+Head ref: %s
+
+Here is the computed difference between the import graphs of the two refs:
+%s
+
+Please provide a short review that:
+1. Summarizes the overall structural change (growth, shrinkage, reorganization)
+2. Calls out newly introduced import cycles and why they may be a problem
+3. Highlights files whose fan-in or fan-out changed significantly and what that implies for coupling
+4. Mentions any new dependencies between previously unrelated areas of the codebase
+
+Only describe changes present in the data above. Keep the review concise and actionable.
+`, baseRef, headRef, string(diffStr))
+}
+
+// buildGraphQueryPrompt builds a prompt for translating a question into a read-only Cypher query
+func buildGraphQueryPrompt(question, schema string, maxRows int) string {
+	return fmt.Sprintf(`
+You translate questions about a codebase into a single read-only Cypher query for Neo4j.
+
+The graph has this schema:
+%s
+
+Rules:
+- Use only MATCH, OPTIONAL MATCH, WHERE, WITH, UNWIND, RETURN, ORDER BY, SKIP and LIMIT.
+- Never use CREATE, MERGE, DELETE, SET, REMOVE, CALL, LOAD CSV or procedures.
+- Start every MATCH pattern from (r:Repository {owner: $owner, name: $repo}) or (h:HistoricalFile {owner: $owner, repo: $repo}), or from a variable bound by an earlier pattern. Scope File nodes to the branch with $branch.
+- File paths are repository-relative, e.g. "backend/internal/github/client.go". Use STARTS WITH or CONTAINS on f.path to match packages or directories.
+- Return plainly named columns, such as path.
+- Return at most %d rows.
+
+Question: %s
+
+Respond with the Cypher query only, without explanation or code fences.
+`, schema, maxRows, question)
+}
+
+// buildGraphQueryAnswerPrompt builds a prompt for answering a question from graph query results
+func buildGraphQueryAnswerPrompt(question, query string, rows interface{}) string {
+	rowsStr, _ := json.MarshalIndent(rows, "", "  ")
+	if len(rowsStr) > 12000 {
+		rowsStr = append(rowsStr[:12000], []byte("\n...[results truncated]")...)
+	}
+
+	return fmt.Sprintf(`
+You are helping an engineer understand a codebase. Their question was answered by running a query over the code graph.
+
+Question: %s
+
+Query:
+%s
+
+Results:
+%s
+
+Answer the question concisely based only on these results. If the results are empty, say that nothing matched.
+`, question, query, string(rowsStr))
+}
+
+// formatOutlines renders the outlines of the files of a codebase in path order, skipping
+// files whose declarations can't be extracted
+func formatOutlines(codebase map[string]string) string {
+	files := make([]string, 0, len(codebase))
+	for file := range codebase {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var b strings.Builder
+	for _, file := range files {
+		if outline, err := utils.OutlineFile(file, codebase[file]); err == nil {
+			b.WriteString(outline.Format())
+		}
+	}
+	if b.Len() == 0 {
+		return "No outline is available.\n"
+	}
+	return b.String()
+}
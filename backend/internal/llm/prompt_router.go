@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // QuestionRouterResponse represents the response from the question router
@@ -14,6 +15,34 @@ type QuestionRouterResponse struct {
 	APIType     string   `json:"api_type"`
 	Explanation string   `json:"explanation"`
 	Keywords    []string `json:"keywords"`
+
+	// Filters the question implies, empty when it doesn't mention them
+	Since   string   `json:"since,omitempty"` // YYYY-MM-DD, inclusive
+	Until   string   `json:"until,omitempty"` // YYYY-MM-DD, inclusive
+	Authors []string `json:"authors,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
+	State   string   `json:"state,omitempty"` // open, closed or merged
+	Path    string   `json:"path,omitempty"`
+}
+
+// routerDateLayout is the date format of the router's time window
+const routerDateLayout = "2006-01-02"
+
+// TimeWindow returns the time window of the question; zero times are unbounded.
+// Until covers the whole of its day.
+func (r *QuestionRouterResponse) TimeWindow() (since, until time.Time) {
+	if t, err := time.Parse(routerDateLayout, strings.TrimSpace(r.Since)); err == nil {
+		since = t
+	}
+	if t, err := time.Parse(routerDateLayout, strings.TrimSpace(r.Until)); err == nil {
+		until = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return since, until
+}
+
+// hasFilters reports whether the question implies any filter
+func (r *QuestionRouterResponse) hasFilters() bool {
+	return r.Since != "" || r.Until != "" || len(r.Authors) > 0 || len(r.Labels) > 0 || r.State != "" || r.Path != ""
 }
 
 // RouterAPIType represents the type of GitHub API to use
@@ -29,12 +58,14 @@ const (
 )
 
 // buildQuestionRouterPrompt builds a prompt for routing a question to the appropriate API
-func buildQuestionRouterPrompt(question string) string {
+func buildQuestionRouterPrompt(question string, today time.Time) string {
 	return fmt.Sprintf(`
 You are an API router for GitHub-related questions. Your task is to analyze a user's question and determine which GitHub API it relates to.
 
 User Question: %s
 
+Today's date: %s
+
 Choose the most appropriate GitHub API from the following options:
 1. code_search - For questions about the codebase, code structure, how specific features are implemented, or any question requiring examination of source code
 2. commits - For questions about commit history, specific commits, or authors of changes
@@ -47,11 +78,26 @@ Choose the most appropriate GitHub API from the following options:
 
 Analyze the question carefully and extract 2-5 keywords that would be useful for searching or filtering with the chosen API.
 
+Also extract the filters the question implies, leaving out any it doesn't mention:
+- since/until: the time window as YYYY-MM-DD dates, both inclusive, resolved against today's date (e.g. "in March" is the most recent March, "last week" the 7 days before today)
+- authors: GitHub usernames, names or emails of the people asked about
+- labels: issue or pull request labels
+- state: "open", "closed" or "merged"
+- path: a file or directory the question is limited to
+
+Do not repeat the filters in the keywords.
+
 Return your response as a JSON object with these fields:
 {
   "api_type": "THE_CHOSEN_API_TYPE",
   "explanation": "A brief explanation of why this API is most appropriate",
-  "keywords": ["keyword1", "keyword2", "keyword3"] 
+  "keywords": ["keyword1", "keyword2", "keyword3"],
+  "since": "YYYY-MM-DD",
+  "until": "YYYY-MM-DD",
+  "authors": ["username"],
+  "labels": ["label"],
+  "state": "open",
+  "path": "path/to/dir"
 }
 
 Make sure the api_type is exactly one of: code_search, commits, pulls, issues, releases, stats, users, repos
@@ -66,30 +112,33 @@ For different API types, focus on extracting these types of keywords:
 - users: Usernames, roles, contribution types
 - repos: Repository attributes, settings, configurations
 
-IMPORTANT: Always return a valid JSON object with all three fields: api_type, explanation, and keywords.
-`, question)
+IMPORTANT: Always return a valid JSON object with the fields api_type, explanation, and keywords; the filter fields are optional.
+`, question, today.Format(routerDateLayout))
 }
 
 // RouteQuestion determines which GitHub API is most appropriate for a question
 func (c *GeminiClient) RouteQuestion(ctx context.Context, question string) (*QuestionRouterResponse, error) {
-	prompt := buildQuestionRouterPrompt(question)
+	prompt := buildQuestionRouterPrompt(question, time.Now())
 	
 	responseText, err := c.GenerateText(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to route question: %w", err)
 	}
 	
-	// Parse JSON response
+	// Parse JSON response, which may be wrapped in a code fence
 	var response QuestionRouterResponse
 	err = json.Unmarshal([]byte(responseText), &response)
+	if start, end := strings.Index(responseText, "{"), strings.LastIndex(responseText, "}"); err != nil && start >= 0 && end > start {
+		err = json.Unmarshal([]byte(responseText[start:end+1]), &response)
+	}
 	if err != nil {
 		// Try to extract structured data from unstructured response
 		extractedResponse := extractRouterResponse(responseText, question)
 		return &extractedResponse, nil
 	}
 	
-	// Ensure we have keywords
-	if response.Keywords == nil || len(response.Keywords) == 0 {
+	// Ensure we have keywords, unless the filters alone narrow the question
+	if len(response.Keywords) == 0 && !response.hasFilters() {
 		// Extract keywords from the question if none were provided
 		response.Keywords = extractKeywordsFromQuestion(question)
 	}
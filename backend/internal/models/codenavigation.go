@@ -1,6 +1,8 @@
 // internal/models/codenavigation.go
 package models
 
+import "github.com/pbearc/github-agent/backend/internal/utils"
+
 // RepositoryInfo holds combined information about a GitHub repository.
 type RepositoryInfo struct {
 	Owner         string         `json:"owner"`
@@ -34,7 +36,8 @@ type CodeWalkthroughRequest struct {
 type FunctionExplainerRequest struct {
 	RepositoryRequest
 	FilePath     string `json:"file_path" binding:"required"`
-	FunctionName string `json:"function_name" binding:"required"`
+	FunctionName string `json:"function_name"` // Name or Type.Method; optional with line
+	Line         int    `json:"line"`          // Explain the function enclosing this line
 	LineStart    int    `json:"line_start"`
 	LineEnd      int    `json:"line_end"`
 }
@@ -44,6 +47,15 @@ type ArchitectureVisualizerRequest struct {
 	RepositoryRequest
 	Detail     string   `json:"detail"` // "high", "medium", "low"
 	FocusPaths []string `json:"focus_paths"`
+	Format     string   `json:"format"`    // "json" (default), "mermaid", "dot", "graphml", "plantuml"
+	MaxNodes   int      `json:"max_nodes"` // Collapse directories into clusters above this many nodes
+}
+
+// ArchitectureGraphRequest contains the request data for exporting the stored architecture graph
+type ArchitectureGraphRequest struct {
+	RepositoryRequest
+	Format   string `json:"format"`    // "json" (default), "mermaid", "dot", "graphml", "plantuml"
+	MaxNodes int    `json:"max_nodes"` // Collapse directories into clusters above this many nodes
 }
 
 // CodebaseQARequest contains the request data for codebase Q&A
@@ -59,12 +71,36 @@ type BestPracticesRequest struct {
 	Path  string `json:"path"`
 }
 
+// CodePositionRequest contains the request data for navigating from a position in a file
+type CodePositionRequest struct {
+	RepositoryRequest
+	FilePath string `json:"file_path" binding:"required"`
+	Line     int    `json:"line" binding:"required"`   // 1-based
+	Column   int    `json:"column" binding:"required"` // 1-based, in characters
+}
+
+// CodeMetricsRequest contains the request data for code metrics
+type CodeMetricsRequest struct {
+	RepositoryRequest
+	Path     string `json:"path"`      // File or directory; the whole repository if empty
+	MaxFiles int    `json:"max_files"` // Defaults to and at most 500
+}
+
+// CodeOutlineRequest contains the request data for a symbol outline
+type CodeOutlineRequest struct {
+	RepositoryRequest
+	Path         string `json:"path"` // File or directory; the whole repository if empty
+	ExportedOnly bool   `json:"exported_only"`
+	MaxFiles     int    `json:"max_files"` // Defaults to and at most 500
+}
+
 // CodeWalkthroughResponse represents the response for code walkthrough generation
 type CodeWalkthroughResponse struct {
 	Overview     string                   `json:"overview"`
 	EntryPoints  []string                 `json:"entry_points"`
 	Walkthrough  []CodeWalkthroughStep    `json:"walkthrough"`
 	Dependencies map[string][]string      `json:"dependencies"`
+	Skipped      utils.SkipCounts         `json:"skipped,omitempty"` // Files left out, by class
 }
 
 // CodeWalkthroughStep represents a single step in a code walkthrough
@@ -78,6 +114,9 @@ type CodeWalkthroughStep struct {
 // FunctionExplainerResponse represents the response for function explanation
 type FunctionExplainerResponse struct {
 	FunctionName   string   `json:"function_name"`
+	FilePath       string   `json:"file_path,omitempty"`
+	LineStart      int      `json:"line_start,omitempty"`
+	LineEnd        int      `json:"line_end,omitempty"`
 	Description    string   `json:"description"`
 	Parameters     []Param  `json:"parameters"`
 	ReturnValues   []Param  `json:"return_values"`
@@ -86,6 +125,42 @@ type FunctionExplainerResponse struct {
 	RelatedFunctions []string `json:"related_functions"`
 }
 
+// Navigation methods report how a definition or its references were found
+const (
+	NavigationTypes   = "types"   // Resolved by the type checker
+	NavigationSymbols = "symbols" // Matched by name against extracted symbols
+)
+
+// CodeLocation is a position in a repository file
+type CodeLocation struct {
+	FilePath string `json:"file_path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	EndLine  int    `json:"end_line,omitempty"`
+	Symbol   string `json:"symbol"`
+	Kind     string `json:"kind,omitempty"`
+	Package  string `json:"package,omitempty"` // Set for declarations outside the repository
+	Snippet  string `json:"snippet,omitempty"` // The line the location is on
+}
+
+// DefinitionResponse represents the response for go-to-definition
+type DefinitionResponse struct {
+	Symbol      string         `json:"symbol"`
+	Method      string         `json:"method"` // "types" or "symbols"
+	Definitions []CodeLocation `json:"definitions"`
+	Degraded    bool           `json:"degraded,omitempty"` // Types from the standard library were unavailable
+}
+
+// ReferencesResponse represents the response for find-references
+type ReferencesResponse struct {
+	Symbol      string         `json:"symbol"`
+	Method      string         `json:"method"` // "types" or "symbols"
+	Definitions []CodeLocation `json:"definitions"`
+	References  []CodeLocation `json:"references"`
+	Truncated   bool           `json:"truncated"`          // Not every file that could refer to the symbol was searched
+	Degraded    bool           `json:"degraded,omitempty"` // Types from the standard library were unavailable
+}
+
 // Param represents a parameter or return value
 type Param struct {
 	Name        string `json:"name"`
@@ -98,6 +173,28 @@ type ArchitectureVisualizerResponse struct {
 	Overview           string            `json:"overview"`
 	DiagramData        DiagramData       `json:"diagram_data"`
 	ComponentDescriptions map[string]string `json:"component_descriptions"`
+	Clusters           []ArchitectureCluster `json:"clusters"`
+	Skipped            utils.SkipCounts  `json:"skipped,omitempty"` // Files left out of the graph, by class
+	LayerViolations    []LayerViolation  `json:"layer_violations,omitempty"`
+}
+
+// LayerViolation is an import between layers the repository's agent configuration doesn't allow
+type LayerViolation struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	FromLayer string `json:"from_layer"`
+	ToLayer   string `json:"to_layer"`
+}
+
+// ArchitectureCluster describes a module discovered by community detection over the import graph
+type ArchitectureCluster struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Layer        string   `json:"layer"`
+	Level        int      `json:"level"`
+	Files        []string `json:"files"`         // Ordered by importance
+	Dependencies []int    `json:"dependencies"`  // IDs of clusters this cluster imports from
 }
 
 // DiagramData contains the data required to render an architecture diagram
@@ -175,3 +272,78 @@ type ArchitectureVisualizerMermaidResponse struct {
 	ComponentDescriptions map[string]string `json:"component_descriptions"`
 }
 
+// ArchitectureDiagramResponse represents an architecture diagram exported to a text format
+type ArchitectureDiagramResponse struct {
+	Format                string            `json:"format"`
+	Diagram               string            `json:"diagram"`
+	Overview              string            `json:"overview,omitempty"`
+	ComponentDescriptions map[string]string `json:"component_descriptions,omitempty"`
+}
+
+// CodeMetricsResponse represents the response for code metrics
+type CodeMetricsResponse struct {
+	Path        string             `json:"path"`
+	Summary     MetricsAggregate   `json:"summary"`
+	Directories []DirectoryMetrics `json:"directories"` // Each includes its subdirectories
+	Files       []FileMetrics      `json:"files"`
+	Hotspots    []FunctionHotspot  `json:"hotspots"` // Most complex functions first
+	Skipped     int                `json:"skipped"`  // Generated or unreadable files
+	Truncated   bool               `json:"truncated"`
+}
+
+// MetricsAggregate summarizes the metrics of a set of files and their functions
+type MetricsAggregate struct {
+	Files             int     `json:"files"`
+	Functions         int     `json:"functions"`
+	Lines             int     `json:"lines"`
+	CodeLines         int     `json:"code_lines"`
+	CommentLines      int     `json:"comment_lines"`
+	CommentRatio      float64 `json:"comment_ratio"`
+	AverageCyclomatic float64 `json:"average_cyclomatic"`
+	MaxCyclomatic     int     `json:"max_cyclomatic"`
+	AverageCognitive  float64 `json:"average_cognitive"`
+	MaxCognitive      int     `json:"max_cognitive"`
+	MaxNesting        int     `json:"max_nesting"`
+	AverageLength     float64 `json:"average_length"`
+	MaxLength         int     `json:"max_length"`
+	AverageParameters float64 `json:"average_parameters"`
+	FlaggedFunctions  int     `json:"flagged_functions"` // Past at least one threshold
+}
+
+// DirectoryMetrics holds the metrics of the files under a directory
+type DirectoryMetrics struct {
+	Path string `json:"path"`
+	MetricsAggregate
+}
+
+// FileMetrics holds the metrics of a file and each of its functions
+type FileMetrics struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+	MetricsAggregate
+	Functions []utils.FunctionMetrics `json:"functions"`
+}
+
+// FunctionHotspot is a function past a complexity or size threshold
+type FunctionHotspot struct {
+	FilePath string `json:"file_path"`
+	utils.FunctionMetrics
+	Findings []string `json:"findings"`
+}
+
+// CodeOutlineResponse represents the response for a symbol outline, grouped by directory
+type CodeOutlineResponse struct {
+	Path      string           `json:"path"`
+	Packages  []PackageOutline `json:"packages"`
+	Files     int              `json:"files"`
+	Symbols   int              `json:"symbols"`
+	Skipped   int              `json:"skipped"` // Generated, unreadable or unsupported files
+	Truncated bool             `json:"truncated"`
+}
+
+// PackageOutline holds the outlines of the files of one directory
+type PackageOutline struct {
+	Directory string               `json:"directory"`
+	Name      string               `json:"name,omitempty"` // Package the files declare, where the language has one
+	Files     []*utils.FileOutline `json:"files"`
+}
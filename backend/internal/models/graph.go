@@ -0,0 +1,141 @@
+package models
+
+import "time"
+
+// GraphEdge represents a single import edge between two files
+type GraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// GraphDiffRequest contains the request data for comparing the architecture of two refs
+type GraphDiffRequest struct {
+	URL     string `json:"url" binding:"required"`
+	BaseRef string `json:"base_ref" binding:"required"` // Branch, tag or commit SHA
+	HeadRef string `json:"head_ref" binding:"required"` // Branch, tag or commit SHA
+	Refresh bool   `json:"refresh"`                     // Rebuild stored graphs even if present
+}
+
+// NodeDegreeChange describes how a file's connectivity changed between two refs
+type NodeDegreeChange struct {
+	Path         string `json:"path"`
+	FanInBefore  int    `json:"fan_in_before"`
+	FanInAfter   int    `json:"fan_in_after"`
+	FanOutBefore int    `json:"fan_out_before"`
+	FanOutAfter  int    `json:"fan_out_after"`
+}
+
+// GraphDiffStats contains size totals for both sides of a graph diff
+type GraphDiffStats struct {
+	BaseFiles int `json:"base_files"`
+	HeadFiles int `json:"head_files"`
+	BaseEdges int `json:"base_edges"`
+	HeadEdges int `json:"head_edges"`
+}
+
+// GraphDiff contains the structural differences between two import graphs
+type GraphDiff struct {
+	AddedFiles   []string           `json:"added_files"`
+	RemovedFiles []string           `json:"removed_files"`
+	AddedEdges   []GraphEdge        `json:"added_edges"`
+	RemovedEdges []GraphEdge        `json:"removed_edges"`
+	NewCycles    [][]string         `json:"new_cycles"`
+	HotNodes     []NodeDegreeChange `json:"hot_nodes"`
+	Stats        GraphDiffStats     `json:"stats"`
+}
+
+// GraphDiffResponse represents the response for an architecture graph diff
+type GraphDiffResponse struct {
+	BaseRef   string `json:"base_ref"`
+	HeadRef   string `json:"head_ref"`
+	GraphDiff
+	Narrative string `json:"narrative"`
+}
+
+// GraphCommit identifies a commit whose graph is stored in the history
+type GraphCommit struct {
+	SHA     string    `json:"sha"`
+	Ref     string    `json:"ref,omitempty"` // Tag name when the commit was selected by tag
+	Message string    `json:"message"`
+	Date    time.Time `json:"date"`
+}
+
+// GraphMetrics contains size and coupling metrics for a single graph snapshot
+type GraphMetrics struct {
+	Files               int     `json:"files"`
+	Edges               int     `json:"edges"`
+	AverageFanOut       float64 `json:"average_fan_out"`
+	MaxFanIn            int     `json:"max_fan_in"`
+	MaxFanOut           int     `json:"max_fan_out"`
+	Cycles              int     `json:"cycles"`
+	CrossDirectoryEdges int     `json:"cross_directory_edges"`
+	CouplingRatio       float64 `json:"coupling_ratio"` // Share of edges that cross directories
+}
+
+// GraphHistoryPoint is one entry of a graph history time series
+type GraphHistoryPoint struct {
+	GraphCommit
+	GraphMetrics
+}
+
+// GraphHistoryRequest contains the request data for a graph history time series
+type GraphHistoryRequest struct {
+	RepositoryRequest
+	Limit   int  `json:"limit"`    // Number of commits or tags, defaults to 10
+	UseTags bool `json:"use_tags"` // Walk tags instead of branch commits
+}
+
+// GraphHistoryResponse represents a time series of graph metrics, oldest first
+type GraphHistoryResponse struct {
+	Points []GraphHistoryPoint `json:"points"`
+}
+
+// DependencyIntroductionRequest contains the request data for finding when a dependency appeared
+type DependencyIntroductionRequest struct {
+	GraphHistoryRequest
+	From string `json:"from" binding:"required"` // Path prefix of the depending package
+	To   string `json:"to" binding:"required"`   // Path prefix of the dependency
+}
+
+// DependencyIntroductionResponse reports the first stored commit in which From depends on To.
+// When the dependency already exists in the oldest searched commit it was introduced before
+// the searched range, so PredatesWindow is set and IntroducedIn is nil.
+type DependencyIntroductionResponse struct {
+	From           string       `json:"from"`
+	To             string       `json:"to"`
+	Found          bool         `json:"found"`
+	IntroducedIn   *GraphCommit `json:"introduced_in,omitempty"`
+	PredatesWindow bool         `json:"predates_window,omitempty"`
+	Edges          []GraphEdge  `json:"edges"`
+	Searched       int          `json:"searched"` // Number of commits in the searched history
+}
+
+// GraphQueryRequest contains the request data for a natural-language graph query
+type GraphQueryRequest struct {
+	RepositoryRequest
+	Question string `json:"question" binding:"required"`
+}
+
+// GraphQueryResponse represents the result of a natural-language graph query
+type GraphQueryResponse struct {
+	Question string                   `json:"question"`
+	Query    string                   `json:"query"`
+	Rows     []map[string]interface{} `json:"rows"`
+	RowCount int                      `json:"row_count"`
+	Answer   string                   `json:"answer"`
+}
+
+// Kinds of external dependencies
+const (
+	DependencyStdlib  = "stdlib"
+	DependencyModule  = "module"
+	DependencyPackage = "package"
+)
+
+// ExternalDependency is a package outside the repository that source files import: a
+// standard library or Node.js core package, a Go module, or an npm package
+type ExternalDependency struct {
+	Name    string `json:"name"` // Import path for the Go standard library, module or package name otherwise
+	Kind    string `json:"kind"` // stdlib, module or package
+	Version string `json:"version,omitempty"`
+}
@@ -1,6 +1,8 @@
 // internal/models/navigator.go (update)
 package models
 
+import "github.com/pbearc/github-agent/backend/internal/utils"
+
 // CodebaseNavigatorRequest contains the request data for codebase Q&A
 type CodebaseNavigatorRequest struct {
     RepositoryRequest
@@ -27,4 +29,5 @@ type CodebaseIndexResponse struct {
     Namespace  string `json:"namespace"`
     FileCount  int    `json:"file_count,omitempty"`
     ChunkCount int    `json:"chunk_count,omitempty"`
+    Skipped    utils.SkipCounts `json:"skipped,omitempty"` // Files left out, by class
 }
\ No newline at end of file
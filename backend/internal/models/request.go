@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/pbearc/github-agent/backend/internal/llm"
 	"github.com/pbearc/github-agent/backend/internal/types"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 )
 
 // RepositoryRequest contains the request data for repository operations
@@ -42,6 +43,25 @@ type CodeSearchRequest struct {
 	Query string `json:"query" binding:"required"`
 }
 
+// FileChange is the new content of a file in a push
+type FileChange struct {
+	Path    string `json:"path" binding:"required"`
+	Content string `json:"content"`
+}
+
+// PushRequest contains the request data for pushing files. All changes are made in one commit.
+type PushRequest struct {
+	RepositoryRequest
+	Path     string       `json:"path"` // Single file to write, with Content
+	Content  string       `json:"content"`
+	Files    []FileChange `json:"files"`  // Further files to write
+	Delete   []string     `json:"delete"` // Files to remove
+	Message  string       `json:"message"`
+	BaseSHA  string       `json:"base_sha"`  // Fail with 409 unless the branch is still at this commit
+	Mode     string       `json:"mode"`      // "direct" or "pull_request"; empty defers to .github-agent.yml
+	PRBranch string       `json:"pr_branch"` // Feature branch in pull_request mode, derived from the paths if empty
+}
+
 // LLMOperationRequest contains the request data for a generic LLM operation
 type LLMOperationRequest struct {
 	Operation llm.Operation `json:"operation" binding:"required"`
@@ -49,9 +69,10 @@ type LLMOperationRequest struct {
 
 // ErrorResponse represents an API error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code,omitempty"`
-	Details string `json:"details,omitempty"`
+	Error    string   `json:"error"`
+	Code     string   `json:"code,omitempty"`
+	Details  string   `json:"details,omitempty"`
+	Problems []string `json:"problems,omitempty"` // Every problem of an invalid request or configuration
 }
 
 // SuccessResponse represents a generic success response
@@ -62,7 +83,8 @@ type SuccessResponse struct {
 
 // GenerateResponse represents the response for generation operations
 type GenerateResponse struct {
-	Content string `json:"content"`
+	Content string           `json:"content"`
+	Skipped utils.SkipCounts `json:"skipped,omitempty"` // Files left out of the file list, by class
 }
 
 // RepositoryInfoResponse represents the response for repository info
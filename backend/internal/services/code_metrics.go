@@ -0,0 +1,167 @@
+// internal/services/code_metrics.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+)
+
+// ErrNoSourceFiles is returned when a path holds no source files to analyze
+var ErrNoSourceFiles = errors.New("no source files found")
+
+// Limits of a metrics analysis
+const (
+	defaultMetricsFiles = 500
+	maxMetricsHotspots  = 20
+)
+
+// AnalyzeMetrics measures the functions of the source files under a path, a single file or
+// the whole repository when empty, and aggregates them per file and per directory
+func (s *CodeNavigationService) AnalyzeMetrics(ctx context.Context, owner, repo, branch, scope string, maxFiles int) (*models.CodeMetricsResponse, error) {
+	src := s.sourceFor(owner, repo)
+	branch, err := s.resolveBranch(ctx, src, branch)
+	if err != nil {
+		return nil, err
+	}
+	// Requests may lower the limit but not raise it
+	if maxFiles <= 0 {
+		maxFiles = defaultMetricsFiles
+	}
+	maxFiles = min(maxFiles, defaultMetricsFiles)
+	scope = strings.Trim(scope, "/")
+
+	paths, err := scopedSourceFiles(ctx, src, branch, scope)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w under %q", ErrNoSourceFiles, scope)
+	}
+
+	response := &models.CodeMetricsResponse{Path: scope}
+	if len(paths) > maxFiles {
+		paths, response.Truncated = paths[:maxFiles], true
+	}
+
+	total := &metricsAccumulator{}
+	directories := make(map[string]*metricsAccumulator)
+	for _, filePath := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		content, err := src.ReadFile(ctx, branch, filePath)
+		if err != nil {
+			s.logger.WithField("error", err).Warning("Failed to read " + filePath)
+			response.Skipped++
+			continue
+		}
+		if utils.IsGenerated(filePath, content.Content) {
+			response.Skipped++
+			continue
+		}
+
+		info, err := utils.ParseCode(content.Content, utils.LanguageID(filePath, content.Content))
+		if err != nil {
+			response.Skipped++
+			continue
+		}
+
+		fileTotal := &metricsAccumulator{}
+		fileTotal.add(info)
+		total.add(info)
+		// Each directory includes its subdirectories, up to the scope
+		for dir := path.Dir(filePath); inScope(dir, scope) || dir == "." && scope == ""; dir = path.Dir(dir) {
+			if directories[dir] == nil {
+				directories[dir] = &metricsAccumulator{}
+			}
+			directories[dir].add(info)
+			if dir == "." {
+				break
+			}
+		}
+
+		response.Files = append(response.Files, models.FileMetrics{
+			Path:             filePath,
+			Language:         info.Language,
+			MetricsAggregate: fileTotal.aggregate(),
+			Functions:        info.Metrics,
+		})
+		for _, m := range info.Metrics {
+			if findings := m.Findings(); len(findings) > 0 {
+				response.Hotspots = append(response.Hotspots, models.FunctionHotspot{FilePath: filePath, FunctionMetrics: m, Findings: findings})
+			}
+		}
+	}
+
+	response.Summary = total.aggregate()
+	for dir, acc := range directories {
+		response.Directories = append(response.Directories, models.DirectoryMetrics{Path: dir, MetricsAggregate: acc.aggregate()})
+	}
+	sort.Slice(response.Directories, func(i, j int) bool { return response.Directories[i].Path < response.Directories[j].Path })
+
+	sort.SliceStable(response.Hotspots, func(i, j int) bool {
+		a, b := response.Hotspots[i], response.Hotspots[j]
+		if a.CognitiveComplexity != b.CognitiveComplexity {
+			return a.CognitiveComplexity > b.CognitiveComplexity
+		}
+		return a.CyclomaticComplexity > b.CyclomaticComplexity
+	})
+	if len(response.Hotspots) > maxMetricsHotspots {
+		response.Hotspots = response.Hotspots[:maxMetricsHotspots]
+	}
+
+	return response, nil
+}
+
+// metricsAccumulator sums the metrics of files for a models.MetricsAggregate
+type metricsAccumulator struct {
+	result                                    models.MetricsAggregate
+	cyclomatic, cognitive, length, parameters int
+}
+
+// add counts the lines and functions of a parsed file
+func (a *metricsAccumulator) add(info *utils.CodeInfo) {
+	r := &a.result
+	r.Files++
+	r.Lines += info.LineCount
+	r.CodeLines += info.CodeLines
+	r.CommentLines += info.CommentLines
+	for _, m := range info.Metrics {
+		r.Functions++
+		a.cyclomatic += m.CyclomaticComplexity
+		a.cognitive += m.CognitiveComplexity
+		a.length += m.Length
+		a.parameters += m.Parameters
+		r.MaxCyclomatic = max(r.MaxCyclomatic, m.CyclomaticComplexity)
+		r.MaxCognitive = max(r.MaxCognitive, m.CognitiveComplexity)
+		r.MaxNesting = max(r.MaxNesting, m.MaxNesting)
+		r.MaxLength = max(r.MaxLength, m.Length)
+		if len(m.Findings()) > 0 {
+			r.FlaggedFunctions++
+		}
+	}
+}
+
+// aggregate returns the totals with their averages
+func (a *metricsAccumulator) aggregate() models.MetricsAggregate {
+	r := a.result
+	if lines := r.CodeLines + r.CommentLines; lines > 0 {
+		r.CommentRatio = float64(r.CommentLines) / float64(lines)
+	}
+	if r.Functions > 0 {
+		n := float64(r.Functions)
+		r.AverageCyclomatic = float64(a.cyclomatic) / n
+		r.AverageCognitive = float64(a.cognitive) / n
+		r.AverageLength = float64(a.length) / n
+		r.AverageParameters = float64(a.parameters) / n
+	}
+	return r
+}
@@ -0,0 +1,125 @@
+// internal/services/code_outline.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// defaultOutlineFiles caps how many files an outline reads unless the request sets a lower limit
+const defaultOutlineFiles = 500
+
+// GenerateOutline parses the source files under a path, a single file or the whole
+// repository when empty, into outlines of their declarations grouped by directory
+func (s *CodeNavigationService) GenerateOutline(ctx context.Context, owner, repo, branch, scope string, exportedOnly bool, maxFiles int) (*models.CodeOutlineResponse, error) {
+	src := s.sourceFor(owner, repo)
+	branch, err := s.resolveBranch(ctx, src, branch)
+	if err != nil {
+		return nil, err
+	}
+	// Requests may lower the limit but not raise it
+	if maxFiles <= 0 {
+		maxFiles = defaultOutlineFiles
+	}
+	maxFiles = min(maxFiles, defaultOutlineFiles)
+	scope = strings.Trim(scope, "/")
+
+	paths, err := scopedSourceFiles(ctx, src, branch, scope)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w under %q", ErrNoSourceFiles, scope)
+	}
+
+	response := &models.CodeOutlineResponse{Path: scope}
+	if len(paths) > maxFiles {
+		paths, response.Truncated = paths[:maxFiles], true
+	}
+
+	packages := make(map[string]*models.PackageOutline)
+	for _, filePath := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		outline, ok := s.outlineFile(ctx, src, branch, filePath)
+		if !ok {
+			response.Skipped++
+			continue
+		}
+		if exportedOnly {
+			outline = outline.Exported()
+		}
+
+		dir := path.Dir(filePath)
+		pkg := packages[dir]
+		if pkg == nil {
+			pkg = &models.PackageOutline{Directory: dir, Name: outline.Package}
+			packages[dir] = pkg
+		}
+		pkg.Files = append(pkg.Files, outline)
+		response.Files++
+		response.Symbols += outline.Count()
+	}
+
+	for _, pkg := range packages {
+		response.Packages = append(response.Packages, *pkg)
+	}
+	sort.Slice(response.Packages, func(i, j int) bool {
+		return response.Packages[i].Directory < response.Packages[j].Directory
+	})
+	return response, nil
+}
+
+// scopedSourceFiles lists the source files under a scope, outside vendored dependencies
+func scopedSourceFiles(ctx context.Context, src github.RepoSource, ref, scope string) ([]string, error) {
+	files, err := src.ListTree(ctx, ref)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list repository files")
+	}
+
+	var paths []string
+	for _, file := range files {
+		if file.Type != "file" || !utils.IsSourceFile(file.Path) || !inScope(file.Path, scope) {
+			continue
+		}
+		if utils.IsVendoredPath(file.Path) {
+			continue
+		}
+		paths = append(paths, file.Path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// inScope reports whether a repository path is the scope or lies under it
+func inScope(filePath, scope string) bool {
+	return scope == "" || filePath == scope || strings.HasPrefix(filePath, scope+"/")
+}
+
+// outlineFile reads and outlines one file, reporting false for files that can't be read,
+// are generated or are in a language without symbol extraction
+func (s *CodeNavigationService) outlineFile(ctx context.Context, src github.RepoSource, ref, filePath string) (*utils.FileOutline, bool) {
+	content, err := src.ReadFile(ctx, ref, filePath)
+	if err != nil {
+		s.logger.WithField("error", err).Warning("Failed to read " + filePath)
+		return nil, false
+	}
+	if utils.IsGenerated(filePath, content.Content) {
+		return nil, false
+	}
+	outline, err := utils.OutlineFile(filePath, content.Content)
+	if err != nil {
+		return nil, false
+	}
+	return outline, true
+}
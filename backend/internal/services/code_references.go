@@ -0,0 +1,260 @@
+// internal/services/code_references.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// maxSymbolSearchFiles caps how many files the symbol-name fallback reads
+const maxSymbolSearchFiles = 300
+
+// ErrInvalidPosition is returned when a position is outside a file or names no identifier
+var ErrInvalidPosition = errors.New("invalid position")
+
+// ErrNoDefinition is returned when no declaration of an identifier is found
+var ErrNoDefinition = errors.New("no definition found")
+
+// symbolFamilies groups languages whose files can declare symbols the others use
+var symbolFamilies = map[string]string{
+	"typescript":  "javascript",
+	"cpp":         "c",
+	"objective-c": "c",
+	"kotlin":      "java",
+	"scala":       "java",
+}
+
+// FindDefinition returns where the identifier at a position of a file is declared. Go files
+// are type-checked; other languages fall back to matching extracted symbols by name.
+func (s *CodeNavigationService) FindDefinition(ctx context.Context, owner, repo, branch, filePath string, line, column int) (*models.DefinitionResponse, error) {
+	src := s.sourceFor(owner, repo)
+	branch, err := s.resolveBranch(ctx, src, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.LanguageID(filePath, "") == "go" {
+		found, err := github.FindGoDefinition(ctx, src, branch, filePath, line, column, s.logger)
+		if err == nil {
+			return &models.DefinitionResponse{
+				Symbol:      found.Definition.Symbol,
+				Method:      models.NavigationTypes,
+				Definitions: []models.CodeLocation{found.Definition},
+				Degraded:    found.Degraded,
+			}, nil
+		}
+		s.logger.WithError(err).Warning("Go type checking failed, falling back to symbol search")
+	}
+
+	search, err := s.searchSymbol(ctx, src, branch, filePath, line, column, false)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DefinitionResponse{
+		Symbol:      search.name,
+		Method:      models.NavigationSymbols,
+		Definitions: search.definitions,
+	}, nil
+}
+
+// FindReferences returns the declaration of the identifier at a position of a file and every
+// place it is used. Go files are type-checked; other languages fall back to finding the name
+// outside strings and comments.
+func (s *CodeNavigationService) FindReferences(ctx context.Context, owner, repo, branch, filePath string, line, column int) (*models.ReferencesResponse, error) {
+	src := s.sourceFor(owner, repo)
+	branch, err := s.resolveBranch(ctx, src, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.LanguageID(filePath, "") == "go" {
+		found, err := github.FindGoReferences(ctx, src, branch, filePath, line, column, s.logger)
+		if err == nil {
+			return &models.ReferencesResponse{
+				Symbol:      found.Definition.Symbol,
+				Method:      models.NavigationTypes,
+				Definitions: []models.CodeLocation{found.Definition},
+				References:  found.References,
+				Truncated:   found.Truncated,
+				Degraded:    found.Degraded,
+			}, nil
+		}
+		s.logger.WithError(err).Warning("Go type checking failed, falling back to symbol search")
+	}
+
+	search, err := s.searchSymbol(ctx, src, branch, filePath, line, column, true)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReferencesResponse{
+		Symbol:      search.name,
+		Method:      models.NavigationSymbols,
+		Definitions: search.definitions,
+		References:  search.references,
+		Truncated:   search.truncated,
+	}, nil
+}
+
+// resolveBranch returns the branch to read, the default branch if none is given
+func (s *CodeNavigationService) resolveBranch(ctx context.Context, src github.RepoSource, branch string) (string, error) {
+	if branch != "" {
+		return branch, nil
+	}
+	repoInfo, err := src.Info(ctx)
+	if err != nil {
+		return "", common.WrapError(err, "failed to get repository info")
+	}
+	return repoInfo.DefaultBranch, nil
+}
+
+// symbolSearch is what searchSymbol found for a name
+type symbolSearch struct {
+	name        string
+	definitions []models.CodeLocation
+	references  []models.CodeLocation
+	truncated   bool
+}
+
+// searchSymbol finds the declarations, and optionally the uses, of the identifier at a position
+// of a file by name, across the files of the repository in the same language family
+func (s *CodeNavigationService) searchSymbol(ctx context.Context, src github.RepoSource, ref, filePath string, line, column int, withReferences bool) (*symbolSearch, error) {
+	content, err := src.ReadFile(ctx, ref, filePath)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get file content")
+	}
+	name, _, err := utils.IdentifierAt(content.Content, line, column)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPosition, err)
+	}
+
+	files, err := src.ListTree(ctx, ref)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list repository files")
+	}
+	candidates := relatedSourceFiles(files, filePath, utils.DetectLanguage(filePath, content.Content))
+
+	search := &symbolSearch{name: name}
+	if len(candidates) > maxSymbolSearchFiles {
+		candidates, search.truncated = candidates[:maxSymbolSearchFiles], true
+	}
+
+	for _, candidate := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		text := content.Content
+		if candidate != filePath {
+			file, err := src.ReadFile(ctx, ref, candidate)
+			if err != nil {
+				s.logger.WithField("error", err).Warning("Failed to read " + candidate)
+				continue
+			}
+			text = file.Content
+		}
+		if !strings.Contains(text, name) {
+			continue
+		}
+
+		lines := strings.Split(text, "\n")
+		declared := make(map[int]bool)
+		symbols, _ := utils.ExtractSymbols(candidate, text)
+		for _, symbol := range symbols {
+			if symbol.Name != name {
+				continue
+			}
+			location := models.CodeLocation{
+				FilePath: candidate,
+				Line:     symbol.StartLine,
+				EndLine:  symbol.EndLine,
+				Symbol:   symbol.QualifiedName(),
+				Kind:     string(symbol.Kind),
+				Snippet:  lineSnippet(lines, symbol.StartLine),
+			}
+			// Point at the name on the first line that has it, past any decorators
+			for _, occurrence := range utils.FindIdentifier(candidate, utils.SymbolCode(text, symbol), name) {
+				location.Line = symbol.StartLine + occurrence.Line - 1
+				location.Column = occurrence.Column
+				location.Snippet = lineSnippet(lines, location.Line)
+				break
+			}
+			declared[location.Line] = true
+			search.definitions = append(search.definitions, location)
+		}
+
+		if !withReferences {
+			continue
+		}
+		for _, occurrence := range utils.FindIdentifier(candidate, text, name) {
+			if declared[occurrence.Line] {
+				continue
+			}
+			search.references = append(search.references, models.CodeLocation{
+				FilePath: candidate,
+				Line:     occurrence.Line,
+				Column:   occurrence.Column,
+				Symbol:   name,
+				Snippet:  lineSnippet(lines, occurrence.Line),
+			})
+		}
+	}
+
+	if len(search.definitions) == 0 && !withReferences {
+		return nil, fmt.Errorf("%w for %s", ErrNoDefinition, name)
+	}
+	return search, nil
+}
+
+// relatedSourceFiles returns the source files of a repository in the language family of a
+// file: the file itself first, then its directory, then the rest by path. Vendored
+// dependencies are skipped.
+func relatedSourceFiles(files []models.GitHubFile, filePath string, lang *utils.LanguageInfo) []string {
+	family := func(id string) string {
+		if f, ok := symbolFamilies[id]; ok {
+			return f
+		}
+		return id
+	}
+
+	var related []string
+	for _, file := range files {
+		if file.Type != "file" || file.Path == filePath {
+			continue
+		}
+		if utils.IsVendoredPath(file.Path) {
+			continue
+		}
+		other := utils.GetLanguageFromPath(file.Path)
+		if other == nil || lang == nil || family(other.ID) != family(lang.ID) {
+			continue
+		}
+		related = append(related, file.Path)
+	}
+
+	dir := path.Dir(filePath)
+	sort.SliceStable(related, func(i, j int) bool {
+		iLocal, jLocal := path.Dir(related[i]) == dir, path.Dir(related[j]) == dir
+		if iLocal != jLocal {
+			return iLocal
+		}
+		return related[i] < related[j]
+	})
+	return append([]string{filePath}, related...)
+}
+
+// lineSnippet returns a 1-based line of a file without surrounding whitespace
+func lineSnippet(lines []string, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
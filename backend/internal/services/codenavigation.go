@@ -15,6 +15,7 @@ import (
 	"github.com/pbearc/github-agent/backend/internal/graph"
 	"github.com/pbearc/github-agent/backend/internal/llm"
 	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 	"github.com/sirupsen/logrus"
 )
@@ -24,6 +25,7 @@ type CodeNavigationService struct {
     githubClient *github.Client
     llmClient    *llm.GeminiClient
     neo4jClient  *graph.Neo4jClient
+    source       github.RepoSource
     logger       *common.Logger
 }
 
@@ -37,11 +39,25 @@ func NewCodeNavigationService(githubClient *github.Client, llmClient *llm.Gemini
     }
 }
 
+// WithSource makes the service read repository files from src instead of the GitHub API
+func (s *CodeNavigationService) WithSource(src github.RepoSource) *CodeNavigationService {
+    s.source = src
+    return s
+}
+
+// sourceFor returns the configured repository source, defaulting to the GitHub API
+func (s *CodeNavigationService) sourceFor(owner, repo string) github.RepoSource {
+    if s.source != nil {
+        return s.source
+    }
+    return s.githubClient.NewAPISource(owner, repo)
+}
+
 
 // GenerateCodeWalkthrough generates a code walkthrough for a repository
 func (s *CodeNavigationService) GenerateCodeWalkthrough(ctx context.Context, owner, repo, branch string, depth int, focusPath string, entryPoints []string) (*models.CodeWalkthroughResponse, error) {
 	// Get repository info
-	repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
+	repoInfo, err := s.sourceFor(owner, repo).Info(ctx)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get repository info")
 	}
@@ -51,15 +67,15 @@ func (s *CodeNavigationService) GenerateCodeWalkthrough(ctx context.Context, own
 		branch = repoInfo.DefaultBranch
 	}
 
-	// Get some basic file structure
-	_, err = s.githubClient.GetRepositoryStructure(ctx, owner, repo, branch)
+	// Get the file structure, without generated, vendored, binary and oversized files
+	tree, err := github.ListSourceTree(ctx, s.sourceFor(owner, repo), branch)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get repository structure")
 	}
 
 	// Auto-detect entry points if not provided
 	if len(entryPoints) == 0 {
-		entryPoints = s.detectEntryPoints(ctx, owner, repo, branch, repoInfo.Language)
+		entryPoints = s.detectEntryPoints(tree, repoInfo.Language)
 	}
 
 	// Collect sample code for LLM context
@@ -67,42 +83,49 @@ func (s *CodeNavigationService) GenerateCodeWalkthrough(ctx context.Context, own
 	
 	// Add entry points
 	for _, entryPoint := range entryPoints {
-		content, err := s.githubClient.GetFileContentText(ctx, owner, repo, entryPoint, branch)
+		content, err := s.sourceFor(owner, repo).ReadFile(ctx, branch, entryPoint)
 		if err != nil {
 			s.logger.WithField("error", err).Warning("Failed to get content for entry point: " + entryPoint)
 			continue
 		}
+		if tree.Skip(entryPoint, content.Content) {
+			continue
+		}
 		codebase[entryPoint] = content.Content
 	}
 
 	// Update the focus path handling in internal/services/codenavigation.go
 	if focusPath != "" {
 		// Try to get file content first
-		focusContent, err := s.githubClient.GetFileContentText(ctx, owner, repo, focusPath, branch)
+		focusContent, err := s.sourceFor(owner, repo).ReadFile(ctx, branch, focusPath)
 		if err == nil {
 			// It's a file, add to codebase
-			codebase[focusPath] = focusContent.Content
+			if !tree.Skip(focusPath, focusContent.Content) {
+				codebase[focusPath] = focusContent.Content
+			}
 		} else {
 			// It might be a directory, try to list files
 			s.logger.WithField("error", err).Warning("Failed to get content for focus path, trying as directory: " + focusPath)
-			files, dirErr := s.githubClient.ListFiles(ctx, owner, repo, focusPath, branch)
-			if dirErr == nil && len(files) > 0 {
-				// It's a directory, get content for some files
-				for i, file := range files {
-					if i >= 3 || file == nil || file.Path == nil {
-						continue // Limit to 3 files
-					}
-					
-					// Only process source code files
-					filePath := *file.Path
-					if !github.IsSourceFile(filePath) {
-						continue
-					}
-					
-					contentFile, contentErr := s.githubClient.GetFileContentText(ctx, owner, repo, filePath, branch)
-					if contentErr == nil {
-						codebase[filePath] = contentFile.Content
-					}
+			dir := strings.Trim(focusPath, "/")
+			added := 0
+			for _, file := range tree.Files {
+				if added >= 3 {
+					break // Limit to 3 files
+				}
+				if file.Type != "file" || filepath.Dir(file.Path) != dir {
+					continue
+				}
+				
+				// Only process source code files
+				filePath := file.Path
+				if !utils.IsSourceFile(filePath) {
+					continue
+				}
+				
+				contentFile, contentErr := s.sourceFor(owner, repo).ReadFile(ctx, branch, filePath)
+				if contentErr == nil && !tree.Skip(filePath, contentFile.Content) {
+					codebase[filePath] = contentFile.Content
+					added++
 				}
 			}
 		}
@@ -121,7 +144,7 @@ func (s *CodeNavigationService) GenerateCodeWalkthrough(ctx context.Context, own
 	}
 
 	// Generate walkthrough using LLM
-	walkthroughJSON, err := s.llmClient.GenerateCodeWalkthrough(ctx, repoInfoMap, codebase, entryPoints)
+	walkthroughJSON, err := s.llmClient.WithGuidance(tree.Config.Guidance()).GenerateCodeWalkthrough(ctx, repoInfoMap, codebase, entryPoints)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to generate code walkthrough")
 	}
@@ -133,14 +156,18 @@ func (s *CodeNavigationService) GenerateCodeWalkthrough(ctx context.Context, own
 		// If JSON parsing fails, try to structure the text response
 		walkthrough = s.structureWalkthroughText(walkthroughJSON, entryPoints)
 	}
+	if len(tree.Skipped) > 0 {
+		walkthrough.Skipped = tree.Skipped
+	}
 
 	return &walkthrough, nil
 }
 
-// ExplainFunction explains a function
-func (s *CodeNavigationService) ExplainFunction(ctx context.Context, owner, repo, branch, filePath, functionName string, lineStart, lineEnd int) (*models.FunctionExplainerResponse, error) {
+// ExplainFunction explains a function, found by its name, which may be qualified as
+// Type.Method, by an explicit line range, or as the symbol enclosing a line
+func (s *CodeNavigationService) ExplainFunction(ctx context.Context, owner, repo, branch, filePath, functionName string, line, lineStart, lineEnd int) (*models.FunctionExplainerResponse, error) {
 	// Get repository info
-	repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
+	repoInfo, err := s.sourceFor(owner, repo).Info(ctx)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get repository info")
 	}
@@ -150,52 +177,69 @@ func (s *CodeNavigationService) ExplainFunction(ctx context.Context, owner, repo
 		branch = repoInfo.DefaultBranch
 	}
 
+	content, err := s.sourceFor(owner, repo).ReadFile(ctx, branch, filePath)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get file content")
+	}
+
 	var functionCode string
-	
-	// If line numbers are specified, extract the function using those
 	if lineStart > 0 && lineEnd > 0 {
-		content, err := s.githubClient.GetFileContentText(ctx, owner, repo, filePath, branch)
+		// If line numbers are specified, extract the function using those
+		lines := strings.Split(content.Content, "\n")
+		if lineStart > len(lines) || lineEnd > len(lines) || lineStart > lineEnd {
+			return nil, common.NewError("invalid line numbers")
+		}
+		functionCode = strings.Join(lines[lineStart-1:lineEnd], "\n")
+	} else {
+		symbols, err := utils.ExtractSymbols(filePath, content.Content)
 		if err != nil {
-			return nil, common.WrapError(err, "failed to get file content")
+			return nil, common.WrapError(err, "failed to extract symbols")
 		}
-		
-		lines := strings.Split(content.Content, "\n")
-		if lineStart <= len(lines) && lineEnd <= len(lines) && lineStart <= lineEnd {
-			// internal/services/codenavigation.go (continued)
-            functionLines := lines[lineStart-1:lineEnd]
-            functionCode = strings.Join(functionLines, "\n")
-        } else {
-            return nil, common.NewError("invalid line numbers")
-        }
-    } else {
-        // Try to detect the function automatically
-        var startLine, endLine int
-        functionCode, startLine, endLine, err = s.githubClient.GetFunctionCode(ctx, owner, repo, filePath, functionName, branch)
-        if err != nil {
-            return nil, common.WrapError(err, "failed to extract function code")
-        }
-        lineStart = startLine
-        lineEnd = endLine
-    }
 
-    // Get the language from the file path
-    language := github.GetLanguageFromPath(filePath)
+		var symbol utils.Symbol
+		var ok bool
+		if functionName != "" {
+			// The line, if any, picks between symbols of the same name
+			symbol, ok = utils.FindSymbol(symbols, functionName, line)
+		} else {
+			symbol, ok = utils.EnclosingSymbol(symbols, line)
+		}
+		if !ok {
+			if functionName != "" {
+				return nil, common.NewError("function not found: " + functionName)
+			}
+			return nil, common.NewError(fmt.Sprintf("no function encloses line %d", line))
+		}
 
-    // Generate explanation using LLM
-    explanationJSON, err := s.llmClient.ExplainFunction(ctx, functionCode, language, filePath)
-    if err != nil {
-        return nil, common.WrapError(err, "failed to explain function")
-    }
+		functionName = symbol.QualifiedName()
+		functionCode = utils.SymbolCode(content.Content, symbol)
+		lineStart, lineEnd = symbol.StartLine, symbol.EndLine
+	}
 
-    // Parse the JSON response
-    var explanation models.FunctionExplainerResponse
-    err = json.Unmarshal([]byte(explanationJSON), &explanation)
-    if err != nil {
-        // If JSON parsing fails, try to structure the text response
-        explanation = s.structureFunctionExplanation(explanationJSON, functionName)
-    }
+	// Get the language from the file path
+	language := utils.LanguageName(filePath, content.Content)
+
+	// Generate explanation using LLM
+	explanationJSON, err := s.llmClient.ExplainFunction(ctx, functionCode, language, filePath)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to explain function")
+	}
+
+	// Parse the JSON response
+	var explanation models.FunctionExplainerResponse
+	err = json.Unmarshal([]byte(explanationJSON), &explanation)
+	if err != nil {
+		// If JSON parsing fails, try to structure the text response
+		explanation = s.structureFunctionExplanation(explanationJSON, functionName)
+	}
+	if explanation.FunctionName == "" {
+		explanation.FunctionName = functionName
+	}
+	explanation.FilePath = filePath
+	explanation.LineStart = lineStart
+	explanation.LineEnd = lineEnd
 
-    return &explanation, nil
+	return &explanation, nil
 }
 
 // Fix for StoreCodebaseInNeo4j method in services/codenavigation.go
@@ -210,7 +254,7 @@ func (s *CodeNavigationService) StoreCodebaseInNeo4j(ctx context.Context, owner,
     }
     
     // Get repository info
-    repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
+    repoInfo, err := s.sourceFor(owner, repo).Info(ctx)
     if err != nil {
         return common.WrapError(err, "failed to get repository info")
     }
@@ -222,7 +266,7 @@ func (s *CodeNavigationService) StoreCodebaseInNeo4j(ctx context.Context, owner,
     }
     
     // Step 1: Get all files from the repository
-    files, err := s.githubClient.GetAllFiles(ctx, owner, repo, branch)
+    files, err := s.sourceFor(owner, repo).ListTree(ctx, branch)
     if err != nil {
         return common.WrapError(err, "failed to get all files")
     }
@@ -230,390 +274,299 @@ func (s *CodeNavigationService) StoreCodebaseInNeo4j(ctx context.Context, owner,
     s.logger.Info(fmt.Sprintf("Found %d files/directories in repository", len(files)))
     
     // Step 2: Get import relationships between files
-    importMap, err := s.githubClient.GetImportMap(ctx, owner, repo, branch)
+    importGraph, err := github.BuildImportGraph(ctx, s.sourceFor(owner, repo), branch)
     if err != nil {
         s.logger.WithError(err).Warning("Failed to get import map, continuing with file structure only")
-        // Continue with empty import map rather than failing completely
-        importMap = make(map[string][]string)
+        // Continue with empty import graph rather than failing completely
+        importGraph = &github.ImportGraph{Imports: make(map[string][]string), Files: files}
     }
+    files = importGraph.Files
     
-    s.logger.Info(fmt.Sprintf("Found %d files with import relationships", len(importMap)))
+    s.logger.Info(fmt.Sprintf("Found %d files with import relationships", len(importGraph.Imports)))
+    if len(importGraph.Skipped) > 0 {
+        s.logger.Info(fmt.Sprintf("Left %s files out of the graph", importGraph.Skipped))
+    }
     
     // Step 3: Store in Neo4j
     // Check the method signature in Neo4jClient
-    err = s.neo4jClient.StoreCodebaseStructure(ctx, owner, repo, branch, files, importMap)
+    err = s.neo4jClient.StoreCodebaseStructure(ctx, owner, repo, branch, files, importGraph.Imports)
     if err != nil {
         return common.WrapError(err, "failed to store codebase structure in Neo4j")
     }
+
+    // Step 4: Store the standard library and third-party packages the files import
+    if len(importGraph.Dependencies) > 0 {
+        err = s.neo4jClient.StoreExternalDependencies(ctx, owner, repo, branch, importGraph.Dependencies, importGraph.External)
+        if err != nil {
+            s.logger.WithError(err).Warning("Failed to store external dependencies")
+        }
+    }
     
     s.logger.Info(fmt.Sprintf("Successfully stored codebase structure for %s/%s@%s in Neo4j", owner, repo, branch))
     return nil
 }
 
-// VisualizeArchitecture generates an architecture visualization
+// VisualizeArchitecture generates an architecture visualization.
+// Modules are discovered by community detection over the import graph, files are ranked
+// by PageRank and betweenness, and layers follow the cluster dependency order. The LLM is
+// only used to name and describe the discovered clusters.
 func (s *CodeNavigationService) VisualizeArchitecture(
     ctx context.Context,
     owner, repo, branch string,
     detail string,
     focusPaths []string,
 ) (*models.ArchitectureVisualizerResponse, error) {
-    // Get codebase graph from Neo4j
-    graphData, err := s.neo4jClient.GetCodebaseGraph(ctx, owner, repo, branch)
+    // If branch is not specified, use the default branch
+    if branch == "" {
+        repoInfo, err := s.sourceFor(owner, repo).Info(ctx)
+        if err != nil {
+            return nil, common.WrapError(err, "failed to get repository info")
+        }
+        branch = repoInfo.DefaultBranch
+    }
+
+    codeGraph, err := s.loadCodeGraph(ctx, owner, repo, branch, false)
     if err != nil {
-        // If Neo4j fails, try to generate a basic visualization without it
-        s.logger.WithError(err).Warning("Failed to get codebase graph from Neo4j, attempting fallback visualization")
-        return s.generateFallbackVisualization(ctx, owner, repo, branch, detail, focusPaths)
+        return nil, common.WrapError(err, "failed to load codebase graph")
     }
-    
-    // Convert graph data to diagram data
-    diagramData := s.convertToDiagramData(graphData, detail, focusPaths)
-    
-    // Generate component descriptions
-    componentDescriptions := make(map[string]string)
-    
-    // For components that appear to be important (based on connections), generate descriptions
-    importantNodes := s.findImportantNodes(diagramData)
-    
-    for _, node := range importantNodes {
-        if node.Type == "file" {
-            // Get file content
-            fileContent, err := s.githubClient.GetFileContentText(ctx, owner, repo, node.ID, branch)
-            if err != nil {
-                s.logger.WithError(err).Warning(fmt.Sprintf("Failed to get content for %s", node.ID))
-                continue
-            }
-            
-            // Generate description using LLM
-            description, err := s.generateComponentDescription(ctx, node.ID, fileContent.Content)
-            if err != nil {
-                s.logger.WithError(err).Warning(fmt.Sprintf("Failed to generate description for %s", node.ID))
-                continue
+    skipped := codeGraph.Skipped
+
+    // The agent configuration declares the intended layers and guides the descriptions
+    config, err := github.LoadAgentConfigAt(ctx, s.sourceFor(owner, repo), branch)
+    if err != nil {
+        return nil, common.WrapError(err, "failed to load agent configuration")
+    }
+    llmClient := s.llmClient.WithGuidance(config.Guidance())
+
+    if len(focusPaths) > 0 {
+        codeGraph = codeGraph.Filter(func(path string) bool {
+            for _, focusPath := range focusPaths {
+                if strings.HasPrefix(path, focusPath) {
+                    return true
+                }
             }
-            
-            componentDescriptions[node.ID] = description
+            return false
+        })
+    }
+
+    analysis := graph.Analyze(codeGraph)
+
+    // Build clusters with path-based default names, then let the LLM name them
+    clusters := make([]models.ArchitectureCluster, len(analysis.Clusters))
+    for i := range analysis.Clusters {
+        clusters[i] = models.ArchitectureCluster{
+            ID:           i,
+            Name:         defaultClusterName(i, analysis.Clusters[i]),
+            Layer:        analysis.LayerName(i),
+            Level:        analysis.Levels[i],
+            Files:        analysis.RankedMembers(i),
+            Dependencies: analysis.ClusterDependencies(codeGraph, i),
         }
     }
-    
+
+    clusterInfos := make([]llm.ClusterInfo, len(clusters))
+    for i, cluster := range clusters {
+        clusterInfos[i] = llm.ClusterInfo{
+            ID:           cluster.ID,
+            Layer:        cluster.Layer,
+            Files:        cluster.Files,
+            Dependencies: cluster.Dependencies,
+        }
+    }
+
+    labels, err := llmClient.NameArchitectureClusters(ctx, clusterInfos)
+    if err != nil {
+        s.logger.WithError(err).Warning("Failed to name architecture clusters, using path-based names")
+    }
+    for i := range clusters {
+        if label, ok := labels[clusters[i].ID]; ok {
+            clusters[i].Name = label.Name
+            clusters[i].Description = label.Description
+        }
+    }
+    clusters = uniqueClusterNames(clusters)
+
+    diagramData := buildArchitectureDiagram(codeGraph, analysis, clusters, detail)
+
+    componentDescriptions := make(map[string]string)
+    for _, cluster := range clusters {
+        if cluster.Description != "" {
+            componentDescriptions[cluster.Name] = cluster.Description
+        }
+    }
+
+    // Check the imports against the declared layers
+    imports := make(map[string][]string, len(codeGraph.Imports))
+    for source, targets := range codeGraph.Imports {
+        for target := range targets {
+            imports[source] = append(imports[source], target)
+        }
+    }
+    violations := config.LayerViolations(imports)
+
     // Generate overview
-    overview, err := s.generateArchitectureOverview(ctx, owner, repo, branch, diagramData)
+    overview, err := s.generateArchitectureOverview(ctx, llmClient, owner, repo, clusters, diagramData)
     if err != nil {
         s.logger.WithError(err).Warning("Failed to generate architecture overview")
         overview = "This is a visualization of the codebase architecture showing key components and their relationships."
     }
-    
+
     return &models.ArchitectureVisualizerResponse{
-        Overview:               overview,
-        DiagramData:            diagramData,
-        ComponentDescriptions:  componentDescriptions,
+        Overview:              overview,
+        DiagramData:           diagramData,
+        ComponentDescriptions: componentDescriptions,
+        Clusters:              clusters,
+        LayerViolations:       violations,
+        Skipped:               skipped,
     }, nil
 }
 
-// Helper function to convert graph data to diagram data
-func (s *CodeNavigationService) convertToDiagramData(graphData map[string]interface{}, detail string, focusPaths []string) models.DiagramData {
-    nodes := []models.DiagramNode{}
-    edges := []models.DiagramEdge{}
-    
-    // Create a map of node IDs to indices
-    nodeMap := make(map[string]int)
-    
-    // Process nodes
-    graphNodes, ok := graphData["nodes"].([]map[string]interface{})
-    if ok {
-        for i, node := range graphNodes {
-            // Skip if not a focus path when focus paths are specified
-            if len(focusPaths) > 0 {
-                matched := false
-                for _, focusPath := range focusPaths {
-                    if strings.HasPrefix(node["path"].(string), focusPath) {
-                        matched = true
-                        break
-                    }
-                }
-                if !matched {
-                    continue
-                }
-            }
-            
-            // Determine node type and category
-            nodeType := node["type"].(string)
-            category := "other"
-            layer := "unknown"
-            technology := "unknown"
-            
-            path := node["path"].(string)
-            ext := filepath.Ext(path)
-            
-            // Set technology based on file extension
-            switch ext {
-            case ".go":
-                technology = "Go"
-                category = "backend"
-                layer = "backend"
-            case ".js", ".jsx", ".ts", ".tsx":
-                technology = "JavaScript/TypeScript"
-                category = "frontend"
-                layer = "frontend"
-            case ".css", ".scss", ".sass", ".less":
-                technology = "CSS"
-                category = "frontend"
-                layer = "frontend"
-            case ".html":
-                technology = "HTML"
-                category = "frontend"
-                layer = "frontend"
-            case ".sql":
-                technology = "SQL"
-                category = "database"
-                layer = "database"
-            case ".md":
-                technology = "Markdown"
-                category = "documentation"
-                layer = "documentation"
-            }
-            
-            // Special cases
-            if nodeType == "directory" {
-                category = "module"
-                layer = "module"
-                
-                // Try to infer directory purpose
-                dirName := filepath.Base(path)
-                switch strings.ToLower(dirName) {
-                case "model", "models":
-                    category = "data"
-                    layer = "data"
-                case "controller", "controllers", "handlers":
-                    category = "controller"
-                    layer = "controller"
-                case "view", "views", "templates":
-                    category = "view"
-                    layer = "frontend"
-                case "config", "conf":
-                    category = "configuration"
-                    layer = "configuration"
-                case "middleware", "middlewares":
-                    category = "middleware"
-                    layer = "middleware"
-                case "service", "services":
-                    category = "service"
-                    layer = "service"
-                case "util", "utils", "helper", "helpers":
-                    category = "utility"
-                    layer = "utility"
-                case "test", "tests":
-                    category = "test"
-                    layer = "test"
-                }
-            }
-            
-            // Set size based on importance
-            size := 5
-            if nodeType == "directory" {
-                size = 7
-            }
-            
-            // Add the node
-            diagramNode := models.DiagramNode{
-                ID:        path,
-                Label:     node["label"].(string),
-                Type:      nodeType,
-                Size:      size,
-                Category:  category,
-                Layer:     layer,
-                Technology: technology,
-                Metadata: make(map[string]string),
-            }
-            
-            nodes = append(nodes, diagramNode)
-            nodeMap[path] = i
+// buildArchitectureDiagram converts the analyzed import graph into diagram data.
+// Size comes from file importance, Category from the file's cluster and Layer from
+// the cluster's dependency level. Lower detail levels keep only the most important files.
+func buildArchitectureDiagram(codeGraph *graph.CodeGraph, analysis *graph.Analysis, clusters []models.ArchitectureCluster, detail string) models.DiagramData {
+    files := codeGraph.SortedFiles()
+
+    maxNodes := 0
+    switch detail {
+    case "low":
+        maxNodes = 30
+    case "high":
+        maxNodes = 0
+    default:
+        maxNodes = 100
+    }
+
+    kept := make(map[string]bool)
+    if maxNodes > 0 && len(files) > maxNodes {
+        ranked := append([]string(nil), files...)
+        sort.SliceStable(ranked, func(i, j int) bool {
+            return analysis.Importance(ranked[i]) > analysis.Importance(ranked[j])
+        })
+        for _, file := range ranked[:maxNodes] {
+            kept[file] = true
+        }
+    } else {
+        for _, file := range files {
+            kept[file] = true
         }
     }
-    
-    // Process relationships
-    graphRelationships, ok := graphData["relationships"].([]map[string]interface{})
-    if ok {
-        for _, rel := range graphRelationships {
-            source := rel["source"].(string)
-            targets, ok := rel["targets"].([]map[string]interface{})
-            
-            if !ok || len(targets) == 0 {
-                continue
-            }
-            
-            // Skip source nodes that weren't included due to focus path filtering
-            if _, exists := nodeMap[source]; !exists {
-                continue
-            }
-            
-            for _, target := range targets {
-                // Skip null targets
-                targetPath, ok := target["target"].(string)
-                if !ok || targetPath == "" {
-                    continue
-                }
-                
-                // Skip target nodes that weren't included due to focus path filtering
-                if _, exists := nodeMap[targetPath]; !exists {
-                    continue
-                }
-                
-                relType, ok := target["type"].(string)
-                if !ok || relType == "" {
-                    relType = "related_to"
-                }
-                
-                // Add the edge
-                edge := models.DiagramEdge{
-                    Source:     source,
-                    Target:     targetPath,
-                    Type:       relType,
-                    Weight:     1,
-                    Label:      relType,
-                    Bidirectional: false,
-                    Metadata:   make(map[string]string),
-                }
-                
-                edges = append(edges, edge)
-            }
+
+    nodes := []models.DiagramNode{}
+    for _, file := range files {
+        if !kept[file] {
+            continue
         }
+        cluster := analysis.ClusterOf[file]
+        technology := "unknown"
+        if lang := utils.GetLanguageFromPath(file); lang != nil {
+            technology = lang.Name
+        }
+        nodes = append(nodes, models.DiagramNode{
+            ID:         file,
+            Label:      filepath.Base(file),
+            Type:       "file",
+            Size:       analysis.Size(file),
+            Category:   clusters[cluster].Name,
+            Layer:      clusters[cluster].Layer,
+            Technology: technology,
+            Metadata: map[string]string{
+                "cluster":     strconv.Itoa(cluster),
+                "pagerank":    strconv.FormatFloat(analysis.PageRank[file], 'f', 6, 64),
+                "betweenness": strconv.FormatFloat(analysis.Betweenness[file], 'f', 2, 64),
+            },
+        })
     }
-    
-    // Filter based on detail level
-    if detail != "high" {
-        // For medium or low detail, limit the number of nodes and edges
-        maxNodes := 100
-        if detail == "low" {
-            maxNodes = 30
+
+    edges := []models.DiagramEdge{}
+    for _, edge := range codeGraph.SortedEdges() {
+        if !kept[edge.Source] || !kept[edge.Target] {
+            continue
         }
-        
-        // If we have too many nodes, keep only the most important ones
-        if len(nodes) > maxNodes {
-            // Sort nodes by importance (size, connections, etc.)
-            // This is a simplified version - you may want to use a more sophisticated algorithm
-            nodeImportance := make(map[string]int)
-            
-            // Count edges for each node
-            for _, edge := range edges {
-                nodeImportance[edge.Source]++
-                nodeImportance[edge.Target]++
-            }
-            
-            // Adjust importance based on node type
-            for i, node := range nodes {
-                baseImportance := nodeImportance[node.ID]
-                
-                // Directories and important files get a boost
-                if node.Type == "directory" {
-                    baseImportance += 10
-                }
-                
-                // Specific important files get a boost
-                if strings.Contains(node.ID, "main.go") || 
-                   strings.Contains(node.ID, "index.js") ||
-                   strings.Contains(node.ID, "app.js") ||
-                   strings.Contains(node.ID, "server.js") {
-                    baseImportance += 5
-                }
-                
-                nodes[i].Size = baseImportance + 1 // Ensure at least size 1
-            }
-            
-            // Sort nodes by importance
-            sort.Slice(nodes, func(i, j int) bool {
-                return nodes[i].Size > nodes[j].Size
-            })
-            
-            // Keep only the most important nodes
-            keptNodeIDs := make(map[string]bool)
-            if len(nodes) > maxNodes {
-                nodes = nodes[:maxNodes]
-            }
-            
-            // Update the kept node IDs map
-            for _, node := range nodes {
-                keptNodeIDs[node.ID] = true
-            }
-            
-            // Filter edges to only include connections between kept nodes
-            filteredEdges := []models.DiagramEdge{}
-            for _, edge := range edges {
-                if keptNodeIDs[edge.Source] && keptNodeIDs[edge.Target] {
-                    filteredEdges = append(filteredEdges, edge)
-                }
-            }
-            edges = filteredEdges
+
+        // Represent mutual imports as a single bidirectional edge
+        bidirectional := codeGraph.HasEdge(edge.Target, edge.Source)
+        if bidirectional && edge.Target < edge.Source {
+            continue
         }
+
+        edges = append(edges, models.DiagramEdge{
+            Source:        edge.Source,
+            Target:        edge.Target,
+            Type:          "imports",
+            Weight:        1,
+            Label:         "imports",
+            Bidirectional: bidirectional,
+            Metadata:      make(map[string]string),
+        })
     }
-    
+
     return models.DiagramData{
         Nodes: nodes,
         Edges: edges,
     }
 }
 
-// findImportantNodes identifies important nodes based on connections
-func (s *CodeNavigationService) findImportantNodes(diagramData models.DiagramData) []models.DiagramNode {
-    // Count connections for each node
-    connections := make(map[string]int)
-    
-    for _, edge := range diagramData.Edges {
-        connections[edge.Source]++
-        connections[edge.Target]++
+// defaultClusterName names a cluster after the deepest directory shared by its members
+func defaultClusterName(id int, members []string) string {
+    if len(members) == 0 {
+        return fmt.Sprintf("cluster-%d", id)
     }
-    
-    // Find nodes with many connections
-    var importantNodes []models.DiagramNode
-    
-    for _, node := range diagramData.Nodes {
-        // If it has more than 2 connections or is a directory, consider it important
-        if connections[node.ID] > 2 || node.Type == "directory" {
-            importantNodes = append(importantNodes, node)
+
+    prefix := strings.Split(filepath.Dir(members[0]), "/")
+    for _, member := range members[1:] {
+        parts := strings.Split(filepath.Dir(member), "/")
+        n := 0
+        for n < len(prefix) && n < len(parts) && prefix[n] == parts[n] {
+            n++
         }
+        prefix = prefix[:n]
     }
-    
-    return importantNodes
-}
 
-// generateComponentDescription generates a description for a component using LLM
-func (s *CodeNavigationService) generateComponentDescription(ctx context.Context, path, content string) (string, error) {
-    // Use a simple prompt for now
-    prompt := fmt.Sprintf("Provide a brief (2-3 sentences max) description of this file's purpose in the codebase: %s\n\nFile content:\n%s", path, content)
-    
-    // Truncate content if too long
-    if len(prompt) > 2000 {
-        prompt = prompt[:2000] + "...[content truncated]"
+    name := strings.Join(prefix, "/")
+    if name == "" || name == "." {
+        return fmt.Sprintf("cluster-%d", id)
     }
-    
-    // Generate description using LLM
-    description, err := s.llmClient.GenerateCompletion(ctx, prompt, 0.7, 100)
-    if err != nil {
-        return "", err
+    return name
+}
+
+// uniqueClusterNames suffixes duplicate cluster names so each name identifies one cluster
+func uniqueClusterNames(clusters []models.ArchitectureCluster) []models.ArchitectureCluster {
+    seen := make(map[string]int)
+    for i := range clusters {
+        seen[clusters[i].Name]++
+        if count := seen[clusters[i].Name]; count > 1 {
+            clusters[i].Name = fmt.Sprintf("%s (%d)", clusters[i].Name, count)
+        }
     }
-    
-    return description, nil
+    return clusters
 }
 
 // generateArchitectureOverview generates an overview of the architecture using LLM
-func (s *CodeNavigationService) generateArchitectureOverview(ctx context.Context, owner, repo, branch string, diagramData models.DiagramData) (string, error) {
+func (s *CodeNavigationService) generateArchitectureOverview(ctx context.Context, llmClient *llm.GeminiClient, owner, repo string, clusters []models.ArchitectureCluster, diagramData models.DiagramData) (string, error) {
     // Create a summary of the architecture
     var sb strings.Builder
     
     sb.WriteString(fmt.Sprintf("Generate a brief overview (3-5 sentences) of the architecture for the repository %s/%s.\n\n", owner, repo))
     
-    // Add information about key components
-    sb.WriteString("Key components:\n")
-    
-    // Focus on directories first
-    for _, node := range diagramData.Nodes {
-        if node.Type == "directory" {
-            sb.WriteString(fmt.Sprintf("- %s (%s)\n", node.Label, node.Layer))
+    // Add information about the discovered modules
+    sb.WriteString("Modules (from lowest to highest layer):\n")
+    ordered := append([]models.ArchitectureCluster(nil), clusters...)
+    sort.SliceStable(ordered, func(i, j int) bool {
+        return ordered[i].Level < ordered[j].Level
+    })
+    for _, cluster := range ordered {
+        sb.WriteString(fmt.Sprintf("- %s (%s, %d files)", cluster.Name, cluster.Layer, len(cluster.Files)))
+        if cluster.Description != "" {
+            sb.WriteString(": " + cluster.Description)
         }
+        sb.WriteString("\n")
     }
     
     // Add important files
     sb.WriteString("\nKey files:\n")
     for _, node := range diagramData.Nodes {
-        if node.Type == "file" && node.Size > 5 {
+        if node.Size > 5 {
             sb.WriteString(fmt.Sprintf("- %s (%s)\n", node.ID, node.Technology))
         }
     }
@@ -621,7 +574,6 @@ func (s *CodeNavigationService) generateArchitectureOverview(ctx context.Context
     // Add information about relationships
     sb.WriteString("\nRelationships:\n")
     sb.WriteString(fmt.Sprintf("- Total files: %d\n", countNodesByType(diagramData.Nodes, "file")))
-    sb.WriteString(fmt.Sprintf("- Total directories: %d\n", countNodesByType(diagramData.Nodes, "directory")))
     sb.WriteString(fmt.Sprintf("- Total connections: %d\n", len(diagramData.Edges)))
     
     // Generate description using LLM
@@ -632,7 +584,7 @@ func (s *CodeNavigationService) generateArchitectureOverview(ctx context.Context
         prompt = prompt[:3000] + "...[truncated]"
     }
     
-    overview, err := s.llmClient.GenerateCompletion(ctx, prompt, 0.7, 200)
+    overview, err := llmClient.GenerateCompletion(ctx, prompt, 0.7, 200)
     if err != nil {
         return "", err
     }
@@ -651,240 +603,10 @@ func countNodesByType(nodes []models.DiagramNode, nodeType string) int {
     return count
 }
 
-// generateFallbackVisualization creates a basic visualization when Neo4j is unavailable
-func (s *CodeNavigationService) generateFallbackVisualization(
-    ctx context.Context, 
-    owner, repo, branch string,
-    detail string,
-    focusPaths []string,
-) (*models.ArchitectureVisualizerResponse, error) {
-    // Get all files
-    files, err := s.githubClient.GetAllFiles(ctx, owner, repo, branch)
-    if err != nil {
-        return nil, err
-    }
-    
-    // Create nodes for each file and directory
-    nodes := []models.DiagramNode{}
-    
-    // Track unique directories
-    directories := make(map[string]bool)
-    
-    for _, file := range files {
-        // Skip files that don't match focus paths if specified
-        if len(focusPaths) > 0 {
-            matched := false
-            for _, focusPath := range focusPaths {
-                if strings.HasPrefix(file.Path, focusPath) {
-                    matched = true
-                    break
-                }
-            }
-            if !matched {
-                continue
-            }
-        }
-        
-        // Add file node
-        if file.Type == "file" {
-            ext := filepath.Ext(file.Path)
-            
-            category := "other"
-            layer := "unknown"
-            technology := "unknown"
-            
-            // Set technology based on file extension
-            switch ext {
-            case ".go":
-                technology = "Go"
-                category = "backend"
-                layer = "backend"
-            case ".js", ".jsx", ".ts", ".tsx":
-                technology = "JavaScript/TypeScript"
-                category = "frontend"
-                layer = "frontend"
-            case ".css", ".scss", ".sass", ".less":
-                technology = "CSS"
-                category = "frontend"
-                layer = "frontend"
-            case ".html":
-                technology = "HTML"
-                category = "frontend"
-                layer = "frontend"
-            case ".sql":
-                technology = "SQL"
-                category = "database"
-                layer = "database"
-            case ".md":
-                technology = "Markdown"
-                category = "documentation"
-                layer = "documentation"
-            }
-            
-            nodes = append(nodes, models.DiagramNode{
-                ID:         file.Path,
-                Label:      file.Name,
-                Type:       "file",
-                Size:       3,
-                Category:   category,
-                Layer:      layer,
-                Technology: technology,
-                Metadata:   make(map[string]string),
-            })
-        }
-        
-        // Track directories
-        dirPath := filepath.Dir(file.Path)
-        if dirPath != "." && dirPath != "" {
-            directories[dirPath] = true
-        }
-    }
-    
-    // Add directory nodes
-    for dirPath := range directories {
-        dirName := filepath.Base(dirPath)
-        
-        category := "module"
-        layer := "module"
-        
-        // Try to infer directory purpose
-        switch strings.ToLower(dirName) {
-        case "model", "models":
-            category = "data"
-            layer = "data"
-        case "controller", "controllers", "handlers":
-            category = "controller"
-            layer = "controller"
-        case "view", "views", "templates":
-            category = "view"
-            layer = "frontend"
-        case "config", "conf":
-            category = "configuration"
-            layer = "configuration"
-        case "middleware", "middlewares":
-            category = "middleware"
-            layer = "middleware"
-        case "service", "services":
-            category = "service"
-            layer = "service"
-        case "util", "utils", "helper", "helpers":
-            category = "utility"
-            layer = "utility"
-        case "test", "tests":
-            category = "test"
-            layer = "test"
-        }
-        
-        nodes = append(nodes, models.DiagramNode{
-            ID:         dirPath,
-            Label:      dirName,
-            Type:       "directory",
-            Size:       6,
-            Category:   category,
-            Layer:      layer,
-            Technology: "N/A",
-            Metadata:   make(map[string]string),
-        })
-    }
-    
-    // Create edges based on directory structure
-    edges := []models.DiagramEdge{}
-    
-    // Connect files to their parent directories
-    for _, node := range nodes {
-        if node.Type == "file" {
-            dirPath := filepath.Dir(node.ID)
-            if dirPath != "." && dirPath != "" {
-                edges = append(edges, models.DiagramEdge{
-                    Source:        dirPath,
-                    Target:        node.ID,
-                    Type:          "contains",
-                    Weight:        1,
-                    Label:         "contains",
-                    Bidirectional: false,
-                    Metadata:      make(map[string]string),
-                })
-            }
-        }
-    }
-    
-    // Connect nested directories
-    for dir1 := range directories {
-        for dir2 := range directories {
-            if dir1 != dir2 && filepath.Dir(dir2) == dir1 {
-                edges = append(edges, models.DiagramEdge{
-                    Source:        dir1,
-                    Target:        dir2,
-                    Type:          "contains",
-                    Weight:        2,
-                    Label:         "contains",
-                    Bidirectional: false,
-                    Metadata:      make(map[string]string),
-                })
-            }
-        }
-    }
-    
-    // Try to infer some additional relationships based on naming patterns
-    for i, node1 := range nodes {
-        if node1.Type != "file" {
-            continue
-        }
-        
-        baseName1 := strings.TrimSuffix(filepath.Base(node1.ID), filepath.Ext(node1.ID))
-        
-        for j, node2 := range nodes {
-            if i == j || node2.Type != "file" {
-                continue
-            }
-            
-            baseName2 := strings.TrimSuffix(filepath.Base(node2.ID), filepath.Ext(node2.ID))
-            
-            // Connect test files to their implementation
-            if strings.HasSuffix(baseName1, "_test") && strings.TrimSuffix(baseName1, "_test") == baseName2 {
-                edges = append(edges, models.DiagramEdge{
-                    Source:        node1.ID,
-                    Target:        node2.ID,
-                    Type:          "tests",
-                    Weight:        3,
-                    Label:         "tests",
-                    Bidirectional: false,
-                    Metadata:      make(map[string]string),
-                })
-            }
-            
-            // Try to infer model-controller relationships
-            if strings.Contains(node1.ID, "/model/") && strings.Contains(node2.ID, "/controller/") {
-                // If model name appears in controller name
-                if strings.Contains(strings.ToLower(baseName2), strings.ToLower(baseName1)) {
-                    edges = append(edges, models.DiagramEdge{
-                        Source:        node2.ID,
-                        Target:        node1.ID,
-                        Type:          "uses",
-                        Weight:        2,
-                        Label:         "uses",
-                        Bidirectional: false,
-                        Metadata:      make(map[string]string),
-                    })
-                }
-            }
-        }
-    }
-    
-    // Generate description for the fallback visualization
-    overview := fmt.Sprintf("This is a basic visualization of the file structure for %s/%s. It shows the main directories and files, with inferred relationships based on naming patterns and directory structure.", owner, repo)
-    
-    return &models.ArchitectureVisualizerResponse{
-        Overview:               overview,
-        DiagramData:            models.DiagramData{Nodes: nodes, Edges: edges},
-        ComponentDescriptions:  make(map[string]string),
-    }, nil
-}
-
 // AnswerCodebaseQuestion answers a question about the codebase
 func (s *CodeNavigationService) AnswerCodebaseQuestion(ctx context.Context, owner, repo, branch, question string, keywords []string) (*models.CodebaseQAResponse, error) {
     // Get repository info
-    repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
+    repoInfo, err := s.sourceFor(owner, repo).Info(ctx)
     if err != nil {
         return nil, common.WrapError(err, "failed to get repository info")
     }
@@ -928,7 +650,7 @@ func (s *CodeNavigationService) AnswerCodebaseQuestion(ctx context.Context, owne
                 continue
             }
             
-            content, err := s.githubClient.GetFileContentText(ctx, owner, repo, *result.Path, branch)
+            content, err := s.sourceFor(owner, repo).ReadFile(ctx, branch, *result.Path)
             if err != nil {
                 s.logger.WithField("error", err).Warning("Failed to get content for file: " + *result.Path)
                 continue
@@ -940,10 +662,13 @@ func (s *CodeNavigationService) AnswerCodebaseQuestion(ctx context.Context, owne
 
     // If couldn't find enough relevant code, get some key files
     if len(relevantCode) < 2 {
-        entryPoints := s.detectEntryPoints(ctx, owner, repo, branch, repoInfo.Language)
+        var entryPoints []string
+        if tree, err := github.ListSourceTree(ctx, s.sourceFor(owner, repo), branch); err == nil {
+            entryPoints = s.detectEntryPoints(tree, repoInfo.Language)
+        }
         for _, entryPoint := range entryPoints {
             if _, exists := relevantCode[entryPoint]; !exists {
-                content, err := s.githubClient.GetFileContentText(ctx, owner, repo, entryPoint, branch)
+                content, err := s.sourceFor(owner, repo).ReadFile(ctx, branch, entryPoint)
                 if err != nil {
                     continue
                 }
@@ -1060,67 +785,39 @@ func (s *CodeNavigationService) fallbackKeywordExtraction(response string) []str
 // Helper functions for detecting entry points and structuring LLM responses
 
 // detectEntryPoints attempts to identify the main entry points of a repository
-func (s *CodeNavigationService) detectEntryPoints(ctx context.Context, owner, repo, branch, language string) []string {
+func (s *CodeNavigationService) detectEntryPoints(tree *github.SourceTree, language string) []string {
+    // The repository's agent configuration names them
+    if len(tree.Config.EntryPoints) > 0 {
+        return append([]string(nil), tree.Config.EntryPoints...)
+    }
+    
     var entryPoints []string
     
     // Look for common entry point patterns based on language
+    var commonFiles []string
     switch language {
     case "Go":
         // Check for main.go files
-        files, err := s.githubClient.GetAllFiles(ctx, owner, repo, branch)
-        if err != nil {
-            s.logger.WithField("error", err).Warning("Failed to get all files when detecting entry points")
-            return entryPoints
-        }
-        
-        for _, file := range files {
-            if file.Type == "file" && (file.Name == "main.go" || strings.HasSuffix(file.Path, "/main.go")) {
-                entryPoints = append(entryPoints, file.Path)
-            }
-        }
-        
+        commonFiles = []string{"main.go"}
     case "JavaScript", "TypeScript":
         // Check for index.js, app.js, server.js, etc.
-        commonFiles := []string{
+        commonFiles = []string{
             "index.js", "app.js", "server.js", "main.js",
             "index.ts", "app.ts", "server.ts", "main.ts",
         }
-        
-        files, err := s.githubClient.GetAllFiles(ctx, owner, repo, branch)
-        if err != nil {
-            s.logger.WithField("error", err).Warning("Failed to get all files when detecting entry points")
-            return entryPoints
-        }
-        
-        for _, file := range files {
-            if file.Type == "file" {
-                for _, commonFile := range commonFiles {
-                    if file.Name == commonFile || strings.HasSuffix(file.Path, "/"+commonFile) {
-                        entryPoints = append(entryPoints, file.Path)
-                        break
-                    }
-                }
-            }
-        }
-        
     case "Python":
         // Check for __main__.py, app.py, main.py, etc.
-        commonFiles := []string{"__main__.py", "app.py", "main.py", "run.py"}
-        
-        files, err := s.githubClient.GetAllFiles(ctx, owner, repo, branch)
-        if err != nil {
-            s.logger.WithField("error", err).Warning("Failed to get all files when detecting entry points")
-            return entryPoints
+        commonFiles = []string{"__main__.py", "app.py", "main.py", "run.py"}
+    }
+    
+    for _, file := range tree.Files {
+        if file.Type != "file" {
+            continue
         }
-        
-        for _, file := range files {
-            if file.Type == "file" {
-                for _, commonFile := range commonFiles {
-                    if file.Name == commonFile || strings.HasSuffix(file.Path, "/"+commonFile) {
-                        entryPoints = append(entryPoints, file.Path)
-                        break
-                    }
-                }
+        for _, commonFile := range commonFiles {
+            if file.Name == commonFile || strings.HasSuffix(file.Path, "/"+commonFile) {
+                entryPoints = append(entryPoints, file.Path)
+                break
             }
         }
     }
@@ -56,28 +56,32 @@ func (s *DockerfileService) GenerateDockerfile(ctx context.Context, owner, repo,
 	return dockerfileContent, nil
 }
 
-// PushDockerfile generates and pushes a Dockerfile to a repository
-func (s *DockerfileService) PushDockerfile(ctx context.Context, owner, repo, branch, language string) error {
-	// Generate Dockerfile
-	dockerfileContent, err := s.GenerateDockerfile(ctx, owner, repo, branch, language)
+// PushDockerfile generates and pushes a Dockerfile to a repository, directly or as a pull request.
+// It fails with github.ErrBranchMoved if the branch moves while the Dockerfile is generated.
+func (s *DockerfileService) PushDockerfile(ctx context.Context, owner, repo, branch, language string, mode github.PushMode) (*github.CommitResult, error) {
+	branch, headSHA, err := resolveHead(ctx, s.githubClient, owner, repo, branch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// If branch is not specified, get the default branch
-	if branch == "" {
-		repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
-		if err != nil {
-			return common.WrapError(err, "failed to get repository info")
-		}
-		branch = repoInfo.DefaultBranch
+	// Generate Dockerfile for the resolved commit
+	dockerfileContent, err := s.GenerateDockerfile(ctx, owner, repo, headSHA, language)
+	if err != nil {
+		return nil, err
 	}
 
 	// Push the Dockerfile
-	err = s.githubClient.CreateDockerfile(ctx, owner, repo, dockerfileContent, branch)
+	commit := s.githubClient.NewCommit(owner, repo, branch).ExpectHead(headSHA).Put("Dockerfile", dockerfileContent)
+	result, err := NewPushService(s.githubClient, s.llmClient).Push(ctx, commit, Change{
+		Mode:    mode,
+		Message: "Add Dockerfile via GitHub Agent",
+		Branch:  github.PullRequestBranch(branch, "dockerfile"),
+		Intent:  "Add a Dockerfile to build and run the project in a container",
+		Files:   map[string]string{"Dockerfile": dockerfileContent},
+	})
 	if err != nil {
-		return common.WrapError(err, "failed to push Dockerfile")
+		return nil, common.WrapError(err, "failed to push Dockerfile")
 	}
 
-	return nil
+	return result, nil
 }
\ No newline at end of file
@@ -0,0 +1,87 @@
+// internal/services/graph_diff.go
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/graph"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// DiffArchitecture compares the import graphs of two refs and explains the structural changes
+func (s *CodeNavigationService) DiffArchitecture(ctx context.Context, owner, repo, baseRef, headRef string, refresh bool) (*models.GraphDiffResponse, error) {
+	baseGraph, err := s.loadCodeGraph(ctx, owner, repo, baseRef, refresh)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to load graph for "+baseRef)
+	}
+
+	headGraph, err := s.loadCodeGraph(ctx, owner, repo, headRef, refresh)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to load graph for "+headRef)
+	}
+
+	diff := graph.DiffGraphs(baseGraph, headGraph, graph.DefaultHotNodeLimit)
+
+	response := &models.GraphDiffResponse{
+		BaseRef:   baseRef,
+		HeadRef:   headRef,
+		GraphDiff: diff,
+	}
+
+	narrative, err := s.llmClient.GenerateGraphDiffNarrative(ctx, baseRef, headRef, diff)
+	if err != nil {
+		s.logger.WithError(err).Warning("Failed to generate graph diff narrative")
+		narrative = fmt.Sprintf("%d files added, %d removed, %d imports added, %d removed, %d new cycles.",
+			len(diff.AddedFiles), len(diff.RemovedFiles), len(diff.AddedEdges), len(diff.RemovedEdges), len(diff.NewCycles))
+	}
+	response.Narrative = narrative
+
+	return response, nil
+}
+
+// loadCodeGraph returns the import graph for a ref. Graphs are stored in Neo4j under the
+// commit the ref resolves to, so a stored graph is reused only while the ref hasn't moved.
+func (s *CodeNavigationService) loadCodeGraph(ctx context.Context, owner, repo, ref string, refresh bool) (*graph.CodeGraph, error) {
+	src := s.sourceFor(owner, repo)
+	sha, err := src.ResolveRef(ctx, ref)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to resolve "+ref)
+	}
+
+	if s.neo4jClient != nil && !refresh {
+		stored, err := s.neo4jClient.GetImportGraph(ctx, owner, repo, sha)
+		if err != nil {
+			s.logger.WithError(err).Warning("Failed to read stored graph, rebuilding from GitHub")
+		} else if stored != nil {
+			return stored, nil
+		}
+	}
+
+	importGraph, err := github.BuildImportGraph(ctx, src, sha)
+	if err != nil {
+		// The file structure alone is returned but not stored, so later reads rebuild it
+		s.logger.WithError(err).Warning("Failed to get import map, continuing with file structure only")
+		files, err := src.ListTree(ctx, sha)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to get all files")
+		}
+		return graph.NewCodeGraph(files, make(map[string][]string)), nil
+	}
+
+	if s.neo4jClient != nil {
+		if err := s.neo4jClient.StoreCodebaseStructure(ctx, owner, repo, sha, importGraph.Files, importGraph.Imports); err != nil {
+			s.logger.WithError(err).Warning("Failed to store graph for " + ref)
+		} else if len(importGraph.Dependencies) > 0 {
+			if err := s.neo4jClient.StoreExternalDependencies(ctx, owner, repo, sha, importGraph.Dependencies, importGraph.External); err != nil {
+				s.logger.WithError(err).Warning("Failed to store external dependencies for " + ref)
+			}
+		}
+	}
+
+	codeGraph := graph.NewCodeGraph(importGraph.Files, importGraph.Imports)
+	codeGraph.Skipped = importGraph.Skipped
+	return codeGraph, nil
+}
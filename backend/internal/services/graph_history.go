@@ -0,0 +1,151 @@
+// internal/services/graph_history.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/graph"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+const (
+	defaultGraphHistoryLimit = 10
+	maxGraphHistoryLimit     = 50
+)
+
+// GetGraphHistory returns node, edge and coupling metrics for the last commits or tags, oldest first
+func (s *CodeNavigationService) GetGraphHistory(ctx context.Context, owner, repo, branch string, limit int, useTags bool) (*models.GraphHistoryResponse, error) {
+	commits, err := s.ensureGraphHistory(ctx, owner, repo, branch, limit, useTags)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.GraphHistoryResponse{
+		Points: make([]models.GraphHistoryPoint, 0, len(commits)),
+	}
+
+	for _, commit := range commits {
+		commitGraph, err := s.neo4jClient.GetCommitGraph(ctx, owner, repo, commit.SHA)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to load graph for commit "+commit.SHA)
+		}
+
+		response.Points = append(response.Points, models.GraphHistoryPoint{
+			GraphCommit:  commit,
+			GraphMetrics: graph.ComputeMetrics(commitGraph),
+		})
+	}
+
+	return response, nil
+}
+
+// FindDependencyIntroduction finds the first commit in the history in which files under
+// the from prefix import files under the to prefix. A dependency present in the oldest
+// searched commit is reported as predating the searched range.
+func (s *CodeNavigationService) FindDependencyIntroduction(ctx context.Context, owner, repo, branch string, limit int, useTags bool, from, to string) (*models.DependencyIntroductionResponse, error) {
+	commits, err := s.ensureGraphHistory(ctx, owner, repo, branch, limit, useTags)
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := s.neo4jClient.GetHistoricalEdges(ctx, owner, repo, from, to)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to query dependency history")
+	}
+
+	response := &models.DependencyIntroductionResponse{
+		From:     from,
+		To:       to,
+		Edges:    []models.GraphEdge{},
+		Searched: len(commits),
+	}
+
+	for i := range commits {
+		commit := commits[i]
+		for _, edge := range edges {
+			if edge.Commits[commit.SHA] {
+				response.Edges = append(response.Edges, edge.GraphEdge)
+			}
+		}
+
+		if len(response.Edges) > 0 {
+			response.Found = true
+			if i == 0 {
+				response.PredatesWindow = true
+			} else {
+				response.IntroducedIn = &commit
+			}
+			break
+		}
+	}
+
+	return response, nil
+}
+
+// ensureGraphHistory makes sure a graph snapshot is stored for each of the last commits
+// or tags and returns those commits ordered from oldest to newest
+func (s *CodeNavigationService) ensureGraphHistory(ctx context.Context, owner, repo, branch string, limit int, useTags bool) ([]models.GraphCommit, error) {
+	if s.neo4jClient == nil {
+		return nil, common.NewError("graph history requires Neo4j")
+	}
+
+	if limit <= 0 {
+		limit = defaultGraphHistoryLimit
+	}
+	if limit > maxGraphHistoryLimit {
+		limit = maxGraphHistoryLimit
+	}
+
+	history, ok := s.sourceFor(owner, repo).(github.HistorySource)
+	if !ok {
+		return nil, common.NewError("graph history is not available for this repository source")
+	}
+
+	var commits []models.GraphCommit
+	var err error
+	if useTags {
+		commits, err = history.ListRecentTags(ctx, limit)
+	} else {
+		commits, err = history.ListRecentCommits(ctx, branch, limit)
+	}
+	if err != nil {
+		return nil, common.WrapError(err, "failed to list history")
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Date.Before(commits[j].Date)
+	})
+
+	for _, commit := range commits {
+		stored, err := s.neo4jClient.HasCommitSnapshot(ctx, owner, repo, commit.SHA)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to check stored history")
+		}
+		if stored {
+			continue
+		}
+
+		s.logger.Info(fmt.Sprintf("Storing graph snapshot for %s/%s@%s", owner, repo, commit.SHA))
+
+		files, err := s.sourceFor(owner, repo).ListTree(ctx, commit.SHA)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to get files for commit "+commit.SHA)
+		}
+
+		importMap, err := github.BuildImportMap(ctx, s.sourceFor(owner, repo), commit.SHA)
+		if err != nil {
+			s.logger.WithError(err).Warning("Failed to get import map for commit " + commit.SHA)
+			importMap = make(map[string][]string)
+		}
+
+		if err := s.neo4jClient.StoreCommitSnapshot(ctx, owner, repo, commit, graph.NewCodeGraph(files, importMap)); err != nil {
+			return nil, common.WrapError(err, "failed to store graph for commit "+commit.SHA)
+		}
+	}
+
+	return commits, nil
+}
@@ -0,0 +1,71 @@
+// internal/services/graph_query.go
+package services
+
+import (
+	"context"
+
+	"github.com/pbearc/github-agent/backend/internal/graph"
+	"github.com/pbearc/github-agent/backend/internal/models"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// QueryGraph answers a natural-language question by translating it into a validated,
+// read-only Cypher query over the stored code graph
+func (s *CodeNavigationService) QueryGraph(ctx context.Context, owner, repo, branch, question string) (*models.GraphQueryResponse, error) {
+	if s.neo4jClient == nil {
+		return nil, common.NewError("graph queries require Neo4j")
+	}
+
+	// If branch is not specified, use the default branch
+	if branch == "" {
+		repoInfo, err := s.sourceFor(owner, repo).Info(ctx)
+		if err != nil {
+			return nil, common.WrapError(err, "failed to get repository info")
+		}
+		branch = repoInfo.DefaultBranch
+	}
+
+	// Make sure the branch graph has been stored
+	stored, err := s.neo4jClient.GetImportGraph(ctx, owner, repo, branch)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to read stored graph")
+	}
+	if stored == nil {
+		if err := s.StoreCodebaseInNeo4j(ctx, owner, repo, branch); err != nil {
+			return nil, err
+		}
+	}
+
+	generated, err := s.llmClient.GenerateGraphQuery(ctx, question, graph.GraphQuerySchema, graph.MaxQueryRows)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to generate graph query")
+	}
+
+	query, err := graph.ValidateReadOnlyQuery(generated)
+	if err != nil {
+		return nil, common.WrapError(err, "generated query was rejected")
+	}
+
+	rows, err := s.neo4jClient.RunReadQuery(ctx, query, map[string]interface{}{
+		"owner":  owner,
+		"repo":   repo,
+		"branch": branch,
+	})
+	if err != nil {
+		return nil, common.WrapError(err, "failed to run graph query")
+	}
+
+	answer, err := s.llmClient.AnswerGraphQuery(ctx, question, query, rows)
+	if err != nil {
+		s.logger.WithError(err).Warning("Failed to generate answer for graph query")
+		answer = ""
+	}
+
+	return &models.GraphQueryResponse{
+		Question: question,
+		Query:    query,
+		Rows:     rows,
+		RowCount: len(rows),
+		Answer:   answer,
+	}, nil
+}
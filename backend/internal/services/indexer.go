@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -11,6 +10,7 @@ import (
 	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/llm"
 	"github.com/pbearc/github-agent/backend/internal/pinecone"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -21,6 +21,15 @@ type CodeChunk struct {
 	StartLine   int
 	EndLine     int
 	ChunkNumber int
+	Symbols     []string // Top-level declarations the chunk covers
+}
+
+// IndexResult describes an indexed repository
+type IndexResult struct {
+	Namespace  string
+	FileCount  int
+	ChunkCount int
+	Skipped    utils.SkipCounts // Files left out, by class
 }
 
 // IndexerService handles codebase indexing
@@ -28,6 +37,7 @@ type IndexerService struct {
 	githubClient   *github.Client
 	pineconeClient *pinecone.Client
 	llmClient      *llm.GeminiClient
+	source         github.RepoSource
 	logger         *common.Logger
 }
 
@@ -45,35 +55,51 @@ func NewIndexerService(
 	}
 }
 
+// WithSource makes the indexer read repository files from src instead of the GitHub API
+func (s *IndexerService) WithSource(src github.RepoSource) *IndexerService {
+	s.source = src
+	return s
+}
+
+// sourceFor returns the configured repository source, defaulting to the GitHub API
+func (s *IndexerService) sourceFor(owner, repo string) github.RepoSource {
+	if s.source != nil {
+		return s.source
+	}
+	return s.githubClient.NewAPISource(owner, repo)
+}
+
 // IndexRepository indexes a GitHub repository
-func (s *IndexerService) IndexRepository(ctx context.Context, owner, repo, branch string) (string, error) {
+func (s *IndexerService) IndexRepository(ctx context.Context, owner, repo, branch string) (*IndexResult, error) {
 	// Generate a namespace for this repo+branch
 	namespace := fmt.Sprintf("%s-%s-%s", owner, repo, branch)
 	
+	src := s.sourceFor(owner, repo)
+
 	// Get repository info for metadata
-	_, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
+	_, err := src.Info(ctx)
 	if err != nil {
-		return "", common.WrapError(err, "failed to get repository info")
+		return nil, common.WrapError(err, "failed to get repository info")
 	}
 
-	// Get repository structure
-	repoStructure, err := s.githubClient.GetRepositoryStructure(ctx, owner, repo, branch)
+	// List the files of the repository, without generated, vendored, binary and oversized ones
+	tree, err := github.ListSourceTree(ctx, src, branch)
 	if err != nil {
-		return "", common.WrapError(err, "failed to get repository structure")
+		return nil, err
 	}
 	
-	// Parse file paths from repository structure
-	filePaths := splitLines(repoStructure)
-	
 	// Filter to only include code files
 	var codeFilePaths []string
-	for _, path := range filePaths {
-		if isCodeFile(path) {
-			codeFilePaths = append(codeFilePaths, path)
+	for _, file := range tree.Files {
+		if file.Type == "file" && utils.GetLanguageFromPath(file.Path) != nil {
+			codeFilePaths = append(codeFilePaths, file.Path)
 		}
 	}
 	
 	s.logger.Info(fmt.Sprintf("Found %d code files to index", len(codeFilePaths)))
+	if len(tree.Skipped) > 0 {
+		s.logger.Info(fmt.Sprintf("Skipped %s files", tree.Skipped))
+	}
 	
 	// Delete existing vectors for this namespace if they exist
 	err = s.pineconeClient.Delete(ctx, pinecone.DeleteRequest{
@@ -87,14 +113,19 @@ func (s *IndexerService) IndexRepository(ctx context.Context, owner, repo, branc
 	// Process each file
 	var vectors []pinecone.Vector
 	totalChunks := 0
+	indexedFiles := 0
 	
 	for _, path := range codeFilePaths {
 		// Get file content
-		fileContent, err := s.githubClient.GetFileContentText(ctx, owner, repo, path, branch)
+		fileContent, err := src.ReadFile(ctx, branch, path)
 		if err != nil {
 			s.logger.WithField("error", err).WithField("path", path).Warning("Failed to get file content, skipping")
 			continue
 		}
+		if tree.Skip(path, fileContent.Content) {
+			continue
+		}
+		indexedFiles++
 		
 		// Split file into chunks
 		chunks := s.splitFileIntoChunks(fileContent.Content, path)
@@ -121,10 +152,14 @@ func (s *IndexerService) IndexRepository(ctx context.Context, owner, repo, branc
 				"startLine":   chunk.StartLine,
 				"endLine":     chunk.EndLine,
 				"chunkNumber": chunk.ChunkNumber,
-				"language":    detectLanguageFromPath(chunk.FilePath),
+				"language":    utils.LanguageID(chunk.FilePath, ""),
 				"timestamp":   time.Now().Unix(),
 			}
 			
+			if len(chunk.Symbols) > 0 {
+				metadata["symbols"] = chunk.Symbols
+			}
+			
 			// Add vector to batch
 			vector := pinecone.Vector{
 				ID:       id,
@@ -138,7 +173,7 @@ func (s *IndexerService) IndexRepository(ctx context.Context, owner, repo, branc
 			if len(vectors) >= 100 {
 				count, err := s.pineconeClient.Upsert(ctx, vectors, namespace)
 				if err != nil {
-					return "", common.WrapError(err, "failed to upsert vectors")
+					return nil, common.WrapError(err, "failed to upsert vectors")
 				}
 				
 				s.logger.Info(fmt.Sprintf("Indexed batch of %d vectors", count))
@@ -151,25 +186,28 @@ func (s *IndexerService) IndexRepository(ctx context.Context, owner, repo, branc
 	if len(vectors) > 0 {
 		count, err := s.pineconeClient.Upsert(ctx, vectors, namespace)
 		if err != nil {
-			return "", common.WrapError(err, "failed to upsert remaining vectors")
+			return nil, common.WrapError(err, "failed to upsert remaining vectors")
 		}
 		
 		s.logger.Info(fmt.Sprintf("Indexed final batch of %d vectors", count))
 	}
 	
-	s.logger.Info(fmt.Sprintf("Indexed %d chunks from %d files", totalChunks, len(codeFilePaths)))
+	s.logger.Info(fmt.Sprintf("Indexed %d chunks from %d files", totalChunks, indexedFiles))
 	
-	return namespace, nil
+	return &IndexResult{
+		Namespace:  namespace,
+		FileCount:  indexedFiles,
+		ChunkCount: totalChunks,
+		Skipped:    tree.Skipped,
+	}, nil
 }
 
-// splitFileIntoChunks splits a file into chunks for embedding
+// splitFileIntoChunks splits a file into chunks for embedding. Chunks follow the outline of
+// the file, so declarations are not cut apart unless one alone exceeds the chunk size.
 func (s *IndexerService) splitFileIntoChunks(content, filePath string) []CodeChunk {
 	// Split content into lines
 	lines := strings.Split(content, "\n")
 	
-	// Initialize result
-	var chunks []CodeChunk
-	
 	// Determine chunk size based on file size
 	// We'll use smaller chunks for larger files
 	chunkSize := 100 // Default chunk size in lines
@@ -177,54 +215,71 @@ func (s *IndexerService) splitFileIntoChunks(content, filePath string) []CodeChu
 		chunkSize = 50
 	}
 	
+	outline, err := utils.OutlineFile(filePath, content)
+	if err != nil || len(outline.Symbols) == 0 {
+		return splitLinesIntoChunks(lines, filePath, 1, len(lines), chunkSize, nil)
+	}
+	
+	var chunks []CodeChunk
+	flush := func(start, end int, symbols []string) {
+		for _, chunk := range splitLinesIntoChunks(lines, filePath, start, end, chunkSize, symbols) {
+			chunk.ChunkNumber = len(chunks) + 1
+			chunks = append(chunks, chunk)
+		}
+	}
+	
+	// Lines between declarations, such as imports and doc comments, go with the next one
+	start, end := 1, 0
+	var symbols []string
+	for _, span := range outline.Spans() {
+		if end >= start && span.EndLine-start+1 > chunkSize {
+			flush(start, end, symbols)
+			start, symbols = end+1, nil
+		}
+		end = span.EndLine
+		symbols = append(symbols, span.QualifiedName())
+	}
+	flush(start, len(lines), symbols)
+	
+	return chunks
+}
+
+// splitLinesIntoChunks splits lines start to end, 1-based and inclusive, into chunks of at
+// most chunkSize lines with some overlap
+func splitLinesIntoChunks(lines []string, filePath string, start, end, chunkSize int, symbols []string) []CodeChunk {
+	var chunks []CodeChunk
+	
 	// Split into chunks with some overlap
 	overlap := 10 // Lines of overlap between chunks
 	
-	for i := 0; i < len(lines); i += (chunkSize - overlap) {
-		end := i + chunkSize
-		if end > len(lines) {
-			end = len(lines)
+	for i := start - 1; i < end; i += (chunkSize - overlap) {
+		chunkEnd := i + chunkSize
+		if chunkEnd > end {
+			chunkEnd = end
 		}
 		
-		// Skip if we're at the end and this would be a very small chunk
-		if i > 0 && end-i < 20 && end == len(lines) {
+		// Fold a very small final chunk into the one before it
+		if i > start-1 && chunkEnd-i < 20 && chunkEnd == end {
+			last := &chunks[len(chunks)-1]
+			last.Content = strings.Join(lines[last.StartLine-1:end], "\n")
+			last.EndLine = end
 			break
 		}
 		
-		chunkContent := strings.Join(lines[i:end], "\n")
-		
 		chunks = append(chunks, CodeChunk{
 			FilePath:    filePath,
-			Content:     chunkContent,
+			Content:     strings.Join(lines[i:chunkEnd], "\n"),
 			StartLine:   i + 1, // 1-based line numbers
-			EndLine:     end,
+			EndLine:     chunkEnd,
 			ChunkNumber: len(chunks) + 1,
+			Symbols:     symbols,
 		})
 		
 		// If we've reached the end, break
-		if end == len(lines) {
+		if chunkEnd == end {
 			break
 		}
 	}
 	
 	return chunks
 }
-
-// isCodeFile determines if a file is a code file worth indexing
-func isCodeFile(path string) bool {
-	// List of file extensions to index
-	codeExtensions := []string{
-		".go", ".py", ".js", ".ts", ".jsx", ".tsx", ".java", ".c", ".cpp", ".cs", 
-		".rb", ".php", ".swift", ".kt", ".rs", ".sh", ".html", ".css", ".md",
-		".yml", ".yaml", ".json", ".xml", ".sql", ".h", ".proto", ".scala",
-	}
-	
-	ext := filepath.Ext(path)
-	for _, codeExt := range codeExtensions {
-		if ext == codeExt {
-			return true
-		}
-	}
-	
-	return false
-}
\ No newline at end of file
@@ -39,6 +39,12 @@ func NewNavigatorService(
 	}
 }
 
+// WithSource makes the navigator and its indexer read repository files from src
+func (s *NavigatorService) WithSource(src github.RepoSource) *NavigatorService {
+	s.indexerService.WithSource(src)
+	return s
+}
+
 // EnsureRepositoryIndexed ensures that a repository is indexed
 func (s *NavigatorService) EnsureRepositoryIndexed(ctx context.Context, owner, repo, branch string) (string, error) {
 	// Generate the namespace
@@ -61,7 +67,11 @@ func (s *NavigatorService) EnsureRepositoryIndexed(ctx context.Context, owner, r
 	// If namespace doesn't exist or is empty, index the repository
 	if !namespaceExists {
 		s.logger.Info(fmt.Sprintf("Namespace %s does not exist, indexing repository", namespace))
-		return s.indexerService.IndexRepository(ctx, owner, repo, branch)
+		result, err := s.indexerService.IndexRepository(ctx, owner, repo, branch)
+		if err != nil {
+			return "", err
+		}
+		return result.Namespace, nil
 	}
 	
 	return namespace, nil
@@ -112,7 +122,7 @@ func (s *NavigatorService) AnswerQuestion(ctx context.Context, owner, repo, bran
 		filePath, _ := metadata["filePath"].(string)
 		
 		// Get file content
-		fileContent, err := s.githubClient.GetFileContentText(ctx, owner, repo, filePath, branch)
+		fileContent, err := s.indexerService.sourceFor(owner, repo).ReadFile(ctx, branch, filePath)
 		if err != nil {
 			s.logger.WithField("error", err).WithField("path", filePath).Warning("Failed to get file content")
 			continue
@@ -10,6 +10,7 @@ import (
 	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/llm"
 	"github.com/pbearc/github-agent/backend/internal/types"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -51,16 +52,27 @@ type llmSummaryResponse struct {
 // GenerateSummary generates a summary for a pull request
 func (s *PRSummaryService) GenerateSummary(ctx context.Context, owner, repo string, prNumber int) (*types.PRSummary, error) {
 	// Get PR details
-	pr, err := s.githubClient.GetPullRequest(ctx, owner, repo, prNumber)
+	pr, prContext, err := s.getPullRequest(ctx, owner, repo, prNumber)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to get pull request details")
 	}
 
+	// Read the agent configuration from the branch the PR targets, or the default branch
+	// when only REST is available
+	var base string
+	if prContext != nil {
+		base = prContext.BaseBranch
+	}
+	config, err := github.LoadAgentConfigAt(ctx, s.githubClient.NewAPISource(owner, repo), base)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to load agent configuration")
+	}
+
 	// Group related files
 	fileGroups := s.groupFiles(pr.Files)
 
 	// Generate summary using LLM
-	llmResponse, err := s.generateLLMSummary(ctx, pr, fileGroups)
+	llmResponse, err := s.generateLLMSummary(ctx, pr, prContext, fileGroups, config)
 	if err != nil {
 		return nil, common.WrapError(err, "failed to generate summary")
 	}
@@ -105,6 +117,31 @@ func (s *PRSummaryService) GenerateSummary(ctx context.Context, owner, repo stri
 	return summary, nil
 }
 
+// getPullRequest gets a pull request with its patches. When GraphQL is available it also
+// returns its reviews, review threads, comments, linked issues and check runs; otherwise,
+// or when the GraphQL query fails, the context is nil.
+func (s *PRSummaryService) getPullRequest(ctx context.Context, owner, repo string, prNumber int) (*github.PullRequest, *github.PullRequestContext, error) {
+	if s.githubClient.GraphQLAvailable() {
+		prContext, err := s.githubClient.GetPullRequestContext(ctx, owner, repo, prNumber)
+		if err == nil {
+			// GraphQL has no patches, so list the files through REST for them
+			if files, err := s.githubClient.ListPullRequestFiles(ctx, owner, repo, prNumber); err == nil {
+				prContext.Files = files
+			} else {
+				s.logger.WithError(err).Warning("Failed to list pull request files, summarizing without patches")
+			}
+			return &prContext.PullRequest, prContext, nil
+		}
+		s.logger.WithError(err).Warning("Failed to get pull request through GraphQL, falling back to REST")
+	}
+
+	pr, err := s.githubClient.GetPullRequest(ctx, owner, repo, prNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pr, nil, nil
+}
+
 // groupFiles groups related files based on directory structure and file types
 func (s *PRSummaryService) groupFiles(files []github.FileChange) []fileGroup {
 	// Simple grouping by top-level directory
@@ -136,8 +173,8 @@ func (s *PRSummaryService) groupFiles(files []github.FileChange) []fileGroup {
 	return groups
 }
 
-// generateLLMSummary uses the LLM to generate a PR summary
-func (s *PRSummaryService) generateLLMSummary(ctx context.Context, pr *github.PullRequest, fileGroups []fileGroup) (*llmSummaryResponse, error) {
+// generateLLMSummary uses the LLM to generate a PR summary, following the repository's agent configuration
+func (s *PRSummaryService) generateLLMSummary(ctx context.Context, pr *github.PullRequest, prContext *github.PullRequestContext, fileGroups []fileGroup, config *github.AgentConfig) (*llmSummaryResponse, error) {
 	// Create a context of the PR for the LLM
 	var promptBuilder strings.Builder
 	
@@ -152,8 +189,20 @@ func (s *PRSummaryService) generateLLMSummary(ctx context.Context, pr *github.Pu
 	// Select the most significant files to include in the prompt
 	var significantFiles []github.FileChange
 	
-	// Sort files by the sum of additions and deletions
+	// Sort files by the sum of additions and deletions, with generated and ignored files last
+	generated := make(map[string]bool)
+	ignored := make(map[string]bool)
+	for _, file := range pr.Files {
+		generated[file.Filename] = utils.IsGenerated(file.Filename, file.Patch)
+		ignored[file.Filename] = config.IsIgnored(file.Filename)
+	}
+	last := func(filename string) bool {
+		return generated[filename] || ignored[filename]
+	}
 	sort.Slice(pr.Files, func(i, j int) bool {
+		if last(pr.Files[i].Filename) != last(pr.Files[j].Filename) {
+			return !last(pr.Files[i].Filename)
+		}
 		return (pr.Files[i].Additions + pr.Files[i].Deletions) > 
 			   (pr.Files[j].Additions + pr.Files[j].Deletions)
 	})
@@ -171,6 +220,12 @@ func (s *PRSummaryService) generateLLMSummary(ctx context.Context, pr *github.Pu
 	
 	for _, file := range significantFiles {
 		promptBuilder.WriteString(fmt.Sprintf("File: %s\n", file.Filename))
+		if kind := describeFileKind(file.Filename, generated[file.Filename], ignored[file.Filename]); kind != "" {
+			promptBuilder.WriteString(fmt.Sprintf("Kind: %s\n", kind))
+		}
+		if layer := config.LayerOf(file.Filename); layer != "" {
+			promptBuilder.WriteString(fmt.Sprintf("Layer: %s\n", layer))
+		}
 		promptBuilder.WriteString(fmt.Sprintf("Status: %s\n", file.Status))
 		promptBuilder.WriteString(fmt.Sprintf("Changes: +%d -%d\n", file.Additions, file.Deletions))
 		
@@ -184,6 +239,11 @@ func (s *PRSummaryService) generateLLMSummary(ctx context.Context, pr *github.Pu
 		promptBuilder.WriteString("\n")
 	}
 	
+	// Add the review discussion, linked issues and checks when we have them
+	if prContext != nil {
+		writePullRequestContext(&promptBuilder, prContext)
+	}
+	
 	// Add instructions for what we want in the summary
 	promptBuilder.WriteString(`
 Based on the PR information above, please generate a comprehensive summary with the following components:
@@ -214,7 +274,7 @@ Format your response as JSON with the following structure:
 `)
 
 	// Send to LLM
-	llmOutput, err := s.llmClient.GenerateText(ctx, promptBuilder.String())
+	llmOutput, err := s.llmClient.WithGuidance(config.Guidance()).GenerateText(ctx, promptBuilder.String())
 	if err != nil {
 		return nil, common.WrapError(err, "failed to generate LLM summary")
 	}
@@ -228,6 +288,96 @@ Format your response as JSON with the following structure:
 	return summary, nil
 }
 
+// maxContextBody is the length at which review and comment bodies are cut in the prompt
+const maxContextBody = 500
+
+// writePullRequestContext writes the reviews, unresolved review threads, comments,
+// linked issues and check runs of a pull request to the prompt
+func writePullRequestContext(b *strings.Builder, prContext *github.PullRequestContext) {
+	if len(prContext.LinkedIssues) > 0 {
+		b.WriteString("Linked issues:\n")
+		for _, issue := range prContext.LinkedIssues {
+			fmt.Fprintf(b, "- #%d %s (%s)\n", issue.Number, issue.Title, issue.State)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(prContext.Reviews) > 0 {
+		b.WriteString("Reviews:\n")
+		for _, review := range prContext.Reviews {
+			fmt.Fprintf(b, "- %s: %s", review.Author, review.State)
+			if body := truncateBody(review.Body); body != "" {
+				fmt.Fprintf(b, " - %s", body)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	var unresolved []github.ReviewThread
+	for _, thread := range prContext.ReviewThreads {
+		if !thread.Resolved && len(thread.Comments) > 0 {
+			unresolved = append(unresolved, thread)
+		}
+	}
+	if len(unresolved) > 0 {
+		b.WriteString("Unresolved review threads:\n")
+		for _, thread := range unresolved {
+			first := thread.Comments[0]
+			fmt.Fprintf(b, "- %s (%d comments) %s: %s\n", thread.Path, len(thread.Comments), first.Author, truncateBody(first.Body))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(prContext.Comments) > 0 {
+		b.WriteString("Discussion:\n")
+		for _, comment := range prContext.Comments {
+			fmt.Fprintf(b, "- %s: %s\n", comment.Author, truncateBody(comment.Body))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(prContext.CheckRuns) > 0 {
+		b.WriteString("Checks:\n")
+		for _, check := range prContext.CheckRuns {
+			status := check.Status
+			if check.Conclusion != "" {
+				status = check.Conclusion
+			}
+			fmt.Fprintf(b, "- %s: %s\n", check.Name, strings.ToLower(status))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// describeFileKind names the language of a changed file and whether it holds tests or
+// generated code, or is ignored by the agent configuration
+func describeFileKind(filename string, generated, ignored bool) string {
+	var parts []string
+	if lang := utils.GetLanguageFromPath(filename); lang != nil {
+		parts = append(parts, lang.Name)
+	}
+	if utils.IsTestFile(filename) {
+		parts = append(parts, "test")
+	}
+	if generated {
+		parts = append(parts, "generated")
+	}
+	if ignored {
+		parts = append(parts, "ignored")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// truncateBody flattens a review or comment body to one line and cuts it to maxContextBody
+func truncateBody(body string) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if runes := []rune(body); len(runes) > maxContextBody {
+		body = string(runes[:maxContextBody]) + "..."
+	}
+	return body
+}
+
 // parseSummaryResponse parses the LLM response into a structured PRSummary
 func (s *PRSummaryService) parseSummaryResponse(response string) (*llmSummaryResponse, error) {
 	// Find JSON within the response (LLM might add additional text)
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pbearc/github-agent/backend/internal/github"
+	"github.com/pbearc/github-agent/backend/internal/llm"
+	"github.com/pbearc/github-agent/backend/pkg/common"
+)
+
+// maxDescribedContent bounds how much of each file is shown to the LLM when describing a change
+const maxDescribedContent = 4000
+
+// maxTitleLength bounds generated pull request titles; longer ones fall back to the commit message
+const maxTitleLength = 100
+
+// Change describes a staged commit and how it should be pushed
+type Change struct {
+	Mode    github.PushMode
+	Message string            // Commit message, and the pull request title if none is generated
+	Branch  string            // Feature branch in pull request mode, see github.PullRequestBranch
+	Intent  string            // What the change is for, used to explain it in the pull request
+	Files   map[string]string // New content by path, used to explain the change
+	Deleted []string
+}
+
+// PushService pushes staged commits straight to a branch or through a pull request
+type PushService struct {
+	githubClient *github.Client
+	llmClient    *llm.GeminiClient
+	logger       *common.Logger
+}
+
+// NewPushService creates a new PushService instance
+func NewPushService(githubClient *github.Client, llmClient *llm.GeminiClient) *PushService {
+	return &PushService{
+		githubClient: githubClient,
+		llmClient:    llmClient,
+		logger:       common.NewLogger(),
+	}
+}
+
+// Push commits the staged changes according to the change's mode, or the mode the
+// repository's agent configuration prefers when the change has none
+func (s *PushService) Push(ctx context.Context, commit *github.CommitBuilder, change Change) (*github.CommitResult, error) {
+	if change.Mode == "" {
+		config, err := commit.AgentConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		change.Mode = config.PushModeFor(change.Mode)
+	}
+	if change.Mode != github.PushModePullRequest {
+		return commit.Commit(ctx, change.Message)
+	}
+
+	title, body := s.describeChange(ctx, change)
+	return commit.CommitPullRequest(ctx, change.Message, github.PullRequestOptions{
+		HeadBranch: change.Branch,
+		Title:      title,
+		Body:       body,
+	})
+}
+
+// resolveHead returns the branch a change targets, the default branch when empty, and the
+// commit it points at. Content is read at that commit and committed with ExpectHead, so a
+// branch that moves in between fails with github.ErrBranchMoved instead of being overwritten.
+func resolveHead(ctx context.Context, client *github.Client, owner, repo, branch string) (string, string, error) {
+	if branch == "" {
+		repoInfo, err := client.GetRepositoryInfo(ctx, owner, repo)
+		if err != nil {
+			return "", "", common.WrapError(err, "failed to get repository info")
+		}
+		branch = repoInfo.DefaultBranch
+	}
+
+	sha, err := client.NewAPISource(owner, repo).ResolveRef(ctx, branch)
+	if err != nil {
+		return "", "", err
+	}
+	return branch, sha, nil
+}
+
+// describeChange generates the pull request title and body explaining a change. If the LLM fails,
+// the title falls back to the commit message and the body to the intent and the list of files.
+func (s *PushService) describeChange(ctx context.Context, change Change) (string, string) {
+	paths := make([]string, 0, len(change.Files))
+	for path := range change.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var files strings.Builder
+	for _, path := range paths {
+		files.WriteString("- `" + path + "`\n")
+	}
+	for _, path := range change.Deleted {
+		files.WriteString("- `" + path + "` (deleted)\n")
+	}
+
+	footer := "\n\n---\n" + files.String() + "\n_Opened by GitHub Agent. Re-running the same operation updates this pull request._"
+
+	var contents strings.Builder
+	for _, path := range paths {
+		content := change.Files[path]
+		if len(content) > maxDescribedContent {
+			content = content[:maxDescribedContent] + "\n... (truncated)"
+		}
+		fmt.Fprintf(&contents, "File: %s\n```\n%s\n```\n\n", path, content)
+	}
+
+	prompt := fmt.Sprintf(`
+You are an expert developer writing the description of a pull request.
+
+Purpose of the change: %s
+
+Deleted files: %s
+
+New content of the changed files:
+%s
+
+On the first line, write a short pull request title in the imperative mood, without markdown.
+Then leave a blank line and write a concise pull request description in markdown that explains
+what the change does and why, and what reviewers should check. Do not repeat the file contents.
+`, change.Intent, strings.Join(change.Deleted, ", "), contents.String())
+
+	text, err := s.llmClient.GenerateText(ctx, prompt)
+	if err != nil || strings.TrimSpace(text) == "" {
+		s.logger.WithField("error", err).Warning("Failed to generate pull request description")
+		return change.Message, change.Intent + footer
+	}
+
+	title, body, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	// Drop the markdown and "Title:" label models add despite the prompt
+	const decoration = "# *`\"'"
+	title = strings.Trim(strings.TrimPrefix(strings.Trim(title, decoration), "Title:"), decoration)
+	body = strings.TrimSpace(body)
+	if title == "" || len(title) > maxTitleLength {
+		title = change.Message
+	}
+	if body == "" {
+		body = change.Intent
+	}
+	return title, body + footer
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/llm"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -24,12 +25,18 @@ func NewReadmeService(githubClient *github.Client, llmClient *llm.GeminiClient)
 	}
 }
 
-// GenerateReadme generates a README.md file for a repository
-func (s *ReadmeService) GenerateReadme(ctx context.Context, owner, repo, branch string, includeFiles bool) (string, error) {
+// Readme is a generated README with the counts of the files left out of its file list
+type Readme struct {
+	Content string
+	Skipped utils.SkipCounts
+}
+
+// GenerateReadme generates a README.md file for a repository, following its agent configuration
+func (s *ReadmeService) GenerateReadme(ctx context.Context, owner, repo, branch string, includeFiles bool) (*Readme, error) {
 	// Get repository info
 	repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
 	if err != nil {
-		return "", common.WrapError(err, "failed to get repository info")
+		return nil, common.WrapError(err, "failed to get repository info")
 	}
 
 	// Convert repository info to a map for the LLM
@@ -50,51 +57,61 @@ func (s *ReadmeService) GenerateReadme(ctx context.Context, owner, repo, branch
 		branch = repoInfo.DefaultBranch
 	}
 
-	// Get file list if requested
+	// The tree carries the agent configuration, and the file list if requested
+	tree, err := github.ListSourceTree(ctx, s.githubClient.NewAPISource(owner, repo), branch)
+	if err != nil {
+		return nil, common.WrapError(err, "failed to get repository structure")
+	}
+
+	readme := &Readme{}
 	var files []string
 	if includeFiles {
-		repoStructure, err := s.githubClient.GetRepositoryStructure(ctx, owner, repo, branch)
-		if err != nil {
-			s.logger.WithField("error", err).Warning("Failed to get repository structure")
-		} else {
-			// Split the structure into lines
-			files = splitLines(repoStructure)
+		// Split the structure into lines
+		files = splitLines(tree.Structure())
+		if len(tree.Skipped) > 0 {
+			readme.Skipped = tree.Skipped
+			repoInfoMap["omitted_files"] = tree.Skipped.String()
 		}
 	}
 
 	// Generate README
-	readmeContent, err := s.llmClient.GenerateReadme(ctx, repoInfoMap, files)
+	readme.Content, err = s.llmClient.WithGuidance(tree.Config.Guidance()).GenerateReadme(ctx, repoInfoMap, files)
 	if err != nil {
-		return "", common.WrapError(err, "failed to generate README")
+		return nil, common.WrapError(err, "failed to generate README")
 	}
 
-	return readmeContent, nil
+	return readme, nil
 }
 
-// PushReadme generates and pushes a README.md file to a repository
-func (s *ReadmeService) PushReadme(ctx context.Context, owner, repo, branch string, includeFiles bool) error {
-	// Generate README
-	readmeContent, err := s.GenerateReadme(ctx, owner, repo, branch, includeFiles)
+// PushReadme generates and pushes a README.md file to a repository, directly or as a pull request.
+// It fails with github.ErrBranchMoved if the branch moves while the README is generated.
+func (s *ReadmeService) PushReadme(ctx context.Context, owner, repo, branch string, includeFiles bool, mode github.PushMode) (*github.CommitResult, error) {
+	branch, headSHA, err := resolveHead(ctx, s.githubClient, owner, repo, branch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// If branch is not specified, get the default branch
-	if branch == "" {
-		repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
-		if err != nil {
-			return common.WrapError(err, "failed to get repository info")
-		}
-		branch = repoInfo.DefaultBranch
+	// Generate README from the resolved commit
+	readme, err := s.GenerateReadme(ctx, owner, repo, headSHA, includeFiles)
+	if err != nil {
+		return nil, err
 	}
+	readmeContent := readme.Content
 
 	// Push the README
-	err = s.githubClient.CreateReadmeFile(ctx, owner, repo, readmeContent, branch)
+	commit := s.githubClient.NewCommit(owner, repo, branch).ExpectHead(headSHA).Put("README.md", readmeContent)
+	result, err := NewPushService(s.githubClient, s.llmClient).Push(ctx, commit, Change{
+		Mode:    mode,
+		Message: "Add README.md via GitHub Agent",
+		Branch:  github.PullRequestBranch(branch, "readme"),
+		Intent:  "Add a README generated from the repository's metadata and structure",
+		Files:   map[string]string{"README.md": readmeContent},
+	})
 	if err != nil {
-		return common.WrapError(err, "failed to push README")
+		return nil, common.WrapError(err, "failed to push README")
 	}
 
-	return nil
+	return result, nil
 }
 
 // splitLines splits a string into lines
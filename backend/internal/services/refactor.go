@@ -2,9 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/pbearc/github-agent/backend/internal/github"
 	"github.com/pbearc/github-agent/backend/internal/llm"
+	"github.com/pbearc/github-agent/backend/internal/utils"
 	"github.com/pbearc/github-agent/backend/pkg/common"
 )
 
@@ -32,8 +35,8 @@ func (s *RefactorService) GenerateRefactoredCode(ctx context.Context, owner, rep
 		return "", common.WrapError(err, "failed to get file content")
 	}
 
-	// Detect language from file extension
-	language := detectLanguageFromPath(path)
+	// Detect the language from the file name and content
+	language := utils.LanguageID(path, fileContent.Content)
 
 	// Generate refactored code
 	refactoredCode, err := s.llmClient.GenerateCodeRefactor(ctx, fileContent.Content, language, instructions)
@@ -44,55 +47,82 @@ func (s *RefactorService) GenerateRefactoredCode(ctx context.Context, owner, rep
 	return refactoredCode, nil
 }
 
-// PushRefactoredFile generates refactored code for a file and pushes it to the repository
-func (s *RefactorService) PushRefactoredFile(ctx context.Context, owner, repo, branch, path, instructions string) error {
-	// Generate refactored code
-	refactoredCode, err := s.GenerateRefactoredCode(ctx, owner, repo, branch, path, instructions)
+// PushRefactoredFile generates refactored code for a file and pushes it to the repository, directly or as a pull request.
+// It fails with github.ErrBranchMoved if the branch moves while the code is refactored.
+func (s *RefactorService) PushRefactoredFile(ctx context.Context, owner, repo, branch, path, instructions string, mode github.PushMode) (*github.CommitResult, error) {
+	branch, headSHA, err := resolveHead(ctx, s.githubClient, owner, repo, branch)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// If branch is not specified, get the default branch
-	if branch == "" {
-		repoInfo, err := s.githubClient.GetRepositoryInfo(ctx, owner, repo)
-		if err != nil {
-			return common.WrapError(err, "failed to get repository info")
-		}
-		branch = repoInfo.DefaultBranch
+	// Refactor the file at the resolved commit
+	refactoredCode, err := s.GenerateRefactoredCode(ctx, owner, repo, headSHA, path, instructions)
+	if err != nil {
+		return nil, err
 	}
 
 	// Push the refactored file
-	commitMessage := "Refactor " + path + " via GitHub Agent"
-	err = s.githubClient.CreateOrUpdateFile(ctx, owner, repo, path, commitMessage, refactoredCode, branch)
+	commit := s.githubClient.NewCommit(owner, repo, branch).ExpectHead(headSHA).Put(path, refactoredCode)
+	result, err := NewPushService(s.githubClient, s.llmClient).Push(ctx, commit, Change{
+		Mode:    mode,
+		Message: "Refactor " + path + " via GitHub Agent",
+		Branch:  github.PullRequestBranch(branch, "refactor", path),
+		Intent:  "Refactor " + path + " following these instructions: " + instructions,
+		Files:   map[string]string{path: refactoredCode},
+	})
 	if err != nil {
-		return common.WrapError(err, "failed to push refactored file")
+		return nil, common.WrapError(err, "failed to push refactored file")
 	}
 
-	return nil
+	return result, nil
 }
 
-// RefactorMultipleFiles refactors multiple files based on the same instructions
+// RefactorMultipleFiles refactors multiple files based on the same instructions and pushes
+// every successful refactor in a single commit. The push fails with github.ErrBranchMoved,
+// returned along with the results, if the branch moves while the files are refactored.
 func (s *RefactorService) RefactorMultipleFiles(ctx context.Context, owner, repo, branch string, paths []string, instructions string) (map[string]string, error) {
+	branch, headSHA, err := resolveHead(ctx, s.githubClient, owner, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
 	results := make(map[string]string)
+	commit := s.githubClient.NewCommit(owner, repo, branch).ExpectHead(headSHA)
+	var refactored []string
 	
 	for _, path := range paths {
-		refactoredCode, err := s.GenerateRefactoredCode(ctx, owner, repo, branch, path, instructions)
+		refactoredCode, err := s.GenerateRefactoredCode(ctx, owner, repo, headSHA, path, instructions)
 		if err != nil {
 			s.logger.WithField("error", err).WithField("path", path).Error("Failed to refactor file")
 			results[path] = "Error: " + err.Error()
 			continue
 		}
 		
-		// Push the refactored file
-		commitMessage := "Refactor " + path + " via GitHub Agent"
-		err = s.githubClient.CreateOrUpdateFile(ctx, owner, repo, path, commitMessage, refactoredCode, branch)
+		commit.Put(path, refactoredCode)
+		refactored = append(refactored, path)
+	}
+	if len(refactored) == 0 {
+		return results, nil
+	}
+	
+	// Push all refactored files together so the branch never holds half of the change
+	commitMessage := fmt.Sprintf("Refactor %d files via GitHub Agent", len(refactored))
+	if len(refactored) == 1 {
+		commitMessage = "Refactor " + refactored[0] + " via GitHub Agent"
+	}
+	_, err = commit.Commit(ctx, commitMessage)
+	for _, path := range refactored {
 		if err != nil {
-			s.logger.WithField("error", err).WithField("path", path).Error("Failed to push refactored file")
 			results[path] = "Error pushing: " + err.Error()
-			continue
+		} else {
+			results[path] = "Successfully refactored and pushed"
+		}
+	}
+	if err != nil {
+		s.logger.WithField("error", err).Error("Failed to push refactored files")
+		if errors.Is(err, github.ErrBranchMoved) {
+			return results, err
 		}
-		
-		results[path] = "Successfully refactored and pushed"
 	}
 	
 	return results, nil
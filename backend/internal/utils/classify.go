@@ -0,0 +1,359 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FileClass is why a file of a repository is, or is not, analyzed as source
+type FileClass string
+
+// File classes. Every class but FileSource is skipped by the analysis pipelines.
+const (
+	FileSource    FileClass = "source"
+	FileGenerated FileClass = "generated"
+	FileVendored  FileClass = "vendored"
+	FileBinary    FileClass = "binary"
+	FileLarge     FileClass = "large"
+	FileIgnored   FileClass = "ignored"
+)
+
+// Size limits of analyzed files, in bytes. Data files such as JSON fixtures get a lower limit.
+const (
+	MaxSourceFileSize = 512 * 1024
+	MaxDataFileSize   = 128 * 1024
+)
+
+// Minified files are detected by the average length of their lines
+const (
+	minifiedLineLength = 110
+	minifiedMinSize    = 1024
+)
+
+// binarySniffLength is how many leading bytes are searched for a NUL byte
+const binarySniffLength = 8000
+
+// vendoredDirectories are directories that hold third-party code
+var vendoredDirectories = map[string]bool{
+	"node_modules":     true,
+	"vendor":           true,
+	"bower_components": true,
+	"jspm_packages":    true,
+	"third_party":      true,
+	"thirdparty":       true,
+	"third-party":      true,
+	"Godeps":           true,
+	"Pods":             true,
+	"Carthage":         true,
+	".yarn":            true,
+}
+
+// generatedFiles are base names of lockfiles and other files written by tools
+var generatedFiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"composer.lock":       true,
+	"Gemfile.lock":        true,
+	"Cargo.lock":          true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"uv.lock":             true,
+	"go.sum":              true,
+	"go.work.sum":         true,
+	"flake.lock":          true,
+	"Podfile.lock":        true,
+	"pubspec.lock":        true,
+	"mix.lock":            true,
+}
+
+// generatedPatterns are base name patterns of files written by code generators and minifiers
+var generatedPatterns = []string{
+	"*.pb.go", "*.pb.gw.go", "*_pb2.py", "*_pb2_grpc.py", "*.pb.h", "*.pb.cc", "*_pb.js", "*_pb.d.ts",
+	"*_generated.go", "zz_generated*.go", "*.gen.go", "*.generated.ts",
+	"*.min.js", "*.min.css", "*.min.mjs", "*-min.js", "*.js.map", "*.css.map", "*.bundle.js",
+	"*.Designer.cs", "*.designer.cs", "*.g.cs", "*.g.dart", "*.freezed.dart",
+}
+
+// binaryExtensions are extensions of files that never hold text
+var binaryExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".webp": true,
+	".tif": true, ".tiff": true, ".psd": true, ".pdf": true,
+	".zip": true, ".gz": true, ".tgz": true, ".tar": true, ".bz2": true, ".xz": true, ".7z": true, ".rar": true,
+	".jar": true, ".war": true, ".class": true, ".pyc": true, ".pyo": true, ".wasm": true,
+	".exe": true, ".dll": true, ".so": true, ".dylib": true, ".o": true, ".a": true, ".lib": true, ".bin": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp3": true, ".mp4": true, ".wav": true, ".ogg": true, ".mov": true, ".avi": true, ".webm": true, ".flac": true,
+	".db": true, ".sqlite": true, ".sqlite3": true,
+}
+
+// minifiedExtensions are extensions of files checked for minified content
+var minifiedExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".cjs": true, ".css": true,
+}
+
+// attributeRule is a line of a .gitattributes file setting a linguist attribute
+type attributeRule struct {
+	pattern   *regexp.Regexp
+	attribute string
+	value     bool
+}
+
+// FileClassifier tells source files from generated, vendored, binary and oversized ones. It
+// applies the linguist-generated, linguist-vendored and binary attributes of .gitattributes
+// files first, then path rules, size limits and heuristics on content. Ignored patterns take
+// precedence over all of them. A nil FileClassifier applies the built-in rules only.
+type FileClassifier struct {
+	rules   []attributeRule
+	ignored []*regexp.Regexp
+}
+
+// NewFileClassifier returns a classifier with the built-in rules only
+func NewFileClassifier() *FileClassifier {
+	return &FileClassifier{}
+}
+
+// AddAttributes adds the rules of the .gitattributes file at attributesPath. Later rules take
+// precedence, so files are added from the root down.
+func (c *FileClassifier) AddAttributes(attributesPath, content string) {
+	dir := path.Dir(attributesPath)
+	if dir == "." {
+		dir = ""
+	}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+		pattern := gitattributesPattern(dir, fields[0])
+		for _, field := range fields[1:] {
+			attribute, value := field, true
+			switch {
+			case strings.HasPrefix(field, "-"):
+				attribute, value = field[1:], false
+			case strings.HasPrefix(field, "!"):
+				continue
+			case strings.Contains(field, "="):
+				attribute, value = field[:strings.Index(field, "=")], isTrueAttribute(field[strings.Index(field, "=")+1:])
+			}
+			switch attribute {
+			case "linguist-generated", "linguist-vendored", "binary":
+				c.rules = append(c.rules, attributeRule{pattern: pattern, attribute: attribute, value: value})
+			}
+		}
+	}
+}
+
+// Ignore makes the classifier skip the files matching any of the patterns, compiled with
+// CompileGlob, before any other rule
+func (c *FileClassifier) Ignore(patterns ...*regexp.Regexp) {
+	c.ignored = append(c.ignored, patterns...)
+}
+
+// isTrueAttribute reports whether the value of an attribute set with "=" enables it
+func isTrueAttribute(value string) bool {
+	return value != "false" && value != "0"
+}
+
+// gitattributesPattern converts a .gitattributes pattern to a regular expression over paths
+// relative to the repository root
+func gitattributesPattern(dir, pattern string) *regexp.Regexp {
+	re, err := regexp.Compile(globExpression(dir, pattern))
+	if err != nil {
+		return regexp.MustCompile(`^` + regexp.QuoteMeta(path.Join(dir, pattern)) + `$`)
+	}
+	return re
+}
+
+// CompileGlob compiles a .gitignore-style glob over paths relative to the repository root.
+// Patterns without a slash match base names at any depth, "**" matches across directories
+// and a pattern naming a directory covers everything inside it.
+func CompileGlob(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	re, err := regexp.Compile(globExpression("", pattern))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return re, nil
+}
+
+// globExpression returns the regular expression of a glob relative to dir
+func globExpression(dir, pattern string) string {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(strings.TrimSuffix(pattern, "/"), "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if dir != "" {
+		b.WriteString(regexp.QuoteMeta(dir) + "/")
+	}
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case ch == '*':
+			b.WriteString("[^/]*")
+		case ch == '?':
+			b.WriteString("[^/]")
+		case ch == '[':
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				b.WriteString(pattern[i : i+end+1])
+				i += end
+				continue
+			}
+			b.WriteString(`\[`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	// A pattern naming a directory covers everything inside it
+	b.WriteString("(?:/.*)?$")
+	return b.String()
+}
+
+// attribute returns the value the last matching rule gives an attribute of a file, and
+// whether any rule sets it
+func (c *FileClassifier) attribute(filePath, attribute string) (value, set bool) {
+	if c == nil {
+		return false, false
+	}
+	for _, rule := range c.rules {
+		if rule.attribute == attribute && rule.pattern.MatchString(filePath) {
+			value, set = rule.value, true
+		}
+	}
+	return value, set
+}
+
+// ClassifyPath classifies a file by its path and size, before it is read. A size of zero or
+// less is unknown and not checked.
+func (c *FileClassifier) ClassifyPath(filePath string, size int) FileClass {
+	if c != nil {
+		for _, pattern := range c.ignored {
+			if pattern.MatchString(filePath) {
+				return FileIgnored
+			}
+		}
+	}
+	if binary, set := c.attribute(filePath, "binary"); binary || !set && binaryExtensions[strings.ToLower(path.Ext(filePath))] {
+		return FileBinary
+	}
+	if vendored, set := c.attribute(filePath, "linguist-vendored"); vendored || !set && IsVendoredPath(filePath) {
+		return FileVendored
+	}
+	if generated, set := c.attribute(filePath, "linguist-generated"); generated || !set && isGeneratedPath(filePath) {
+		return FileGenerated
+	}
+
+	limit := MaxSourceFileSize
+	if lang := GetLanguageFromPath(filePath); lang != nil && lang.Type == LanguageData {
+		limit = MaxDataFileSize
+	}
+	if size > limit {
+		return FileLarge
+	}
+	return FileSource
+}
+
+// Classify classifies a file by its path and content, once it is read: binary content,
+// generated code headers and minified code are detected on top of ClassifyPath
+func (c *FileClassifier) Classify(filePath, content string) FileClass {
+	if class := c.ClassifyPath(filePath, len(content)); class != FileSource {
+		return class
+	}
+	if _, set := c.attribute(filePath, "binary"); !set && isBinaryContent(content) {
+		return FileBinary
+	}
+	if _, set := c.attribute(filePath, "linguist-generated"); !set && (IsGenerated(filePath, content) || isMinified(filePath, content)) {
+		return FileGenerated
+	}
+	return FileSource
+}
+
+// IsVendoredPath reports whether a file is inside a directory of third-party code
+func IsVendoredPath(filePath string) bool {
+	dirs := strings.Split(path.Dir(filePath), "/")
+	for _, dir := range dirs {
+		if vendoredDirectories[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedPath reports whether the name of a file is one of a lockfile or generated code
+func isGeneratedPath(filePath string) bool {
+	base := path.Base(filePath)
+	if generatedFiles[base] {
+		return true
+	}
+	for _, pattern := range generatedPatterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinaryContent reports whether the start of content holds a NUL byte, as git decides
+func isBinaryContent(content string) bool {
+	return strings.IndexByte(content[:min(len(content), binarySniffLength)], 0) >= 0
+}
+
+// isMinified reports whether a script or stylesheet has the long lines of minified code
+func isMinified(filePath, content string) bool {
+	if !minifiedExtensions[strings.ToLower(path.Ext(filePath))] || len(content) < minifiedMinSize {
+		return false
+	}
+	lines := strings.Count(strings.TrimRight(content, "\n"), "\n") + 1
+	return len(content)/lines > minifiedLineLength
+}
+
+// SkipCounts counts the files a pipeline skipped, by class
+type SkipCounts map[FileClass]int
+
+// Add counts a skipped file of a class; source files are not counted
+func (s SkipCounts) Add(class FileClass) {
+	if class != FileSource {
+		s[class]++
+	}
+}
+
+// Total returns the number of skipped files
+func (s SkipCounts) Total() int {
+	total := 0
+	for _, n := range s {
+		total += n
+	}
+	return total
+}
+
+// String lists the counts by class, like "3 generated, 12 vendored"
+func (s SkipCounts) String() string {
+	classes := make([]string, 0, len(s))
+	for class, n := range s {
+		if n > 0 {
+			classes = append(classes, string(class))
+		}
+	}
+	sort.Strings(classes)
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%d %s", s[FileClass(class)], class)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Occurrence is a position in a file with a 1-based line and a 1-based column in characters
+type Occurrence struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// IdentifierAt returns the identifier under a 1-based line and column, in characters, of content
+// and the column it starts at
+func IdentifierAt(content string, line, column int) (string, int, error) {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", 0, fmt.Errorf("line %d is outside the file", line)
+	}
+	text := []rune(lines[line-1])
+	if column < 1 || column > len(text)+1 {
+		return "", 0, fmt.Errorf("column %d is outside line %d", column, line)
+	}
+
+	// A cursor just after an identifier still names it
+	i := column - 1
+	if i == len(text) || !isIdentifierRune(text[i]) {
+		if i == 0 || !isIdentifierRune(text[i-1]) {
+			return "", 0, fmt.Errorf("no identifier at line %d, column %d", line, column)
+		}
+		i--
+	}
+
+	start, end := i, i
+	for start > 0 && isIdentifierRune(text[start-1]) {
+		start--
+	}
+	for end < len(text) && isIdentifierRune(text[end]) {
+		end++
+	}
+	if unicode.IsDigit(text[start]) {
+		return "", 0, fmt.Errorf("no identifier at line %d, column %d", line, column)
+	}
+	return string(text[start:end]), start + 1, nil
+}
+
+// FindIdentifier returns the positions where name occurs as a whole identifier in code,
+// skipping strings and comments where the language of the file is known
+func FindIdentifier(filePath, content, name string) []Occurrence {
+	if name == "" {
+		return nil
+	}
+	code := content
+	if lang := DetectLanguage(filePath, content); lang != nil && lang.Type == LanguageProgramming {
+		code = maskCode(content, lang)
+	}
+
+	// Masking keeps byte offsets, so columns are counted on the original lines
+	lines := strings.Split(content, "\n")
+	var occurrences []Occurrence
+	for i, text := range strings.Split(code, "\n") {
+		for offset := 0; ; {
+			j := strings.Index(text[offset:], name)
+			if j < 0 {
+				break
+			}
+			start, end := offset+j, offset+j+len(name)
+			offset = end
+			if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isIdentifierRune(before) {
+				continue
+			}
+			if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isIdentifierRune(after) {
+				continue
+			}
+			occurrences = append(occurrences, Occurrence{Line: i + 1, Column: RuneColumn(lines[i], start+1)})
+		}
+	}
+	return occurrences
+}
+
+// RuneColumn converts a 1-based byte column of a line to a 1-based column in characters
+func RuneColumn(line string, byteColumn int) int {
+	if byteColumn < 1 {
+		return byteColumn
+	}
+	return utf8.RuneCountInString(line[:min(byteColumn-1, len(line))]) + 1
+}
+
+// ByteColumn converts a 1-based column in characters of a line to a 1-based byte column
+func ByteColumn(line string, runeColumn int) int {
+	column := 1
+	for i := range line {
+		if column == runeColumn {
+			return i + 1
+		}
+		column++
+	}
+	return len(line) + runeColumn - column + 1
+}
+
+// isIdentifierRune reports whether r can be part of an identifier in the supported languages
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,744 @@
+package utils
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// LanguageType groups languages the way GitHub linguist does
+type LanguageType string
+
+const (
+	LanguageProgramming LanguageType = "programming"
+	LanguageMarkup      LanguageType = "markup"
+	LanguageData        LanguageType = "data"
+	LanguageProse       LanguageType = "prose"
+)
+
+// LanguageInfo contains information about a language: how to recognize its files, its
+// comment syntax and its test and generated file conventions
+type LanguageInfo struct {
+	ID               string // Lowercase identifier, as used in prompts and metadata
+	Name             string // Display name, as GitHub reports it
+	Type             LanguageType
+	Aliases          []string
+	Extensions       []string
+	Filenames        []string // Base names or globs, such as Dockerfile or Dockerfile.*
+	Interpreters     []string // Shebang interpreters, without version suffixes
+	CommentPrefix    string
+	MultiLineStart   string
+	MultiLineEnd     string
+	TestPatterns     []string         // Globs matched against the base name of test files
+	GeneratedMarkers []string         // Header text of generated files, besides the common ones
+	Heuristics       []*regexp.Regexp // Patterns typical of the language's source
+	PackagePattern   *regexp.Regexp
+	ImportPattern    *regexp.Regexp
+	FunctionPattern  *regexp.Regexp
+	ClassPattern     *regexp.Regexp
+}
+
+// languageList is the language registry, in order of precedence for ambiguous extensions
+// and content heuristics
+var languageList = []*LanguageInfo{
+	{
+		ID:             "go",
+		Name:           "Go",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"golang"},
+		Extensions:     []string{".go"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*_test.go"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^package\s+\w+\s*$`),
+			regexp.MustCompile(`(?m)^func\s`),
+			regexp.MustCompile(`(?m)^import\s+\(`),
+			regexp.MustCompile(`\w+\s*:=`),
+		},
+		PackagePattern:  regexp.MustCompile(`package\s+(\w+)`),
+		ImportPattern:   regexp.MustCompile(`import\s+(?:"([^"]+)"|(\w+)\s+"([^"]+)")`),
+		FunctionPattern: regexp.MustCompile(`func\s+(\w+)`),
+		ClassPattern:    nil, // Go doesn't have classes in the traditional sense
+	},
+	{
+		ID:               "python",
+		Name:             "Python",
+		Type:             LanguageProgramming,
+		Aliases:          []string{"py"},
+		Extensions:       []string{".py", ".pyi", ".pyw"},
+		Interpreters:     []string{"python"},
+		CommentPrefix:    "#",
+		MultiLineStart:   `"""`,
+		MultiLineEnd:     `"""`,
+		TestPatterns:     []string{"test_*.py", "*_test.py", "conftest.py"},
+		GeneratedMarkers: []string{"Generated by the protocol buffer compiler"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*def\s+\w+\s*\(.*\)\s*(->\s*[^:]+)?:\s*$`),
+			regexp.MustCompile(`(?m)^(?:from\s+[\w.]+\s+)?import\s+\w`),
+			regexp.MustCompile(`(?m)^\s*class\s+\w+.*:\s*$`),
+			regexp.MustCompile(`(?m)^if\s+__name__\s*==`),
+		},
+		ImportPattern:   regexp.MustCompile(`(?:from\s+(\w+(?:\.\w+)*)\s+)?import\s+(.+)`),
+		FunctionPattern: regexp.MustCompile(`def\s+(\w+)\s*\(`),
+		ClassPattern:    regexp.MustCompile(`class\s+(\w+)`),
+	},
+	{
+		ID:             "typescript",
+		Name:           "TypeScript",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"ts"},
+		Extensions:     []string{".ts", ".tsx", ".mts", ".cts"},
+		Interpreters:   []string{"ts-node", "deno"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*.test.ts", "*.spec.ts", "*.test.tsx", "*.spec.tsx"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`\binterface\s+\w+(?:<[^>]*>)?\s*(?:extends\s+[\w<>, ]+)?\{`),
+			regexp.MustCompile(`:\s*(?:string|number|boolean|void|any|unknown)\b`),
+			regexp.MustCompile(`(?m)^\s*(?:export\s+)?type\s+\w+(?:<[^>]*>)?\s*=`),
+			regexp.MustCompile(`\b(?:public|private|readonly)\s+\w+\s*:`),
+		},
+		ImportPattern:   regexp.MustCompile(`import\s+.*?from\s+['"](.+?)['"]`),
+		FunctionPattern: regexp.MustCompile(`(?:function\s+(\w+)|(?:const|let|var)\s+(\w+)\s*=\s*(?:function|\([^)]*\)\s*=>)|(?:async\s+)?function\s*(\w+))`),
+		ClassPattern:    regexp.MustCompile(`class\s+(\w+)`),
+	},
+	{
+		ID:               "javascript",
+		Name:             "JavaScript",
+		Type:             LanguageProgramming,
+		Aliases:          []string{"js", "node"},
+		Extensions:       []string{".js", ".jsx", ".mjs", ".cjs"},
+		Interpreters:     []string{"node", "nodejs"},
+		CommentPrefix:    "//",
+		MultiLineStart:   "/*",
+		MultiLineEnd:     "*/",
+		TestPatterns:     []string{"*.test.js", "*.spec.js", "*.test.jsx", "*.spec.jsx", "*.test.mjs", "*.spec.mjs"},
+		GeneratedMarkers: []string{"webpackBootstrap"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`\b(?:const|let|var)\s+\w+\s*=`),
+			regexp.MustCompile(`\bfunction\s*\w*\s*\(`),
+			regexp.MustCompile(`\brequire\(\s*['"]`),
+			regexp.MustCompile(`(?m)^\s*(?:module\.)?exports\b`),
+		},
+		ImportPattern:   regexp.MustCompile(`(?:import|require)\s+.*?(?:from\s+)?['"](.+?)['"]\)?`),
+		FunctionPattern: regexp.MustCompile(`(?:function\s+(\w+)|(?:const|let|var)\s+(\w+)\s*=\s*(?:function|\([^)]*\)\s*=>))`),
+		ClassPattern:    regexp.MustCompile(`class\s+(\w+)`),
+	},
+	{
+		ID:             "java",
+		Name:           "Java",
+		Type:           LanguageProgramming,
+		Extensions:     []string{".java"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*Test.java", "*Tests.java", "*IT.java"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*(?:public\s+)?(?:abstract\s+|final\s+)?class\s+\w+`),
+			regexp.MustCompile(`(?m)^import\s+java\.`),
+			regexp.MustCompile(`\bpublic\s+static\s+void\s+main\b`),
+			regexp.MustCompile(`(?m)^package\s+[\w.]+;`),
+		},
+		PackagePattern:  regexp.MustCompile(`package\s+([a-z0-9.]+)`),
+		ImportPattern:   regexp.MustCompile(`import\s+([a-z0-9.]+)`),
+		FunctionPattern: regexp.MustCompile(`(?:public|private|protected|static|\s) +[\w<>\[\]]+\s+(\w+) *\([^\)]*\) *(?:\{?|[^;])`),
+		ClassPattern:    regexp.MustCompile(`class\s+(\w+)`),
+	},
+	{
+		ID:             "kotlin",
+		Name:           "Kotlin",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"kt"},
+		Extensions:     []string{".kt", ".kts"},
+		Interpreters:   []string{"kotlin"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*Test.kt", "*Tests.kt"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*(?:(?:private|internal|override|suspend)\s+)*fun\s+[\w.<>]+\s*\(`),
+			regexp.MustCompile(`\b(?:val|var)\s+\w+\s*:\s*\w+`),
+			regexp.MustCompile(`(?m)^\s*(?:data|sealed|object)\s+(?:class\s+)?\w+`),
+		},
+	},
+	{
+		ID:             "scala",
+		Name:           "Scala",
+		Type:           LanguageProgramming,
+		Extensions:     []string{".scala", ".sc"},
+		Interpreters:   []string{"scala"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*Spec.scala", "*Test.scala", "*Suite.scala"},
+	},
+	{
+		ID:             "c",
+		Name:           "C",
+		Type:           LanguageProgramming,
+		Extensions:     []string{".c", ".h"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"test_*.c", "*_test.c"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^#include\s*<\w+\.h>`),
+			regexp.MustCompile(`\b(?:printf|malloc|free|sizeof)\s*\(`),
+			regexp.MustCompile(`(?m)^(?:static\s+)?(?:int|void|char|unsigned|struct\s+\w+)\s*\*?\s*\w+\s*\([^)]*\)\s*\{?\s*$`),
+		},
+	},
+	{
+		ID:             "cpp",
+		Name:           "C++",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"c++", "cxx"},
+		Extensions:     []string{".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx", ".h"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*_test.cpp", "*_test.cc", "*_unittest.cc", "test_*.cpp"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`\bstd::`),
+			regexp.MustCompile(`(?m)^#include\s*<\w+>`),
+			regexp.MustCompile(`(?m)^\s*(?:namespace\s+\w+|template\s*<)`),
+			regexp.MustCompile(`(?m)^\s*class\s+\w+\s*(?::\s*(?:public|private|protected)\s+\w+)?\s*\{`),
+		},
+	},
+	{
+		ID:             "objective-c",
+		Name:           "Objective-C",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"objc"},
+		Extensions:     []string{".m", ".mm", ".h"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*Tests.m"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*@(?:interface|implementation|protocol)\b`),
+			regexp.MustCompile(`(?m)^#import\s*[<"]`),
+		},
+	},
+	{
+		ID:               "csharp",
+		Name:             "C#",
+		Type:             LanguageProgramming,
+		Aliases:          []string{"c#", "cs"},
+		Extensions:       []string{".cs"},
+		CommentPrefix:    "//",
+		MultiLineStart:   "/*",
+		MultiLineEnd:     "*/",
+		TestPatterns:     []string{"*Tests.cs", "*Test.cs"},
+		GeneratedMarkers: []string{"<auto-generated"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*using\s+System(?:\.[\w.]+)?;`),
+			regexp.MustCompile(`(?m)^\s*namespace\s+[\w.]+\s*[{;]?\s*$`),
+			regexp.MustCompile(`\{\s*get;\s*(?:private\s+)?set;\s*\}`),
+		},
+	},
+	{
+		ID:             "ruby",
+		Name:           "Ruby",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"rb"},
+		Extensions:     []string{".rb", ".rake", ".gemspec", ".ru"},
+		Filenames:      []string{"Gemfile", "Rakefile", "Guardfile", "Vagrantfile"},
+		Interpreters:   []string{"ruby"},
+		CommentPrefix:  "#",
+		MultiLineStart: "=begin",
+		MultiLineEnd:   "=end",
+		TestPatterns:   []string{"*_spec.rb", "*_test.rb", "test_*.rb"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*def\s+[\w.?!]+(?:\(.*\))?\s*$`),
+			regexp.MustCompile(`(?m)^\s*end\s*$`),
+			regexp.MustCompile(`(?m)^\s*require(?:_relative)?\s+['"]`),
+			regexp.MustCompile(`(?m)^\s*(?:module|class)\s+[A-Z]\w*(?:\s*<\s*[\w:]+)?\s*$`),
+		},
+	},
+	{
+		ID:             "php",
+		Name:           "PHP",
+		Type:           LanguageProgramming,
+		Extensions:     []string{".php", ".phtml"},
+		Interpreters:   []string{"php"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*Test.php"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`<\?php`),
+			regexp.MustCompile(`\$this->`),
+		},
+	},
+	{
+		ID:             "swift",
+		Name:           "Swift",
+		Type:           LanguageProgramming,
+		Extensions:     []string{".swift"},
+		Interpreters:   []string{"swift"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		TestPatterns:   []string{"*Tests.swift", "*Test.swift"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^import\s+(?:Foundation|UIKit|SwiftUI|XCTest)\s*$`),
+			regexp.MustCompile(`\bfunc\s+\w+\s*\([^)]*\)\s*->`),
+			regexp.MustCompile(`\bguard\s+let\b`),
+		},
+	},
+	{
+		ID:             "rust",
+		Name:           "Rust",
+		Type:           LanguageProgramming,
+		Aliases:        []string{"rs"},
+		Extensions:     []string{".rs"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?fn\s+\w+`),
+			regexp.MustCompile(`\blet\s+mut\b`),
+			regexp.MustCompile(`(?m)^\s*use\s+(?:std|crate|super|self)::`),
+			regexp.MustCompile(`(?m)^\s*impl(?:<[^>]*>)?\s+\w+`),
+		},
+	},
+	{
+		ID:             "lua",
+		Name:           "Lua",
+		Type:           LanguageProgramming,
+		Extensions:     []string{".lua"},
+		Interpreters:   []string{"lua"},
+		CommentPrefix:  "--",
+		MultiLineStart: "--[[",
+		MultiLineEnd:   "]]",
+		TestPatterns:   []string{"*_spec.lua"},
+	},
+	{
+		ID:            "perl",
+		Name:          "Perl",
+		Type:          LanguageProgramming,
+		Extensions:    []string{".pl", ".pm"},
+		Interpreters:  []string{"perl"},
+		CommentPrefix: "#",
+		TestPatterns:  []string{"*.t"},
+	},
+	{
+		ID:            "shell",
+		Name:          "Shell",
+		Type:          LanguageProgramming,
+		Aliases:       []string{"bash", "sh", "zsh"},
+		Extensions:    []string{".sh", ".bash", ".zsh"},
+		Filenames:     []string{".bashrc", ".bash_profile", ".zshrc", ".profile"},
+		Interpreters:  []string{"sh", "bash", "zsh", "dash", "ksh", "ash"},
+		CommentPrefix: "#",
+		TestPatterns:  []string{"*.bats"},
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\s*(?:fi|done|esac)\s*$`),
+			regexp.MustCompile(`(?m)^\s*if\s+\[\[?\s`),
+			regexp.MustCompile(`(?m)^\s*(?:export\s+)?[A-Z_][A-Z0-9_]*=`),
+		},
+	},
+	{
+		ID:            "dockerfile",
+		Name:          "Dockerfile",
+		Type:          LanguageProgramming,
+		Aliases:       []string{"docker", "containerfile"},
+		Extensions:    []string{".dockerfile"},
+		Filenames:     []string{"Dockerfile", "Dockerfile.*", "*.Dockerfile", "Containerfile"},
+		CommentPrefix: "#",
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^FROM\s+\S+`),
+			regexp.MustCompile(`(?m)^(?:RUN|COPY|ENTRYPOINT|CMD|WORKDIR)\s`),
+		},
+	},
+	{
+		ID:            "makefile",
+		Name:          "Makefile",
+		Type:          LanguageProgramming,
+		Aliases:       []string{"make"},
+		Extensions:    []string{".mk", ".mak"},
+		Filenames:     []string{"Makefile", "makefile", "GNUmakefile"},
+		Interpreters:  []string{"make"},
+		CommentPrefix: "#",
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?m)^\.PHONY\s*:`),
+			regexp.MustCompile(`(?m)^[\w.-]+\s*:[^=]*\n\t`),
+		},
+	},
+	{
+		ID:            "cmake",
+		Name:          "CMake",
+		Type:          LanguageProgramming,
+		Extensions:    []string{".cmake"},
+		Filenames:     []string{"CMakeLists.txt"},
+		CommentPrefix: "#",
+	},
+	{
+		ID:             "sql",
+		Name:           "SQL",
+		Type:           LanguageData,
+		Extensions:     []string{".sql"},
+		CommentPrefix:  "--",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bselect\b[\s\S]+?\bfrom\b`),
+			regexp.MustCompile(`(?i)\bcreate\s+(?:table|index|view)\b`),
+			regexp.MustCompile(`(?i)\binsert\s+into\b`),
+		},
+	},
+	{
+		ID:             "protobuf",
+		Name:           "Protocol Buffer",
+		Type:           LanguageData,
+		Aliases:        []string{"proto"},
+		Extensions:     []string{".proto"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+	},
+	{
+		ID:             "html",
+		Name:           "HTML",
+		Type:           LanguageMarkup,
+		Extensions:     []string{".html", ".htm", ".xhtml"},
+		MultiLineStart: "<!--",
+		MultiLineEnd:   "-->",
+		Heuristics: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)<!DOCTYPE\s+html`),
+			regexp.MustCompile(`(?i)<(?:html|head|body)[\s>]`),
+		},
+	},
+	{
+		ID:             "xml",
+		Name:           "XML",
+		Type:           LanguageData,
+		Extensions:     []string{".xml", ".xsd", ".xsl", ".xslt", ".plist", ".csproj"},
+		MultiLineStart: "<!--",
+		MultiLineEnd:   "-->",
+		Heuristics:     []*regexp.Regexp{regexp.MustCompile(`^\s*<\?xml\s`)},
+	},
+	{
+		ID:             "css",
+		Name:           "CSS",
+		Type:           LanguageMarkup,
+		Extensions:     []string{".css"},
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+	},
+	{
+		ID:             "scss",
+		Name:           "SCSS",
+		Type:           LanguageMarkup,
+		Aliases:        []string{"sass"},
+		Extensions:     []string{".scss", ".sass", ".less"},
+		CommentPrefix:  "//",
+		MultiLineStart: "/*",
+		MultiLineEnd:   "*/",
+	},
+	{
+		ID:             "markdown",
+		Name:           "Markdown",
+		Type:           LanguageProse,
+		Aliases:        []string{"md"},
+		Extensions:     []string{".md", ".markdown", ".mdx"},
+		MultiLineStart: "<!--",
+		MultiLineEnd:   "-->",
+	},
+	{
+		ID:         "json",
+		Name:       "JSON",
+		Type:       LanguageData,
+		Extensions: []string{".json", ".jsonc", ".json5"},
+		Filenames:  []string{".babelrc", ".eslintrc", ".prettierrc"},
+	},
+	{
+		ID:            "yaml",
+		Name:          "YAML",
+		Type:          LanguageData,
+		Aliases:       []string{"yml"},
+		Extensions:    []string{".yml", ".yaml"},
+		CommentPrefix: "#",
+	},
+	{
+		ID:            "toml",
+		Name:          "TOML",
+		Type:          LanguageData,
+		Extensions:    []string{".toml"},
+		Filenames:     []string{"Cargo.lock", "Pipfile"},
+		CommentPrefix: "#",
+	},
+	{
+		ID:         "text",
+		Name:       "Text",
+		Type:       LanguageProse,
+		Aliases:    []string{"txt", "plaintext"},
+		Extensions: []string{".txt"},
+	},
+}
+
+// Languages maps the ID of each language of the registry to its information. The
+// information is shared and must not be modified.
+var Languages = make(map[string]*LanguageInfo)
+
+var (
+	languagesByExtension   = make(map[string][]*LanguageInfo)
+	languagesByName        = make(map[string]*LanguageInfo)
+	languagesByInterpreter = make(map[string]*LanguageInfo)
+)
+
+// generatedMarkers appear in the header of generated files of any language
+var generatedMarkers = []string{
+	"@generated",
+	"DO NOT EDIT",
+	"Code generated by",
+	"This file is automatically generated",
+	"This file was automatically generated",
+	"autogenerated file",
+	"Autogenerated by",
+}
+
+// testDirectories hold test files of any name
+var testDirectories = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true, "testdata": true}
+
+// shebangVersionRegex matches the version suffix of an interpreter, as in python3.11
+var shebangVersionRegex = regexp.MustCompile(`[\d.]+$`)
+
+func init() {
+	for _, lang := range languageList {
+		Languages[lang.ID] = lang
+		for _, name := range append([]string{lang.ID, lang.Name}, lang.Aliases...) {
+			if _, ok := languagesByName[strings.ToLower(name)]; !ok {
+				languagesByName[strings.ToLower(name)] = lang
+			}
+		}
+		for _, ext := range lang.Extensions {
+			languagesByExtension[ext] = append(languagesByExtension[ext], lang)
+		}
+		for _, interpreter := range lang.Interpreters {
+			languagesByInterpreter[interpreter] = lang
+		}
+	}
+}
+
+// DetectLanguage determines the language of a file from its name, its extension, its
+// shebang and, for extensions several languages share, content heuristics. Without a
+// path, the content alone decides. It returns nil for unknown languages.
+func DetectLanguage(filePath, content string) *LanguageInfo {
+	if filePath == "" {
+		return GetLanguageFromContent(content)
+	}
+	if lang := languageFromFilename(path.Base(filePath)); lang != nil {
+		return lang
+	}
+	if candidates := languagesByExtension[strings.ToLower(path.Ext(filePath))]; len(candidates) > 0 {
+		if len(candidates) > 1 && content != "" {
+			if lang := bestHeuristicMatch(candidates, content); lang != nil {
+				return lang
+			}
+		}
+		return candidates[0]
+	}
+	return languageFromShebang(content)
+}
+
+// GetLanguageFromPath determines the language of a file from its name and extension
+func GetLanguageFromPath(filePath string) *LanguageInfo {
+	return DetectLanguage(filePath, "")
+}
+
+// GetLanguageFromExtension returns the language information based on file extension
+func GetLanguageFromExtension(filename string) *LanguageInfo {
+	return GetLanguageFromPath(filename)
+}
+
+// GetLanguageFromContent determines the language of source code from its shebang, or the
+// language whose heuristics match it best
+func GetLanguageFromContent(content string) *LanguageInfo {
+	if lang := languageFromShebang(content); lang != nil {
+		return lang
+	}
+	return bestHeuristicMatch(languageList, content)
+}
+
+// GetLanguageByName returns the language information based on its ID, name or an alias
+func GetLanguageByName(name string) *LanguageInfo {
+	return languagesByName[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// LanguageID returns the ID of the language DetectLanguage finds for a file, or "unknown"
+func LanguageID(filePath, content string) string {
+	if lang := DetectLanguage(filePath, content); lang != nil {
+		return lang.ID
+	}
+	return "unknown"
+}
+
+// LanguageName returns the display name of the language DetectLanguage finds for a file,
+// or "Unknown"
+func LanguageName(filePath, content string) string {
+	if lang := DetectLanguage(filePath, content); lang != nil {
+		return lang.Name
+	}
+	return "Unknown"
+}
+
+// IsSourceFile reports whether a file is written in a programming language
+func IsSourceFile(filePath string) bool {
+	lang := GetLanguageFromPath(filePath)
+	return lang != nil && lang.Type == LanguageProgramming
+}
+
+// IsTestFile reports whether a file holds tests, by the naming conventions of its language
+// or by living in a test directory
+func IsTestFile(filePath string) bool {
+	lang := GetLanguageFromPath(filePath)
+	if lang == nil {
+		return false
+	}
+	if lang.IsTestFile(filePath) {
+		return true
+	}
+	if lang.Type != LanguageProgramming {
+		return false
+	}
+	dirs := strings.Split(path.Dir(filePath), "/")
+	for _, dir := range dirs {
+		if testDirectories[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTestFile reports whether the base name of a file matches the test patterns of the language
+func (l *LanguageInfo) IsTestFile(filePath string) bool {
+	base := path.Base(filePath)
+	for _, pattern := range l.TestPatterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGenerated reports whether the header of a file, its first lines, carries a marker of
+// generated code
+func IsGenerated(filePath, content string) bool {
+	if lang := DetectLanguage(filePath, content); lang != nil {
+		return lang.IsGenerated(content)
+	}
+	return hasGeneratedMarker(content, generatedMarkers)
+}
+
+// IsGenerated reports whether the header of content carries a marker of generated code,
+// common or specific to the language
+func (l *LanguageInfo) IsGenerated(content string) bool {
+	return hasGeneratedMarker(content, generatedMarkers) || hasGeneratedMarker(content, l.GeneratedMarkers)
+}
+
+// generatedHeaderLines is how many lines of a file are searched for generated markers
+const generatedHeaderLines = 20
+
+func hasGeneratedMarker(content string, markers []string) bool {
+	if len(markers) == 0 {
+		return false
+	}
+	header := content
+	for i, n := 0, 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			if n++; n == generatedHeaderLines {
+				header = content[:i]
+				break
+			}
+		}
+	}
+	for _, marker := range markers {
+		if strings.Contains(header, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Comment formats text as a comment in the language: a block comment for several lines
+// when the language has one, or line comments
+func (l *LanguageInfo) Comment(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	switch {
+	case len(lines) > 1 && l.MultiLineStart == "/*":
+		return "/*\n * " + strings.Join(lines, "\n * ") + "\n */\n"
+	case l.MultiLineStart != "" && (len(lines) > 1 || l.CommentPrefix == ""):
+		if l.CommentPrefix == "" {
+			return l.MultiLineStart + " " + strings.Join(lines, "\n") + " " + l.MultiLineEnd + "\n"
+		}
+		return l.MultiLineStart + "\n" + strings.Join(lines, "\n") + "\n" + l.MultiLineEnd + "\n"
+	}
+	prefix := l.CommentPrefix
+	if prefix == "" {
+		prefix = "#"
+	}
+	return prefix + " " + strings.Join(lines, "\n"+prefix+" ") + "\n"
+}
+
+// languageFromFilename matches a base name against the file names of the registry
+func languageFromFilename(base string) *LanguageInfo {
+	for _, lang := range languageList {
+		for _, pattern := range lang.Filenames {
+			if ok, _ := path.Match(pattern, base); ok {
+				return lang
+			}
+		}
+	}
+	return nil
+}
+
+// languageFromShebang returns the language of the interpreter named by the first line of
+// content, as in #!/bin/bash or #!/usr/bin/env python3
+func languageFromShebang(content string) *LanguageInfo {
+	line, ok := strings.CutPrefix(content, "#!")
+	if !ok {
+		return nil
+	}
+	line, _, _ = strings.Cut(line, "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	if lang, ok := languagesByInterpreter[interpreter]; ok {
+		return lang
+	}
+	return languagesByInterpreter[shebangVersionRegex.ReplaceAllString(interpreter, "")]
+}
+
+// bestHeuristicMatch returns the candidate matching the most of its heuristics in content,
+// the earliest on ties, or nil if none matches
+func bestHeuristicMatch(candidates []*LanguageInfo, content string) *LanguageInfo {
+	var best *LanguageInfo
+	bestScore := 0
+	for _, lang := range candidates {
+		score := 0
+		for _, heuristic := range lang.Heuristics {
+			if heuristic.MatchString(content) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,418 @@
+package utils
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// Thresholds past which a function is flagged as hard to maintain
+const (
+	MaxCyclomaticComplexity = 10
+	MaxCognitiveComplexity  = 15
+	MaxNestingDepth         = 4
+	MaxFunctionLength       = 60
+	MaxParameters           = 5
+)
+
+// FunctionMetrics measures the size and complexity of one function or method
+type FunctionMetrics struct {
+	Name                 string  `json:"name"` // Qualified as Type.Method
+	StartLine            int     `json:"start_line"`
+	EndLine              int     `json:"end_line"`
+	Length               int     `json:"length"` // Lines from the declaration to its end
+	Parameters           int     `json:"parameters"`
+	CyclomaticComplexity int     `json:"cyclomatic_complexity"`
+	CognitiveComplexity  int     `json:"cognitive_complexity"`
+	MaxNesting           int     `json:"max_nesting"`
+	CommentLines         int     `json:"comment_lines"`
+	CommentRatio         float64 `json:"comment_ratio"` // Share of the function's lines holding comments
+}
+
+// Findings describes each threshold the function is past, such as "cognitive complexity 23 (over 15)"
+func (m FunctionMetrics) Findings() []string {
+	var findings []string
+	check := func(what string, value, limit int) {
+		if value > limit {
+			findings = append(findings, fmt.Sprintf("%s %d (over %d)", what, value, limit))
+		}
+	}
+	check("cyclomatic complexity", m.CyclomaticComplexity, MaxCyclomaticComplexity)
+	check("cognitive complexity", m.CognitiveComplexity, MaxCognitiveComplexity)
+	check("nesting depth", m.MaxNesting, MaxNestingDepth)
+	check("length", m.Length, MaxFunctionLength)
+	check("parameters", m.Parameters, MaxParameters)
+	return findings
+}
+
+// computeMetrics measures the functions of content. Go is measured from its AST; other
+// languages from the symbols the structural scanners find and the indentation of their
+// control statements.
+func computeMetrics(content string, lang *LanguageInfo) []FunctionMetrics {
+	if lang.ID == "go" {
+		if metrics, err := goMetrics(content); err == nil {
+			return metrics
+		}
+	}
+
+	symbols, err := extractSymbols(lang, content)
+	if err != nil {
+		return nil
+	}
+	masked := strings.Split(maskCode(content, lang), "\n")
+	lines := strings.Split(content, "\n")
+
+	var metrics []FunctionMetrics
+	for i, symbol := range symbols {
+		if !symbol.IsFunction() || symbol.EndLine <= symbol.StartLine && lang.ID != "python" && lang.ID != "ruby" {
+			continue // Bodiless declarations such as interface methods
+		}
+		// Nested functions are measured on their own
+		skip := make(map[int]bool)
+		for _, other := range symbols[i+1:] {
+			if other.IsFunction() && other.StartLine > symbol.StartLine && other.EndLine <= symbol.EndLine {
+				for line := other.StartLine; line <= other.EndLine; line++ {
+					skip[line] = true
+				}
+			}
+		}
+		metrics = append(metrics, scanFunctionMetrics(symbol, lines, masked, skip, lang))
+	}
+	return metrics
+}
+
+// goMetrics measures the functions and methods of a Go file from its AST
+func goMetrics(content string) ([]FunctionMetrics, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []FunctionMetrics
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			name = goReceiverType(fn.Recv.List[0].Type) + "." + name
+		}
+
+		walker := &goComplexity{cyclomatic: 1}
+		walker.walk(fn.Body, 0)
+
+		m := FunctionMetrics{
+			Name:                 name,
+			StartLine:            fset.Position(fn.Pos()).Line,
+			EndLine:              fset.Position(fn.End()).Line,
+			CyclomaticComplexity: walker.cyclomatic,
+			CognitiveComplexity:  walker.cognitive,
+			MaxNesting:           walker.maxNesting,
+		}
+		m.Length = m.EndLine - m.StartLine + 1
+		for _, field := range fn.Type.Params.List {
+			m.Parameters += max(len(field.Names), 1)
+		}
+
+		commented := make(map[int]bool)
+		for _, group := range file.Comments {
+			if group.Pos() < fn.Pos() || group.End() > fn.End() {
+				continue
+			}
+			for line := fset.Position(group.Pos()).Line; line <= fset.Position(group.End()).Line; line++ {
+				commented[line] = true
+			}
+		}
+		m.CommentLines = len(commented)
+		m.CommentRatio = ratio(m.CommentLines, m.Length)
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// goComplexity accumulates the complexity of a Go function body. Cognitive complexity
+// follows the SonarSource definition: control structures cost one plus their nesting,
+// else branches, labelled jumps and each run of like logical operators cost one.
+type goComplexity struct {
+	cyclomatic int
+	cognitive  int
+	maxNesting int
+}
+
+// walk measures a node at a nesting depth
+func (g *goComplexity) walk(node ast.Node, nesting int) {
+	if node == nil {
+		return
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			g.ifStmt(n, nesting, false)
+			return false
+		case *ast.ForStmt:
+			g.structure(nesting)
+			g.walkAll(nesting, n.Init, n.Cond, n.Post)
+			g.walk(n.Body, nesting+1)
+			return false
+		case *ast.RangeStmt:
+			g.structure(nesting)
+			g.walk(n.X, nesting)
+			g.walk(n.Body, nesting+1)
+			return false
+		case *ast.SwitchStmt:
+			g.structure(nesting)
+			g.cyclomatic-- // Counted per case instead
+			g.walkAll(nesting, n.Init, n.Tag)
+			g.walk(n.Body, nesting+1)
+			return false
+		case *ast.TypeSwitchStmt:
+			g.structure(nesting)
+			g.cyclomatic--
+			g.walkAll(nesting, n.Init, n.Assign)
+			g.walk(n.Body, nesting+1)
+			return false
+		case *ast.SelectStmt:
+			g.structure(nesting)
+			g.cyclomatic--
+			g.walk(n.Body, nesting+1)
+			return false
+		case *ast.CaseClause:
+			if n.List != nil {
+				g.cyclomatic++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				g.cyclomatic++
+			}
+		case *ast.FuncLit:
+			g.walk(n.Body, nesting+1)
+			return false
+		case *ast.BranchStmt:
+			if n.Label != nil || n.Tok == token.GOTO {
+				g.cognitive++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				g.logical(n, token.ILLEGAL, nesting)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// walkAll walks each of several optional nodes
+func (g *goComplexity) walkAll(nesting int, nodes ...ast.Node) {
+	for _, node := range nodes {
+		if node != nil {
+			g.walk(node, nesting)
+		}
+	}
+}
+
+// structure counts a control structure whose body is one level deeper
+func (g *goComplexity) structure(nesting int) {
+	g.cyclomatic++
+	g.cognitive += 1 + nesting
+	g.maxNesting = max(g.maxNesting, nesting+1)
+}
+
+// ifStmt counts an if statement and its else chain, where else if and else cost one
+func (g *goComplexity) ifStmt(n *ast.IfStmt, nesting int, elseIf bool) {
+	if elseIf {
+		g.cyclomatic++
+		g.cognitive++
+		g.maxNesting = max(g.maxNesting, nesting+1)
+	} else {
+		g.structure(nesting)
+	}
+	g.walkAll(nesting, n.Init, n.Cond)
+	g.walk(n.Body, nesting+1)
+
+	switch els := n.Else.(type) {
+	case *ast.IfStmt:
+		g.ifStmt(els, nesting, true)
+	case *ast.BlockStmt:
+		g.cognitive++
+		g.walk(els, nesting+1)
+	}
+}
+
+// logical counts a chain of && and || operators: each operator is a branch, and each run of
+// the same operator adds to cognitive complexity
+func (g *goComplexity) logical(expr ast.Expr, parent token.Token, nesting int) {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		if e.Op == token.LAND || e.Op == token.LOR {
+			g.cyclomatic++
+			if e.Op != parent {
+				g.cognitive++
+			}
+			g.logical(e.X, e.Op, nesting)
+			g.logical(e.Y, e.Op, nesting)
+			return
+		}
+	case *ast.ParenExpr:
+		g.logical(e.X, parent, nesting)
+		return
+	}
+	g.walk(expr, nesting)
+}
+
+var (
+	// controlKeywordRegex matches the keyword a control statement line starts with,
+	// after any closing brace
+	controlKeywordRegex = regexp.MustCompile(`^[}\s]*(else\s+if|elif|elsif|else|if|for|foreach|while|until|unless|switch|match|when|case|catch|except|rescue|guard)\b`)
+	// decisionRegex matches the keywords and operators that add a path through a function
+	decisionRegex = regexp.MustCompile(`\b(if|elif|elsif|for|foreach|while|until|unless|case|when|catch|except|rescue|guard|and|or)\b|&&|\|\||\s\?\s`)
+	// logicalOperatorRegex matches the logical operators whose runs add to cognitive complexity
+	logicalOperatorRegex = regexp.MustCompile(`&&|\|\||\b(and|or)\b`)
+)
+
+// scanFunctionMetrics measures a function found by a structural scanner. Nesting is read
+// from the indentation of control statement lines, which holds for formatted code.
+func scanFunctionMetrics(symbol Symbol, lines, masked []string, skip map[int]bool, lang *LanguageInfo) FunctionMetrics {
+	m := FunctionMetrics{
+		Name:                 symbol.QualifiedName(),
+		StartLine:            symbol.StartLine,
+		EndLine:              symbol.EndLine,
+		Length:               symbol.EndLine - symbol.StartLine + 1,
+		Parameters:           countParameters(symbol, masked),
+		CyclomaticComplexity: 1,
+	}
+
+	// The word that opens a multi-way branch, where case or when may instead open an arm
+	switchWord := "switch"
+	switch lang.ID {
+	case "ruby":
+		switchWord = "case"
+	case "kotlin":
+		switchWord = "when"
+	}
+
+	var stack []int // Indentation of the enclosing control statements
+	for line := symbol.StartLine + 1; line <= symbol.EndLine && line <= len(lines); line++ {
+		code, text := masked[line-1], lines[line-1]
+		if strings.TrimSpace(code) == "" {
+			if strings.TrimSpace(text) != "" {
+				m.CommentLines++
+			}
+			continue
+		}
+		if skip[line] {
+			continue
+		}
+
+		decisions := decisionRegex.FindAllStringSubmatch(code, -1)
+		for _, decision := range decisions {
+			if (decision[1] == "case" || decision[1] == "when") && decision[1] == switchWord {
+				continue
+			}
+			m.CyclomaticComplexity++
+		}
+
+		indent := indentWidth(code)
+		for len(stack) > 0 && stack[len(stack)-1] >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		nesting := len(stack)
+
+		if match := controlKeywordRegex.FindStringSubmatch(code); match != nil {
+			keyword := strings.Join(strings.Fields(match[1]), " ")
+			switch {
+			case keyword == "else" || keyword == "else if" || keyword == "elif" || keyword == "elsif":
+				m.CognitiveComplexity++
+				stack = append(stack, indent)
+			case (keyword == "case" || keyword == "when") && keyword != switchWord:
+				// An arm of a multi-way branch adds neither complexity nor nesting
+			default:
+				m.CognitiveComplexity += 1 + nesting
+				stack = append(stack, indent)
+			}
+			m.MaxNesting = max(m.MaxNesting, len(stack))
+		}
+
+		// Ternaries nest like control statements; runs of logical operators cost one each
+		m.CognitiveComplexity += strings.Count(code, " ? ") * (1 + nesting)
+		previous := ""
+		for _, operator := range logicalOperatorRegex.FindAllString(code, -1) {
+			if operator != previous {
+				m.CognitiveComplexity++
+			}
+			previous = operator
+		}
+	}
+
+	m.CommentRatio = ratio(m.CommentLines, m.Length)
+	return m
+}
+
+// countParameters counts the parameters of a function declaration, leaving out receivers
+// such as self and this
+func countParameters(symbol Symbol, masked []string) int {
+	end := min(symbol.StartLine+10, len(masked), symbol.EndLine)
+	if symbol.StartLine < 1 || symbol.StartLine > end {
+		return 0
+	}
+	header := strings.Join(masked[symbol.StartLine-1:end], "\n")
+	at := strings.Index(header, symbol.Name)
+	if at < 0 {
+		return 0
+	}
+	open := strings.Index(header[at:], "(")
+	if open < 0 {
+		return 0
+	}
+
+	var params []string
+	depth, start := 0, at+open+1
+	for i := start; i < len(header); i++ {
+		switch header[i] {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			if header[i] == '>' && i > 0 && header[i-1] == '=' {
+				continue // The arrow of a default value such as () => x
+			}
+			if depth == 0 {
+				params = append(params, header[start:i])
+				return countNamedParameters(params)
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, header[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// countNamedParameters counts parameters that are not empty, receivers or separators such
+// as Python's * and /
+func countNamedParameters(params []string) int {
+	n := 0
+	for _, param := range params {
+		param = strings.TrimSpace(param)
+		switch strings.TrimPrefix(strings.TrimPrefix(param, "&"), "mut ") {
+		case "", "self", "cls", "this", "*", "/":
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// ratio divides two counts, returning 0 for an empty whole
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OutlineNode is a symbol of an outline with the symbols declared inside it
+type OutlineNode struct {
+	Name      string         `json:"name"`
+	Kind      SymbolKind     `json:"kind"`
+	StartLine int            `json:"start_line"`
+	EndLine   int            `json:"end_line"`
+	Exported  bool           `json:"exported"`
+	Children  []*OutlineNode `json:"children,omitempty"`
+}
+
+// FileOutline is the outline of the declarations of a file
+type FileOutline struct {
+	Path     string         `json:"path"`
+	Language string         `json:"language"`
+	Package  string         `json:"package,omitempty"`
+	Symbols  []*OutlineNode `json:"symbols"`
+}
+
+// OutlineFile parses a source file into the tree of its declarations
+func OutlineFile(filePath, content string) (*FileOutline, error) {
+	lang := DetectLanguage(filePath, content)
+	if lang == nil {
+		return nil, fmt.Errorf("unknown language for %s", filePath)
+	}
+	symbols, err := extractSymbols(lang, content)
+	if err != nil {
+		return nil, err
+	}
+
+	outline := &FileOutline{
+		Path:     filePath,
+		Language: lang.Name,
+		Symbols:  BuildOutline(symbols),
+	}
+	if lang.PackagePattern != nil {
+		if match := lang.PackagePattern.FindStringSubmatch(content); len(match) > 1 {
+			outline.Package = match[1]
+		}
+	}
+	return outline, nil
+}
+
+// BuildOutline nests symbols under the symbols their parents name. Symbols whose parent is
+// declared elsewhere, such as Go methods of a type in another file, stay at the top level
+// under their qualified name.
+func BuildOutline(symbols []Symbol) []*OutlineNode {
+	nodes := make([]*OutlineNode, len(symbols))
+	byName := make(map[string]*OutlineNode)
+	for i, symbol := range symbols {
+		nodes[i] = &OutlineNode{
+			Name:      symbol.Name,
+			Kind:      symbol.Kind,
+			StartLine: symbol.StartLine,
+			EndLine:   symbol.EndLine,
+			Exported:  symbol.Exported,
+		}
+		// Functions don't hold members, so a name shared with a type goes to the type
+		name := symbol.QualifiedName()
+		if existing, ok := byName[name]; !ok || existing.Kind == SymbolFunction || existing.Kind == SymbolMethod {
+			byName[name] = nodes[i]
+		}
+	}
+
+	var roots []*OutlineNode
+	for i, symbol := range symbols {
+		parent, ok := byName[symbol.Parent]
+		switch {
+		case symbol.Parent == "":
+			roots = append(roots, nodes[i])
+		case ok && parent != nodes[i]:
+			parent.Children = append(parent.Children, nodes[i])
+		default:
+			nodes[i].Name = symbol.QualifiedName()
+			roots = append(roots, nodes[i])
+		}
+	}
+	return roots
+}
+
+// Exported returns a copy of the outline holding only exported symbols. Members of
+// unexported types are dropped with them.
+func (o *FileOutline) Exported() *FileOutline {
+	exported := *o
+	exported.Symbols = exportedNodes(o.Symbols)
+	return &exported
+}
+
+func exportedNodes(nodes []*OutlineNode) []*OutlineNode {
+	var kept []*OutlineNode
+	for _, node := range nodes {
+		if !node.Exported {
+			continue
+		}
+		copied := *node
+		copied.Children = exportedNodes(node.Children)
+		kept = append(kept, &copied)
+	}
+	return kept
+}
+
+// Spans returns the outermost declarations of the outline in line order as symbols, so
+// members declared apart from their type, like Go methods, are spans of their own
+func (o *FileOutline) Spans() []Symbol {
+	var all []Symbol
+	var collect func(nodes []*OutlineNode, parent string)
+	collect = func(nodes []*OutlineNode, parent string) {
+		for _, node := range nodes {
+			symbol := Symbol{Name: node.Name, Kind: node.Kind, Parent: parent, StartLine: node.StartLine, EndLine: node.EndLine, Exported: node.Exported}
+			all = append(all, symbol)
+			collect(node.Children, symbol.QualifiedName())
+		}
+	}
+	collect(o.Symbols, "")
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].StartLine != all[j].StartLine {
+			return all[i].StartLine < all[j].StartLine
+		}
+		return all[i].EndLine > all[j].EndLine
+	})
+
+	var spans []Symbol
+	for _, symbol := range all {
+		if len(spans) > 0 && symbol.EndLine <= spans[len(spans)-1].EndLine {
+			continue
+		}
+		spans = append(spans, symbol)
+	}
+	return spans
+}
+
+// Count returns the number of symbols in the outline, nested ones included
+func (o *FileOutline) Count() int {
+	return countNodes(o.Symbols)
+}
+
+func countNodes(nodes []*OutlineNode) int {
+	n := len(nodes)
+	for _, node := range nodes {
+		n += countNodes(node.Children)
+	}
+	return n
+}
+
+// Format renders the outline as indented text, one symbol per line, for prompts
+func (o *FileOutline) Format() string {
+	var b strings.Builder
+	b.WriteString(o.Path + " (" + o.Language)
+	if o.Package != "" {
+		b.WriteString(", package " + o.Package)
+	}
+	b.WriteString(")\n")
+	formatNodes(&b, o.Symbols, "  ")
+	return b.String()
+}
+
+func formatNodes(b *strings.Builder, nodes []*OutlineNode, indent string) {
+	for _, node := range nodes {
+		visibility := ""
+		if node.Exported {
+			visibility = ", exported"
+		}
+		fmt.Fprintf(b, "%s%s %s (lines %d-%d%s)\n", indent, node.Kind, node.Name, node.StartLine, node.EndLine, visibility)
+		formatNodes(b, node.Children, indent+"  ")
+	}
+}
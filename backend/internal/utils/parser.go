@@ -1,109 +1,10 @@
 package utils
 
 import (
-	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-// LanguageInfo contains information about a programming language
-type LanguageInfo struct {
-	Name             string
-	Extensions       []string
-	CommentPrefix    string
-	MultiLineStart   string
-	MultiLineEnd     string
-	PackagePattern   *regexp.Regexp
-	ImportPattern    *regexp.Regexp
-	FunctionPattern  *regexp.Regexp
-	ClassPattern     *regexp.Regexp
-}
-
-// Languages defines supported programming languages
-var Languages = map[string]LanguageInfo{
-	"go": {
-		Name:             "Go",
-		Extensions:       []string{".go"},
-		CommentPrefix:    "//",
-		MultiLineStart:   "/*",
-		MultiLineEnd:     "*/",
-		PackagePattern:   regexp.MustCompile(`package\s+(\w+)`),
-		ImportPattern:    regexp.MustCompile(`import\s+(?:"([^"]+)"|(\w+)\s+"([^"]+)")`),
-		FunctionPattern:  regexp.MustCompile(`func\s+(\w+)`),
-		ClassPattern:     nil, // Go doesn't have classes in the traditional sense
-	},
-	"python": {
-		Name:             "Python",
-		Extensions:       []string{".py"},
-		CommentPrefix:    "#",
-		MultiLineStart:   `"""`,
-		MultiLineEnd:     `"""`,
-		PackagePattern:   nil,
-		ImportPattern:    regexp.MustCompile(`(?:from\s+(\w+(?:\.\w+)*)\s+)?import\s+(.+)`),
-		FunctionPattern:  regexp.MustCompile(`def\s+(\w+)\s*\(`),
-		ClassPattern:     regexp.MustCompile(`class\s+(\w+)`),
-	},
-	"javascript": {
-		Name:             "JavaScript",
-		Extensions:       []string{".js", ".jsx"},
-		CommentPrefix:    "//",
-		MultiLineStart:   "/*",
-		MultiLineEnd:     "*/",
-		PackagePattern:   nil,
-		ImportPattern:    regexp.MustCompile(`(?:import|require)\s+.*?(?:from\s+)?['"](.+?)['"]\)?`),
-		FunctionPattern:  regexp.MustCompile(`(?:function\s+(\w+)|(?:const|let|var)\s+(\w+)\s*=\s*(?:function|\([^)]*\)\s*=>))`),
-		ClassPattern:     regexp.MustCompile(`class\s+(\w+)`),
-	},
-	"typescript": {
-		Name:             "TypeScript",
-		Extensions:       []string{".ts", ".tsx"},
-		CommentPrefix:    "//",
-		MultiLineStart:   "/*",
-		MultiLineEnd:     "*/",
-		PackagePattern:   nil,
-		ImportPattern:    regexp.MustCompile(`import\s+.*?from\s+['"](.+?)['"]`),
-		FunctionPattern:  regexp.MustCompile(`(?:function\s+(\w+)|(?:const|let|var)\s+(\w+)\s*=\s*(?:function|\([^)]*\)\s*=>)|(?:async\s+)?function\s*(\w+))`),
-		ClassPattern:     regexp.MustCompile(`class\s+(\w+)`),
-	},
-	"java": {
-		Name:             "Java",
-		Extensions:       []string{".java"},
-		CommentPrefix:    "//",
-		MultiLineStart:   "/*",
-		MultiLineEnd:     "*/",
-		PackagePattern:   regexp.MustCompile(`package\s+([a-z0-9.]+)`),
-		ImportPattern:    regexp.MustCompile(`import\s+([a-z0-9.]+)`),
-		FunctionPattern:  regexp.MustCompile(`(?:public|private|protected|static|\s) +[\w<>\[\]]+\s+(\w+) *\([^\)]*\) *(?:\{?|[^;])`),
-		ClassPattern:     regexp.MustCompile(`class\s+(\w+)`),
-	},
-}
-
-// GetLanguageFromExtension returns the language information based on file extension
-func GetLanguageFromExtension(filename string) *LanguageInfo {
-	ext := filepath.Ext(filename)
-	for _, lang := range Languages {
-		for _, langExt := range lang.Extensions {
-			if langExt == ext {
-				// Create a copy of the language info to return its address
-				langCopy := lang
-				return &langCopy
-			}
-		}
-	}
-	return nil
-}
-
-// GetLanguageByName returns the language information based on name
-func GetLanguageByName(name string) *LanguageInfo {
-	name = strings.ToLower(name)
-	if lang, ok := Languages[name]; ok {
-		// Create a copy of the language info to return its address
-		langCopy := lang
-		return &langCopy
-	}
-	return nil
-}
-
 // CodeInfo contains extracted information from code
 type CodeInfo struct {
 	Language    string   `json:"language"`
@@ -114,6 +15,19 @@ type CodeInfo struct {
 	LineCount   int      `json:"line_count"`
 	CommentLines int     `json:"comment_lines"`
 	CodeLines   int      `json:"code_lines"`
+	CommentRatio float64 `json:"comment_ratio"`
+	Metrics     []FunctionMetrics `json:"metrics,omitempty"`
+}
+
+// FlaggedFunctions returns the functions past at least one complexity or size threshold
+func (c *CodeInfo) FlaggedFunctions() []FunctionMetrics {
+	var flagged []FunctionMetrics
+	for _, m := range c.Metrics {
+		if len(m.Findings()) > 0 {
+			flagged = append(flagged, m)
+		}
+	}
+	return flagged
 }
 
 // ParseCode extracts information from code
@@ -121,7 +35,7 @@ func ParseCode(code, language string) (*CodeInfo, error) {
 	lang := GetLanguageByName(language)
 	if lang == nil {
 		// Try to guess the language
-		lang = guessLanguage(code)
+		lang = GetLanguageFromContent(code)
 		if lang == nil {
 			// Default to a simple parser
 			return simpleCodeParse(code, language), nil
@@ -150,9 +64,9 @@ func ParseCode(code, language string) (*CodeInfo, error) {
 			if strings.Contains(trimmedLine, lang.MultiLineEnd) {
 				inMultiLineComment = false
 			}
-		} else if strings.HasPrefix(trimmedLine, lang.CommentPrefix) {
+		} else if lang.CommentPrefix != "" && strings.HasPrefix(trimmedLine, lang.CommentPrefix) {
 			info.CommentLines++
-		} else if strings.HasPrefix(trimmedLine, lang.MultiLineStart) {
+		} else if lang.MultiLineStart != "" && strings.HasPrefix(trimmedLine, lang.MultiLineStart) {
 			info.CommentLines++
 			if !strings.Contains(trimmedLine, lang.MultiLineEnd) {
 				inMultiLineComment = true
@@ -183,8 +97,16 @@ func ParseCode(code, language string) (*CodeInfo, error) {
 		}
 	}
 
-	// Extract functions
-	if lang.FunctionPattern != nil {
+	info.CommentRatio = ratio(info.CommentLines, info.CommentLines+info.CodeLines)
+
+	// Measure functions, listing them by the names the measurements found
+	info.Metrics = computeMetrics(code, lang)
+	for _, m := range info.Metrics {
+		info.Functions = append(info.Functions, m.Name)
+	}
+
+	// Extract functions by pattern where they could not be measured
+	if len(info.Metrics) == 0 && lang.FunctionPattern != nil {
 		matches := lang.FunctionPattern.FindAllStringSubmatch(code, -1)
 		for _, match := range matches {
 			if len(match) > 1 && match[1] != "" {
@@ -210,44 +132,6 @@ func ParseCode(code, language string) (*CodeInfo, error) {
 	return info, nil
 }
 
-// guessLanguage attempts to guess the language from the code content
-func guessLanguage(code string) *LanguageInfo {
-	// Simple heuristics for language detection
-	
-	// Check for Go
-	if strings.Contains(code, "package main") || strings.Contains(code, "import (") {
-		// Create a copy of the language info to return its address
-		langCopy := Languages["go"]
-		return &langCopy
-	}
-	
-	// Check for Python
-	if strings.Contains(code, "def ") && strings.Contains(code, "import ") {
-		langCopy := Languages["python"]
-		return &langCopy
-	}
-	
-	// Check for JavaScript
-	if strings.Contains(code, "const ") && strings.Contains(code, "function ") {
-		langCopy := Languages["javascript"]
-		return &langCopy
-	}
-	
-	// Check for TypeScript
-	if strings.Contains(code, "interface ") || strings.Contains(code, ": string") {
-		langCopy := Languages["typescript"]
-		return &langCopy
-	}
-	
-	// Check for Java
-	if strings.Contains(code, "public class ") || strings.Contains(code, "import java.") {
-		langCopy := Languages["java"]
-		return &langCopy
-	}
-	
-	return nil
-}
-
 // simpleCodeParse performs a simple parsing of code without language-specific rules
 func simpleCodeParse(code, language string) *CodeInfo {
 	lines := strings.Split(code, "\n")
@@ -278,7 +162,7 @@ func AddImport(code, importStmt, language string) string {
 	}
 
 	// Different strategies based on language
-	switch language {
+	switch lang.ID {
 	case "go":
 		// Find existing import block
 		importBlockRegex := regexp.MustCompile(`import\s+\(\s*((?:.|\n)*?)\s*\)`)
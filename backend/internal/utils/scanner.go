@@ -0,0 +1,646 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// braceSyntax describes how a language whose blocks are delimited by braces declares
+// symbols. The regular expressions match the text before an opening brace, from the start
+// of a line; classes have kind and name groups and functions name and optional parent groups.
+type braceSyntax struct {
+	classes    []*regexp.Regexp
+	functions  []*regexp.Regexp
+	statements []*regexp.Regexp // Functions without a block, ended by a semicolon
+	directives bool             // Whether lines may be directives or attributes starting with #
+	attributes bool             // Whether lines may start with [Attribute] lists
+	exported   func(decl, name string, parent SymbolKind) bool
+}
+
+// declModifiers matches the lowercase modifiers before a declaration, such as public static
+// or pub(crate)
+const declModifiers = `(?:[a-z]+(?:\([\w: ]+\))?\s+)*`
+
+// classRegex matches a declaration introduced by one of the keywords; tail matches the rest
+// of the declaration after the name
+func classRegex(keywords, tail string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)^\s*` + declModifiers + `(?P<kind>` + keywords + `)\b\s*(?:<.*?>\s*)?(?P<name>[A-Za-z_$][\w$]*(?:(?:\.|::)[A-Za-z_$][\w$]*)*)?` + tail + `$`)
+}
+
+// keywordFunctionRegex matches a function introduced by a keyword such as fn or fun, with an
+// optional receiver type as in Kotlin's fun String.name()
+func keywordFunctionRegex(keyword string) *regexp.Regexp {
+	return regexp.MustCompile(`(?s)^\s*` + declModifiers + `(?:` + keyword + `)\s+(?:<.*?>\s*)?(?:(?P<parent>[\w$.<>?]+)\.)?(?P<name>[\w$]+|` + "`[^`]+`" + `)\s*(?:[(<:=\[].*)?$`)
+}
+
+var (
+	// A C-family function or method: a return type, a name possibly qualified by ::, a
+	// parameter list and trailing qualifiers, initializer lists or return types
+	cFunctionRegex = regexp.MustCompile(`(?s)^\s*[\w$:<>,?*&\[\]. ~\t\n]*?(?P<name>~?[A-Za-z_$][\w$]*(?:::~?[A-Za-z_$][\w$]*)*|operator\s*[^\s(]+)\s*\(.*\)\s*(?:(?:const|noexcept|override|final|volatile|throws\s+[\w.,\s]+|->\s*.*|:\s*.*|where\s+.*)\s*)*$`)
+
+	jsFunctionRegex      = regexp.MustCompile(`(?s)^\s*(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>[\w$]+)\s*(?:<.*?>)?\s*\(.*\)\s*(?::.*)?$`)
+	jsAssignedPrefix     = `(?s)^\s*(?:export\s+)?(?:(?:const|let|var|static|public|private|protected|readonly)\s+)*(?:[\w$]+\.)*(?P<name>#?[\w$]+)\s*(?:(?::[^=]*)?=|:)\s*(?:async\s+)?`
+	jsAssignedFunction   = regexp.MustCompile(jsAssignedPrefix + `function\b\s*\*?\s*[\w$]*\s*\(.*\)\s*(?::.*)?$`)
+	jsAssignedArrow      = regexp.MustCompile(jsAssignedPrefix + `(?:<.*?>\s*)?(?:\(.*\)\s*(?::.*?)?|[\w$]+\s*)=>\s*$`)
+	jsArrowExpression    = regexp.MustCompile(`(?s)^\s*(?:export\s+)?(?:const|let|var)\s+(?P<name>[\w$]+)\s*(?::[^=]*)?=\s*(?:async\s+)?(?:\(.*?\)|[\w$]+)\s*(?::[^=]*?)?=>.*$`)
+	jsMethodRegex        = regexp.MustCompile(`(?s)^\s*(?:(?:public|private|protected|static|async|readonly|override|abstract|declare|get|set)\s+)*\*?\s*(?P<name>#?[\w$]+)\s*\??\s*(?:<.*?>)?\s*\(.*\)\s*(?::.*)?$`)
+	goFunctionRegex      = regexp.MustCompile(`(?s)^\s*func\s*(?:\(\s*(?:\w+\s+)?\*?\s*(?P<parent>\w+)(?:\[[^\]]*\])?\s*\)\s*)?(?P<name>\w+)\s*(?:\[.*?\])?\s*\(.*$`)
+	goTypeRegex          = regexp.MustCompile(`(?s)^\s*type\s+(?P<name>\w+)(?:\[.*?\])?\s+(?P<kind>struct|interface)\s*$`)
+	rustImplRegex        = regexp.MustCompile(`(?s)^\s*(?:unsafe\s+)?(?P<kind>impl)\b(?:\s*<.*?>)?\s+(?:.*?\s+for\s+)?&?(?:\w+::)*(?P<name>\w+).*$`)
+	swiftInitRegex       = regexp.MustCompile(`(?s)^\s*` + declModifiers + `(?P<name>init|deinit)\b\s*[?!]?\s*(?:[(<].*)?$`)
+	shellFunctionRegex   = regexp.MustCompile(`(?s)^\s*(?:function\s+)?(?P<name>[\w.:-]+)\s*\(\s*\)\s*$`)
+	shellKeywordFunction = regexp.MustCompile(`(?s)^\s*function\s+(?P<name>[\w.:-]+)\s*$`)
+
+	// Annotations, decorators and preprocessor, attribute and C# attribute lines, which are
+	// removed from declarations before they are matched
+	annotationRegex   = regexp.MustCompile(`@[\w.]+(?:\s*\((?:[^()]|\([^()]*\))*\))?`)
+	hashLineRegex     = regexp.MustCompile(`(?m)^[ \t]*#.*$`)
+	csAttributeRegex  = regexp.MustCompile(`(?m)^[ \t]*\[[^\]\n]*\][ \t]*`)
+	firstWordRegex    = regexp.MustCompile(`^\s*([A-Za-z_]+)`)
+	pyDefinitionRegex = regexp.MustCompile(`^(?:async\s+)?(def|class)\s+(\w+)`)
+	rbDefinitionRegex = regexp.MustCompile(`^(def|class|module)\s+(?:self\.)?([\w:.]+[?!=]?|\[\]=?|[+\-*/%<>=!~^&|]+)`)
+	rbBlockOpenRegex  = regexp.MustCompile(`^(?:if|unless|while|until|case|begin|for)\b|=\s*(?:if|unless|case|begin)\b|\bdo\s*(?:\|[^|]*\|)?\s*$`)
+	rbEndRegex        = regexp.MustCompile(`^end\b`)
+	rbInlineEndRegex  = regexp.MustCompile(`[;\s]end\s*$|^def\s+[\w?!.]+(?:\(.*\))?\s*=`)
+)
+
+// blockStopWords start statements whose blocks are never declarations
+var blockStopWords = map[string]bool{
+	"if": true, "else": true, "for": true, "while": true, "do": true, "switch": true, "case": true,
+	"catch": true, "try": true, "finally": true, "using": true, "lock": true, "foreach": true,
+	"fixed": true, "synchronized": true, "return": true, "new": true, "throw": true, "when": true,
+	"match": true, "loop": true, "guard": true, "defer": true, "with": true, "typeof": true,
+	"sizeof": true, "delete": true, "await": true, "yield": true, "elif": true, "elseif": true,
+}
+
+// symbolKinds maps declaration keywords to symbol kinds
+var symbolKinds = map[string]SymbolKind{
+	"class": SymbolClass, "record": SymbolClass, "object": SymbolClass, "actor": SymbolClass,
+	"interface": SymbolInterface, "protocol": SymbolInterface,
+	"struct": SymbolStruct, "union": SymbolStruct,
+	"enum": SymbolEnum, "trait": SymbolTrait,
+	"impl": SymbolImpl, "extension": SymbolImpl,
+	"namespace": SymbolNamespace, "module": SymbolModule, "mod": SymbolModule,
+}
+
+// visibleUnless returns an exported check for languages whose declarations are visible
+// unless a modifier restricts them
+func visibleUnless(modifiers string) func(decl, name string, parent SymbolKind) bool {
+	re := regexp.MustCompile(`\b(?:` + modifiers + `)\b`)
+	return func(decl, name string, parent SymbolKind) bool { return !re.MatchString(decl) }
+}
+
+// visibleWith returns an exported check for languages whose declarations are only visible
+// with a modifier, and members of interfaces
+func visibleWith(modifiers string) func(decl, name string, parent SymbolKind) bool {
+	re := regexp.MustCompile(`\b(?:` + modifiers + `)\b`)
+	return func(decl, name string, parent SymbolKind) bool {
+		return re.MatchString(decl) || parent == SymbolInterface
+	}
+}
+
+var (
+	jsExportRegex  = regexp.MustCompile(`\bexports?\b`)
+	jsPrivateRegex = regexp.MustCompile(`\b(?:private|protected)\b`)
+	cStaticRegex   = regexp.MustCompile(`\bstatic\b`)
+)
+
+// jsExported treats top-level declarations as exported when they are, and class members
+// unless they are private
+func jsExported(decl, name string, parent SymbolKind) bool {
+	if parent == "" {
+		return jsExportRegex.MatchString(decl)
+	}
+	return !strings.HasPrefix(name, "#") && !jsPrivateRegex.MatchString(decl)
+}
+
+// cExported treats declarations as exported unless they are static at file scope
+func cExported(decl, name string, parent SymbolKind) bool {
+	return parent != "" || !cStaticRegex.MatchString(decl)
+}
+
+var jsSyntax = &braceSyntax{
+	classes:    []*regexp.Regexp{classRegex("class|interface|enum|namespace|module", ".*")},
+	functions:  []*regexp.Regexp{jsFunctionRegex, jsAssignedFunction, jsAssignedArrow, jsMethodRegex},
+	statements: []*regexp.Regexp{jsArrowExpression},
+	exported:   jsExported,
+}
+
+// braceSyntaxes are the languages scanBraceSymbols reads, by language ID
+var braceSyntaxes = map[string]*braceSyntax{
+	"javascript": jsSyntax,
+	"typescript": jsSyntax,
+	"java": {
+		classes:   []*regexp.Regexp{classRegex("class|interface|enum|record", ".*")},
+		functions: []*regexp.Regexp{cFunctionRegex},
+		exported:  visibleWith("public"),
+	},
+	"csharp": {
+		directives: true,
+		classes:    []*regexp.Regexp{classRegex("class|interface|struct|enum|record|namespace", ".*")},
+		functions:  []*regexp.Regexp{cFunctionRegex},
+		attributes: true,
+		exported:   visibleWith("public"),
+	},
+	"c": {
+		directives: true,
+		classes:    []*regexp.Regexp{classRegex("struct|union|enum", "[^(]*")},
+		functions:  []*regexp.Regexp{cFunctionRegex},
+		exported:   cExported,
+	},
+	"cpp": {
+		directives: true,
+		classes:    []*regexp.Regexp{classRegex("class|struct|union|enum|namespace", "[^(]*")},
+		functions:  []*regexp.Regexp{cFunctionRegex},
+		exported:   cExported,
+	},
+	"kotlin": {
+		classes:   []*regexp.Regexp{classRegex("class|interface|object", ".*")},
+		functions: []*regexp.Regexp{keywordFunctionRegex("fun")},
+		exported:  visibleUnless("private|internal|protected"),
+	},
+	"scala": {
+		classes:   []*regexp.Regexp{classRegex("class|trait|object", ".*")},
+		functions: []*regexp.Regexp{keywordFunctionRegex("def")},
+		exported:  visibleUnless("private|protected"),
+	},
+	"swift": {
+		directives: true,
+		classes:    []*regexp.Regexp{classRegex("class|struct|enum|protocol|extension|actor", ".*")},
+		functions:  []*regexp.Regexp{keywordFunctionRegex("func"), swiftInitRegex},
+		exported:   visibleWith("public|open"),
+	},
+	"rust": {
+		directives: true,
+		classes:    []*regexp.Regexp{classRegex("struct|enum|trait|mod|union", ".*"), rustImplRegex},
+		functions:  []*regexp.Regexp{keywordFunctionRegex("fn")},
+		exported:   visibleWith("pub"),
+	},
+	"php": {
+		directives: true,
+		classes:    []*regexp.Regexp{classRegex("class|interface|trait|enum", ".*")},
+		functions:  []*regexp.Regexp{keywordFunctionRegex("function")},
+		exported:   visibleUnless("private|protected"),
+	},
+	"go": {
+		classes:   []*regexp.Regexp{goTypeRegex},
+		functions: []*regexp.Regexp{goFunctionRegex},
+		exported: func(decl, name string, parent SymbolKind) bool {
+			return name != "" && unicode.IsUpper([]rune(name)[0])
+		},
+	},
+	"shell": {
+		functions: []*regexp.Regexp{shellFunctionRegex, shellKeywordFunction},
+		exported:  func(decl, name string, parent SymbolKind) bool { return true },
+	},
+}
+
+// maskCode blanks out the comments and string literals of source code, keeping newlines so
+// that offsets and line numbers still match the original
+func maskCode(content string, lang *LanguageInfo) string {
+	out := []byte(content)
+	blank := func(from, to int) {
+		for k := from; k < to && k < len(out); k++ {
+			if out[k] != '\n' {
+				out[k] = ' '
+			}
+		}
+	}
+	at := func(i int, s string) bool { return s != "" && strings.HasPrefix(content[i:], s) }
+
+	blockStart, blockEnd := lang.MultiLineStart, lang.MultiLineEnd
+	if strings.HasPrefix(blockStart, `"`) {
+		blockStart, blockEnd = "", "" // Python docstrings are strings
+	}
+	backtick := lang.ID == "javascript" || lang.ID == "typescript" || lang.ID == "go"
+
+	for i := 0; i < len(content); {
+		switch c := content[i]; {
+		case at(i, blockStart) && (blockStart[0] != '=' || i == 0 || content[i-1] == '\n'):
+			end := strings.Index(content[i+len(blockStart):], blockEnd)
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += i + len(blockStart) + len(blockEnd)
+			}
+			blank(i, end)
+			i = end
+		case at(i, lang.CommentPrefix):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				end = len(content) - i
+			}
+			blank(i, i+end)
+			i += end
+		case at(i, `"""`) || (lang.ID == "python" && at(i, "'''")):
+			quote := content[i : i+3]
+			end := strings.Index(content[i+3:], quote)
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += i + 6
+			}
+			blank(i, end)
+			i = end
+		case c == '"' || c == '\'' || (backtick && c == '`'):
+			if c == '\'' && lang.ID == "rust" && !isRustCharLiteral(content, i) {
+				i++ // A lifetime such as 'a
+				continue
+			}
+			end := i + 1
+			for end < len(content) && content[end] != c && (c == '`' || content[end] != '\n') {
+				if content[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(content))
+			blank(i, end)
+			i = end
+		default:
+			i++
+		}
+	}
+	return string(out)
+}
+
+// isRustCharLiteral tells a char literal such as 'a' or '\n' from a lifetime such as 'a
+func isRustCharLiteral(content string, i int) bool {
+	if i+2 >= len(content) {
+		return false
+	}
+	if content[i+1] == '\\' {
+		return true
+	}
+	rest := []rune(content[i+1 : min(i+6, len(content))])
+	return len(rest) >= 2 && rest[1] == '\''
+}
+
+// braceScope is a block opened by a brace
+type braceScope struct {
+	symbol int          // Index of the symbol the block belongs to, or -1
+	saved  *braceHeader // For blocks inside expressions, such as callbacks, the text around them
+}
+
+// braceHeader is the text since the last statement or block boundary
+type braceHeader struct {
+	text  strings.Builder
+	line  int // Line the text starts on
+	paren int // Depth of open parentheses
+}
+
+// scanBraceSymbols finds the declarations of a language whose blocks are delimited by braces,
+// classifying the text before each opening brace
+func scanBraceSymbols(content string, lang *LanguageInfo, syntax *braceSyntax) []Symbol {
+	masked := maskCode(content, lang)
+	var symbols []Symbol
+	var scopes []braceScope
+	header := &braceHeader{line: 1}
+	line := 1
+
+	// enclosing returns the innermost symbol containing the current position
+	enclosing := func() *Symbol {
+		for i := len(scopes) - 1; i >= 0; i-- {
+			if scopes[i].symbol >= 0 {
+				return &symbols[scopes[i].symbol]
+			}
+		}
+		return nil
+	}
+	reset := func() { header = &braceHeader{line: line} }
+
+	for i := 0; i < len(masked); i++ {
+		c := masked[i]
+		switch c {
+		case '{':
+			if header.paren > 0 || strings.ContainsAny(lastByte(header.text.String()), "<:|&,=") {
+				scopes = append(scopes, braceScope{symbol: -1, saved: header})
+				reset()
+				continue
+			}
+			index := -1
+			if symbol, ok := classifyDeclaration(header, syntax, syntax.classes, syntax.functions, enclosing()); ok {
+				symbols = append(symbols, symbol)
+				index = len(symbols) - 1
+			}
+			scopes = append(scopes, braceScope{symbol: index})
+			reset()
+		case '}':
+			if len(scopes) == 0 {
+				reset()
+				continue
+			}
+			scope := scopes[len(scopes)-1]
+			scopes = scopes[:len(scopes)-1]
+			if scope.symbol >= 0 {
+				symbols[scope.symbol].EndLine = line
+			}
+			if scope.saved != nil {
+				header = scope.saved
+				header.text.WriteString("{}")
+				continue
+			}
+			reset()
+		case ';':
+			if header.paren > 0 {
+				header.text.WriteByte(c)
+				continue
+			}
+			if len(syntax.statements) > 0 {
+				if symbol, ok := classifyDeclaration(header, syntax, nil, syntax.statements, enclosing()); ok {
+					symbol.EndLine = line
+					symbols = append(symbols, symbol)
+				}
+			}
+			reset()
+		default:
+			switch c {
+			case '(':
+				header.paren++
+			case ')':
+				header.paren = max(header.paren-1, 0)
+			}
+			header.text.WriteByte(c)
+		}
+		if c == '\n' {
+			line++
+		}
+	}
+
+	// Close blocks left open by unbalanced braces at the end of the file
+	for _, scope := range scopes {
+		if scope.symbol >= 0 && symbols[scope.symbol].EndLine == 0 {
+			symbols[scope.symbol].EndLine = line
+		}
+	}
+	return symbols
+}
+
+// classifyDeclaration matches the declaration ending a header, trying each line the header
+// could start on from the last, so that earlier statements without semicolons are ignored
+func classifyDeclaration(header *braceHeader, syntax *braceSyntax, classes, functions []*regexp.Regexp, parent *Symbol) (Symbol, bool) {
+	text := annotationRegex.ReplaceAllStringFunc(header.text.String(), blankText)
+	if syntax.directives {
+		text = hashLineRegex.ReplaceAllStringFunc(text, blankText)
+	}
+	if syntax.attributes {
+		text = csAttributeRegex.ReplaceAllStringFunc(text, blankText)
+	}
+
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' && i+1 < len(text) {
+			starts = append(starts, i+1)
+		}
+	}
+
+	for i := len(starts) - 1; i >= 0; i-- {
+		segment := text[starts[i]:]
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+		if match := firstWordRegex.FindStringSubmatch(segment); match != nil && blockStopWords[match[1]] {
+			return Symbol{}, false
+		}
+		if strings.Count(segment, "(") != strings.Count(segment, ")") {
+			continue
+		}
+
+		startLine := header.line + strings.Count(text[:starts[i]], "\n") + leadingNewlines(segment)
+		for _, re := range classes {
+			if symbol, ok := matchDeclaration(re, segment, true, syntax, parent); ok {
+				symbol.StartLine = startLine
+				return symbol, true
+			}
+		}
+		for _, re := range functions {
+			if symbol, ok := matchDeclaration(re, segment, false, syntax, parent); ok {
+				symbol.StartLine = startLine
+				return symbol, true
+			}
+		}
+	}
+	return Symbol{}, false
+}
+
+// matchDeclaration builds the symbol a declaration regex matches
+func matchDeclaration(re *regexp.Regexp, segment string, class bool, syntax *braceSyntax, parent *Symbol) (Symbol, bool) {
+	match := re.FindStringSubmatch(segment)
+	if match == nil {
+		return Symbol{}, false
+	}
+	group := func(name string) string {
+		if i := re.SubexpIndex(name); i >= 0 {
+			return match[i]
+		}
+		return ""
+	}
+
+	name := strings.Trim(group("name"), "`")
+	switch name {
+	case "", "extends", "implements", "where", "function", "if", "for", "while", "switch", "catch", "return":
+		return Symbol{}, false
+	}
+
+	symbol := Symbol{Name: name, Kind: SymbolFunction}
+	var parentKind SymbolKind
+	if parent != nil {
+		symbol.Parent = parent.QualifiedName()
+		parentKind = parent.Kind
+	}
+	if class {
+		symbol.Kind = symbolKinds[group("kind")]
+	} else {
+		// Members of types are methods, as are functions declared with a receiver or as Type::name
+		explicit := group("parent")
+		if i := strings.LastIndex(name, "::"); i > 0 {
+			explicit, symbol.Name = strings.ReplaceAll(name[:i], "::", "."), name[i+2:]
+		}
+		if explicit != "" {
+			symbol.Parent, parentKind = explicit, SymbolClass
+		}
+		if parentKind != "" && parentKind != SymbolFunction && parentKind != SymbolMethod {
+			symbol.Kind = SymbolMethod
+		}
+	}
+	if syntax.exported != nil {
+		symbol.Exported = syntax.exported(segment, symbol.Name, parentKind)
+	}
+	return symbol, true
+}
+
+// lastByte returns the last non-space character of s; braces after an operator, such as
+// an object type in Promise<{ id: string }>, are part of an expression
+func lastByte(s string) string {
+	s = strings.TrimRightFunc(s, unicode.IsSpace)
+	if s == "" {
+		return ""
+	}
+	return s[len(s)-1:]
+}
+
+// blankText replaces everything but newlines with spaces
+func blankText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' {
+			return r
+		}
+		return ' '
+	}, s)
+}
+
+// leadingNewlines counts the newlines before the first non-space character of s
+func leadingNewlines(s string) int {
+	return strings.Count(s[:len(s)-len(strings.TrimLeftFunc(s, unicode.IsSpace))], "\n")
+}
+
+// indentWidth measures the indentation of a line, with tabs as eight columns
+func indentWidth(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 8 - width%8
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// scanPythonSymbols finds the classes and functions of Python source by indentation; a block
+// ends before the next line indented no deeper than its definition. Decorators belong to the
+// definition they precede.
+func scanPythonSymbols(content string, lang *LanguageInfo) []Symbol {
+	type block struct{ symbol, indent int }
+	var symbols []Symbol
+	var blocks []block
+	decorators, lastLine, depth := 0, 0, 0
+
+	closeBlocks := func(indent int) {
+		for len(blocks) > 0 && blocks[len(blocks)-1].indent >= indent {
+			symbols[blocks[len(blocks)-1].symbol].EndLine = lastLine
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+
+	for i, raw := range strings.Split(maskCode(content, lang), "\n") {
+		line := i + 1
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" {
+			continue
+		}
+		if depth > 0 {
+			// A continuation of brackets opened on an earlier line
+			depth = max(depth+bracketBalance(trimmed), 0)
+			lastLine = line
+			continue
+		}
+
+		indent := indentWidth(raw)
+		closeBlocks(indent)
+
+		if match := pyDefinitionRegex.FindStringSubmatch(trimmed); match != nil {
+			symbol := Symbol{Name: match[2], Kind: SymbolFunction, StartLine: line, Exported: !strings.HasPrefix(match[2], "_")}
+			if match[1] == "class" {
+				symbol.Kind = SymbolClass
+			}
+			if decorators > 0 {
+				symbol.StartLine = decorators
+			}
+			if len(blocks) > 0 {
+				enclosing := symbols[blocks[len(blocks)-1].symbol]
+				symbol.Parent = enclosing.QualifiedName()
+				if symbol.Kind == SymbolFunction && enclosing.Kind == SymbolClass {
+					symbol.Kind = SymbolMethod
+				}
+			}
+			symbols = append(symbols, symbol)
+			blocks = append(blocks, block{symbol: len(symbols) - 1, indent: indent})
+		}
+
+		if strings.HasPrefix(trimmed, "@") {
+			if decorators == 0 {
+				decorators = line
+			}
+		} else {
+			decorators = 0
+		}
+		depth = max(bracketBalance(trimmed), 0)
+		lastLine = line
+	}
+	closeBlocks(0)
+	return symbols
+}
+
+// bracketBalance returns the number of brackets a line opens minus the number it closes
+func bracketBalance(line string) int {
+	return strings.Count(line, "(") + strings.Count(line, "[") + strings.Count(line, "{") -
+		strings.Count(line, ")") - strings.Count(line, "]") - strings.Count(line, "}")
+}
+
+// scanRubySymbols finds the classes, modules and methods of Ruby source by pairing the
+// keywords that open blocks with their end
+func scanRubySymbols(content string, lang *LanguageInfo) []Symbol {
+	var symbols []Symbol
+	var blocks []int // Symbol index of each open block, or -1
+
+	enclosing := func() *Symbol {
+		for i := len(blocks) - 1; i >= 0; i-- {
+			if blocks[i] >= 0 {
+				return &symbols[blocks[i]]
+			}
+		}
+		return nil
+	}
+
+	for i, raw := range strings.Split(maskCode(content, lang), "\n") {
+		line := i + 1
+		trimmed := strings.TrimSpace(raw)
+		switch {
+		case trimmed == "":
+		case rbEndRegex.MatchString(trimmed):
+			if len(blocks) > 0 {
+				if index := blocks[len(blocks)-1]; index >= 0 {
+					symbols[index].EndLine = line
+				}
+				blocks = blocks[:len(blocks)-1]
+			}
+		case rbDefinitionRegex.MatchString(trimmed):
+			match := rbDefinitionRegex.FindStringSubmatch(trimmed)
+			symbol := Symbol{Name: match[2], Kind: SymbolMethod, StartLine: line, Exported: !strings.HasPrefix(match[2], "_")}
+			switch match[1] {
+			case "class":
+				symbol.Kind = SymbolClass
+			case "module":
+				symbol.Kind = SymbolModule
+			}
+			if parent := enclosing(); parent != nil {
+				symbol.Parent = parent.QualifiedName()
+			} else if symbol.Kind == SymbolMethod {
+				symbol.Kind = SymbolFunction
+			}
+			symbols = append(symbols, symbol)
+			if symbol.Kind != SymbolClass && symbol.Kind != SymbolModule && rbInlineEndRegex.MatchString(trimmed) {
+				symbols[len(symbols)-1].EndLine = line // def name; end, or def name = value
+				continue
+			}
+			blocks = append(blocks, len(symbols)-1)
+		case strings.HasPrefix(trimmed, "class <<"):
+			blocks = append(blocks, -1)
+		case rbBlockOpenRegex.MatchString(trimmed):
+			if !rbInlineEndRegex.MatchString(trimmed) {
+				blocks = append(blocks, -1)
+			}
+		}
+	}
+	for _, index := range blocks {
+		if index >= 0 && symbols[index].EndLine == 0 {
+			symbols[index].EndLine = strings.Count(content, "\n") + 1
+		}
+	}
+	return symbols
+}
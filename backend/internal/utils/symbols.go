@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// SymbolKind is the kind of declaration a symbol is
+type SymbolKind string
+
+const (
+	SymbolFunction  SymbolKind = "function"
+	SymbolMethod    SymbolKind = "method"
+	SymbolClass     SymbolKind = "class"
+	SymbolInterface SymbolKind = "interface"
+	SymbolStruct    SymbolKind = "struct"
+	SymbolEnum      SymbolKind = "enum"
+	SymbolTrait     SymbolKind = "trait"
+	SymbolImpl      SymbolKind = "impl"
+	SymbolNamespace SymbolKind = "namespace"
+	SymbolModule    SymbolKind = "module"
+	SymbolType      SymbolKind = "type"
+	SymbolConstant  SymbolKind = "constant"
+	SymbolVariable  SymbolKind = "variable"
+)
+
+// Symbol is a declaration found in source code, with its 1-based line range
+type Symbol struct {
+	Name      string     `json:"name"`
+	Kind      SymbolKind `json:"kind"`
+	Parent    string     `json:"parent,omitempty"` // Qualified name of the enclosing type or function
+	StartLine int        `json:"start_line"`
+	EndLine   int        `json:"end_line"`
+	Exported  bool       `json:"exported"`
+}
+
+// QualifiedName returns the name of the symbol prefixed with its parents, as in Type.Method
+func (s Symbol) QualifiedName() string {
+	if s.Parent == "" {
+		return s.Name
+	}
+	return s.Parent + "." + s.Name
+}
+
+// IsFunction reports whether the symbol is a function or a method
+func (s Symbol) IsFunction() bool {
+	return s.Kind == SymbolFunction || s.Kind == SymbolMethod
+}
+
+// Contains reports whether a 1-based line falls within the symbol
+func (s Symbol) Contains(line int) bool {
+	return line >= s.StartLine && line <= s.EndLine
+}
+
+// ExtractSymbols returns the declarations of a source file in order of their start lines.
+// Go is parsed with go/parser; other languages are read by structural scanners that skip
+// strings and comments.
+func ExtractSymbols(filePath, content string) ([]Symbol, error) {
+	lang := DetectLanguage(filePath, content)
+	if lang == nil {
+		return nil, fmt.Errorf("unknown language for %s", filePath)
+	}
+	return extractSymbols(lang, content)
+}
+
+// extractSymbols returns the declarations of content in a known language
+func extractSymbols(lang *LanguageInfo, content string) ([]Symbol, error) {
+	var symbols []Symbol
+	switch {
+	case lang.ID == "go":
+		var err error
+		if symbols, err = goSymbols(content); err != nil {
+			// Fall back to scanning files that don't parse, such as templates
+			symbols = scanBraceSymbols(content, lang, braceSyntaxes["go"])
+		}
+	case lang.ID == "python":
+		symbols = scanPythonSymbols(content, lang)
+	case lang.ID == "ruby":
+		symbols = scanRubySymbols(content, lang)
+	case braceSyntaxes[lang.ID] != nil:
+		symbols = scanBraceSymbols(content, lang, braceSyntaxes[lang.ID])
+	default:
+		return nil, fmt.Errorf("symbol extraction is not supported for %s", lang.Name)
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].StartLine < symbols[j].StartLine })
+	return symbols, nil
+}
+
+// FindSymbol finds a symbol by name: a plain name, or a name qualified by its types such as
+// Type.Method, (*Type).Method, Type::method or Type#method. Functions are preferred over
+// other symbols, then the match containing line or starting nearest to it when line > 0.
+func FindSymbol(symbols []Symbol, name string, line int) (Symbol, bool) {
+	name = normalizeSymbolName(name)
+	var matches []Symbol
+	for _, symbol := range symbols {
+		qualified := symbol.QualifiedName()
+		if qualified == name || strings.HasSuffix(qualified, "."+name) {
+			matches = append(matches, symbol)
+		}
+	}
+	if len(matches) == 0 {
+		return Symbol{}, false
+	}
+
+	best := matches[0]
+	for _, match := range matches[1:] {
+		if betterSymbolMatch(match, best, line) {
+			best = match
+		}
+	}
+	return best, true
+}
+
+// betterSymbolMatch reports whether a is a better match than b for FindSymbol
+func betterSymbolMatch(a, b Symbol, line int) bool {
+	if a.IsFunction() != b.IsFunction() {
+		return a.IsFunction()
+	}
+	if line <= 0 {
+		return false
+	}
+	if a.Contains(line) != b.Contains(line) {
+		return a.Contains(line)
+	}
+	return abs(a.StartLine-line) < abs(b.StartLine-line)
+}
+
+// EnclosingSymbol returns the innermost function or method containing a 1-based line, or
+// the innermost symbol of any kind if no function contains it
+func EnclosingSymbol(symbols []Symbol, line int) (Symbol, bool) {
+	var function, any *Symbol
+	for i := range symbols {
+		symbol := &symbols[i]
+		if !symbol.Contains(line) {
+			continue
+		}
+		if any == nil || symbol.EndLine-symbol.StartLine <= any.EndLine-any.StartLine {
+			any = symbol
+		}
+		if symbol.IsFunction() && (function == nil || symbol.EndLine-symbol.StartLine <= function.EndLine-function.StartLine) {
+			function = symbol
+		}
+	}
+	switch {
+	case function != nil:
+		return *function, true
+	case any != nil:
+		return *any, true
+	}
+	return Symbol{}, false
+}
+
+// SymbolCode returns the lines of content a symbol spans
+func SymbolCode(content string, symbol Symbol) string {
+	lines := strings.Split(content, "\n")
+	start, end := max(symbol.StartLine, 1), min(symbol.EndLine, len(lines))
+	if start > end {
+		return ""
+	}
+	return strings.Join(lines[start-1:end], "\n")
+}
+
+// normalizeSymbolName rewrites the ways languages qualify members to Type.Method
+func normalizeSymbolName(name string) string {
+	name = strings.NewReplacer("::", ".", "#", ".", "(", "", ")", "", "*", "").Replace(strings.TrimSpace(name))
+	return strings.Trim(name, ".")
+}
+
+// goSymbols reads the declarations of a Go file from its AST
+func goSymbols(content string) ([]Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if file == nil || (err != nil && len(file.Decls) == 0) {
+		return nil, err
+	}
+
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	var symbols []Symbol
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			symbol := Symbol{
+				Name:      decl.Name.Name,
+				Kind:      SymbolFunction,
+				StartLine: line(decl.Pos()),
+				EndLine:   line(decl.End()),
+				Exported:  decl.Name.IsExported(),
+			}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				symbol.Kind = SymbolMethod
+				symbol.Parent = goReceiverType(decl.Recv.List[0].Type)
+			}
+			symbols = append(symbols, symbol)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				// Ungrouped declarations span the keyword too
+				start, end := line(spec.Pos()), line(spec.End())
+				if !decl.Lparen.IsValid() {
+					start, end = line(decl.Pos()), line(decl.End())
+				}
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, Symbol{
+						Name:      spec.Name.Name,
+						Kind:      goTypeKind(spec.Type),
+						StartLine: start,
+						EndLine:   end,
+						Exported:  spec.Name.IsExported(),
+					})
+					if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+						symbols = append(symbols, goInterfaceMethods(iface, spec.Name.Name, line)...)
+					}
+				case *ast.ValueSpec:
+					kind := SymbolVariable
+					if decl.Tok == token.CONST {
+						kind = SymbolConstant
+					}
+					for _, name := range spec.Names {
+						if name.Name == "_" {
+							continue
+						}
+						symbols = append(symbols, Symbol{Name: name.Name, Kind: kind, StartLine: start, EndLine: end, Exported: name.IsExported()})
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// goInterfaceMethods returns the methods an interface declares
+func goInterfaceMethods(iface *ast.InterfaceType, parent string, line func(token.Pos) int) []Symbol {
+	var symbols []Symbol
+	for _, field := range iface.Methods.List {
+		if _, ok := field.Type.(*ast.FuncType); !ok {
+			continue // An embedded interface or type constraint
+		}
+		for _, name := range field.Names {
+			symbols = append(symbols, Symbol{
+				Name:      name.Name,
+				Kind:      SymbolMethod,
+				Parent:    parent,
+				StartLine: line(field.Pos()),
+				EndLine:   line(field.End()),
+				Exported:  name.IsExported(),
+			})
+		}
+	}
+	return symbols
+}
+
+// goReceiverType returns the type name of a method receiver such as *Stack[T]
+func goReceiverType(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return goReceiverType(expr.X)
+	case *ast.ParenExpr:
+		return goReceiverType(expr.X)
+	case *ast.IndexExpr:
+		return goReceiverType(expr.X)
+	case *ast.IndexListExpr:
+		return goReceiverType(expr.X)
+	case *ast.Ident:
+		return expr.Name
+	}
+	return ""
+}
+
+// goTypeKind returns the symbol kind of a Go type declaration
+func goTypeKind(expr ast.Expr) SymbolKind {
+	switch expr.(type) {
+	case *ast.StructType:
+		return SymbolStruct
+	case *ast.InterfaceType:
+		return SymbolInterface
+	}
+	return SymbolType
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}